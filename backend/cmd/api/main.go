@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"trackmymoney/internal/config"
@@ -8,9 +10,11 @@ import (
 	"trackmymoney/internal/handlers"
 	"trackmymoney/internal/jobs"
 	"trackmymoney/internal/middleware"
+	"trackmymoney/internal/models"
 	"trackmymoney/internal/repository"
 	"trackmymoney/internal/scheduler"
 	"trackmymoney/internal/services"
+	"trackmymoney/internal/services/calendar"
 	"trackmymoney/internal/services/notification"
 	"trackmymoney/pkg/logger"
 )
@@ -62,31 +66,129 @@ func main() {
 	logger.Info("Asset repository initialized")
 
 	// Initialize asset services
-	assetService := services.NewAssetService(database.GetDB())
-	handlers.SetAssetService(assetService)
-	logger.Info("Asset service initialized")
-
 	cashAssetService := services.NewCashAssetService(assetRepo)
 	handlers.SetCashAssetService(cashAssetService)
 	logger.Info("Cash asset service initialized")
 
 	// Set config for auth handlers
 	handlers.SetConfig(cfg)
+	wsTicketService := services.NewWSTicketService(time.Duration(cfg.WebSocket.TicketTTL) * time.Second)
+	handlers.SetWSTicketService(wsTicketService)
+
+	// Load exchange calendar
+	marketCalendar := calendar.Default()
+	if cfg.Market.CalendarFile != "" {
+		if loaded, err := calendar.Load(cfg.Market.CalendarFile); err != nil {
+			logger.Warn("Failed to load exchange calendar, using built-in sessions without holidays", zap.Error(err))
+		} else {
+			marketCalendar = loaded
+		}
+	}
 
 	// Initialize market service
 	marketService := services.NewMarketService(services.MarketServiceConfig{
-		BaseURL:    cfg.Market.BaseURL,
-		Timeout:    cfg.Market.Timeout,
-		MaxRetries: cfg.Market.MaxRetries,
+		Provider:    models.ProviderType(cfg.Market.Provider),
+		DemoSeed:    cfg.Market.DemoSeed,
+		BaseURL:     cfg.Market.BaseURL,
+		WSURL:       cfg.Market.WSURL,
+		Timeout:     cfg.Market.Timeout,
+		CallTimeout: cfg.Market.CallTimeout,
+		MaxRetries:  cfg.Market.MaxRetries,
+
+		HealthWindow:     cfg.Market.HealthWindow,
+		HealthMinSamples: cfg.Market.HealthMinSamples,
+		HealthThreshold:  cfg.Market.HealthThreshold,
+
+		QuoteCacheTTL: cfg.Market.QuoteCacheTTL,
+		InfoCacheTTL:  cfg.Market.InfoCacheTTL,
+		CacheMaxStale: cfg.Market.CacheMaxStale,
+
+		BreakerFailureThreshold: cfg.Market.BreakerFailureThreshold,
+		BreakerOpenTimeout:      cfg.Market.BreakerOpenTimeout,
+		RateLimit:               cfg.Market.RateLimit,
+		RateBurst:               cfg.Market.RateBurst,
+
+		Calendar: marketCalendar,
 	})
 	handlers.SetMarketService(marketService)
-	logger.Info("Market service initialized", zap.String("base_url", cfg.Market.BaseURL))
+	marketHub := services.NewMarketHub(marketService)
+	handlers.SetMarketHub(marketHub)
+
+	// Initialize FX service (summaries are reported in the base currency)
+	fxService := services.NewFXService(database.GetDB(), marketService, cfg.FX.BaseCurrency)
+	handlers.SetFXService(fxService)
+	logger.Info("FX service initialized", zap.String("base_currency", fxService.Base()))
+
+	assetService := services.NewAssetService(database.GetDB(), fxService)
+	handlers.SetAssetService(assetService)
+	logger.Info("Asset service initialized")
+
+	// Initialize portfolio stream (live valuation from market ticks and asset edits)
+	portfolioStream := services.NewPortfolioStream(database.GetDB(), marketHub, marketService, fxService, services.PortfolioStreamConfig{
+		Interval: time.Duration(cfg.WebSocket.PortfolioInterval) * time.Second,
+	})
+	handlers.SetPortfolioStream(portfolioStream)
+	logger.Info("Market service initialized", zap.String("provider", cfg.Market.Provider), zap.String("base_url", cfg.Market.BaseURL))
 
 	// Initialize asset market service
 	assetMarketService := services.NewAssetMarketService(marketService)
 	handlers.SetAssetMarketService(assetMarketService)
 	logger.Info("Asset market service initialized")
 
+	// Initialize price history service
+	priceHistoryService := services.NewPriceHistoryService(database.GetDB(), marketService)
+	handlers.SetPriceHistoryService(priceHistoryService)
+	logger.Info("Price history service initialized")
+
+	// Initialize symbol master service
+	symbolService := services.NewSymbolService(database.GetDB(), marketService)
+	handlers.SetSymbolService(symbolService)
+	if count, err := symbolService.SyncHoldings(); err != nil {
+		logger.Warn("Failed to sync held symbols", zap.Error(err))
+	} else {
+		logger.Info("Symbol master service initialized", zap.Int("held_symbols", count))
+	}
+
+	// Initialize transaction ledger service
+	transactionService := services.NewTransactionService(database.GetDB(), models.CostBasisMethod(cfg.Ledger.CostBasisMethod), fxService)
+	handlers.SetTransactionService(transactionService)
+	logger.Info("Transaction service initialized")
+
+	// Initialize corporate action service
+	corporateActionService := services.NewCorporateActionService(database.GetDB(), marketService, transactionService)
+	handlers.SetCorporateActionService(corporateActionService)
+	logger.Info("Corporate action service initialized")
+
+	// Initialize income service
+	incomeService := services.NewIncomeService(database.GetDB(), marketService)
+	handlers.SetIncomeService(incomeService)
+	logger.Info("Income service initialized")
+
+	// Initialize loan service
+	loanService := services.NewLoanService(database.GetDB())
+	handlers.SetLoanService(loanService)
+	logger.Info("Loan service initialized")
+
+	// Initialize maturity service
+	maturityService := services.NewMaturityService(database.GetDB(), cfg.Maturity.NoticeDays)
+	handlers.SetMaturityService(maturityService)
+	logger.Info("Maturity service initialized")
+
+	// Initialize credit card service
+	creditCardService := services.NewCreditCardService(database.GetDB(), cfg.CreditCard.ReminderDays)
+	handlers.SetCreditCardService(creditCardService)
+	logger.Info("Credit card service initialized")
+
+	// Initialize other asset service
+	otherAssetService := services.NewOtherAssetService(database.GetDB())
+	handlers.SetOtherAssetService(otherAssetService)
+	logger.Info("Other asset service initialized")
+
+	// Initialize collateral service
+	collateralService := services.NewCollateralService(database.GetDB(), fxService, cfg.Collateral.LTVThreshold)
+	handlers.SetCollateralService(collateralService)
+	logger.Info("Collateral service initialized")
+
 	// Initialize watchlist service
 	watchlistService := services.NewWatchlistService(marketService)
 	handlers.SetWatchlistService(watchlistService)
@@ -108,6 +210,14 @@ func main() {
 		handlers.SetScheduler(schedulerInstance)
 
 		// Register built-in jobs
+		// Runs before the daily snapshot so that it is valued at today's exchange rates
+		fxRateSyncJob := jobs.NewFXRateSyncJob(fxService)
+		if err := schedulerInstance.AddJob("fx_rate_sync", fxRateSyncJob, "45 5 * * *"); err != nil {
+			logger.Error("Failed to add FX rate sync job", zap.Error(err))
+		} else {
+			logger.Info("FX rate sync job registered", zap.String("schedule", "45 5 * * *"))
+		}
+
 		dailySnapshotJob := jobs.NewDailySnapshotJob(assetMarketService, assetService)
 		if err := schedulerInstance.AddJob("daily_snapshot", dailySnapshotJob, "0 6 * * *"); err != nil {
 			logger.Error("Failed to add daily snapshot job", zap.Error(err))
@@ -115,7 +225,66 @@ func main() {
 			logger.Info("Daily snapshot job registered", zap.String("schedule", "0 6 * * *"))
 		}
 
-		notificationDispatchJob := jobs.NewNotificationDispatchJob(notificationService)
+		priceHistorySyncJob := jobs.NewPriceHistorySyncJob(priceHistoryService)
+		if err := schedulerInstance.AddJob("price_history_sync", priceHistorySyncJob, "0 */6 * * *"); err != nil {
+			logger.Error("Failed to add price history sync job", zap.Error(err))
+		} else {
+			logger.Info("Price history sync job registered", zap.String("schedule", "0 */6 * * *"))
+		}
+
+		symbolSyncJob := jobs.NewSymbolSyncJob(symbolService)
+		if err := schedulerInstance.AddJob("symbol_sync", symbolSyncJob, "30 2 * * *"); err != nil {
+			logger.Error("Failed to add symbol sync job", zap.Error(err))
+		} else {
+			logger.Info("Symbol sync job registered", zap.String("schedule", "30 2 * * *"))
+		}
+
+		marketCloseRefreshJob := jobs.NewMarketCloseRefreshJob(assetMarketService)
+		if err := schedulerInstance.AddJob("market_close_refresh", marketCloseRefreshJob, "*/15 * * * *"); err != nil {
+			logger.Error("Failed to add market close refresh job", zap.Error(err))
+		} else {
+			logger.Info("Market close refresh job registered", zap.String("schedule", "*/15 * * * *"))
+		}
+
+		// Runs before the daily snapshot so that splits are reflected in it
+		corporateActionSyncJob := jobs.NewCorporateActionSyncJob(corporateActionService)
+		if err := schedulerInstance.AddJob("corporate_action_sync", corporateActionSyncJob, "0 5 * * *"); err != nil {
+			logger.Error("Failed to add corporate action sync job", zap.Error(err))
+		} else {
+			logger.Info("Corporate action sync job registered", zap.String("schedule", "0 5 * * *"))
+		}
+
+		// Runs after the corporate action sync so that dividends use split-adjusted quantities
+		incomeImportJob := jobs.NewIncomeImportJob(incomeService)
+		if err := schedulerInstance.AddJob("income_import", incomeImportJob, "30 5 * * *"); err != nil {
+			logger.Error("Failed to add income import job", zap.Error(err))
+		} else {
+			logger.Info("Income import job registered", zap.String("schedule", "30 5 * * *"))
+		}
+
+		// Runs before the daily snapshot so that renewals and pay-outs are reflected in it
+		maturityJob := jobs.NewMaturityJob(maturityService, notificationService)
+		if err := schedulerInstance.AddJob("maturity_processing", maturityJob, "0 4 * * *"); err != nil {
+			logger.Error("Failed to add maturity processing job", zap.Error(err))
+		} else {
+			logger.Info("Maturity processing job registered", zap.String("schedule", "0 4 * * *"))
+		}
+
+		creditCardJob := jobs.NewCreditCardJob(creditCardService, notificationService)
+		if err := schedulerInstance.AddJob("credit_card_cycle", creditCardJob, "0 9 * * *"); err != nil {
+			logger.Error("Failed to add credit card cycle job", zap.Error(err))
+		} else {
+			logger.Info("Credit card cycle job registered", zap.String("schedule", "0 9 * * *"))
+		}
+
+		collateralJob := jobs.NewCollateralJob(collateralService, notificationService)
+		if err := schedulerInstance.AddJob("collateral_ltv_check", collateralJob, "10 * * * *"); err != nil {
+			logger.Error("Failed to add collateral LTV check job", zap.Error(err))
+		} else {
+			logger.Info("Collateral LTV check job registered", zap.String("schedule", "10 * * * *"))
+		}
+
+		notificationDispatchJob := jobs.NewNotificationDispatchJob(notificationService, fxService)
 		if err := schedulerInstance.AddJob("notification_dispatch", notificationDispatchJob, "*/30 * * * *"); err != nil {
 			logger.Error("Failed to add notification dispatch job", zap.Error(err))
 		} else {
@@ -163,6 +332,14 @@ func setupRoutes(r *gin.Engine, cfg *config.Config) {
 		auth.GET("/verify", handlers.VerifyToken)
 	}
 
+	// WebSocket routes authenticate the upgrade request themselves, with a ticket or a bearer token
+	ws := api.Group("/ws")
+	{
+		ws.GET("/market", handlers.MarketWebSocket)
+		ws.GET("/market/:symbol", handlers.MarketWebSocket)
+		ws.GET("/portfolio", handlers.PortfolioWebSocket)
+	}
+
 	// Create authentication middleware
 	authMiddleware := middleware.AuthMiddleware(cfg.Auth.JWTSecret)
 
@@ -191,6 +368,9 @@ func setupRoutes(r *gin.Engine, cfg *config.Config) {
 				interestBearing.GET("/:id", handlers.GetInterestBearingAsset)
 				interestBearing.PUT("/:id", handlers.UpdateInterestBearingAsset)
 				interestBearing.DELETE("/:id", handlers.DeleteInterestBearingAsset)
+				interestBearing.GET("/maturing", handlers.GetMaturingAssets)
+				interestBearing.GET("/maturity-events", handlers.GetMaturityEvents)
+				interestBearing.POST("/maturity/process", handlers.ProcessMaturities)
 			}
 
 			// Stock assets
@@ -212,6 +392,50 @@ func setupRoutes(r *gin.Engine, cfg *config.Config) {
 				debt.GET("/:id", handlers.GetDebtAsset)
 				debt.PUT("/:id", handlers.UpdateDebtAsset)
 				debt.DELETE("/:id", handlers.DeleteDebtAsset)
+				debt.GET("/:id/schedule", handlers.GetLoanSchedule)
+				debt.GET("/:id/prepayments", handlers.GetLoanPrepayments)
+				debt.POST("/:id/prepayments", handlers.CreateLoanPrepayment)
+				debt.DELETE("/:id/prepayments/:prepayment_id", handlers.DeleteLoanPrepayment)
+			}
+
+			// Credit cards
+			creditCard := assets.Group("/credit-card")
+			{
+				creditCard.POST("", handlers.CreateCreditCard)
+				creditCard.GET("", handlers.GetCreditCards)
+				creditCard.GET("/:id", handlers.GetCreditCard)
+				creditCard.PUT("/:id", handlers.UpdateCreditCard)
+				creditCard.DELETE("/:id", handlers.DeleteCreditCard)
+				creditCard.POST("/:id/payments", handlers.PayCreditCard)
+				creditCard.GET("/:id/statements", handlers.GetCreditCardStatements)
+				creditCard.POST("/:id/statements", handlers.CreateCreditCardStatement)
+				creditCard.PUT("/:id/statements/:statement_id", handlers.UpdateCreditCardStatement)
+				creditCard.DELETE("/:id/statements/:statement_id", handlers.DeleteCreditCardStatement)
+				creditCard.GET("/reminders", handlers.GetCreditCardReminders)
+				creditCard.POST("/cycle/process", handlers.ProcessCreditCardCycle)
+			}
+
+			// Equity of assets securing debts
+			equity := assets.Group("/equity")
+			{
+				equity.GET("", handlers.GetEquity)
+				equity.GET("/warnings", handlers.GetCollateralWarnings)
+				equity.POST("/check", handlers.CheckCollateral)
+			}
+
+			// Other (manually valued) assets
+			other := assets.Group("/other")
+			{
+				other.POST("", handlers.CreateOtherAsset)
+				other.GET("", handlers.GetOtherAssets)
+				other.GET("/:id", handlers.GetOtherAsset)
+				other.PUT("/:id", handlers.UpdateOtherAsset)
+				other.DELETE("/:id", handlers.DeleteOtherAsset)
+				other.GET("/:id/appraisals", handlers.GetOtherAssetAppraisals)
+				other.POST("/:id/appraisals", handlers.CreateOtherAssetAppraisal)
+				other.PUT("/:id/appraisals/:appraisal_id", handlers.UpdateOtherAssetAppraisal)
+				other.DELETE("/:id/appraisals/:appraisal_id", handlers.DeleteOtherAssetAppraisal)
+				other.GET("/:id/projection", handlers.GetOtherAssetProjection)
 			}
 
 			// Crypto assets
@@ -229,6 +453,7 @@ func setupRoutes(r *gin.Engine, cfg *config.Config) {
 			assets.GET("/summary", handlers.GetAssetsSummary)
 			assets.GET("/history", handlers.GetAssetsHistory)
 			assets.GET("/statistics", handlers.GetAssetsStatistics)
+			assets.GET("/currency-attribution", handlers.GetCurrencyAttribution)
 		}
 
 		// Market routes
@@ -239,13 +464,73 @@ func setupRoutes(r *gin.Engine, cfg *config.Config) {
 			market.GET("/history/:symbol", handlers.GetHistory)
 			market.GET("/info/:symbol", handlers.GetInfo)
 			market.GET("/search", handlers.SearchMarket)
+			market.POST("/symbols/import", handlers.ImportSymbols)
 			market.GET("/ws-url", handlers.GetMarketWebSocketURL)
+			market.GET("/health", handlers.GetMarketHealth)
+			market.GET("/breakers", handlers.GetMarketBreakers)
+			market.GET("/cache", handlers.GetMarketCacheStats)
+			market.GET("/calendar", handlers.GetMarketCalendar)
+			market.GET("/streams", handlers.GetMarketStreamStats)
+		}
+
+		// WebSocket tickets for browsers, which cannot send the Authorization header on upgrade
+		protected.POST("/ws/ticket", handlers.IssueWebSocketTicket)
+
+		// Transaction ledger routes
+		transactions := protected.Group("/transactions")
+		{
+			transactions.GET("", handlers.GetTransactions)
+			transactions.POST("", handlers.CreateTransaction)
+			transactions.PUT("/:id", handlers.UpdateTransaction)
+			transactions.DELETE("/:id", handlers.DeleteTransaction)
+		}
+
+		// Cost basis and gain routes
+		gains := protected.Group("/gains")
+		{
+			gains.GET("/realized", handlers.GetRealizedGains)
+			gains.GET("/lots", handlers.GetOpenLots)
+		}
+
+		// Income routes
+		income := protected.Group("/income")
+		{
+			income.GET("", handlers.GetIncomeEvents)
+			income.POST("", handlers.CreateIncomeEvent)
+			income.PUT("/:id", handlers.UpdateIncomeEvent)
+			income.DELETE("/:id", handlers.DeleteIncomeEvent)
+			income.POST("/import", handlers.ImportIncome)
+			income.GET("/yearly", handlers.GetYearlyIncome)
+		}
+
+		// FX rate routes
+		fx := protected.Group("/fx")
+		{
+			fx.GET("/rates", handlers.GetFXRates)
+			fx.POST("/rates", handlers.SetFXRate)
+			fx.DELETE("/rates/:id", handlers.DeleteFXRate)
+			fx.GET("/latest", handlers.GetLatestFXRates)
+			fx.POST("/sync", handlers.SyncFXRates)
+			fx.POST("/backfill", handlers.BackfillFXRates)
+		}
+
+		// Corporate action routes
+		corporateActions := protected.Group("/corporate-actions")
+		{
+			corporateActions.GET("", handlers.GetCorporateActions)
+			corporateActions.POST("", handlers.CreateCorporateAction)
+			corporateActions.POST("/sync", handlers.SyncCorporateActions)
+			corporateActions.GET("/adjustments", handlers.GetCorporateActionAdjustments)
 		}
 
-		// WebSocket proxy routes (for real-time market data)
-		ws := protected.Group("/ws")
+		// Data source routes
+		datasources := protected.Group("/datasources")
 		{
-			ws.GET("/market/:symbol", handlers.ProxyMarketWebSocket)
+			datasources.POST("", handlers.CreateDataSource)
+			datasources.GET("", handlers.GetDataSources)
+			datasources.GET("/:id", handlers.GetDataSource)
+			datasources.PUT("/:id", handlers.UpdateDataSource)
+			datasources.DELETE("/:id", handlers.DeleteDataSource)
 		}
 
 		// Watchlist routes
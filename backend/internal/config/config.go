@@ -13,6 +13,12 @@ type Config struct {
 	Log       LogConfig       `yaml:"log"`
 	Market    MarketConfig    `yaml:"market"`
 	Scheduler SchedulerConfig `yaml:"scheduler"`
+	WebSocket WebSocketConfig `yaml:"websocket"`
+	Ledger    LedgerConfig    `yaml:"ledger"`
+	Maturity  MaturityConfig  `yaml:"maturity"`
+	CreditCard CreditCardConfig `yaml:"credit_card"`
+	FX        FXConfig        `yaml:"fx"`
+	Collateral CollateralConfig `yaml:"collateral"`
 }
 
 type ServerConfig struct {
@@ -40,9 +46,31 @@ type LogConfig struct {
 }
 
 type MarketConfig struct {
-	BaseURL    string `yaml:"base_url"`    // Market service URL
-	Timeout    int    `yaml:"timeout"`     // Request timeout in seconds
-	MaxRetries int    `yaml:"max_retries"` // Maximum number of retries
+	Provider    string `yaml:"provider"`     // Built-in provider: yfinance (default) or demo for offline synthetic data
+	DemoSeed    int64  `yaml:"demo_seed"`    // Random seed of the demo provider
+	BaseURL     string `yaml:"base_url"`     // Market service URL
+	WSURL       string `yaml:"ws_url"`       // Market service WebSocket URL, shared by all clients through the market hub
+	Timeout     int    `yaml:"timeout"`      // Request timeout in seconds
+	CallTimeout int    `yaml:"call_timeout"` // Deadline for a whole call including retries, in seconds
+	MaxRetries  int    `yaml:"max_retries"`  // Maximum number of retries
+
+	CalendarFile string `yaml:"calendar_file"` // Exchange sessions and holidays, built-in sessions without holidays if empty
+
+	// Provider health scoring for the failover chain
+	HealthWindow     int     `yaml:"health_window"`      // Rolling window in seconds
+	HealthMinSamples int     `yaml:"health_min_samples"` // Samples required before a provider can be demoted
+	HealthThreshold  float64 `yaml:"health_threshold"`   // Providers scoring below this (0-1) are demoted
+
+	// In-process cache
+	QuoteCacheTTL int `yaml:"quote_cache_ttl"` // Quote cache TTL in seconds
+	InfoCacheTTL  int `yaml:"info_cache_ttl"`  // Info cache TTL in seconds
+	CacheMaxStale int `yaml:"cache_max_stale"` // Seconds past the TTL that stale data may be served
+
+	// Circuit breaker and outbound rate limit, per provider
+	BreakerFailureThreshold int     `yaml:"breaker_failure_threshold"` // Consecutive failures that open the breaker
+	BreakerOpenTimeout      int     `yaml:"breaker_open_timeout"`      // Seconds the breaker stays open before a trial call
+	RateLimit               float64 `yaml:"rate_limit"`                // Requests per minute
+	RateBurst               int     `yaml:"rate_burst"`                // Requests allowed at once before the rate applies
 }
 
 type SchedulerConfig struct {
@@ -51,6 +79,36 @@ type SchedulerConfig struct {
 	Timezone      string `yaml:"timezone"`
 }
 
+type WebSocketConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`  // Browser origins allowed to connect besides the API's own; "*" allows any
+	TicketTTL      int      `yaml:"ticket_ttl"`       // Seconds a connection ticket stays valid
+	MessageRate    float64  `yaml:"message_rate"`     // Client messages per second per connection
+	MessageBurst   int      `yaml:"message_burst"`    // Client messages allowed at once before the rate applies
+	MaxMessageSize int64    `yaml:"max_message_size"` // Largest client message in bytes
+
+	PortfolioInterval int `yaml:"portfolio_interval"` // Seconds between portfolio stream updates; changes within an interval are merged
+}
+
+type LedgerConfig struct {
+	CostBasisMethod string `yaml:"cost_basis_method"` // fifo, lifo, average (default) or specific_lot; holdings can override it
+}
+
+type MaturityConfig struct {
+	NoticeDays int `yaml:"notice_days"` // Days before maturity that deposits and bonds are reported as maturing soon
+}
+
+type CreditCardConfig struct {
+	ReminderDays int `yaml:"reminder_days"` // Days before the due date that unpaid statements are reminded
+}
+
+type FXConfig struct {
+	BaseCurrency string `yaml:"base_currency"` // Currency summaries, history and notifications are reported in
+}
+
+type CollateralConfig struct {
+	LTVThreshold float64 `yaml:"ltv_threshold"` // Loan-to-value percentage at which secured debts are warned about; debts can override it
+}
+
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
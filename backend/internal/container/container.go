@@ -1,11 +1,15 @@
 package container
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 	"trackmymoney/internal/config"
+	"trackmymoney/internal/models"
 	"trackmymoney/internal/repository"
 	"trackmymoney/internal/scheduler"
 	"trackmymoney/internal/services"
+	"trackmymoney/internal/services/calendar"
 	"trackmymoney/internal/services/notification"
 )
 
@@ -19,9 +23,23 @@ type Container struct {
 
 	// Services
 	AssetService       *services.AssetService
+	FXService          *services.FXService
 	CashAssetService   *services.CashAssetService
 	MarketService      *services.MarketService
+	MarketHub          *services.MarketHub
+	WSTicketService    *services.WSTicketService
+	PortfolioStream    *services.PortfolioStream
 	AssetMarketService *services.AssetMarketService
+	PriceHistoryService *services.PriceHistoryService
+	SymbolService      *services.SymbolService
+	TransactionService *services.TransactionService
+	CorporateActionService *services.CorporateActionService
+	IncomeService      *services.IncomeService
+	MaturityService    *services.MaturityService
+	LoanService        *services.LoanService
+	CreditCardService  *services.CreditCardService
+	OtherAssetService  *services.OtherAssetService
+	CollateralService  *services.CollateralService
 	WatchlistService   *services.WatchlistService
 	NotificationService *notification.Service
 
@@ -40,16 +58,58 @@ func NewContainer(cfg *config.Config, db *gorm.DB) *Container {
 	container.AssetRepo = repository.NewAssetRepository(db)
 
 	// Initialize services
-	container.AssetService = services.NewAssetService(db)
 	container.CashAssetService = services.NewCashAssetService(container.AssetRepo)
 
+	marketCalendar := calendar.Default()
+	if cfg.Market.CalendarFile != "" {
+		if loaded, err := calendar.Load(cfg.Market.CalendarFile); err == nil {
+			marketCalendar = loaded
+		}
+	}
+
 	container.MarketService = services.NewMarketService(services.MarketServiceConfig{
-		BaseURL:    cfg.Market.BaseURL,
-		Timeout:    cfg.Market.Timeout,
-		MaxRetries: cfg.Market.MaxRetries,
+		Provider:    models.ProviderType(cfg.Market.Provider),
+		DemoSeed:    cfg.Market.DemoSeed,
+		BaseURL:     cfg.Market.BaseURL,
+		WSURL:       cfg.Market.WSURL,
+		Timeout:     cfg.Market.Timeout,
+		CallTimeout: cfg.Market.CallTimeout,
+		MaxRetries:  cfg.Market.MaxRetries,
+
+		HealthWindow:     cfg.Market.HealthWindow,
+		HealthMinSamples: cfg.Market.HealthMinSamples,
+		HealthThreshold:  cfg.Market.HealthThreshold,
+
+		QuoteCacheTTL: cfg.Market.QuoteCacheTTL,
+		InfoCacheTTL:  cfg.Market.InfoCacheTTL,
+		CacheMaxStale: cfg.Market.CacheMaxStale,
+
+		BreakerFailureThreshold: cfg.Market.BreakerFailureThreshold,
+		BreakerOpenTimeout:      cfg.Market.BreakerOpenTimeout,
+		RateLimit:               cfg.Market.RateLimit,
+		RateBurst:               cfg.Market.RateBurst,
+
+		Calendar: marketCalendar,
 	})
 
+	container.MarketHub = services.NewMarketHub(container.MarketService)
+	container.FXService = services.NewFXService(db, container.MarketService, cfg.FX.BaseCurrency)
+	container.AssetService = services.NewAssetService(db, container.FXService)
+	container.WSTicketService = services.NewWSTicketService(time.Duration(cfg.WebSocket.TicketTTL) * time.Second)
+	container.PortfolioStream = services.NewPortfolioStream(db, container.MarketHub, container.MarketService, container.FXService, services.PortfolioStreamConfig{
+		Interval: time.Duration(cfg.WebSocket.PortfolioInterval) * time.Second,
+	})
 	container.AssetMarketService = services.NewAssetMarketService(container.MarketService)
+	container.PriceHistoryService = services.NewPriceHistoryService(db, container.MarketService)
+	container.SymbolService = services.NewSymbolService(db, container.MarketService)
+	container.TransactionService = services.NewTransactionService(db, models.CostBasisMethod(cfg.Ledger.CostBasisMethod), container.FXService)
+	container.CorporateActionService = services.NewCorporateActionService(db, container.MarketService, container.TransactionService)
+	container.IncomeService = services.NewIncomeService(db, container.MarketService)
+	container.MaturityService = services.NewMaturityService(db, cfg.Maturity.NoticeDays)
+	container.LoanService = services.NewLoanService(db)
+	container.CreditCardService = services.NewCreditCardService(db, cfg.CreditCard.ReminderDays)
+	container.OtherAssetService = services.NewOtherAssetService(db)
+	container.CollateralService = services.NewCollateralService(db, container.FXService, cfg.Collateral.LTVThreshold)
 	container.WatchlistService = services.NewWatchlistService(container.MarketService)
 	container.NotificationService = notification.NewService()
 
@@ -29,6 +29,14 @@ func Init(cfg *config.DatabaseConfig) error {
 		return err
 	}
 
+	if err := migrateCanonicalSymbols(); err != nil {
+		return err
+	}
+
+	if err := migrateOpeningBalances(); err != nil {
+		return err
+	}
+
 	logger.Info("Database migration completed")
 
 	return nil
@@ -48,6 +56,23 @@ func AutoMigrate() error {
 		&models.Watchlist{},
 		&models.ScheduledJob{},
 		&models.JobExecutionLog{},
+		&models.DataSource{},
+		&models.PriceBar{},
+		&models.PriceBarSync{},
+		&models.MarketSymbol{},
+		&models.CorporateAction{},
+		&models.CorporateActionAdjustment{},
+		&models.Transaction{},
+		&models.IncomeEvent{},
+		&models.MaturityEvent{},
+		&models.LoanPrepayment{},
+		&models.CreditCard{},
+		&models.CreditCardStatement{},
+		&models.CreditCardReminder{},
+		&models.OtherAsset{},
+		&models.OtherAssetAppraisal{},
+		&models.CollateralWarning{},
+		&models.FXRate{},
 	)
 }
 
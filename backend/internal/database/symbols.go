@@ -0,0 +1,87 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+)
+
+// migrateCanonicalSymbols rewrites symbols stored in provider form (600519.SS, BTC-USD)
+// to canonical form. Rows already in canonical form are left untouched, so it is safe to run on every start.
+func migrateCanonicalSymbols() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var stocks []models.StockAsset
+		if err := tx.Where("exchange = '' OR exchange IS NULL").Find(&stocks).Error; err != nil {
+			return fmt.Errorf("failed to load stock assets: %w", err)
+		}
+		for _, stock := range stocks {
+			sym := symbol.Parse(stock.Symbol)
+			if sym.Code == stock.Symbol && sym.Exchange == symbol.ExchangeNone {
+				continue
+			}
+			if err := tx.Model(&models.StockAsset{}).Where("id = ?", stock.ID).
+				UpdateColumns(map[string]interface{}{"symbol": sym.Code, "exchange": string(sym.Exchange)}).Error; err != nil {
+				return fmt.Errorf("failed to migrate stock asset %d: %w", stock.ID, err)
+			}
+		}
+
+		var cryptos []models.CryptoAsset
+		if err := tx.Find(&cryptos).Error; err != nil {
+			return fmt.Errorf("failed to load crypto assets: %w", err)
+		}
+		for _, crypto := range cryptos {
+			// Before quote currencies were stored, a pair in the symbol was the only source of the quote currency
+			quoteCurrency := crypto.QuoteCurrency
+			if symbol.Parse(crypto.Symbol).IsCrypto() {
+				quoteCurrency = ""
+			}
+			sym := symbol.Crypto(crypto.Symbol, quoteCurrency)
+			if sym.Code == crypto.Symbol && sym.QuoteCurrency == crypto.QuoteCurrency {
+				continue
+			}
+			if err := tx.Model(&models.CryptoAsset{}).Where("id = ?", crypto.ID).
+				UpdateColumns(map[string]interface{}{"symbol": sym.Code, "quote_currency": sym.QuoteCurrency}).Error; err != nil {
+				return fmt.Errorf("failed to migrate crypto asset %d: %w", crypto.ID, err)
+			}
+		}
+
+		var watchlist []models.Watchlist
+		if err := tx.Find(&watchlist).Error; err != nil {
+			return fmt.Errorf("failed to load watchlist: %w", err)
+		}
+		for _, item := range watchlist {
+			canonical := symbol.Parse(item.Symbol).String()
+			if models.AssetType(item.AssetType) == models.AssetTypeCrypto {
+				canonical = symbol.Crypto(item.Symbol, "").String()
+			}
+			if canonical == item.Symbol {
+				continue
+			}
+			if err := tx.Model(&models.Watchlist{}).Where("id = ?", item.ID).
+				UpdateColumn("symbol", canonical).Error; err != nil {
+				return fmt.Errorf("failed to migrate watchlist item %d: %w", item.ID, err)
+			}
+		}
+
+		var barSymbols []string
+		if err := tx.Model(&models.PriceBarSync{}).Distinct().Pluck("symbol", &barSymbols).Error; err != nil {
+			return fmt.Errorf("failed to load price bar symbols: %w", err)
+		}
+		for _, stored := range barSymbols {
+			canonical := symbol.Parse(stored).String()
+			if canonical == stored {
+				continue
+			}
+			if err := tx.Model(&models.PriceBar{}).Where("symbol = ?", stored).UpdateColumn("symbol", canonical).Error; err != nil {
+				return fmt.Errorf("failed to migrate price bars of %s: %w", stored, err)
+			}
+			if err := tx.Model(&models.PriceBarSync{}).Where("symbol = ?", stored).UpdateColumn("symbol", canonical).Error; err != nil {
+				return fmt.Errorf("failed to migrate price bar sync of %s: %w", stored, err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+)
+
+// migrateOpeningBalances records an opening balance for every stock and crypto holding that has
+// no ledger yet, so that holdings entered before the ledger existed become projections of it.
+// Holdings with transactions are left untouched, so it is safe to run on every start.
+func migrateOpeningBalances() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var stocks []models.StockAsset
+		if err := tx.Where("id NOT IN (?)", ledgerAssetIDs(tx, models.AssetTypeStock)).Find(&stocks).Error; err != nil {
+			return fmt.Errorf("failed to load stock assets: %w", err)
+		}
+		for _, stock := range stocks {
+			// The quantity already reflects splits applied to the holding, so the balance opens after them
+			date := stock.CreatedAt
+			var actions []models.CorporateAction
+			if err := tx.Joins("JOIN corporate_action_adjustments ON corporate_action_adjustments.action_id = corporate_actions.id").
+				Where("corporate_action_adjustments.stock_asset_id = ?", stock.ID).
+				Order("corporate_actions.ex_date DESC").Limit(1).Find(&actions).Error; err != nil {
+				return fmt.Errorf("failed to load corporate actions of stock asset %d: %w", stock.ID, err)
+			}
+			if len(actions) > 0 && actions[0].ExDate.After(date) {
+				date = actions[0].ExDate
+			}
+
+			if err := createOpeningBalance(tx, models.AssetTypeStock, stock.ID, stock.Quantity, stock.PurchasePrice, stock.Currency, date); err != nil {
+				return err
+			}
+		}
+
+		var cryptos []models.CryptoAsset
+		if err := tx.Where("id NOT IN (?)", ledgerAssetIDs(tx, models.AssetTypeCrypto)).Find(&cryptos).Error; err != nil {
+			return fmt.Errorf("failed to load crypto assets: %w", err)
+		}
+		for _, crypto := range cryptos {
+			if err := createOpeningBalance(tx, models.AssetTypeCrypto, crypto.ID, crypto.Quantity, crypto.PurchasePrice, crypto.QuoteCurrency, crypto.CreatedAt); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ledgerAssetIDs returns a subquery of the holdings of a type that have transactions
+func ledgerAssetIDs(tx *gorm.DB, assetType models.AssetType) *gorm.DB {
+	return tx.Model(&models.Transaction{}).Where("asset_type = ?", assetType).Select("asset_id")
+}
+
+func createOpeningBalance(tx *gorm.DB, assetType models.AssetType, assetID uint, quantity, price float64, currency string, date time.Time) error {
+	if quantity <= 0 {
+		return nil
+	}
+
+	opening := models.Transaction{
+		AssetType: assetType,
+		AssetID:   assetID,
+		Type:      models.TransactionOpeningBalance,
+		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		Quantity:  quantity,
+		Price:     price,
+		Currency:  currency,
+		Note:      "Migrated holding",
+	}
+	if err := tx.Create(&opening).Error; err != nil {
+		return fmt.Errorf("failed to record opening balance of %s asset %d: %w", assetType, assetID, err)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"trackmymoney/internal/services"
@@ -16,14 +18,24 @@ func SetAssetService(service *services.AssetService) {
 }
 
 type AssetsSummary struct {
-	TotalAssets float64            `json:"total_assets"`
-	TotalDebt   float64            `json:"total_debt"`
-	NetAssets   float64            `json:"net_assets"`
-	Categories  map[string]float64 `json:"categories"`
+	BaseCurrency string                 `json:"base_currency"` // Currency of all amounts
+	TotalAssets  float64                `json:"total_assets"`
+	TotalDebt    float64                `json:"total_debt"`
+	NetAssets    float64                `json:"net_assets"`
+	Categories   map[string]float64     `json:"categories"`
+	Income       services.IncomeSummary `json:"income"` // Net income received
+
+	InterestBearing services.InterestBearingTotals `json:"interest_bearing"` // Principal and accrued interest
+	CreditCards     services.CreditCardTotals      `json:"credit_cards"`     // Balances, limits and utilization
+	OtherAssets     services.OtherAssetTotals      `json:"other_assets"`     // Value of manually valued assets
+
+	Currencies   []services.CurrencyBreakdown `json:"currencies"`              // Assets and debts by original currency
+	MissingRates []string                     `json:"missing_rates,omitempty"` // Currencies counted unconverted for lack of a rate
 }
 
 type AssetHistory struct {
 	Date        string             `json:"date"`
+	Currency    string             `json:"currency"`
 	TotalAssets float64            `json:"total_assets"`
 	TotalDebt   float64            `json:"total_debt"`
 	NetAssets   float64            `json:"net_assets"`
@@ -53,10 +65,19 @@ func GetAssetsSummary(c *gin.Context) {
 
 	// Convert to response format
 	responseSummary := AssetsSummary{
-		TotalAssets: summary.TotalAssets,
-		TotalDebt:   summary.TotalDebt,
-		NetAssets:   summary.NetAssets,
-		Categories:  summary.Categories,
+		BaseCurrency: summary.BaseCurrency,
+		TotalAssets:  summary.TotalAssets,
+		TotalDebt:    summary.TotalDebt,
+		NetAssets:    summary.NetAssets,
+		Categories:   summary.Categories,
+		Income:       summary.Income,
+
+		InterestBearing: summary.InterestBearing,
+		CreditCards:     summary.CreditCards,
+		OtherAssets:     summary.OtherAssets,
+
+		Currencies:   summary.Currencies,
+		MissingRates: summary.MissingRates,
 	}
 
 	response.Success(c, responseSummary)
@@ -91,6 +112,7 @@ func GetAssetsHistory(c *gin.Context) {
 	for _, record := range historyRecords {
 		history = append(history, AssetHistory{
 			Date:        record.Date.Format("2006-01-02"),
+			Currency:    record.Currency,
 			TotalAssets: record.TotalAssets,
 			TotalDebt:   record.TotalDebt,
 			NetAssets:   record.NetAssets,
@@ -106,6 +128,7 @@ type AssetStatisticsItem struct {
 	Profit      float64 `json:"profit"`
 	ProfitRate  float64 `json:"profit_rate"`
 	NetAssets   float64 `json:"net_assets"`
+	Income      float64 `json:"income"` // Net income received in the period
 }
 
 // GetAssetsStatistics gets asset statistics aggregated by dimension
@@ -143,8 +166,40 @@ func GetAssetsStatistics(c *gin.Context) {
 			Profit:      item.Profit,
 			ProfitRate:  item.ProfitRate,
 			NetAssets:   item.NetAssets,
+			Income:      item.Income,
 		})
 	}
 
 	response.Success(c, statistics)
 }
+
+// GetCurrencyAttribution splits the change in net assets by currency
+// @Summary Get currency attribution
+// @Description Split the change in net assets since the first snapshot of a period into the change of the amounts held in each currency and the movement of its exchange rate
+// @Tags assets
+// @Produce json
+// @Param period query string false "Time period: 7d, 30d, 90d, 1y" default(30d)
+// @Success 200 {object} response.Response{data=services.CurrencyAttribution}
+// @Router /api/assets/currency-attribution [get]
+func GetCurrencyAttribution(c *gin.Context) {
+	if globalAssetService == nil {
+		logger.Error("AssetService not initialized")
+		response.InternalError(c, "Service not available")
+		return
+	}
+
+	period := c.DefaultQuery("period", "30d")
+
+	attribution, err := globalAssetService.CurrencyAttribution(period)
+	if err != nil {
+		if errors.Is(err, services.ErrNoCurrencySnapshot) {
+			response.NotFound(c, "No snapshot with a currency breakdown in the period")
+			return
+		}
+		logger.Error("Failed to compute currency attribution", zap.Error(err))
+		response.InternalError(c, "Failed to compute currency attribution")
+		return
+	}
+
+	response.Success(c, attribution)
+}
@@ -21,14 +21,24 @@ func NewAssetSummaryHandler(assetService *services.AssetService) *AssetSummaryHa
 }
 
 type AssetsSummaryResponse struct {
-	TotalAssets float64            `json:"total_assets"`
-	TotalDebt   float64            `json:"total_debt"`
-	NetAssets   float64            `json:"net_assets"`
-	Categories  map[string]float64 `json:"categories"`
+	BaseCurrency string                 `json:"base_currency"` // Currency of all amounts
+	TotalAssets  float64                `json:"total_assets"`
+	TotalDebt    float64                `json:"total_debt"`
+	NetAssets    float64                `json:"net_assets"`
+	Categories   map[string]float64     `json:"categories"`
+	Income       services.IncomeSummary `json:"income"` // Net income received
+
+	InterestBearing services.InterestBearingTotals `json:"interest_bearing"` // Principal and accrued interest
+	CreditCards     services.CreditCardTotals      `json:"credit_cards"`     // Balances, limits and utilization
+	OtherAssets     services.OtherAssetTotals      `json:"other_assets"`     // Value of manually valued assets
+
+	Currencies   []services.CurrencyBreakdown `json:"currencies"`              // Assets and debts by original currency
+	MissingRates []string                     `json:"missing_rates,omitempty"` // Currencies counted unconverted for lack of a rate
 }
 
 type AssetHistoryResponse struct {
 	Date        string             `json:"date"`
+	Currency    string             `json:"currency"`
 	TotalAssets float64            `json:"total_assets"`
 	TotalDebt   float64            `json:"total_debt"`
 	NetAssets   float64            `json:"net_assets"`
@@ -52,10 +62,19 @@ func (h *AssetSummaryHandler) GetSummary(c *gin.Context) {
 
 	// Convert to response format
 	responseSummary := AssetsSummaryResponse{
-		TotalAssets: summary.TotalAssets,
-		TotalDebt:   summary.TotalDebt,
-		NetAssets:   summary.NetAssets,
-		Categories:  summary.Categories,
+		BaseCurrency: summary.BaseCurrency,
+		TotalAssets:  summary.TotalAssets,
+		TotalDebt:    summary.TotalDebt,
+		NetAssets:    summary.NetAssets,
+		Categories:   summary.Categories,
+		Income:       summary.Income,
+
+		InterestBearing: summary.InterestBearing,
+		CreditCards:     summary.CreditCards,
+		OtherAssets:     summary.OtherAssets,
+
+		Currencies:   summary.Currencies,
+		MissingRates: summary.MissingRates,
 	}
 
 	response.Success(c, responseSummary)
@@ -84,6 +103,7 @@ func (h *AssetSummaryHandler) GetHistory(c *gin.Context) {
 	for _, record := range historyRecords {
 		history = append(history, AssetHistoryResponse{
 			Date:        record.Date.Format("2006-01-02"),
+			Currency:    record.Currency,
 			TotalAssets: record.TotalAssets,
 			TotalDebt:   record.TotalDebt,
 			NetAssets:   record.NetAssets,
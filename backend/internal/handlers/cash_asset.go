@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -245,6 +246,10 @@ func DeleteCashAsset(c *gin.Context) {
 	}
 
 	if err := globalCashAssetService.Delete(uint(id)); err != nil {
+		if errors.Is(err, services.ErrAssetSecuresDebt) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Error("Failed to delete cash asset", zap.Error(err))
 		response.NotFound(c, "Cash asset not found")
 		return
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"trackmymoney/internal/database"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var collateralService *services.CollateralService
+
+// SetCollateralService sets the collateral service instance
+func SetCollateralService(service *services.CollateralService) {
+	collateralService = service
+}
+
+// GetEquity lists the equity in each asset or broker account that secures a debt
+// @Summary Get equity of secured assets
+// @Description Value each asset or broker account linked to a debt as collateral, with the linked debts, the equity left and the loan-to-value ratio, in the base currency
+// @Tags assets
+// @Produce json
+// @Success 200 {object} response.Response{data=services.EquityReport}
+// @Router /api/assets/equity [get]
+func GetEquity(c *gin.Context) {
+	report, err := collateralService.Equity(time.Now())
+	if err != nil {
+		logger.Error("Failed to get equity", zap.Error(err))
+		response.InternalError(c, "Failed to get equity")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetCollateralWarnings lists the loan-to-value warnings
+// @Summary List collateral warnings
+// @Description List the warnings recorded when a secured debt's loan-to-value ratio crossed its threshold, newest first
+// @Tags assets
+// @Produce json
+// @Param key query string false "Filter by collateral key, e.g. other:3 or account:Futu"
+// @Success 200 {object} response.Response{data=[]models.CollateralWarning}
+// @Router /api/assets/equity/warnings [get]
+func GetCollateralWarnings(c *gin.Context) {
+	warnings, err := collateralService.Warnings(c.Query("key"))
+	if err != nil {
+		logger.Error("Failed to get collateral warnings", zap.Error(err))
+		response.InternalError(c, "Failed to get collateral warnings")
+		return
+	}
+
+	response.Success(c, warnings)
+}
+
+// CheckCollateral runs the loan-to-value check now
+// @Summary Check loan-to-value ratios
+// @Description Record warnings for positions whose loan-to-value ratio crossed the threshold, as the hourly job does, without sending notifications
+// @Tags assets
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.CollateralWarning}
+// @Router /api/assets/equity/check [post]
+func CheckCollateral(c *gin.Context) {
+	warnings, err := collateralService.Check(time.Now())
+	if err != nil {
+		logger.Error("Failed to check collateral", zap.Error(err))
+		response.InternalError(c, "Failed to check collateral")
+		return
+	}
+
+	response.Success(c, warnings)
+}
+
+// normalizeCollateral validates the collateral of a debt, responding with an error if it is invalid
+func normalizeCollateral(c *gin.Context, asset *models.DebtAsset) bool {
+	if err := services.NormalizeCollateral(database.GetDB(), asset); err != nil {
+		if errors.Is(err, services.ErrInvalidCollateral) {
+			response.BadRequest(c, err.Error())
+		} else {
+			logger.Error("Failed to validate collateral", zap.Error(err))
+			response.InternalError(c, "Failed to validate collateral")
+		}
+		return false
+	}
+	return true
+}
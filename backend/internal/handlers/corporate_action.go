@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/internal/services/symbol"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var corporateActionService *services.CorporateActionService
+
+// SetCorporateActionService sets the corporate action service instance
+func SetCorporateActionService(service *services.CorporateActionService) {
+	corporateActionService = service
+}
+
+// CreateCorporateActionRequest represents the request body for entering a corporate action manually
+type CreateCorporateActionRequest struct {
+	Symbol    string                     `json:"symbol" binding:"required"`  // e.g., AAPL, 600519, 0700.HK
+	Exchange  string                     `json:"exchange"`                   // e.g., SSE, HKEX; inferred from the symbol if empty
+	Type      models.CorporateActionType `json:"type" binding:"required"`    // split or symbol_change
+	ExDate    string                     `json:"ex_date" binding:"required"` // YYYY-MM-DD
+	Ratio     float64                    `json:"ratio"`                      // New shares per old share, for splits
+	NewSymbol string                     `json:"new_symbol"`                 // New symbol, for symbol changes
+	Note      string                     `json:"note"`
+}
+
+// CreateCorporateActionResponse represents the response of a manually entered corporate action
+type CreateCorporateActionResponse struct {
+	Action   models.CorporateAction `json:"action"`
+	Adjusted int                    `json:"adjusted"` // Holdings adjusted
+}
+
+// GetCorporateActions lists recorded corporate actions
+// @Summary List corporate actions
+// @Description List recorded splits and symbol changes, newest first
+// @Tags corporate-actions
+// @Produce json
+// @Param symbol query string false "Filter by symbol (old or new)"
+// @Param type query string false "Filter by type (split, symbol_change)"
+// @Success 200 {object} response.Response{data=[]models.CorporateAction}
+// @Router /api/corporate-actions [get]
+func GetCorporateActions(c *gin.Context) {
+	actions, err := corporateActionService.List(c.Query("symbol"), models.CorporateActionType(c.Query("type")))
+	if err != nil {
+		logger.Error("Failed to get corporate actions", zap.Error(err))
+		response.InternalError(c, "Failed to get corporate actions")
+		return
+	}
+
+	response.Success(c, actions)
+}
+
+// CreateCorporateAction enters a corporate action manually
+// @Summary Create corporate action
+// @Description Enter a split or symbol change; it is applied to affected holdings once its ex-date has passed
+// @Tags corporate-actions
+// @Accept json
+// @Produce json
+// @Param action body CreateCorporateActionRequest true "Corporate action"
+// @Success 200 {object} response.Response{data=CreateCorporateActionResponse}
+// @Router /api/corporate-actions [post]
+func CreateCorporateAction(c *gin.Context) {
+	var req CreateCorporateActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	exDate, err := time.Parse("2006-01-02", req.ExDate)
+	if err != nil {
+		response.BadRequest(c, "Invalid ex_date, expected YYYY-MM-DD")
+		return
+	}
+
+	action := models.CorporateAction{
+		Symbol:    symbol.New(req.Symbol, req.Exchange).String(),
+		Type:      req.Type,
+		ExDate:    exDate,
+		Ratio:     req.Ratio,
+		NewSymbol: req.NewSymbol,
+		Note:      req.Note,
+	}
+
+	adjusted, err := corporateActionService.Create(&action)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCorporateAction) || errors.Is(err, services.ErrDuplicateCorporateAction) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logger.Error("Failed to apply corporate action", zap.Error(err))
+		response.InternalError(c, "Failed to apply corporate action")
+		return
+	}
+
+	logger.Info("Corporate action created", zap.Uint("id", action.ID), zap.Int("adjusted", adjusted))
+	response.Success(c, CreateCorporateActionResponse{Action: action, Adjusted: adjusted})
+}
+
+// SyncCorporateActions fetches corporate actions of held stocks from the provider
+// @Summary Sync corporate actions
+// @Description Fetch splits and symbol changes of held stocks and apply new ones
+// @Tags corporate-actions
+// @Produce json
+// @Success 200 {object} response.Response{data=services.CorporateActionSyncResult}
+// @Router /api/corporate-actions/sync [post]
+func SyncCorporateActions(c *gin.Context) {
+	result, err := corporateActionService.Sync(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to sync corporate actions", zap.Error(err))
+		response.InternalError(c, "Failed to sync corporate actions")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetCorporateActionAdjustments lists the audit records of applied corporate actions
+// @Summary List corporate action adjustments
+// @Description List the holding adjustments made by corporate actions
+// @Tags corporate-actions
+// @Produce json
+// @Param action_id query int false "Filter by corporate action ID"
+// @Param stock_asset_id query int false "Filter by stock asset ID"
+// @Success 200 {object} response.Response{data=[]models.CorporateActionAdjustment}
+// @Router /api/corporate-actions/adjustments [get]
+func GetCorporateActionAdjustments(c *gin.Context) {
+	actionID, _ := strconv.ParseUint(c.Query("action_id"), 10, 32)
+	stockAssetID, _ := strconv.ParseUint(c.Query("stock_asset_id"), 10, 32)
+
+	adjustments, err := corporateActionService.Adjustments(uint(actionID), uint(stockAssetID))
+	if err != nil {
+		logger.Error("Failed to get corporate action adjustments", zap.Error(err))
+		response.InternalError(c, "Failed to get corporate action adjustments")
+		return
+	}
+
+	response.Success(c, adjustments)
+}
@@ -0,0 +1,524 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/database"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var creditCardService *services.CreditCardService
+
+// SetCreditCardService sets the credit card service instance
+func SetCreditCardService(service *services.CreditCardService) {
+	creditCardService = service
+}
+
+// CreateCreditCardRequest represents the request body for creating a credit card
+type CreateCreditCardRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	Issuer            string  `json:"issuer"`
+	Currency          string  `json:"currency"`
+	Description       string  `json:"description"`
+	CreditLimit       float64 `json:"credit_limit" binding:"required"`
+	Balance           float64 `json:"balance"`                          // Amount owed now
+	StatementDay      int     `json:"statement_day" binding:"required"` // Day of month the statement closes
+	DueDay            int     `json:"due_day" binding:"required"`       // Day of month payment is due
+	MinPaymentPercent float64 `json:"min_payment_percent"`              // Percentage of the statement balance; 10 when neither rule is given
+	MinPaymentFloor   float64 `json:"min_payment_floor"`                // Smallest minimum payment
+}
+
+// UpdateCreditCardRequest represents the request body for updating a credit card
+type UpdateCreditCardRequest struct {
+	Name              *string  `json:"name"`
+	Issuer            *string  `json:"issuer"`
+	Currency          *string  `json:"currency"`
+	Description       *string  `json:"description"`
+	CreditLimit       *float64 `json:"credit_limit"`
+	Balance           *float64 `json:"balance"`
+	StatementDay      *int     `json:"statement_day"`
+	DueDay            *int     `json:"due_day"`
+	MinPaymentPercent *float64 `json:"min_payment_percent"`
+	MinPaymentFloor   *float64 `json:"min_payment_floor"`
+}
+
+// CreditCardStatementRequest represents the request body for recording or correcting a statement
+type CreditCardStatementRequest struct {
+	StatementDate  string   `json:"statement_date"` // YYYY-MM-DD; defaults to the latest cycle close
+	DueDate        string   `json:"due_date"`       // YYYY-MM-DD; defaults to the card's due day
+	Balance        *float64 `json:"balance"`
+	MinimumPayment *float64 `json:"minimum_payment"` // Defaults to the card's minimum payment rule
+	PaidAmount     *float64 `json:"paid_amount"`
+	Note           *string  `json:"note"`
+}
+
+// CreditCardPaymentRequest represents the request body for paying a credit card
+type CreditCardPaymentRequest struct {
+	Amount float64 `json:"amount" binding:"required"`
+}
+
+// CreditCardResponse is a credit card with its utilization
+type CreditCardResponse struct {
+	models.CreditCard
+	AvailableCredit float64 `json:"available_credit"`
+	Utilization     float64 `json:"utilization"` // Balance as a percentage of the credit limit
+}
+
+// CreateCreditCard creates a new credit card
+// @Summary Create credit card
+// @Description Create a credit card liability with its statement cycle and minimum payment rule
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param card body CreateCreditCardRequest true "Credit card info"
+// @Success 200 {object} response.Response{data=CreditCardResponse}
+// @Router /api/assets/credit-card [post]
+func CreateCreditCard(c *gin.Context) {
+	var req CreateCreditCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	card := models.CreditCard{
+		Name:              req.Name,
+		Issuer:            req.Issuer,
+		Currency:          req.Currency,
+		Description:       req.Description,
+		CreditLimit:       req.CreditLimit,
+		Balance:           req.Balance,
+		StatementDay:      req.StatementDay,
+		DueDay:            req.DueDay,
+		MinPaymentPercent: req.MinPaymentPercent,
+		MinPaymentFloor:   req.MinPaymentFloor,
+	}
+	if err := creditCardService.SaveCard(&card, time.Now()); err != nil {
+		respondCreditCardError(c, "Failed to create credit card", err)
+		return
+	}
+
+	logger.Info("Credit card created", zap.Uint("id", card.ID))
+	response.Success(c, creditCardResponse(card))
+}
+
+// GetCreditCards retrieves all credit cards
+// @Summary List credit cards
+// @Description Get all credit cards with their cycle state and utilization
+// @Tags assets
+// @Produce json
+// @Success 200 {object} response.Response{data=[]CreditCardResponse}
+// @Router /api/assets/credit-card [get]
+func GetCreditCards(c *gin.Context) {
+	var cards []models.CreditCard
+	if err := database.GetDB().Find(&cards).Error; err != nil {
+		logger.Error("Failed to retrieve credit cards", zap.Error(err))
+		response.InternalError(c, "Failed to retrieve credit cards")
+		return
+	}
+
+	result := make([]CreditCardResponse, 0, len(cards))
+	for _, card := range cards {
+		result = append(result, creditCardResponse(card))
+	}
+	response.Success(c, result)
+}
+
+// GetCreditCard retrieves a single credit card by ID
+// @Summary Get credit card
+// @Description Get a credit card by ID
+// @Tags assets
+// @Produce json
+// @Param id path int true "Credit Card ID"
+// @Success 200 {object} response.Response{data=CreditCardResponse}
+// @Router /api/assets/credit-card/{id} [get]
+func GetCreditCard(c *gin.Context) {
+	card, ok := loadCreditCard(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, creditCardResponse(*card))
+}
+
+// UpdateCreditCard updates a credit card
+// @Summary Update credit card
+// @Description Update a credit card; its next statement and due dates follow the new terms
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Credit Card ID"
+// @Param card body UpdateCreditCardRequest true "Credit card info"
+// @Success 200 {object} response.Response{data=CreditCardResponse}
+// @Router /api/assets/credit-card/{id} [put]
+func UpdateCreditCard(c *gin.Context) {
+	card, ok := loadCreditCard(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCreditCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if req.Name != nil {
+		card.Name = *req.Name
+	}
+	if req.Issuer != nil {
+		card.Issuer = *req.Issuer
+	}
+	if req.Currency != nil {
+		card.Currency = *req.Currency
+	}
+	if req.Description != nil {
+		card.Description = *req.Description
+	}
+	if req.CreditLimit != nil {
+		card.CreditLimit = *req.CreditLimit
+	}
+	if req.Balance != nil {
+		card.Balance = *req.Balance
+	}
+	if req.StatementDay != nil {
+		card.StatementDay = *req.StatementDay
+	}
+	if req.DueDay != nil {
+		card.DueDay = *req.DueDay
+	}
+	if req.MinPaymentPercent != nil {
+		card.MinPaymentPercent = *req.MinPaymentPercent
+	}
+	if req.MinPaymentFloor != nil {
+		card.MinPaymentFloor = *req.MinPaymentFloor
+	}
+
+	if err := creditCardService.SaveCard(card, time.Now()); err != nil {
+		respondCreditCardError(c, "Failed to update credit card", err)
+		return
+	}
+
+	logger.Info("Credit card updated", zap.Uint("id", card.ID))
+	response.Success(c, creditCardResponse(*card))
+}
+
+// DeleteCreditCard deletes a credit card
+// @Summary Delete credit card
+// @Description Delete a credit card with its statements and reminders
+// @Tags assets
+// @Param id path int true "Credit Card ID"
+// @Success 200 {object} response.Response
+// @Router /api/assets/credit-card/{id} [delete]
+func DeleteCreditCard(c *gin.Context) {
+	card, ok := loadCreditCard(c)
+	if !ok {
+		return
+	}
+
+	if err := creditCardService.DeleteCard(card); err != nil {
+		logger.Error("Failed to delete credit card", zap.Error(err))
+		response.InternalError(c, "Failed to delete credit card")
+		return
+	}
+
+	logger.Info("Credit card deleted", zap.Uint("id", card.ID))
+	response.Success(c, gin.H{"message": "Credit card deleted successfully"})
+}
+
+// PayCreditCard records a payment to a credit card
+// @Summary Pay credit card
+// @Description Record a payment; it lowers the card balance and is applied to the unpaid statements oldest first
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Credit Card ID"
+// @Param payment body CreditCardPaymentRequest true "Payment"
+// @Success 200 {object} response.Response{data=CreditCardResponse}
+// @Router /api/assets/credit-card/{id}/payments [post]
+func PayCreditCard(c *gin.Context) {
+	card, ok := loadCreditCard(c)
+	if !ok {
+		return
+	}
+
+	var req CreditCardPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := creditCardService.Pay(card, req.Amount, time.Now()); err != nil {
+		respondCreditCardError(c, "Failed to record payment", err)
+		return
+	}
+
+	logger.Info("Credit card payment recorded", zap.Uint("id", card.ID), zap.Float64("amount", req.Amount))
+	response.Success(c, creditCardResponse(*card))
+}
+
+// GetCreditCardStatements lists the statements of a credit card
+// @Summary List statements
+// @Description List the statements of a credit card, newest first
+// @Tags assets
+// @Produce json
+// @Param id path int true "Credit Card ID"
+// @Success 200 {object} response.Response{data=[]models.CreditCardStatement}
+// @Router /api/assets/credit-card/{id}/statements [get]
+func GetCreditCardStatements(c *gin.Context) {
+	card, ok := loadCreditCard(c)
+	if !ok {
+		return
+	}
+
+	statements, err := creditCardService.Statements(card.ID)
+	if err != nil {
+		logger.Error("Failed to get statements", zap.Error(err))
+		response.InternalError(c, "Failed to get statements")
+		return
+	}
+
+	response.Success(c, statements)
+}
+
+// CreateCreditCardStatement records a statement
+// @Summary Create statement
+// @Description Record the balance billed at the close of a cycle; the due date and minimum payment default to the card's terms
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Credit Card ID"
+// @Param statement body CreditCardStatementRequest true "Statement"
+// @Success 200 {object} response.Response{data=models.CreditCardStatement}
+// @Router /api/assets/credit-card/{id}/statements [post]
+func CreateCreditCardStatement(c *gin.Context) {
+	card, ok := loadCreditCard(c)
+	if !ok {
+		return
+	}
+
+	var req CreditCardStatementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if req.Balance == nil {
+		response.BadRequest(c, "balance is required")
+		return
+	}
+
+	var statement models.CreditCardStatement
+	if !applyStatementRequest(c, &statement, &req) {
+		return
+	}
+	if err := creditCardService.AddStatement(card, &statement, time.Now()); err != nil {
+		respondCreditCardError(c, "Failed to create statement", err)
+		return
+	}
+
+	logger.Info("Credit card statement created", zap.Uint("credit_card_id", card.ID), zap.Uint("id", statement.ID))
+	response.Success(c, statement)
+}
+
+// UpdateCreditCardStatement corrects a statement
+// @Summary Update statement
+// @Description Correct a statement; changing its paid amount does not change the card balance, use the payments endpoint for new payments
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Credit Card ID"
+// @Param statement_id path int true "Statement ID"
+// @Param statement body CreditCardStatementRequest true "Statement"
+// @Success 200 {object} response.Response{data=models.CreditCardStatement}
+// @Router /api/assets/credit-card/{id}/statements/{statement_id} [put]
+func UpdateCreditCardStatement(c *gin.Context) {
+	card, ok := loadCreditCard(c)
+	if !ok {
+		return
+	}
+	statementID, err := strconv.ParseUint(c.Param("statement_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid statement ID")
+		return
+	}
+
+	var statement models.CreditCardStatement
+	if err := database.GetDB().Where("credit_card_id = ?", card.ID).First(&statement, statementID).Error; err != nil {
+		logger.Error("Statement not found", zap.Error(err))
+		response.NotFound(c, "Statement not found")
+		return
+	}
+
+	var req CreditCardStatementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if !applyStatementRequest(c, &statement, &req) {
+		return
+	}
+	if err := creditCardService.UpdateStatement(card, &statement, time.Now()); err != nil {
+		respondCreditCardError(c, "Failed to update statement", err)
+		return
+	}
+
+	logger.Info("Credit card statement updated", zap.Uint("credit_card_id", card.ID), zap.Uint("id", statement.ID))
+	response.Success(c, statement)
+}
+
+// DeleteCreditCardStatement deletes a statement
+// @Summary Delete statement
+// @Description Delete a statement of a credit card and its reminders
+// @Tags assets
+// @Param id path int true "Credit Card ID"
+// @Param statement_id path int true "Statement ID"
+// @Success 200 {object} response.Response
+// @Router /api/assets/credit-card/{id}/statements/{statement_id} [delete]
+func DeleteCreditCardStatement(c *gin.Context) {
+	card, ok := loadCreditCard(c)
+	if !ok {
+		return
+	}
+	statementID, err := strconv.ParseUint(c.Param("statement_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid statement ID")
+		return
+	}
+
+	if err := creditCardService.DeleteStatement(card, uint(statementID), time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Statement not found")
+			return
+		}
+		logger.Error("Failed to delete statement", zap.Error(err))
+		response.InternalError(c, "Failed to delete statement")
+		return
+	}
+
+	logger.Info("Credit card statement deleted", zap.Uint("credit_card_id", card.ID), zap.Uint64("id", statementID))
+	response.Success(c, gin.H{"message": "Statement deleted successfully"})
+}
+
+// GetCreditCardReminders lists the due-soon and overdue reminders
+// @Summary List credit card reminders
+// @Description List the due-soon and overdue reminders recorded by the credit card cycle, newest first
+// @Tags assets
+// @Produce json
+// @Param credit_card_id query int false "Filter by credit card ID"
+// @Success 200 {object} response.Response{data=[]models.CreditCardReminder}
+// @Router /api/assets/credit-card/reminders [get]
+func GetCreditCardReminders(c *gin.Context) {
+	cardID, _ := strconv.ParseUint(c.Query("credit_card_id"), 10, 32)
+
+	reminders, err := creditCardService.Reminders(uint(cardID))
+	if err != nil {
+		logger.Error("Failed to get credit card reminders", zap.Error(err))
+		response.InternalError(c, "Failed to get credit card reminders")
+		return
+	}
+
+	response.Success(c, reminders)
+}
+
+// ProcessCreditCardCycle runs the credit card cycle now
+// @Summary Process credit card cycle
+// @Description Update the cycle state of every credit card and record new reminders, as the daily job does, without sending notifications
+// @Tags assets
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.CreditCardReminder}
+// @Router /api/assets/credit-card/cycle/process [post]
+func ProcessCreditCardCycle(c *gin.Context) {
+	reminders, err := creditCardService.Cycle(time.Now())
+	if err != nil {
+		logger.Error("Failed to process credit card cycle", zap.Error(err))
+		response.InternalError(c, "Failed to process credit card cycle")
+		return
+	}
+
+	response.Success(c, reminders)
+}
+
+// applyStatementRequest copies the fields present in a statement request, responding with an error
+// if a date is malformed
+func applyStatementRequest(c *gin.Context, statement *models.CreditCardStatement, req *CreditCardStatementRequest) bool {
+	if req.StatementDate != "" {
+		date, err := time.Parse("2006-01-02", req.StatementDate)
+		if err != nil {
+			response.BadRequest(c, "Invalid statement_date, expected YYYY-MM-DD")
+			return false
+		}
+		statement.StatementDate = date
+	}
+	if req.DueDate != "" {
+		date, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil {
+			response.BadRequest(c, "Invalid due_date, expected YYYY-MM-DD")
+			return false
+		}
+		statement.DueDate = date
+	}
+	if req.Balance != nil {
+		statement.Balance = *req.Balance
+	}
+	if req.MinimumPayment != nil {
+		statement.MinimumPayment = *req.MinimumPayment
+	}
+	if req.PaidAmount != nil {
+		statement.PaidAmount = *req.PaidAmount
+	}
+	if req.Note != nil {
+		statement.Note = *req.Note
+	}
+	return true
+}
+
+// loadCreditCard loads the credit card named by the id path parameter, responding with an error if it is missing
+func loadCreditCard(c *gin.Context) (*models.CreditCard, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid credit card ID")
+		return nil, false
+	}
+
+	var card models.CreditCard
+	if err := database.GetDB().First(&card, id).Error; err != nil {
+		logger.Error("Credit card not found", zap.Error(err))
+		response.NotFound(c, "Credit card not found")
+		return nil, false
+	}
+	return &card, true
+}
+
+// creditCardResponse adds the utilization to a credit card
+func creditCardResponse(card models.CreditCard) CreditCardResponse {
+	return CreditCardResponse{
+		CreditCard:      card,
+		AvailableCredit: card.CreditLimit - card.Balance,
+		Utilization:     services.CardUtilization(&card),
+	}
+}
+
+// respondCreditCardError maps credit card errors to client errors and logs the rest
+func respondCreditCardError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCreditCard),
+		errors.Is(err, services.ErrInvalidStatement):
+		response.BadRequest(c, err.Error())
+	default:
+		logger.Error(message, zap.Error(err))
+		response.InternalError(c, message)
+	}
+}
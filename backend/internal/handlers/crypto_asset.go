@@ -1,34 +1,45 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"trackmymoney/internal/database"
 	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/internal/services/symbol"
+	"trackmymoney/pkg/errorcode"
 	"trackmymoney/pkg/logger"
 	"trackmymoney/pkg/response"
 )
 
 // CreateCryptoAssetRequest represents the request body for creating a crypto asset
 type CreateCryptoAssetRequest struct {
-	Name          string  `json:"name" binding:"required"`
-	Description   string  `json:"description"`
-	Symbol        string  `json:"symbol" binding:"required"` // e.g., BTC, ETH
-	Quantity      float64 `json:"quantity" binding:"required"`
-	PurchasePrice float64 `json:"purchase_price" binding:"required"`
-	CurrentPrice  float64 `json:"current_price"`
+	Name            string                 `json:"name" binding:"required"`
+	Description     string                 `json:"description"`
+	Symbol          string                 `json:"symbol" binding:"required"` // e.g., BTC, ETH, BTC-CNY
+	QuoteCurrency   string                 `json:"quote_currency"`            // e.g., USD, CNY; defaults to USD
+	Quantity        float64                `json:"quantity" binding:"required"`
+	PurchasePrice   float64                `json:"purchase_price" binding:"required"`
+	CurrentPrice    float64                `json:"current_price"`
+	DataSourceID    *uint                  `json:"data_source_id"`
+	Date            string                 `json:"date"`              // YYYY-MM-DD of the opening balance; defaults to today
+	CostBasisMethod models.CostBasisMethod `json:"cost_basis_method"` // fifo, lifo, average or specific_lot; the configured default if empty
 }
 
 // UpdateCryptoAssetRequest represents the request body for updating a crypto asset
 type UpdateCryptoAssetRequest struct {
-	Name          *string  `json:"name"`
-	Description   *string  `json:"description"`
-	Symbol        *string  `json:"symbol"`
-	Quantity      *float64 `json:"quantity"`
-	PurchasePrice *float64 `json:"purchase_price"`
-	CurrentPrice  *float64 `json:"current_price"`
+	Name            *string                 `json:"name"`
+	Description     *string                 `json:"description"`
+	Symbol          *string                 `json:"symbol"`
+	QuoteCurrency   *string                 `json:"quote_currency"`
+	Quantity        *float64                `json:"quantity"`
+	PurchasePrice   *float64                `json:"purchase_price"`
+	CurrentPrice    *float64                `json:"current_price"`
+	DataSourceID    *uint                   `json:"data_source_id"`    // 0 unbinds the data source
+	CostBasisMethod *models.CostBasisMethod `json:"cost_basis_method"` // Empty resets to the configured default
 }
 
 // CreateCryptoAsset creates a new crypto asset
@@ -48,25 +59,48 @@ func CreateCryptoAsset(c *gin.Context) {
 		return
 	}
 
+	date, err := parseTransactionDate(req.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
 	asset := models.CryptoAsset{
-		Name:          req.Name,
-		Description:   req.Description,
-		Symbol:        req.Symbol,
-		Quantity:      req.Quantity,
-		PurchasePrice: req.PurchasePrice,
-		CurrentPrice:  req.CurrentPrice,
+		Name:            req.Name,
+		Description:     req.Description,
+		Symbol:          req.Symbol,
+		QuoteCurrency:   req.QuoteCurrency,
+		Quantity:        req.Quantity,
+		PurchasePrice:   req.PurchasePrice,
+		CurrentPrice:    req.CurrentPrice,
+		DataSourceID:    req.DataSourceID,
+		CostBasisMethod: req.CostBasisMethod,
 	}
 
+	if err := validateDataSourceID(asset.DataSourceID); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if asset.CostBasisMethod != "" && !services.ValidCostBasisMethod(asset.CostBasisMethod) {
+		response.BadRequest(c, "Invalid cost_basis_method, expected fifo, lifo, average or specific_lot")
+		return
+	}
+
+	services.NormalizeCryptoAsset(&asset)
+
 	// Validate symbol and enrich with market data
 	if assetMarketService != nil {
-		if err := assetMarketService.ValidateAndEnrichCryptoAsset(&asset); err != nil {
+		if err := assetMarketService.ValidateAndEnrichCryptoAsset(c.Request.Context(), &asset); err != nil {
 			logger.Warn("Failed to validate/enrich crypto asset", zap.Error(err))
 			// Don't fail the request, just log the warning
 		}
 	}
 
-	db := database.GetDB()
-	if err := db.Create(&asset).Error; err != nil {
+	if err := transactionService.CreateCrypto(&asset, date); err != nil {
+		if errors.Is(err, services.ErrInvalidTransaction) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Error("Failed to create crypto asset", zap.Error(err))
 		response.InternalError(c, "Failed to create crypto asset")
 		return
@@ -166,11 +200,20 @@ func UpdateCryptoAsset(c *gin.Context) {
 	if req.Description != nil {
 		asset.Description = *req.Description
 	}
-	if req.Symbol != nil {
-		if *req.Symbol != asset.Symbol {
-			symbolChanged = true
+	if req.Symbol != nil || req.QuoteCurrency != nil {
+		previous := services.CryptoSymbol(&asset)
+		if req.Symbol != nil {
+			asset.Symbol = *req.Symbol
+			// A symbol written as a pair (BTC-CNY) carries its own quote currency
+			if symbol.Parse(asset.Symbol).IsCrypto() {
+				asset.QuoteCurrency = ""
+			}
+		}
+		if req.QuoteCurrency != nil {
+			asset.QuoteCurrency = *req.QuoteCurrency
 		}
-		asset.Symbol = *req.Symbol
+		services.NormalizeCryptoAsset(&asset)
+		symbolChanged = services.CryptoSymbol(&asset) != previous
 	}
 	if req.Quantity != nil {
 		asset.Quantity = *req.Quantity
@@ -181,15 +224,38 @@ func UpdateCryptoAsset(c *gin.Context) {
 	if req.CurrentPrice != nil {
 		asset.CurrentPrice = *req.CurrentPrice
 	}
+	if req.DataSourceID != nil {
+		if *req.DataSourceID == 0 {
+			asset.DataSourceID = nil
+		} else {
+			if err := validateDataSourceID(req.DataSourceID); err != nil {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			asset.DataSourceID = req.DataSourceID
+		}
+	}
+
+	if req.CostBasisMethod != nil {
+		if *req.CostBasisMethod != "" && !services.ValidCostBasisMethod(*req.CostBasisMethod) {
+			response.BadRequest(c, "Invalid cost_basis_method, expected fifo, lifo, average or specific_lot")
+			return
+		}
+		asset.CostBasisMethod = *req.CostBasisMethod
+	}
 
 	// If symbol changed, revalidate and update market data
 	if symbolChanged && assetMarketService != nil {
-		if err := assetMarketService.ValidateAndEnrichCryptoAsset(&asset); err != nil {
+		if err := assetMarketService.ValidateAndEnrichCryptoAsset(c.Request.Context(), &asset); err != nil {
 			logger.Warn("Failed to validate/enrich updated crypto asset", zap.Error(err))
 		}
 	}
 
-	if err := db.Save(&asset).Error; err != nil {
+	if err := transactionService.SaveCrypto(&asset); err != nil {
+		if errors.Is(err, services.ErrLedgerHasTrades) || errors.Is(err, services.ErrInvalidTransaction) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Error("Failed to update crypto asset", zap.Error(err))
 		response.InternalError(c, "Failed to update crypto asset")
 		return
@@ -222,7 +288,11 @@ func DeleteCryptoAsset(c *gin.Context) {
 		return
 	}
 
-	if err := db.Delete(&asset).Error; err != nil {
+	if err := transactionService.DeleteHolding(models.AssetTypeCrypto, asset.ID); err != nil {
+		if errors.Is(err, services.ErrAssetSecuresDebt) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Error("Failed to delete crypto asset", zap.Error(err))
 		response.InternalError(c, "Failed to delete crypto asset")
 		return
@@ -264,9 +334,13 @@ func RefreshCryptoAssetsPrices(c *gin.Context) {
 	}
 
 	// Update prices
-	updated, failed, err := assetMarketService.UpdateCryptoAssetsPrices(assets)
+	updated, failed, err := assetMarketService.UpdateCryptoAssetsPrices(c.Request.Context(), assets)
 	if err != nil {
 		logger.Error("Failed to refresh crypto prices", zap.Error(err))
+		if services.IsMarketFastFail(err) {
+			response.ErrorWithCode(c, errorcode.ExternalAPIError, "Failed to refresh crypto prices: "+err.Error())
+			return
+		}
 		response.InternalError(c, "Failed to refresh crypto prices")
 		return
 	}
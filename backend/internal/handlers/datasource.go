@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"trackmymoney/internal/database"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/provider"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+type CreateDataSourceRequest struct {
+	Name        string              `json:"name" binding:"required"`
+	Provider    models.ProviderType `json:"provider" binding:"required"`
+	Description string              `json:"description"`
+	BaseURL     string              `json:"base_url"`
+	Credentials string              `json:"credentials"`
+	Enabled     *bool               `json:"enabled"`
+
+	Priority     int     `json:"priority"`
+	AssetClasses string  `json:"asset_classes"`
+	RateLimit    float64 `json:"rate_limit" binding:"gte=0"`
+}
+
+type UpdateDataSourceRequest struct {
+	Name        *string              `json:"name"`
+	Provider    *models.ProviderType `json:"provider"`
+	Description *string              `json:"description"`
+	BaseURL     *string              `json:"base_url"`
+	Credentials *string              `json:"credentials"`
+	Enabled     *bool                `json:"enabled"`
+
+	Priority     *int     `json:"priority"`
+	AssetClasses *string  `json:"asset_classes"`
+	RateLimit    *float64 `json:"rate_limit" binding:"omitempty,gte=0"`
+}
+
+// DataSourceResponse is a data source without its credentials, which are write-only
+type DataSourceResponse struct {
+	models.DataSource
+	HasCredentials bool `json:"has_credentials"`
+}
+
+// newDataSourceResponse hides the credentials of a data source
+func newDataSourceResponse(dataSource models.DataSource) DataSourceResponse {
+	return DataSourceResponse{DataSource: dataSource, HasCredentials: dataSource.Credentials != ""}
+}
+
+// @Summary Create data source
+// @Description Create a new market data source
+// @Tags datasources
+// @Accept json
+// @Produce json
+// @Param datasource body CreateDataSourceRequest true "Data source info"
+// @Success 200 {object} response.Response{data=DataSourceResponse}
+// @Router /api/datasources [post]
+func CreateDataSource(c *gin.Context) {
+	var req CreateDataSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if !provider.IsSupported(req.Provider) {
+		response.BadRequest(c, "Unsupported provider: "+string(req.Provider))
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	dataSource := models.DataSource{
+		Name:        req.Name,
+		Provider:    req.Provider,
+		Description: req.Description,
+		BaseURL:     req.BaseURL,
+		Credentials: req.Credentials,
+		Enabled:     enabled,
+
+		Priority:     req.Priority,
+		AssetClasses: req.AssetClasses,
+		RateLimit:    req.RateLimit,
+	}
+
+	db := database.GetDB()
+	if err := db.Create(&dataSource).Error; err != nil {
+		logger.Error("Failed to create data source", zap.Error(err))
+		response.InternalError(c, "Failed to create data source")
+		return
+	}
+
+	logger.Info("Data source created", zap.Uint("id", dataSource.ID))
+	response.Success(c, newDataSourceResponse(dataSource))
+}
+
+// @Summary List data sources
+// @Description Get all market data sources
+// @Tags datasources
+// @Produce json
+// @Success 200 {object} response.Response{data=[]DataSourceResponse}
+// @Router /api/datasources [get]
+func GetDataSources(c *gin.Context) {
+	var dataSources []models.DataSource
+	db := database.GetDB()
+
+	if err := db.Order("priority ASC, id ASC").Find(&dataSources).Error; err != nil {
+		logger.Error("Failed to retrieve data sources", zap.Error(err))
+		response.InternalError(c, "Failed to retrieve data sources")
+		return
+	}
+
+	result := make([]DataSourceResponse, 0, len(dataSources))
+	for _, dataSource := range dataSources {
+		result = append(result, newDataSourceResponse(dataSource))
+	}
+	response.Success(c, result)
+}
+
+// @Summary Get data source
+// @Description Get a market data source by ID
+// @Tags datasources
+// @Produce json
+// @Param id path int true "Data source ID"
+// @Success 200 {object} response.Response{data=DataSourceResponse}
+// @Router /api/datasources/{id} [get]
+func GetDataSource(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid data source ID")
+		return
+	}
+
+	var dataSource models.DataSource
+	db := database.GetDB()
+
+	if err := db.First(&dataSource, id).Error; err != nil {
+		logger.Error("Data source not found", zap.Error(err))
+		response.NotFound(c, "Data source not found")
+		return
+	}
+
+	response.Success(c, newDataSourceResponse(dataSource))
+}
+
+// @Summary Update data source
+// @Description Update a market data source
+// @Tags datasources
+// @Accept json
+// @Produce json
+// @Param id path int true "Data source ID"
+// @Param datasource body UpdateDataSourceRequest true "Data source info"
+// @Success 200 {object} response.Response{data=DataSourceResponse}
+// @Router /api/datasources/{id} [put]
+func UpdateDataSource(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid data source ID")
+		return
+	}
+
+	var req UpdateDataSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	db := database.GetDB()
+	var dataSource models.DataSource
+
+	if err := db.First(&dataSource, id).Error; err != nil {
+		logger.Error("Data source not found", zap.Error(err))
+		response.NotFound(c, "Data source not found")
+		return
+	}
+
+	if req.Name != nil {
+		dataSource.Name = *req.Name
+	}
+	if req.Provider != nil {
+		if !provider.IsSupported(*req.Provider) {
+			response.BadRequest(c, "Unsupported provider: "+string(*req.Provider))
+			return
+		}
+		dataSource.Provider = *req.Provider
+	}
+	if req.Description != nil {
+		dataSource.Description = *req.Description
+	}
+	if req.BaseURL != nil {
+		dataSource.BaseURL = *req.BaseURL
+	}
+	if req.Credentials != nil {
+		dataSource.Credentials = *req.Credentials
+	}
+	if req.Enabled != nil {
+		dataSource.Enabled = *req.Enabled
+	}
+	if req.Priority != nil {
+		dataSource.Priority = *req.Priority
+	}
+	if req.AssetClasses != nil {
+		dataSource.AssetClasses = *req.AssetClasses
+	}
+	if req.RateLimit != nil {
+		dataSource.RateLimit = *req.RateLimit
+	}
+
+	if err := db.Save(&dataSource).Error; err != nil {
+		logger.Error("Failed to update data source", zap.Error(err))
+		response.InternalError(c, "Failed to update data source")
+		return
+	}
+
+	logger.Info("Data source updated", zap.Uint("id", dataSource.ID))
+	response.Success(c, newDataSourceResponse(dataSource))
+}
+
+// @Summary Delete data source
+// @Description Delete a market data source. Assets bound to it fall back to the default source.
+// @Tags datasources
+// @Param id path int true "Data source ID"
+// @Success 200 {object} response.Response
+// @Router /api/datasources/{id} [delete]
+func DeleteDataSource(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid data source ID")
+		return
+	}
+
+	db := database.GetDB()
+	var dataSource models.DataSource
+
+	if err := db.First(&dataSource, id).Error; err != nil {
+		logger.Error("Data source not found", zap.Error(err))
+		response.NotFound(c, "Data source not found")
+		return
+	}
+
+	if err := db.Delete(&dataSource).Error; err != nil {
+		logger.Error("Failed to delete data source", zap.Error(err))
+		response.InternalError(c, "Failed to delete data source")
+		return
+	}
+
+	// Unbind assets and watchlist items so they use the default source again
+	for _, model := range []interface{}{&models.StockAsset{}, &models.CryptoAsset{}, &models.Watchlist{}} {
+		if err := db.Model(model).Where("data_source_id = ?", id).Update("data_source_id", nil).Error; err != nil {
+			logger.Warn("Failed to unbind data source", zap.Uint("id", uint(id)), zap.Error(err))
+		}
+	}
+
+	logger.Info("Data source deleted", zap.Uint("id", uint(id)))
+	response.Success(c, gin.H{"message": "Data source deleted successfully"})
+}
+
+// validateDataSourceID checks that an optional data source binding points to an existing record
+func validateDataSourceID(dataSourceID *uint) error {
+	if dataSourceID == nil {
+		return nil
+	}
+
+	var dataSource models.DataSource
+	if err := database.GetDB().First(&dataSource, *dataSourceID).Error; err != nil {
+		return fmt.Errorf("data source %d not found", *dataSourceID)
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"go.uber.org/zap"
 	"trackmymoney/internal/database"
 	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
 	"trackmymoney/pkg/logger"
 	"trackmymoney/pkg/response"
 )
@@ -19,8 +20,21 @@ type CreateDebtAssetRequest struct {
 	Currency     string     `json:"currency"`
 	Description  string     `json:"description"`
 	Creditor     string     `json:"creditor" binding:"required"`
-	InterestRate *float64   `json:"interest_rate,omitempty"`
+	InterestRate *float64   `json:"interest_rate,omitempty"` // Annual rate in percentage
 	DueDate      *time.Time `json:"due_date,omitempty"`
+
+	// Loan terms, for debts repaid in installments
+	Amortization     models.AmortizationType `json:"amortization"`      // equal_installment or equal_principal
+	Principal        float64                 `json:"principal"`         // Amount borrowed
+	TermMonths       int                     `json:"term_months"`       // Term in months
+	PaymentFrequency models.PaymentFrequency `json:"payment_frequency"` // monthly (default), quarterly, semiannual or annual
+	LoanStartDate    *time.Time              `json:"loan_start_date"`   // The first installment is due one period later
+
+	// Collateral, for secured debts such as a mortgage or a margin loan
+	CollateralType    models.AssetType `json:"collateral_type"`    // cash, interest_bearing, stock, crypto or other
+	CollateralID      *uint            `json:"collateral_id"`      // ID of the collateral asset
+	CollateralAccount string           `json:"collateral_account"` // Broker account whose stocks secure the debt, instead of a single asset
+	LTVThreshold      *float64         `json:"ltv_threshold"`      // Loan-to-value percentage that triggers a warning
 }
 
 // UpdateDebtAssetRequest represents the request body for updating a debt asset
@@ -32,16 +46,33 @@ type UpdateDebtAssetRequest struct {
 	Creditor     *string    `json:"creditor"`
 	InterestRate *float64   `json:"interest_rate,omitempty"`
 	DueDate      *time.Time `json:"due_date,omitempty"`
+
+	Amortization     *models.AmortizationType `json:"amortization"` // Empty turns the loan into a static balance
+	Principal        *float64                 `json:"principal"`
+	TermMonths       *int                     `json:"term_months"`
+	PaymentFrequency *models.PaymentFrequency `json:"payment_frequency"`
+	LoanStartDate    *time.Time               `json:"loan_start_date"`
+
+	CollateralType    *models.AssetType `json:"collateral_type"` // Empty makes the debt unsecured
+	CollateralID      *uint             `json:"collateral_id"`
+	CollateralAccount *string           `json:"collateral_account"`
+	LTVThreshold      *float64          `json:"ltv_threshold"` // 0 uses the configured default
+}
+
+// DebtAssetResponse is a debt with its outstanding balance
+type DebtAssetResponse struct {
+	models.DebtAsset
+	Balance float64 `json:"balance"` // Outstanding today: the amortized balance for loans, the amount otherwise
 }
 
 // CreateDebtAsset creates a new debt asset
 // @Summary Create debt asset
-// @Description Create a new debt/liability asset
+// @Description Create a new debt/liability asset, optionally secured by an asset or the stocks of a broker account
 // @Tags assets
 // @Accept json
 // @Produce json
 // @Param asset body CreateDebtAssetRequest true "Debt asset info"
-// @Success 200 {object} response.Response{data=models.DebtAsset}
+// @Success 200 {object} response.Response{data=DebtAssetResponse}
 // @Router /api/assets/debt [post]
 func CreateDebtAsset(c *gin.Context) {
 	var req CreateDebtAssetRequest
@@ -59,11 +90,29 @@ func CreateDebtAsset(c *gin.Context) {
 		Creditor:     req.Creditor,
 		InterestRate: req.InterestRate,
 		DueDate:      req.DueDate,
+
+		Amortization:     req.Amortization,
+		Principal:        req.Principal,
+		TermMonths:       req.TermMonths,
+		PaymentFrequency: req.PaymentFrequency,
+		LoanStartDate:    req.LoanStartDate,
+
+		CollateralType:    req.CollateralType,
+		CollateralID:      req.CollateralID,
+		CollateralAccount: req.CollateralAccount,
+		LTVThreshold:      req.LTVThreshold,
 	}
 
 	if asset.Currency == "" {
 		asset.Currency = "CNY"
 	}
+	if err := services.NormalizeLoanTerms(&asset); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if !normalizeCollateral(c, &asset) {
+		return
+	}
 
 	db := database.GetDB()
 	if err := db.Create(&asset).Error; err != nil {
@@ -73,7 +122,7 @@ func CreateDebtAsset(c *gin.Context) {
 	}
 
 	logger.Info("Debt asset created", zap.Uint("id", asset.ID))
-	response.Success(c, asset)
+	respondDebtAsset(c, asset)
 }
 
 // GetDebtAssets retrieves all debt assets
@@ -81,7 +130,7 @@ func CreateDebtAsset(c *gin.Context) {
 // @Description Get all debt/liability assets
 // @Tags assets
 // @Produce json
-// @Success 200 {object} response.Response{data=[]models.DebtAsset}
+// @Success 200 {object} response.Response{data=[]DebtAssetResponse}
 // @Router /api/assets/debt [get]
 func GetDebtAssets(c *gin.Context) {
 	var assets []models.DebtAsset
@@ -93,7 +142,18 @@ func GetDebtAssets(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, assets)
+	result := make([]DebtAssetResponse, 0, len(assets))
+	for _, asset := range assets {
+		balance, err := loanService.Balance(&asset, time.Now())
+		if err != nil {
+			logger.Error("Failed to compute debt balance", zap.Uint("id", asset.ID), zap.Error(err))
+			response.InternalError(c, "Failed to retrieve debt assets")
+			return
+		}
+		result = append(result, DebtAssetResponse{DebtAsset: asset, Balance: balance})
+	}
+
+	response.Success(c, result)
 }
 
 // GetDebtAsset retrieves a single debt asset by ID
@@ -102,7 +162,7 @@ func GetDebtAssets(c *gin.Context) {
 // @Tags assets
 // @Produce json
 // @Param id path int true "Debt Asset ID"
-// @Success 200 {object} response.Response{data=models.DebtAsset}
+// @Success 200 {object} response.Response{data=DebtAssetResponse}
 // @Router /api/assets/debt/{id} [get]
 func GetDebtAsset(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -120,7 +180,7 @@ func GetDebtAsset(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, asset)
+	respondDebtAsset(c, asset)
 }
 
 // UpdateDebtAsset updates an existing debt asset
@@ -131,7 +191,7 @@ func GetDebtAsset(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Debt Asset ID"
 // @Param asset body UpdateDebtAssetRequest true "Debt asset info"
-// @Success 200 {object} response.Response{data=models.DebtAsset}
+// @Success 200 {object} response.Response{data=DebtAssetResponse}
 // @Router /api/assets/debt/{id} [put]
 func UpdateDebtAsset(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -178,6 +238,50 @@ func UpdateDebtAsset(c *gin.Context) {
 	if req.DueDate != nil {
 		asset.DueDate = req.DueDate
 	}
+	if req.Amortization != nil {
+		asset.Amortization = *req.Amortization
+	}
+	if req.Principal != nil {
+		asset.Principal = *req.Principal
+	}
+	if req.TermMonths != nil {
+		asset.TermMonths = *req.TermMonths
+	}
+	if req.PaymentFrequency != nil {
+		asset.PaymentFrequency = *req.PaymentFrequency
+	}
+	if req.LoanStartDate != nil {
+		asset.LoanStartDate = req.LoanStartDate
+	}
+	if req.CollateralType != nil {
+		asset.CollateralType = *req.CollateralType
+		if asset.CollateralType == "" {
+			asset.CollateralID = nil
+			asset.CollateralAccount = ""
+		}
+	}
+	// An asset and a broker account replace each other
+	if req.CollateralID != nil {
+		asset.CollateralID = req.CollateralID
+		asset.CollateralAccount = ""
+	}
+	if req.CollateralAccount != nil {
+		asset.CollateralAccount = *req.CollateralAccount
+		if asset.CollateralAccount != "" {
+			asset.CollateralType = models.AssetTypeStock
+			asset.CollateralID = nil
+		}
+	}
+	if req.LTVThreshold != nil {
+		asset.LTVThreshold = req.LTVThreshold
+	}
+	if err := services.NormalizeLoanTerms(&asset); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if !normalizeCollateral(c, &asset) {
+		return
+	}
 
 	if err := db.Save(&asset).Error; err != nil {
 		logger.Error("Failed to update debt asset", zap.Error(err))
@@ -186,7 +290,7 @@ func UpdateDebtAsset(c *gin.Context) {
 	}
 
 	logger.Info("Debt asset updated", zap.Uint("id", asset.ID))
-	response.Success(c, asset)
+	respondDebtAsset(c, asset)
 }
 
 // DeleteDebtAsset deletes a debt asset
@@ -212,7 +316,7 @@ func DeleteDebtAsset(c *gin.Context) {
 		return
 	}
 
-	if err := db.Delete(&asset).Error; err != nil {
+	if err := loanService.DeleteDebt(&asset); err != nil {
 		logger.Error("Failed to delete debt asset", zap.Error(err))
 		response.InternalError(c, "Failed to delete debt asset")
 		return
@@ -221,3 +325,15 @@ func DeleteDebtAsset(c *gin.Context) {
 	logger.Info("Debt asset deleted", zap.Uint("id", uint(id)))
 	response.Success(c, gin.H{"message": "Debt asset deleted successfully"})
 }
+
+// respondDebtAsset responds with a debt and its outstanding balance
+func respondDebtAsset(c *gin.Context, asset models.DebtAsset) {
+	balance, err := loanService.Balance(&asset, time.Now())
+	if err != nil {
+		logger.Error("Failed to compute debt balance", zap.Uint("id", asset.ID), zap.Error(err))
+		response.InternalError(c, "Failed to compute debt balance")
+		return
+	}
+
+	response.Success(c, DebtAssetResponse{DebtAsset: asset, Balance: balance})
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var fxService *services.FXService
+
+// SetFXService sets the FX service instance
+func SetFXService(service *services.FXService) {
+	fxService = service
+}
+
+// SetFXRateRequest represents the request body for a manual exchange rate
+type SetFXRateRequest struct {
+	BaseCurrency  string  `json:"base_currency" binding:"required"`
+	QuoteCurrency string  `json:"quote_currency"`          // Defaults to the base currency of summaries
+	Rate          float64 `json:"rate" binding:"required"` // Units of the quote currency per unit of the base currency
+	Date          string  `json:"date"`                    // YYYY-MM-DD; defaults to today. The rate applies from this date on
+	Note          string  `json:"note"`
+}
+
+// FXBackfillResponse reports an exchange rate backfill
+type FXBackfillResponse struct {
+	BaseCurrency string    `json:"base_currency"`
+	From         time.Time `json:"from"`
+	Stored       int       `json:"stored"`
+	Failed       []string  `json:"failed"` // Currencies the provider has no history for
+}
+
+// FXSyncResponse reports an exchange rate sync
+type FXSyncResponse struct {
+	BaseCurrency string   `json:"base_currency"`
+	Synced       int      `json:"synced"`
+	Failed       []string `json:"failed"` // Currencies the provider has no rate for
+}
+
+// GetFXRates lists stored exchange rates
+// @Summary List exchange rates
+// @Description List stored provider and manual exchange rates, newest first
+// @Tags fx
+// @Produce json
+// @Param currency query string false "Only pairs involving this currency"
+// @Param limit query int false "Maximum number of rates"
+// @Success 200 {object} response.Response{data=[]models.FXRate}
+// @Router /api/fx/rates [get]
+func GetFXRates(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			response.BadRequest(c, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	rates, err := fxService.Rates(c.Query("currency"), limit)
+	if err != nil {
+		logger.Error("Failed to get exchange rates", zap.Error(err))
+		response.InternalError(c, "Failed to get exchange rates")
+		return
+	}
+
+	response.Success(c, rates)
+}
+
+// GetLatestFXRates returns the exchange rates in effect
+// @Summary Current exchange rates
+// @Description The rate in effect today of every held currency against the base currency; manual rates take precedence
+// @Tags fx
+// @Produce json
+// @Success 200 {object} response.Response{data=[]services.FXQuote}
+// @Router /api/fx/latest [get]
+func GetLatestFXRates(c *gin.Context) {
+	quotes, err := fxService.Latest(time.Now())
+	if err != nil {
+		logger.Error("Failed to get current exchange rates", zap.Error(err))
+		response.InternalError(c, "Failed to get current exchange rates")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"base_currency": fxService.Base(),
+		"rates":         quotes,
+	})
+}
+
+// SetFXRate records a manual exchange rate
+// @Summary Set exchange rate
+// @Description Record a manual exchange rate that overrides the provider rate of the same date
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param rate body SetFXRateRequest true "Exchange rate"
+// @Success 200 {object} response.Response{data=models.FXRate}
+// @Router /api/fx/rates [post]
+func SetFXRate(c *gin.Context) {
+	var req SetFXRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	date, err := parseTransactionDate(req.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	rate := models.FXRate{
+		BaseCurrency:  req.BaseCurrency,
+		QuoteCurrency: req.QuoteCurrency,
+		Date:          date,
+		Rate:          req.Rate,
+		Note:          req.Note,
+	}
+	if rate.QuoteCurrency == "" {
+		rate.QuoteCurrency = fxService.Base()
+	}
+
+	if err := fxService.SetRate(&rate); err != nil {
+		if errors.Is(err, services.ErrInvalidFXRate) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logger.Error("Failed to set exchange rate", zap.Error(err))
+		response.InternalError(c, "Failed to set exchange rate")
+		return
+	}
+
+	logger.Info("Exchange rate set", zap.String("base", rate.BaseCurrency), zap.String("quote", rate.QuoteCurrency), zap.Float64("rate", rate.Rate))
+	response.Success(c, rate)
+}
+
+// DeleteFXRate deletes a stored exchange rate
+// @Summary Delete exchange rate
+// @Description Delete a stored exchange rate
+// @Tags fx
+// @Produce json
+// @Param id path int true "Exchange rate ID"
+// @Success 200 {object} response.Response
+// @Router /api/fx/rates/{id} [delete]
+func DeleteFXRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid exchange rate ID")
+		return
+	}
+
+	if err := fxService.DeleteRate(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Exchange rate not found")
+			return
+		}
+		logger.Error("Failed to delete exchange rate", zap.Error(err))
+		response.InternalError(c, "Failed to delete exchange rate")
+		return
+	}
+
+	logger.Info("Exchange rate deleted", zap.Uint("id", uint(id)))
+	response.Success(c, gin.H{"message": "Exchange rate deleted successfully"})
+}
+
+// SyncFXRates fetches today's exchange rates from the provider
+// @Summary Sync exchange rates
+// @Description Fetch and store today's rate of every held currency against the base currency
+// @Tags fx
+// @Produce json
+// @Success 200 {object} response.Response{data=FXSyncResponse}
+// @Router /api/fx/sync [post]
+func SyncFXRates(c *gin.Context) {
+	count, failed, err := fxService.Sync(c.Request.Context(), time.Now())
+	if err != nil {
+		logger.Error("Failed to sync exchange rates", zap.Error(err))
+		response.InternalError(c, "Failed to sync exchange rates")
+		return
+	}
+
+	response.Success(c, FXSyncResponse{
+		BaseCurrency: fxService.Base(),
+		Synced:       count,
+		Failed:       failed,
+	})
+}
+
+// BackfillFXRates fetches past exchange rates from the provider
+// @Summary Backfill exchange rates
+// @Description Fetch and store the daily rates of every held currency since a date, so that snapshots, transactions and income are converted at the rate of their own date
+// @Tags fx
+// @Produce json
+// @Param from query string false "First date (YYYY-MM-DD); defaults to the oldest snapshot, transaction or income event"
+// @Success 200 {object} response.Response{data=FXBackfillResponse}
+// @Router /api/fx/backfill [post]
+func BackfillFXRates(c *gin.Context) {
+	now := time.Now()
+	var from time.Time
+	if value := c.Query("from"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			response.BadRequest(c, "Invalid from, expected YYYY-MM-DD")
+			return
+		}
+		from = date
+	} else {
+		earliest, ok, err := fxService.EarliestDate()
+		if err != nil {
+			logger.Error("Failed to find the earliest date", zap.Error(err))
+			response.InternalError(c, "Failed to backfill exchange rates")
+			return
+		}
+		if !ok {
+			earliest = now
+		}
+		from = earliest
+	}
+
+	stored, failed, err := fxService.Backfill(c.Request.Context(), from, now)
+	if err != nil {
+		logger.Error("Failed to backfill exchange rates", zap.Error(err))
+		response.InternalError(c, "Failed to backfill exchange rates")
+		return
+	}
+
+	logger.Info("Exchange rates backfilled", zap.Int("stored", stored), zap.Strings("failed", failed))
+	response.Success(c, FXBackfillResponse{
+		BaseCurrency: fxService.Base(),
+		From:         from,
+		Stored:       stored,
+		Failed:       failed,
+	})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"trackmymoney/internal/models"
+	"trackmymoney/pkg/response"
+)
+
+// GetRealizedGains reports realized gains
+// @Summary Realized gains
+// @Description Realized profit and loss of the sales in a date range, per sale with the lots it was matched to, and grouped by holding and by year. Base currency amounts convert proceeds at the rate of the sale date and each lot's cost at the rate of its acquisition date
+// @Tags gains
+// @Produce json
+// @Param from query string false "Earliest sale date (YYYY-MM-DD)"
+// @Param to query string false "Latest sale date (YYYY-MM-DD)"
+// @Param asset_type query string false "Filter by asset type (stock, crypto)"
+// @Param asset_id query int false "Filter by asset ID"
+// @Success 200 {object} response.Response{data=services.RealizedReport}
+// @Router /api/gains/realized [get]
+func GetRealizedGains(c *gin.Context) {
+	var from, to *time.Time
+	for param, target := range map[string]**time.Time{"from": &from, "to": &to} {
+		if value := c.Query(param); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				response.BadRequest(c, "Invalid "+param+", expected YYYY-MM-DD")
+				return
+			}
+			*target = &date
+		}
+	}
+	assetID, _ := strconv.ParseUint(c.Query("asset_id"), 10, 32)
+
+	report, err := transactionService.RealizedGains(from, to, models.AssetType(c.Query("asset_type")), uint(assetID))
+	if err != nil {
+		respondTransactionError(c, "Failed to compute realized gains", err)
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetOpenLots lists open lots with their unrealized gains
+// @Summary Open lots
+// @Description Open lots of stock and crypto holdings under their cost basis method, valued at the stored current price
+// @Tags gains
+// @Produce json
+// @Param asset_type query string false "Filter by asset type (stock, crypto)"
+// @Param asset_id query int false "Filter by asset ID"
+// @Success 200 {object} response.Response{data=[]services.HoldingLots}
+// @Router /api/gains/lots [get]
+func GetOpenLots(c *gin.Context) {
+	assetID, _ := strconv.ParseUint(c.Query("asset_id"), 10, 32)
+
+	lots, err := transactionService.OpenLots(models.AssetType(c.Query("asset_type")), uint(assetID))
+	if err != nil {
+		respondTransactionError(c, "Failed to compute open lots", err)
+		return
+	}
+
+	response.Success(c, lots)
+}
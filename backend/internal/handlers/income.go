@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var incomeService *services.IncomeService
+
+// SetIncomeService sets the income service instance
+func SetIncomeService(service *services.IncomeService) {
+	incomeService = service
+}
+
+// CreateIncomeEventRequest represents the request body for recording income
+type CreateIncomeEventRequest struct {
+	AssetType      models.AssetType  `json:"asset_type" binding:"required"` // cash, interest_bearing, stock or crypto
+	AssetID        uint              `json:"asset_id" binding:"required"`
+	Type           models.IncomeType `json:"type" binding:"required"` // dividend, coupon, interest, staking or other
+	Amount         float64           `json:"amount" binding:"required"`
+	WithholdingTax float64           `json:"withholding_tax"`
+	Currency       string            `json:"currency"` // Defaults to the asset's currency
+	PayDate        string            `json:"pay_date"` // YYYY-MM-DD; defaults to today
+	ExDate         string            `json:"ex_date"`  // YYYY-MM-DD, for dividends
+	Note           string            `json:"note"`
+}
+
+// UpdateIncomeEventRequest represents the request body for updating income
+type UpdateIncomeEventRequest struct {
+	Type           *models.IncomeType `json:"type"`
+	Amount         *float64           `json:"amount"`
+	WithholdingTax *float64           `json:"withholding_tax"`
+	Currency       *string            `json:"currency"`
+	PayDate        *string            `json:"pay_date"`
+	ExDate         *string            `json:"ex_date"` // Empty clears the ex-date
+	Note           *string            `json:"note"`
+}
+
+// GetIncomeEvents lists income events
+// @Summary List income
+// @Description List dividends, coupons, interest and staking rewards, newest first
+// @Tags income
+// @Produce json
+// @Param asset_type query string false "Filter by asset type"
+// @Param asset_id query int false "Filter by asset ID"
+// @Param type query string false "Filter by income type (dividend, coupon, interest, staking, other)"
+// @Param from query string false "Earliest pay date (YYYY-MM-DD)"
+// @Param to query string false "Latest pay date (YYYY-MM-DD)"
+// @Success 200 {object} response.Response{data=[]models.IncomeEvent}
+// @Router /api/income [get]
+func GetIncomeEvents(c *gin.Context) {
+	assetID, _ := strconv.ParseUint(c.Query("asset_id"), 10, 32)
+	filter := services.IncomeFilter{
+		AssetType: models.AssetType(c.Query("asset_type")),
+		AssetID:   uint(assetID),
+		Type:      models.IncomeType(c.Query("type")),
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(param); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				response.BadRequest(c, "Invalid "+param+", expected YYYY-MM-DD")
+				return
+			}
+			*target = &date
+		}
+	}
+
+	events, err := incomeService.List(filter)
+	if err != nil {
+		logger.Error("Failed to get income events", zap.Error(err))
+		response.InternalError(c, "Failed to get income events")
+		return
+	}
+
+	response.Success(c, events)
+}
+
+// CreateIncomeEvent records income
+// @Summary Create income
+// @Description Record a dividend, coupon, interest payment or staking reward of an asset
+// @Tags income
+// @Accept json
+// @Produce json
+// @Param event body CreateIncomeEventRequest true "Income event"
+// @Success 200 {object} response.Response{data=models.IncomeEvent}
+// @Router /api/income [post]
+func CreateIncomeEvent(c *gin.Context) {
+	var req CreateIncomeEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	payDate, err := parseTransactionDate(req.PayDate)
+	if err != nil {
+		response.BadRequest(c, "Invalid pay_date, expected YYYY-MM-DD")
+		return
+	}
+
+	event := models.IncomeEvent{
+		AssetType:      req.AssetType,
+		AssetID:        req.AssetID,
+		Type:           req.Type,
+		Amount:         req.Amount,
+		WithholdingTax: req.WithholdingTax,
+		Currency:       req.Currency,
+		PayDate:        payDate,
+		Note:           req.Note,
+	}
+	if req.ExDate != "" {
+		exDate, err := time.Parse("2006-01-02", req.ExDate)
+		if err != nil {
+			response.BadRequest(c, "Invalid ex_date, expected YYYY-MM-DD")
+			return
+		}
+		event.ExDate = &exDate
+	}
+
+	if err := incomeService.Create(&event); err != nil {
+		respondIncomeError(c, "Failed to create income event", err)
+		return
+	}
+
+	logger.Info("Income event created", zap.Uint("id", event.ID), zap.String("type", string(event.Type)))
+	response.Success(c, event)
+}
+
+// UpdateIncomeEvent updates income
+// @Summary Update income
+// @Description Update an income event
+// @Tags income
+// @Accept json
+// @Produce json
+// @Param id path int true "Income event ID"
+// @Param event body UpdateIncomeEventRequest true "Income event"
+// @Success 200 {object} response.Response{data=models.IncomeEvent}
+// @Router /api/income/{id} [put]
+func UpdateIncomeEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid income event ID")
+		return
+	}
+
+	var req UpdateIncomeEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	event, err := incomeService.Get(uint(id))
+	if err != nil {
+		logger.Error("Income event not found", zap.Error(err))
+		response.NotFound(c, "Income event not found")
+		return
+	}
+
+	if req.Type != nil {
+		event.Type = *req.Type
+	}
+	if req.Amount != nil {
+		event.Amount = *req.Amount
+	}
+	if req.WithholdingTax != nil {
+		event.WithholdingTax = *req.WithholdingTax
+	}
+	if req.Currency != nil {
+		event.Currency = *req.Currency
+	}
+	if req.PayDate != nil {
+		payDate, err := time.Parse("2006-01-02", *req.PayDate)
+		if err != nil {
+			response.BadRequest(c, "Invalid pay_date, expected YYYY-MM-DD")
+			return
+		}
+		event.PayDate = payDate
+	}
+	if req.ExDate != nil {
+		event.ExDate = nil
+		if *req.ExDate != "" {
+			exDate, err := time.Parse("2006-01-02", *req.ExDate)
+			if err != nil {
+				response.BadRequest(c, "Invalid ex_date, expected YYYY-MM-DD")
+				return
+			}
+			event.ExDate = &exDate
+		}
+	}
+	if req.Note != nil {
+		event.Note = *req.Note
+	}
+
+	if err := incomeService.Update(event); err != nil {
+		respondIncomeError(c, "Failed to update income event", err)
+		return
+	}
+
+	logger.Info("Income event updated", zap.Uint("id", event.ID))
+	response.Success(c, event)
+}
+
+// DeleteIncomeEvent deletes income
+// @Summary Delete income
+// @Description Delete an income event; deleted imported dividends are not imported again
+// @Tags income
+// @Param id path int true "Income event ID"
+// @Success 200 {object} response.Response
+// @Router /api/income/{id} [delete]
+func DeleteIncomeEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid income event ID")
+		return
+	}
+
+	event, err := incomeService.Get(uint(id))
+	if err != nil {
+		logger.Error("Income event not found", zap.Error(err))
+		response.NotFound(c, "Income event not found")
+		return
+	}
+
+	if err := incomeService.Delete(event); err != nil {
+		logger.Error("Failed to delete income event", zap.Error(err))
+		response.InternalError(c, "Failed to delete income event")
+		return
+	}
+
+	logger.Info("Income event deleted", zap.Uint("id", uint(id)))
+	response.Success(c, gin.H{"message": "Income event deleted successfully"})
+}
+
+// ImportIncome imports dividends of held stocks from the provider
+// @Summary Import dividends
+// @Description Record the dividends paid on held stocks from the provider's dividend history, for the units held before each ex-date
+// @Tags income
+// @Produce json
+// @Success 200 {object} response.Response{data=services.IncomeImportResult}
+// @Router /api/income/import [post]
+func ImportIncome(c *gin.Context) {
+	result, err := incomeService.Import(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to import dividends", zap.Error(err))
+		response.InternalError(c, "Failed to import dividends")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetYearlyIncome reports income per year
+// @Summary Yearly income report
+// @Description Gross, withholding tax and net income per year and currency, broken down by income type and asset
+// @Tags income
+// @Produce json
+// @Param asset_type query string false "Filter by asset type"
+// @Param asset_id query int false "Filter by asset ID"
+// @Success 200 {object} response.Response{data=[]services.IncomeYear}
+// @Router /api/income/yearly [get]
+func GetYearlyIncome(c *gin.Context) {
+	assetID, _ := strconv.ParseUint(c.Query("asset_id"), 10, 32)
+
+	report, err := incomeService.YearlyReport(models.AssetType(c.Query("asset_type")), uint(assetID))
+	if err != nil {
+		logger.Error("Failed to get yearly income", zap.Error(err))
+		response.InternalError(c, "Failed to get yearly income")
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// respondIncomeError maps income errors to client errors and logs the rest
+func respondIncomeError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrAssetNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, services.ErrInvalidIncomeEvent):
+		response.BadRequest(c, err.Error())
+	default:
+		logger.Error(message, zap.Error(err))
+		response.InternalError(c, message)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"go.uber.org/zap"
 	"trackmymoney/internal/database"
 	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
 	"trackmymoney/pkg/logger"
 	"trackmymoney/pkg/response"
 )
@@ -21,6 +23,14 @@ type CreateInterestBearingAssetRequest struct {
 	InterestRate float64    `json:"interest_rate" binding:"required"` // Annual interest rate in percentage
 	StartDate    *time.Time `json:"start_date" binding:"required"`
 	MaturityDate *time.Time `json:"maturity_date,omitempty"`
+
+	InterestType         models.InterestType         `json:"interest_type"`         // simple (default) or compound
+	CompoundingFrequency models.CompoundingFrequency `json:"compounding_frequency"` // daily, monthly, quarterly, semiannual or annual (default)
+	DayCount             models.DayCount             `json:"day_count"`             // ACT/365 (default) or 30/360
+
+	MaturityPolicy    models.MaturityPolicy `json:"maturity_policy"`      // flag (default), roll or cash
+	RollRate          *float64              `json:"roll_rate"`            // Annual rate in percentage of renewed terms, for the roll policy
+	PayoutCashAssetID *uint                 `json:"payout_cash_asset_id"` // Cash asset in the same currency credited at maturity, for the cash policy
 }
 
 // UpdateInterestBearingAssetRequest represents the request body for updating an interest-bearing asset
@@ -32,6 +42,20 @@ type UpdateInterestBearingAssetRequest struct {
 	InterestRate *float64   `json:"interest_rate"`
 	StartDate    *time.Time `json:"start_date"`
 	MaturityDate *time.Time `json:"maturity_date,omitempty"`
+
+	InterestType         *models.InterestType         `json:"interest_type"`
+	CompoundingFrequency *models.CompoundingFrequency `json:"compounding_frequency"`
+	DayCount             *models.DayCount             `json:"day_count"`
+
+	MaturityPolicy    *models.MaturityPolicy `json:"maturity_policy"`
+	RollRate          *float64               `json:"roll_rate"`            // Negative clears the roll rate
+	PayoutCashAssetID *uint                  `json:"payout_cash_asset_id"` // 0 clears the cash asset
+}
+
+// InterestBearingAssetResponse is an interest-bearing asset with its valuation
+type InterestBearingAssetResponse struct {
+	models.InterestBearingAsset
+	Accrual services.InterestAccrual `json:"accrual"`
 }
 
 // CreateInterestBearingAsset creates a new interest-bearing asset
@@ -41,7 +65,7 @@ type UpdateInterestBearingAssetRequest struct {
 // @Accept json
 // @Produce json
 // @Param asset body CreateInterestBearingAssetRequest true "Interest-bearing asset info"
-// @Success 200 {object} response.Response{data=models.InterestBearingAsset}
+// @Success 200 {object} response.Response{data=InterestBearingAssetResponse}
 // @Router /api/assets/interest-bearing [post]
 func CreateInterestBearingAsset(c *gin.Context) {
 	var req CreateInterestBearingAssetRequest
@@ -64,13 +88,29 @@ func CreateInterestBearingAsset(c *gin.Context) {
 		InterestRate: req.InterestRate,
 		StartDate:    startDate,
 		MaturityDate: req.MaturityDate,
+
+		InterestType:         req.InterestType,
+		CompoundingFrequency: req.CompoundingFrequency,
+		DayCount:             req.DayCount,
+
+		MaturityPolicy:    req.MaturityPolicy,
+		RollRate:          req.RollRate,
+		PayoutCashAssetID: req.PayoutCashAssetID,
 	}
 
 	if asset.Currency == "" {
 		asset.Currency = "CNY"
 	}
+	if err := services.NormalizeInterestTerms(&asset); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
 
 	db := database.GetDB()
+	if !normalizeMaturityPolicy(c, &asset) {
+		return
+	}
+
 	if err := db.Create(&asset).Error; err != nil {
 		logger.Error("Failed to create interest-bearing asset", zap.Error(err))
 		response.InternalError(c, "Failed to create interest-bearing asset")
@@ -78,17 +118,24 @@ func CreateInterestBearingAsset(c *gin.Context) {
 	}
 
 	logger.Info("Interest-bearing asset created", zap.Uint("id", asset.ID))
-	response.Success(c, asset)
+	response.Success(c, interestBearingResponse(asset, time.Now()))
 }
 
 // GetInterestBearingAssets retrieves all interest-bearing assets
 // @Summary List interest-bearing assets
-// @Description Get all interest-bearing assets
+// @Description Get all interest-bearing assets, valued with the interest accrued as of a date
 // @Tags assets
 // @Produce json
-// @Success 200 {object} response.Response{data=[]models.InterestBearingAsset}
+// @Param as_of query string false "Valuation date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} response.Response{data=[]InterestBearingAssetResponse}
 // @Router /api/assets/interest-bearing [get]
 func GetInterestBearingAssets(c *gin.Context) {
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid as_of, expected YYYY-MM-DD")
+		return
+	}
+
 	var assets []models.InterestBearingAsset
 	db := database.GetDB()
 
@@ -98,16 +145,22 @@ func GetInterestBearingAssets(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, assets)
+	result := make([]InterestBearingAssetResponse, 0, len(assets))
+	for _, asset := range assets {
+		result = append(result, interestBearingResponse(asset, asOf))
+	}
+
+	response.Success(c, result)
 }
 
 // GetInterestBearingAsset retrieves a single interest-bearing asset by ID
 // @Summary Get interest-bearing asset
-// @Description Get an interest-bearing asset by ID
+// @Description Get an interest-bearing asset by ID, valued with the interest accrued as of a date
 // @Tags assets
 // @Produce json
 // @Param id path int true "Interest-Bearing Asset ID"
-// @Success 200 {object} response.Response{data=models.InterestBearingAsset}
+// @Param as_of query string false "Valuation date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} response.Response{data=InterestBearingAssetResponse}
 // @Router /api/assets/interest-bearing/{id} [get]
 func GetInterestBearingAsset(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -116,6 +169,12 @@ func GetInterestBearingAsset(c *gin.Context) {
 		return
 	}
 
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid as_of, expected YYYY-MM-DD")
+		return
+	}
+
 	var asset models.InterestBearingAsset
 	db := database.GetDB()
 
@@ -125,7 +184,7 @@ func GetInterestBearingAsset(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, asset)
+	response.Success(c, interestBearingResponse(asset, asOf))
 }
 
 // UpdateInterestBearingAsset updates an existing interest-bearing asset
@@ -136,7 +195,7 @@ func GetInterestBearingAsset(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Interest-Bearing Asset ID"
 // @Param asset body UpdateInterestBearingAssetRequest true "Interest-bearing asset info"
-// @Success 200 {object} response.Response{data=models.InterestBearingAsset}
+// @Success 200 {object} response.Response{data=InterestBearingAssetResponse}
 // @Router /api/assets/interest-bearing/{id} [put]
 func UpdateInterestBearingAsset(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -183,6 +242,37 @@ func UpdateInterestBearingAsset(c *gin.Context) {
 	if req.MaturityDate != nil {
 		asset.MaturityDate = req.MaturityDate
 	}
+	if req.InterestType != nil {
+		asset.InterestType = *req.InterestType
+	}
+	if req.CompoundingFrequency != nil {
+		asset.CompoundingFrequency = *req.CompoundingFrequency
+	}
+	if req.DayCount != nil {
+		asset.DayCount = *req.DayCount
+	}
+	if req.MaturityPolicy != nil {
+		asset.MaturityPolicy = *req.MaturityPolicy
+	}
+	if req.RollRate != nil {
+		asset.RollRate = req.RollRate
+		if *req.RollRate < 0 {
+			asset.RollRate = nil
+		}
+	}
+	if req.PayoutCashAssetID != nil {
+		asset.PayoutCashAssetID = req.PayoutCashAssetID
+		if *req.PayoutCashAssetID == 0 {
+			asset.PayoutCashAssetID = nil
+		}
+	}
+	if err := services.NormalizeInterestTerms(&asset); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if !normalizeMaturityPolicy(c, &asset) {
+		return
+	}
 
 	if err := db.Save(&asset).Error; err != nil {
 		logger.Error("Failed to update interest-bearing asset", zap.Error(err))
@@ -191,7 +281,7 @@ func UpdateInterestBearingAsset(c *gin.Context) {
 	}
 
 	logger.Info("Interest-bearing asset updated", zap.Uint("id", asset.ID))
-	response.Success(c, asset)
+	response.Success(c, interestBearingResponse(asset, time.Now()))
 }
 
 // DeleteInterestBearingAsset deletes an interest-bearing asset
@@ -217,6 +307,16 @@ func DeleteInterestBearingAsset(c *gin.Context) {
 		return
 	}
 
+	if err := services.CheckNotCollateral(db, models.AssetTypeInterestBearing, asset.ID); err != nil {
+		if errors.Is(err, services.ErrAssetSecuresDebt) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logger.Error("Failed to look up secured debts", zap.Error(err))
+		response.InternalError(c, "Failed to delete interest-bearing asset")
+		return
+	}
+
 	if err := db.Delete(&asset).Error; err != nil {
 		logger.Error("Failed to delete interest-bearing asset", zap.Error(err))
 		response.InternalError(c, "Failed to delete interest-bearing asset")
@@ -226,3 +326,34 @@ func DeleteInterestBearingAsset(c *gin.Context) {
 	logger.Info("Interest-bearing asset deleted", zap.Uint("id", uint(id)))
 	response.Success(c, gin.H{"message": "Interest-bearing asset deleted successfully"})
 }
+
+// interestBearingResponse values an interest-bearing asset as of a date
+func interestBearingResponse(asset models.InterestBearingAsset, asOf time.Time) InterestBearingAssetResponse {
+	return InterestBearingAssetResponse{
+		InterestBearingAsset: asset,
+		Accrual:              services.AccrueInterest(&asset, asOf),
+	}
+}
+
+// parseAsOf parses the optional as_of query date, defaulting to today
+func parseAsOf(c *gin.Context) (time.Time, error) {
+	value := c.Query("as_of")
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// normalizeMaturityPolicy validates the asset's maturity policy, responding with an error if it is invalid
+func normalizeMaturityPolicy(c *gin.Context, asset *models.InterestBearingAsset) bool {
+	if err := services.NormalizeMaturityPolicy(database.GetDB(), asset); err != nil {
+		if errors.Is(err, services.ErrInvalidMaturityPolicy) {
+			response.BadRequest(c, err.Error())
+		} else {
+			logger.Error("Failed to validate maturity policy", zap.Error(err))
+			response.InternalError(c, "Failed to validate maturity policy")
+		}
+		return false
+	}
+	return true
+}
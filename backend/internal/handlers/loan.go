@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/database"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var loanService *services.LoanService
+
+// SetLoanService sets the loan service instance
+func SetLoanService(service *services.LoanService) {
+	loanService = service
+}
+
+// CreateLoanPrepaymentRequest represents the request body for recording a prepayment
+type CreateLoanPrepaymentRequest struct {
+	Date   string                `json:"date"` // YYYY-MM-DD; defaults to today
+	Amount float64               `json:"amount" binding:"required"`
+	Mode   models.PrepaymentMode `json:"mode"` // reduce_term (default) or reduce_payment
+	Note   string                `json:"note"`
+}
+
+// GetLoanSchedule returns the payment schedule of a loan
+// @Summary Loan schedule
+// @Description Full payment schedule of a loan including its prepayments, with the outstanding balance as of a date
+// @Tags assets
+// @Produce json
+// @Param id path int true "Debt Asset ID"
+// @Param as_of query string false "Valuation date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} response.Response{data=services.LoanSchedule}
+// @Router /api/assets/debt/{id}/schedule [get]
+func GetLoanSchedule(c *gin.Context) {
+	debt, ok := loadDebt(c)
+	if !ok {
+		return
+	}
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid as_of, expected YYYY-MM-DD")
+		return
+	}
+
+	schedule, err := loanService.Schedule(debt, asOf)
+	if err != nil {
+		respondLoanError(c, "Failed to generate loan schedule", err)
+		return
+	}
+
+	response.Success(c, schedule)
+}
+
+// GetLoanPrepayments lists the prepayments of a loan
+// @Summary List prepayments
+// @Description List the prepayments of a loan in date order
+// @Tags assets
+// @Produce json
+// @Param id path int true "Debt Asset ID"
+// @Success 200 {object} response.Response{data=[]models.LoanPrepayment}
+// @Router /api/assets/debt/{id}/prepayments [get]
+func GetLoanPrepayments(c *gin.Context) {
+	debt, ok := loadDebt(c)
+	if !ok {
+		return
+	}
+
+	prepayments, err := loanService.Prepayments(debt.ID)
+	if err != nil {
+		logger.Error("Failed to get prepayments", zap.Error(err))
+		response.InternalError(c, "Failed to get prepayments")
+		return
+	}
+
+	response.Success(c, prepayments)
+}
+
+// CreateLoanPrepayment records a prepayment
+// @Summary Create prepayment
+// @Description Record an extra principal repayment; the remaining schedule is recomputed to either pay off sooner or lower the installments
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Debt Asset ID"
+// @Param prepayment body CreateLoanPrepaymentRequest true "Prepayment"
+// @Success 200 {object} response.Response{data=models.LoanPrepayment}
+// @Router /api/assets/debt/{id}/prepayments [post]
+func CreateLoanPrepayment(c *gin.Context) {
+	debt, ok := loadDebt(c)
+	if !ok {
+		return
+	}
+
+	var req CreateLoanPrepaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	date, err := parseTransactionDate(req.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	prepayment := models.LoanPrepayment{
+		Date:   date,
+		Amount: req.Amount,
+		Mode:   req.Mode,
+		Note:   req.Note,
+	}
+	if err := loanService.AddPrepayment(debt, &prepayment); err != nil {
+		respondLoanError(c, "Failed to create prepayment", err)
+		return
+	}
+
+	logger.Info("Loan prepayment created", zap.Uint("debt_id", debt.ID), zap.Float64("amount", prepayment.Amount))
+	response.Success(c, prepayment)
+}
+
+// DeleteLoanPrepayment deletes a prepayment
+// @Summary Delete prepayment
+// @Description Delete a prepayment of a loan; the schedule is recomputed without it
+// @Tags assets
+// @Param id path int true "Debt Asset ID"
+// @Param prepayment_id path int true "Prepayment ID"
+// @Success 200 {object} response.Response
+// @Router /api/assets/debt/{id}/prepayments/{prepayment_id} [delete]
+func DeleteLoanPrepayment(c *gin.Context) {
+	debt, ok := loadDebt(c)
+	if !ok {
+		return
+	}
+	prepaymentID, err := strconv.ParseUint(c.Param("prepayment_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid prepayment ID")
+		return
+	}
+
+	if err := loanService.DeletePrepayment(debt.ID, uint(prepaymentID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Prepayment not found")
+			return
+		}
+		logger.Error("Failed to delete prepayment", zap.Error(err))
+		response.InternalError(c, "Failed to delete prepayment")
+		return
+	}
+
+	logger.Info("Loan prepayment deleted", zap.Uint("debt_id", debt.ID), zap.Uint64("id", prepaymentID))
+	response.Success(c, gin.H{"message": "Prepayment deleted successfully"})
+}
+
+// loadDebt loads the debt named by the id path parameter, responding with an error if it is missing
+func loadDebt(c *gin.Context) (*models.DebtAsset, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid asset ID")
+		return nil, false
+	}
+
+	var debt models.DebtAsset
+	if err := database.GetDB().First(&debt, id).Error; err != nil {
+		logger.Error("Debt asset not found", zap.Error(err))
+		response.NotFound(c, "Debt asset not found")
+		return nil, false
+	}
+	return &debt, true
+}
+
+// respondLoanError maps loan errors to client errors and logs the rest
+func respondLoanError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotALoan),
+		errors.Is(err, services.ErrInvalidLoan),
+		errors.Is(err, services.ErrInvalidPrepayment):
+		response.BadRequest(c, err.Error())
+	default:
+		logger.Error(message, zap.Error(err))
+		response.InternalError(c, message)
+	}
+}
@@ -3,11 +3,13 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"trackmymoney/internal/models"
 	"trackmymoney/internal/services"
+	"trackmymoney/pkg/errorcode"
 	"trackmymoney/pkg/response"
 )
 
@@ -18,6 +20,20 @@ func SetMarketService(service *services.MarketService) {
 	marketService = service
 }
 
+var priceHistoryService *services.PriceHistoryService
+
+// SetPriceHistoryService sets the price history service instance
+func SetPriceHistoryService(service *services.PriceHistoryService) {
+	priceHistoryService = service
+}
+
+var symbolService *services.SymbolService
+
+// SetSymbolService sets the symbol master service instance
+func SetSymbolService(service *services.SymbolService) {
+	symbolService = service
+}
+
 // GetQuote godoc
 // @Summary Get real-time quote
 // @Description Get real-time quote for a single stock or crypto symbol
@@ -32,9 +48,9 @@ func SetMarketService(service *services.MarketService) {
 func GetQuote(c *gin.Context) {
 	symbol := c.Param("symbol")
 
-	quote, err := marketService.GetQuote(symbol)
+	quote, err := marketService.GetQuote(c.Request.Context(), symbol)
 	if err != nil {
-		response.Error(c, http.StatusNotFound, err.Error())
+		marketError(c, http.StatusNotFound, err)
 		return
 	}
 
@@ -60,9 +76,9 @@ func GetQuotes(c *gin.Context) {
 		return
 	}
 
-	quotes, err := marketService.GetQuotes(req.Symbols)
+	quotes, err := marketService.GetQuotes(c.Request.Context(), req.Symbols)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		marketError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -71,7 +87,7 @@ func GetQuotes(c *gin.Context) {
 
 // GetHistory godoc
 // @Summary Get historical price data
-// @Description Get historical price data for a stock or crypto
+// @Description Get historical price data for a stock or crypto. Daily and longer intervals are served from stored bars.
 // @Tags Market
 // @Accept json
 // @Produce json
@@ -87,9 +103,15 @@ func GetHistory(c *gin.Context) {
 	period := c.DefaultQuery("period", "1mo")
 	interval := c.DefaultQuery("interval", "1d")
 
-	history, err := marketService.GetHistory(symbol, period, interval)
+	var history *models.HistoryResponse
+	var err error
+	if priceHistoryService != nil {
+		history, err = priceHistoryService.GetHistory(c.Request.Context(), symbol, period, interval)
+	} else {
+		history, err = marketService.GetHistory(c.Request.Context(), symbol, period, interval)
+	}
 	if err != nil {
-		response.Error(c, http.StatusNotFound, err.Error())
+		marketError(c, http.StatusNotFound, err)
 		return
 	}
 
@@ -110,9 +132,9 @@ func GetHistory(c *gin.Context) {
 func GetInfo(c *gin.Context) {
 	symbol := c.Param("symbol")
 
-	info, err := marketService.GetInfo(symbol)
+	info, err := marketService.GetInfo(c.Request.Context(), symbol)
 	if err != nil {
-		response.Error(c, http.StatusNotFound, err.Error())
+		marketError(c, http.StatusNotFound, err)
 		return
 	}
 
@@ -121,7 +143,7 @@ func GetInfo(c *gin.Context) {
 
 // SearchMarket godoc
 // @Summary Search stocks/crypto
-// @Description Search for stocks or cryptocurrencies by name or symbol
+// @Description Search for stocks or cryptocurrencies by name or symbol. The local symbol master is searched first; upstream search is used only when nothing matches locally.
 // @Tags Market
 // @Accept json
 // @Produce json
@@ -144,11 +166,102 @@ func SearchMarket(c *gin.Context) {
 		limit = 10
 	}
 
-	results, err := marketService.Search(query, limit)
+	var results *models.SearchResponse
+	if symbolService != nil {
+		results, err = symbolService.Search(c.Request.Context(), query, limit)
+	} else {
+		results, err = marketService.Search(c.Request.Context(), query, limit)
+	}
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		marketError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	response.Success(c, results)
 }
+
+// ImportSymbols godoc
+// @Summary Import symbol listing
+// @Description Import a CSV symbol listing into the local symbol master. Header: symbol (required), name, exchange, asset_type, currency, aliases
+// @Tags Market
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file"
+// @Success 200 {object} response.Response{data=services.SymbolImportResult}
+// @Failure 400 {object} response.Response
+// @Router /market/symbols/import [post]
+func ImportSymbols(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "CSV file is required: "+err.Error())
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "Failed to open CSV file: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	result, err := symbolService.ImportCSV(file)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetMarketHealth godoc
+// @Summary Get market provider health
+// @Description Get rolling health scores (error rate, latency) of the market data providers in the failover chain
+// @Tags Market
+// @Produce json
+// @Success 200 {object} response.Response{data=[]services.ProviderHealth}
+// @Router /market/health [get]
+func GetMarketHealth(c *gin.Context) {
+	response.Success(c, marketService.Health())
+}
+
+// GetMarketBreakers godoc
+// @Summary Get market provider circuit breakers
+// @Description Get circuit breaker state and remaining outbound request quota of the market data providers
+// @Tags Market
+// @Produce json
+// @Success 200 {object} response.Response{data=[]services.ProviderGuardStatus}
+// @Router /market/breakers [get]
+func GetMarketBreakers(c *gin.Context) {
+	response.Success(c, marketService.Breakers())
+}
+
+// GetMarketCacheStats godoc
+// @Summary Get market cache statistics
+// @Description Get hit and miss counters of the in-process quote and info caches
+// @Tags Market
+// @Produce json
+// @Success 200 {object} response.Response{data=services.MarketCacheStats}
+// @Router /market/cache [get]
+func GetMarketCacheStats(c *gin.Context) {
+	response.Success(c, marketService.CacheStats())
+}
+
+// marketError responds to a failed market call, reporting breaker and rate limit rejections as external API errors
+func marketError(c *gin.Context, status int, err error) {
+	if services.IsMarketFastFail(err) {
+		response.ErrorWithCode(c, errorcode.ExternalAPIError, err.Error())
+		return
+	}
+	response.Error(c, status, err.Error())
+}
+
+// GetMarketCalendar godoc
+// @Summary Get market trading status
+// @Description Get whether each market is trading now, with its sessions, holiday and surrounding open and close times
+// @Tags Market
+// @Produce json
+// @Success 200 {object} response.Response{data=[]calendar.MarketStatus}
+// @Router /market/calendar [get]
+func GetMarketCalendar(c *gin.Context) {
+	response.Success(c, marketService.Calendar().Statuses(time.Now()))
+}
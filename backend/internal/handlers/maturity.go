@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var maturityService *services.MaturityService
+
+// SetMaturityService sets the maturity service instance
+func SetMaturityService(service *services.MaturityService) {
+	maturityService = service
+}
+
+// GetMaturingAssets lists deposits and bonds maturing soon
+// @Summary Maturing soon
+// @Description List interest-bearing assets maturing within a number of days, including matured ones still held, soonest first
+// @Tags assets
+// @Produce json
+// @Param days query int false "Days ahead, defaults to the configured notice period"
+// @Success 200 {object} response.Response{data=[]services.MaturingAsset}
+// @Router /api/assets/interest-bearing/maturing [get]
+func GetMaturingAssets(c *gin.Context) {
+	days := 0
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			response.BadRequest(c, "Invalid days")
+			return
+		}
+		days = parsed
+	}
+
+	maturing, err := maturityService.Maturing(days, time.Now())
+	if err != nil {
+		logger.Error("Failed to get maturing assets", zap.Error(err))
+		response.InternalError(c, "Failed to get maturing assets")
+		return
+	}
+
+	response.Success(c, maturing)
+}
+
+// GetMaturityEvents lists what was done about maturities
+// @Summary List maturity events
+// @Description List maturity notices, renewals, pay-outs and flagged maturities, newest first
+// @Tags assets
+// @Produce json
+// @Param asset_id query int false "Filter by interest-bearing asset ID"
+// @Success 200 {object} response.Response{data=[]models.MaturityEvent}
+// @Router /api/assets/interest-bearing/maturity-events [get]
+func GetMaturityEvents(c *gin.Context) {
+	assetID, _ := strconv.ParseUint(c.Query("asset_id"), 10, 32)
+
+	events, err := maturityService.Events(uint(assetID))
+	if err != nil {
+		logger.Error("Failed to get maturity events", zap.Error(err))
+		response.InternalError(c, "Failed to get maturity events")
+		return
+	}
+
+	response.Success(c, events)
+}
+
+// ProcessMaturities applies the maturity policies now
+// @Summary Process maturities
+// @Description Apply the maturity policy of every matured interest-bearing asset, as the daily job does, without sending notifications
+// @Tags assets
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.MaturityEvent}
+// @Router /api/assets/interest-bearing/maturity/process [post]
+func ProcessMaturities(c *gin.Context) {
+	events, err := maturityService.Process(time.Now())
+	if err != nil {
+		logger.Error("Failed to process maturities", zap.Error(err))
+		response.InternalError(c, "Failed to process maturities")
+		return
+	}
+
+	response.Success(c, events)
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +22,7 @@ type CreateNotificationRequest struct {
 	Config      string                       `json:"config" binding:"required"`
 	Schedule    string                       `json:"schedule"`
 	Enabled     *bool                        `json:"enabled"`
+	Alerts      string                       `json:"alerts"` // Comma-separated alert kinds (maturity, credit_card, collateral); empty receives all
 }
 
 type UpdateNotificationRequest struct {
@@ -29,6 +32,30 @@ type UpdateNotificationRequest struct {
 	Config      *string                      `json:"config"`
 	Schedule    *string                      `json:"schedule"`
 	Enabled     *bool                        `json:"enabled"`
+	Alerts      *string                      `json:"alerts"`
+}
+
+// alertKinds are the alert kinds a notification can subscribe to
+var alertKinds = map[models.AlertKind]bool{
+	models.AlertMaturity:   true,
+	models.AlertCreditCard: true,
+	models.AlertCollateral: true,
+}
+
+// normalizeAlerts validates a comma-separated list of alert kinds and removes blanks
+func normalizeAlerts(alerts string) (string, error) {
+	kinds := []string{}
+	for _, alert := range strings.Split(alerts, ",") {
+		alert = strings.TrimSpace(alert)
+		if alert == "" {
+			continue
+		}
+		if !alertKinds[models.AlertKind(alert)] {
+			return "", fmt.Errorf("unsupported alert kind %q", alert)
+		}
+		kinds = append(kinds, alert)
+	}
+	return strings.Join(kinds, ","), nil
 }
 
 // @Summary Create notification
@@ -52,6 +79,12 @@ func CreateNotification(c *gin.Context) {
 		enabled = *req.Enabled
 	}
 
+	alerts, err := normalizeAlerts(req.Alerts)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
 	notification := models.Notification{
 		Name:        req.Name,
 		Channel:     req.Channel,
@@ -59,6 +92,7 @@ func CreateNotification(c *gin.Context) {
 		Config:      req.Config,
 		Schedule:    req.Schedule,
 		Enabled:     enabled,
+		Alerts:      alerts,
 	}
 
 	db := database.GetDB()
@@ -167,6 +201,14 @@ func UpdateNotification(c *gin.Context) {
 	if req.Enabled != nil {
 		notification.Enabled = *req.Enabled
 	}
+	if req.Alerts != nil {
+		alerts, err := normalizeAlerts(*req.Alerts)
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		notification.Alerts = alerts
+	}
 
 	if err := db.Save(&notification).Error; err != nil {
 		logger.Error("Failed to update notification", zap.Error(err))
@@ -0,0 +1,508 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/database"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var otherAssetService *services.OtherAssetService
+
+// SetOtherAssetService sets the other asset service instance
+func SetOtherAssetService(service *services.OtherAssetService) {
+	otherAssetService = service
+}
+
+// CreateOtherAssetRequest represents the request body for creating a manually valued asset
+type CreateOtherAssetRequest struct {
+	Name             string                 `json:"name" binding:"required"`
+	Kind             models.OtherAssetKind  `json:"kind"` // real_estate, vehicle, collectible or other (default)
+	Currency         string                 `json:"currency"`
+	Description      string                 `json:"description"`
+	PurchaseDate     string                 `json:"purchase_date"` // YYYY-MM-DD
+	PurchasePrice    float64                `json:"purchase_price"`
+	ValuationMethod  models.ValuationMethod `json:"valuation_method"` // manual (default), straight_line, declining_balance or appreciation
+	UsefulLifeYears  float64                `json:"useful_life_years"`
+	SalvageValue     float64                `json:"salvage_value"`
+	DepreciationRate float64                `json:"depreciation_rate"` // Percent per year
+	AppreciationRate float64                `json:"appreciation_rate"` // Percent per year
+	Value            *float64               `json:"value"`             // Current appraisal, recorded for today
+}
+
+// UpdateOtherAssetRequest represents the request body for updating a manually valued asset
+type UpdateOtherAssetRequest struct {
+	Name             *string                 `json:"name"`
+	Kind             *models.OtherAssetKind  `json:"kind"`
+	Currency         *string                 `json:"currency"`
+	Description      *string                 `json:"description"`
+	PurchaseDate     *string                 `json:"purchase_date"` // YYYY-MM-DD; empty clears it
+	PurchasePrice    *float64                `json:"purchase_price"`
+	ValuationMethod  *models.ValuationMethod `json:"valuation_method"`
+	UsefulLifeYears  *float64                `json:"useful_life_years"`
+	SalvageValue     *float64                `json:"salvage_value"`
+	DepreciationRate *float64                `json:"depreciation_rate"`
+	AppreciationRate *float64                `json:"appreciation_rate"`
+}
+
+// AppraisalRequest represents the request body for recording or correcting an appraisal
+type AppraisalRequest struct {
+	Date   string   `json:"date"` // YYYY-MM-DD; defaults to today
+	Value  *float64 `json:"value"`
+	Source *string  `json:"source"`
+	Note   *string  `json:"note"`
+}
+
+// OtherAssetResponse is a manually valued asset with its current valuation
+type OtherAssetResponse struct {
+	models.OtherAsset
+	Valuation services.OtherAssetValuation `json:"valuation"`
+}
+
+// CreateOtherAsset creates a new manually valued asset
+// @Summary Create other asset
+// @Description Create a manually valued asset such as real estate, a vehicle or a collectible, optionally with its current appraisal
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param asset body CreateOtherAssetRequest true "Asset info"
+// @Success 200 {object} response.Response{data=OtherAssetResponse}
+// @Router /api/assets/other [post]
+func CreateOtherAsset(c *gin.Context) {
+	var req CreateOtherAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	asset := models.OtherAsset{
+		Name:             req.Name,
+		Kind:             req.Kind,
+		Currency:         req.Currency,
+		Description:      req.Description,
+		PurchasePrice:    req.PurchasePrice,
+		ValuationMethod:  req.ValuationMethod,
+		UsefulLifeYears:  req.UsefulLifeYears,
+		SalvageValue:     req.SalvageValue,
+		DepreciationRate: req.DepreciationRate,
+		AppreciationRate: req.AppreciationRate,
+	}
+	if req.PurchaseDate != "" {
+		date, err := time.Parse("2006-01-02", req.PurchaseDate)
+		if err != nil {
+			response.BadRequest(c, "Invalid purchase_date, expected YYYY-MM-DD")
+			return
+		}
+		asset.PurchaseDate = &date
+	}
+
+	if err := otherAssetService.SaveAsset(&asset); err != nil {
+		respondOtherAssetError(c, "Failed to create asset", err)
+		return
+	}
+	if req.Value != nil {
+		appraisal := models.OtherAssetAppraisal{Value: *req.Value}
+		if err := otherAssetService.AddAppraisal(&asset, &appraisal, time.Now()); err != nil {
+			respondOtherAssetError(c, "Failed to record appraisal", err)
+			return
+		}
+	}
+
+	logger.Info("Other asset created", zap.Uint("id", asset.ID), zap.String("kind", string(asset.Kind)))
+	respondOtherAsset(c, asset)
+}
+
+// GetOtherAssets retrieves all manually valued assets
+// @Summary List other assets
+// @Description Get all manually valued assets with their current valuation
+// @Tags assets
+// @Produce json
+// @Success 200 {object} response.Response{data=[]OtherAssetResponse}
+// @Router /api/assets/other [get]
+func GetOtherAssets(c *gin.Context) {
+	var assets []models.OtherAsset
+	if err := database.GetDB().Find(&assets).Error; err != nil {
+		logger.Error("Failed to retrieve other assets", zap.Error(err))
+		response.InternalError(c, "Failed to retrieve other assets")
+		return
+	}
+
+	now := time.Now()
+	result := make([]OtherAssetResponse, 0, len(assets))
+	for i := range assets {
+		valuation, err := otherAssetService.Value(&assets[i], now)
+		if err != nil {
+			logger.Error("Failed to value other asset", zap.Error(err))
+			response.InternalError(c, "Failed to retrieve other assets")
+			return
+		}
+		result = append(result, OtherAssetResponse{OtherAsset: assets[i], Valuation: valuation})
+	}
+	response.Success(c, result)
+}
+
+// GetOtherAsset retrieves a single manually valued asset by ID
+// @Summary Get other asset
+// @Description Get a manually valued asset by ID
+// @Tags assets
+// @Produce json
+// @Param id path int true "Other Asset ID"
+// @Success 200 {object} response.Response{data=OtherAssetResponse}
+// @Router /api/assets/other/{id} [get]
+func GetOtherAsset(c *gin.Context) {
+	asset, ok := loadOtherAsset(c)
+	if !ok {
+		return
+	}
+
+	respondOtherAsset(c, *asset)
+}
+
+// UpdateOtherAsset updates a manually valued asset
+// @Summary Update other asset
+// @Description Update a manually valued asset and its valuation method
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Other Asset ID"
+// @Param asset body UpdateOtherAssetRequest true "Asset info"
+// @Success 200 {object} response.Response{data=OtherAssetResponse}
+// @Router /api/assets/other/{id} [put]
+func UpdateOtherAsset(c *gin.Context) {
+	asset, ok := loadOtherAsset(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateOtherAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if req.Name != nil {
+		asset.Name = *req.Name
+	}
+	if req.Kind != nil {
+		asset.Kind = *req.Kind
+	}
+	if req.Currency != nil {
+		asset.Currency = *req.Currency
+	}
+	if req.Description != nil {
+		asset.Description = *req.Description
+	}
+	if req.PurchaseDate != nil {
+		if *req.PurchaseDate == "" {
+			asset.PurchaseDate = nil
+		} else {
+			date, err := time.Parse("2006-01-02", *req.PurchaseDate)
+			if err != nil {
+				response.BadRequest(c, "Invalid purchase_date, expected YYYY-MM-DD")
+				return
+			}
+			asset.PurchaseDate = &date
+		}
+	}
+	if req.PurchasePrice != nil {
+		asset.PurchasePrice = *req.PurchasePrice
+	}
+	if req.ValuationMethod != nil {
+		asset.ValuationMethod = *req.ValuationMethod
+	}
+	if req.UsefulLifeYears != nil {
+		asset.UsefulLifeYears = *req.UsefulLifeYears
+	}
+	if req.SalvageValue != nil {
+		asset.SalvageValue = *req.SalvageValue
+	}
+	if req.DepreciationRate != nil {
+		asset.DepreciationRate = *req.DepreciationRate
+	}
+	if req.AppreciationRate != nil {
+		asset.AppreciationRate = *req.AppreciationRate
+	}
+
+	if err := otherAssetService.SaveAsset(asset); err != nil {
+		respondOtherAssetError(c, "Failed to update asset", err)
+		return
+	}
+
+	logger.Info("Other asset updated", zap.Uint("id", asset.ID))
+	respondOtherAsset(c, *asset)
+}
+
+// DeleteOtherAsset deletes a manually valued asset
+// @Summary Delete other asset
+// @Description Delete a manually valued asset with its appraisals
+// @Tags assets
+// @Param id path int true "Other Asset ID"
+// @Success 200 {object} response.Response
+// @Router /api/assets/other/{id} [delete]
+func DeleteOtherAsset(c *gin.Context) {
+	asset, ok := loadOtherAsset(c)
+	if !ok {
+		return
+	}
+
+	if err := otherAssetService.DeleteAsset(asset); err != nil {
+		if errors.Is(err, services.ErrAssetSecuresDebt) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logger.Error("Failed to delete other asset", zap.Error(err))
+		response.InternalError(c, "Failed to delete asset")
+		return
+	}
+
+	logger.Info("Other asset deleted", zap.Uint("id", asset.ID))
+	response.Success(c, gin.H{"message": "Asset deleted successfully"})
+}
+
+// GetOtherAssetAppraisals lists the appraisals of a manually valued asset
+// @Summary List appraisals
+// @Description List the valuation history of a manually valued asset, newest first
+// @Tags assets
+// @Produce json
+// @Param id path int true "Other Asset ID"
+// @Success 200 {object} response.Response{data=[]models.OtherAssetAppraisal}
+// @Router /api/assets/other/{id}/appraisals [get]
+func GetOtherAssetAppraisals(c *gin.Context) {
+	asset, ok := loadOtherAsset(c)
+	if !ok {
+		return
+	}
+
+	appraisals, err := otherAssetService.Appraisals(asset.ID)
+	if err != nil {
+		logger.Error("Failed to get appraisals", zap.Error(err))
+		response.InternalError(c, "Failed to get appraisals")
+		return
+	}
+
+	response.Success(c, appraisals)
+}
+
+// CreateOtherAssetAppraisal records an appraisal
+// @Summary Create appraisal
+// @Description Record the value of a manually valued asset on a date; later valuations are carried from it
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Other Asset ID"
+// @Param appraisal body AppraisalRequest true "Appraisal"
+// @Success 200 {object} response.Response{data=models.OtherAssetAppraisal}
+// @Router /api/assets/other/{id}/appraisals [post]
+func CreateOtherAssetAppraisal(c *gin.Context) {
+	asset, ok := loadOtherAsset(c)
+	if !ok {
+		return
+	}
+
+	var req AppraisalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if req.Value == nil {
+		response.BadRequest(c, "value is required")
+		return
+	}
+
+	var appraisal models.OtherAssetAppraisal
+	if !applyAppraisalRequest(c, &appraisal, &req) {
+		return
+	}
+	if err := otherAssetService.AddAppraisal(asset, &appraisal, time.Now()); err != nil {
+		respondOtherAssetError(c, "Failed to create appraisal", err)
+		return
+	}
+
+	logger.Info("Appraisal created", zap.Uint("other_asset_id", asset.ID), zap.Uint("id", appraisal.ID))
+	response.Success(c, appraisal)
+}
+
+// UpdateOtherAssetAppraisal corrects an appraisal
+// @Summary Update appraisal
+// @Description Correct the date, value, source or note of an appraisal
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Other Asset ID"
+// @Param appraisal_id path int true "Appraisal ID"
+// @Param appraisal body AppraisalRequest true "Appraisal"
+// @Success 200 {object} response.Response{data=models.OtherAssetAppraisal}
+// @Router /api/assets/other/{id}/appraisals/{appraisal_id} [put]
+func UpdateOtherAssetAppraisal(c *gin.Context) {
+	asset, ok := loadOtherAsset(c)
+	if !ok {
+		return
+	}
+	appraisalID, err := strconv.ParseUint(c.Param("appraisal_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid appraisal ID")
+		return
+	}
+
+	var appraisal models.OtherAssetAppraisal
+	if err := database.GetDB().Where("other_asset_id = ?", asset.ID).First(&appraisal, appraisalID).Error; err != nil {
+		logger.Error("Appraisal not found", zap.Error(err))
+		response.NotFound(c, "Appraisal not found")
+		return
+	}
+
+	var req AppraisalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if !applyAppraisalRequest(c, &appraisal, &req) {
+		return
+	}
+	if err := otherAssetService.UpdateAppraisal(&appraisal); err != nil {
+		respondOtherAssetError(c, "Failed to update appraisal", err)
+		return
+	}
+
+	logger.Info("Appraisal updated", zap.Uint("other_asset_id", asset.ID), zap.Uint("id", appraisal.ID))
+	response.Success(c, appraisal)
+}
+
+// DeleteOtherAssetAppraisal deletes an appraisal
+// @Summary Delete appraisal
+// @Description Delete an appraisal of a manually valued asset
+// @Tags assets
+// @Param id path int true "Other Asset ID"
+// @Param appraisal_id path int true "Appraisal ID"
+// @Success 200 {object} response.Response
+// @Router /api/assets/other/{id}/appraisals/{appraisal_id} [delete]
+func DeleteOtherAssetAppraisal(c *gin.Context) {
+	asset, ok := loadOtherAsset(c)
+	if !ok {
+		return
+	}
+	appraisalID, err := strconv.ParseUint(c.Param("appraisal_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid appraisal ID")
+		return
+	}
+
+	if err := otherAssetService.DeleteAppraisal(asset.ID, uint(appraisalID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.NotFound(c, "Appraisal not found")
+			return
+		}
+		logger.Error("Failed to delete appraisal", zap.Error(err))
+		response.InternalError(c, "Failed to delete appraisal")
+		return
+	}
+
+	logger.Info("Appraisal deleted", zap.Uint("other_asset_id", asset.ID), zap.Uint64("id", appraisalID))
+	response.Success(c, gin.H{"message": "Appraisal deleted successfully"})
+}
+
+// GetOtherAssetProjection projects the value of a manually valued asset
+// @Summary Project asset value
+// @Description Project the value of a manually valued asset on each anniversary of today under its depreciation or appreciation terms
+// @Tags assets
+// @Produce json
+// @Param id path int true "Other Asset ID"
+// @Param years query int false "Number of years to project (1-50)" default(5)
+// @Success 200 {object} response.Response{data=[]services.OtherAssetProjection}
+// @Router /api/assets/other/{id}/projection [get]
+func GetOtherAssetProjection(c *gin.Context) {
+	asset, ok := loadOtherAsset(c)
+	if !ok {
+		return
+	}
+	years, err := strconv.Atoi(c.DefaultQuery("years", "5"))
+	if err != nil {
+		response.BadRequest(c, "Invalid years")
+		return
+	}
+
+	projection, err := otherAssetService.Project(asset, time.Now(), years)
+	if err != nil {
+		respondOtherAssetError(c, "Failed to project asset value", err)
+		return
+	}
+
+	response.Success(c, projection)
+}
+
+// applyAppraisalRequest copies the fields present in an appraisal request, responding with an error
+// if the date is malformed
+func applyAppraisalRequest(c *gin.Context, appraisal *models.OtherAssetAppraisal, req *AppraisalRequest) bool {
+	if req.Date != "" {
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			response.BadRequest(c, "Invalid date, expected YYYY-MM-DD")
+			return false
+		}
+		appraisal.Date = date
+	}
+	if req.Value != nil {
+		appraisal.Value = *req.Value
+	}
+	if req.Source != nil {
+		appraisal.Source = *req.Source
+	}
+	if req.Note != nil {
+		appraisal.Note = *req.Note
+	}
+	return true
+}
+
+// loadOtherAsset loads the asset named by the id path parameter, responding with an error if it is missing
+func loadOtherAsset(c *gin.Context) (*models.OtherAsset, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid asset ID")
+		return nil, false
+	}
+
+	var asset models.OtherAsset
+	if err := database.GetDB().First(&asset, id).Error; err != nil {
+		logger.Error("Other asset not found", zap.Error(err))
+		response.NotFound(c, "Asset not found")
+		return nil, false
+	}
+	return &asset, true
+}
+
+// respondOtherAsset responds with an asset and its current valuation
+func respondOtherAsset(c *gin.Context, asset models.OtherAsset) {
+	valuation, err := otherAssetService.Value(&asset, time.Now())
+	if err != nil {
+		logger.Error("Failed to value other asset", zap.Error(err))
+		response.InternalError(c, "Failed to value asset")
+		return
+	}
+
+	response.Success(c, OtherAssetResponse{OtherAsset: asset, Valuation: valuation})
+}
+
+// respondOtherAssetError maps other asset errors to client errors and logs the rest
+func respondOtherAssetError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidOtherAsset),
+		errors.Is(err, services.ErrInvalidAppraisal):
+		response.BadRequest(c, err.Error())
+	default:
+		logger.Error(message, zap.Error(err))
+		response.InternalError(c, message)
+	}
+}
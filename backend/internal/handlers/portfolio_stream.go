@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+)
+
+var portfolioStream *services.PortfolioStream
+
+// SetPortfolioStream sets the portfolio stream instance
+func SetPortfolioStream(stream *services.PortfolioStream) {
+	portfolioStream = stream
+}
+
+// portfolioClient is a single WebSocket connection receiving portfolio updates
+type portfolioClient struct {
+	*wsWriter
+}
+
+// portfolioMessage is a portfolio update as sent to clients
+type portfolioMessage struct {
+	Type string `json:"type"`
+	*services.PortfolioUpdate
+}
+
+// DeliverPortfolio queues an update, dropping it if the client is not keeping up
+func (c *portfolioClient) DeliverPortfolio(update *services.PortfolioUpdate) {
+	c.offer(portfolioMessage{Type: "portfolio", PortfolioUpdate: update})
+}
+
+// PortfolioWebSocket godoc
+// @Summary WebSocket stream of portfolio valuation
+// @Description Push the live valuation (total assets, debt, net assets, categories and per-holding day change) on connect and whenever a held symbol ticks or an asset is edited, at most once per update interval.
+// @Description Client messages: {"type":"ping"}. Server messages: connection, portfolio, pong and error messages.
+// @Tags assets
+// @Param ticket query string false "Ticket from POST /api/ws/ticket; non-browser clients may send a bearer token instead"
+// @Success 101 {string} string "Switching Protocols"
+// @Router /ws/portfolio [get]
+func PortfolioWebSocket(c *gin.Context) {
+	conn, username, ok := upgradeWebSocket(c)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+
+	client := &portfolioClient{wsWriter: newWSWriter(conn)}
+	defer client.close()
+
+	if !client.reply(gin.H{"type": "connection", "status": "connected", "message": "Subscribed to portfolio updates"}) {
+		return
+	}
+	if err := portfolioStream.Subscribe(c.Request.Context(), client); err != nil {
+		logger.Error("Failed to start portfolio stream", zap.Error(err))
+		closeWebSocket(conn, websocket.CloseInternalServerErr, "Failed to value portfolio")
+		return
+	}
+	defer portfolioStream.Unsubscribe(client)
+
+	logger.Info("WebSocket portfolio stream established",
+		zap.String("username", username),
+		zap.String("client_remote", c.Request.RemoteAddr))
+
+	limiter := newWSMessageLimiter()
+	for {
+		var msg marketClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !errors.Is(err, net.ErrClosed) {
+				logger.Debug("WebSocket portfolio stream read failed", zap.Error(err))
+			}
+			break
+		}
+		if !limiter.Allow() {
+			logger.Warn("WebSocket client exceeded message rate", zap.String("username", username))
+			closeWebSocket(conn, websocket.ClosePolicyViolation, "message rate exceeded")
+			break
+		}
+
+		var reply gin.H
+		switch msg.Type {
+		case "ping":
+			reply = gin.H{"type": "pong", "timestamp": msg.Timestamp}
+		default:
+			reply = gin.H{"type": "error", "message": fmt.Sprintf("unknown message type %q", msg.Type)}
+		}
+		if !client.reply(reply) {
+			break
+		}
+	}
+
+	logger.Info("WebSocket portfolio stream closed", zap.Int64("dropped_updates", client.dropped.Load()))
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -8,6 +9,7 @@ import (
 	"trackmymoney/internal/database"
 	"trackmymoney/internal/models"
 	"trackmymoney/internal/services"
+	"trackmymoney/pkg/errorcode"
 	"trackmymoney/pkg/logger"
 	"trackmymoney/pkg/response"
 )
@@ -28,26 +30,33 @@ type RefreshPricesResponse struct {
 
 // CreateStockAssetRequest represents the request body for creating a stock asset
 type CreateStockAssetRequest struct {
-	Name          string  `json:"name" binding:"required"`
-	Description   string  `json:"description"`
-	BrokerAccount string  `json:"broker_account" binding:"required"`
-	Symbol        string  `json:"symbol" binding:"required"`
-	Quantity      float64 `json:"quantity" binding:"required"`
-	PurchasePrice float64 `json:"purchase_price" binding:"required"`
-	CurrentPrice  float64 `json:"current_price"`
-	Currency      string  `json:"currency"`
+	Name            string                 `json:"name" binding:"required"`
+	Description     string                 `json:"description"`
+	BrokerAccount   string                 `json:"broker_account" binding:"required"`
+	Symbol          string                 `json:"symbol" binding:"required"` // e.g., AAPL, 600519, 0700.HK
+	Exchange        string                 `json:"exchange"`                  // e.g., SSE, SZSE, HKEX; inferred from the symbol if empty
+	Quantity        float64                `json:"quantity" binding:"required"`
+	PurchasePrice   float64                `json:"purchase_price" binding:"required"`
+	CurrentPrice    float64                `json:"current_price"`
+	Currency        string                 `json:"currency"`
+	DataSourceID    *uint                  `json:"data_source_id"`
+	Date            string                 `json:"date"`              // YYYY-MM-DD of the opening balance; defaults to today
+	CostBasisMethod models.CostBasisMethod `json:"cost_basis_method"` // fifo, lifo, average or specific_lot; the configured default if empty
 }
 
 // UpdateStockAssetRequest represents the request body for updating a stock asset
 type UpdateStockAssetRequest struct {
-	Name          *string  `json:"name"`
-	Description   *string  `json:"description"`
-	BrokerAccount *string  `json:"broker_account"`
-	Symbol        *string  `json:"symbol"`
-	Quantity      *float64 `json:"quantity"`
-	PurchasePrice *float64 `json:"purchase_price"`
-	CurrentPrice  *float64 `json:"current_price"`
-	Currency      *string  `json:"currency"`
+	Name            *string                 `json:"name"`
+	Description     *string                 `json:"description"`
+	BrokerAccount   *string                 `json:"broker_account"`
+	Symbol          *string                 `json:"symbol"`
+	Exchange        *string                 `json:"exchange"`
+	Quantity        *float64                `json:"quantity"`
+	PurchasePrice   *float64                `json:"purchase_price"`
+	CurrentPrice    *float64                `json:"current_price"`
+	Currency        *string                 `json:"currency"`
+	DataSourceID    *uint                   `json:"data_source_id"`    // 0 unbinds the data source
+	CostBasisMethod *models.CostBasisMethod `json:"cost_basis_method"` // Empty resets to the configured default
 }
 
 // CreateStockAsset creates a new stock asset
@@ -67,32 +76,55 @@ func CreateStockAsset(c *gin.Context) {
 		return
 	}
 
+	date, err := parseTransactionDate(req.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
 	asset := models.StockAsset{
-		Name:          req.Name,
-		Description:   req.Description,
-		BrokerAccount: req.BrokerAccount,
-		Symbol:        req.Symbol,
-		Quantity:      req.Quantity,
-		PurchasePrice: req.PurchasePrice,
-		CurrentPrice:  req.CurrentPrice,
-		Currency:      req.Currency,
+		Name:            req.Name,
+		Description:     req.Description,
+		BrokerAccount:   req.BrokerAccount,
+		Symbol:          req.Symbol,
+		Exchange:        req.Exchange,
+		Quantity:        req.Quantity,
+		PurchasePrice:   req.PurchasePrice,
+		CurrentPrice:    req.CurrentPrice,
+		Currency:        req.Currency,
+		DataSourceID:    req.DataSourceID,
+		CostBasisMethod: req.CostBasisMethod,
+	}
+
+	if err := validateDataSourceID(asset.DataSourceID); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if asset.CostBasisMethod != "" && !services.ValidCostBasisMethod(asset.CostBasisMethod) {
+		response.BadRequest(c, "Invalid cost_basis_method, expected fifo, lifo, average or specific_lot")
+		return
 	}
 
 	if asset.Currency == "" {
 		asset.Currency = "CNY"
 	}
 
+	services.NormalizeStockAsset(&asset)
+
 	// Validate symbol and enrich with market data
 	if assetMarketService != nil {
-		if err := assetMarketService.ValidateAndEnrichStockAsset(&asset); err != nil {
+		if err := assetMarketService.ValidateAndEnrichStockAsset(c.Request.Context(), &asset); err != nil {
 			logger.Warn("Failed to validate/enrich stock asset", zap.Error(err))
 			// Don't fail the request, just log the warning
 			// User can still create the asset manually
 		}
 	}
 
-	db := database.GetDB()
-	if err := db.Create(&asset).Error; err != nil {
+	if err := transactionService.CreateStock(&asset, date); err != nil {
+		if errors.Is(err, services.ErrInvalidTransaction) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Error("Failed to create stock asset", zap.Error(err))
 		response.InternalError(c, "Failed to create stock asset")
 		return
@@ -195,11 +227,18 @@ func UpdateStockAsset(c *gin.Context) {
 	if req.BrokerAccount != nil {
 		asset.BrokerAccount = *req.BrokerAccount
 	}
-	if req.Symbol != nil {
-		if *req.Symbol != asset.Symbol {
-			symbolChanged = true
+	if req.Symbol != nil || req.Exchange != nil {
+		previous := services.StockSymbol(&asset)
+		if req.Symbol != nil {
+			asset.Symbol = *req.Symbol
+			// A new symbol carries its own exchange unless one is given
+			asset.Exchange = ""
+		}
+		if req.Exchange != nil {
+			asset.Exchange = *req.Exchange
 		}
-		asset.Symbol = *req.Symbol
+		services.NormalizeStockAsset(&asset)
+		symbolChanged = services.StockSymbol(&asset) != previous
 	}
 	if req.Quantity != nil {
 		asset.Quantity = *req.Quantity
@@ -213,15 +252,38 @@ func UpdateStockAsset(c *gin.Context) {
 	if req.Currency != nil {
 		asset.Currency = *req.Currency
 	}
+	if req.DataSourceID != nil {
+		if *req.DataSourceID == 0 {
+			asset.DataSourceID = nil
+		} else {
+			if err := validateDataSourceID(req.DataSourceID); err != nil {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			asset.DataSourceID = req.DataSourceID
+		}
+	}
+
+	if req.CostBasisMethod != nil {
+		if *req.CostBasisMethod != "" && !services.ValidCostBasisMethod(*req.CostBasisMethod) {
+			response.BadRequest(c, "Invalid cost_basis_method, expected fifo, lifo, average or specific_lot")
+			return
+		}
+		asset.CostBasisMethod = *req.CostBasisMethod
+	}
 
 	// If symbol changed, revalidate and update market data
 	if symbolChanged && assetMarketService != nil {
-		if err := assetMarketService.ValidateAndEnrichStockAsset(&asset); err != nil {
+		if err := assetMarketService.ValidateAndEnrichStockAsset(c.Request.Context(), &asset); err != nil {
 			logger.Warn("Failed to validate/enrich updated stock asset", zap.Error(err))
 		}
 	}
 
-	if err := db.Save(&asset).Error; err != nil {
+	if err := transactionService.SaveStock(&asset); err != nil {
+		if errors.Is(err, services.ErrLedgerHasTrades) || errors.Is(err, services.ErrInvalidTransaction) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Error("Failed to update stock asset", zap.Error(err))
 		response.InternalError(c, "Failed to update stock asset")
 		return
@@ -254,7 +316,11 @@ func DeleteStockAsset(c *gin.Context) {
 		return
 	}
 
-	if err := db.Delete(&asset).Error; err != nil {
+	if err := transactionService.DeleteHolding(models.AssetTypeStock, asset.ID); err != nil {
+		if errors.Is(err, services.ErrAssetSecuresDebt) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Error("Failed to delete stock asset", zap.Error(err))
 		response.InternalError(c, "Failed to delete stock asset")
 		return
@@ -296,9 +362,13 @@ func RefreshStockAssetsPrices(c *gin.Context) {
 	}
 
 	// Update prices
-	updated, failed, err := assetMarketService.UpdateStockAssetsPrices(assets)
+	updated, failed, err := assetMarketService.UpdateStockAssetsPrices(c.Request.Context(), assets)
 	if err != nil {
 		logger.Error("Failed to refresh stock prices", zap.Error(err))
+		if services.IsMarketFastFail(err) {
+			response.ErrorWithCode(c, errorcode.ExternalAPIError, "Failed to refresh stock prices: "+err.Error())
+			return
+		}
 		response.InternalError(c, "Failed to refresh stock prices")
 		return
 	}
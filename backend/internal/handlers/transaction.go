@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var transactionService *services.TransactionService
+
+// SetTransactionService sets the transaction service instance
+func SetTransactionService(service *services.TransactionService) {
+	transactionService = service
+}
+
+// CreateTransactionRequest represents the request body for recording a transaction
+type CreateTransactionRequest struct {
+	AssetType models.AssetType       `json:"asset_type" binding:"required"` // stock or crypto
+	AssetID   uint                   `json:"asset_id" binding:"required"`
+	Type      models.TransactionType `json:"type" binding:"required"` // buy, sell, transfer_in, transfer_out, fee, split, dividend_reinvest
+	Date      string                 `json:"date"`                    // YYYY-MM-DD; defaults to today
+	Quantity  float64                `json:"quantity"`
+	Price     float64                `json:"price"`
+	Fees      float64                `json:"fees"`
+	Ratio     float64                `json:"ratio"`    // New units per old unit, for splits
+	Currency  string                 `json:"currency"` // Defaults to the holding's currency
+	Note      string                 `json:"note"`
+	LotID     *uint                  `json:"lot_id"` // Lot sold from, for holdings using the specific-lot method
+}
+
+// UpdateTransactionRequest represents the request body for updating a transaction
+type UpdateTransactionRequest struct {
+	Type     *models.TransactionType `json:"type"`
+	Date     *string                 `json:"date"`
+	Quantity *float64                `json:"quantity"`
+	Price    *float64                `json:"price"`
+	Fees     *float64                `json:"fees"`
+	Ratio    *float64                `json:"ratio"`
+	Currency *string                 `json:"currency"`
+	Note     *string                 `json:"note"`
+	LotID    *uint                   `json:"lot_id"` // 0 clears the lot
+}
+
+// GetTransactions lists ledger transactions
+// @Summary List transactions
+// @Description List ledger transactions in date order, optionally of a single holding
+// @Tags transactions
+// @Produce json
+// @Param asset_type query string false "Filter by asset type (stock, crypto)"
+// @Param asset_id query int false "Filter by asset ID"
+// @Param type query string false "Filter by transaction type"
+// @Param from query string false "Earliest date (YYYY-MM-DD)"
+// @Param to query string false "Latest date (YYYY-MM-DD)"
+// @Success 200 {object} response.Response{data=[]models.Transaction}
+// @Router /api/transactions [get]
+func GetTransactions(c *gin.Context) {
+	assetID, _ := strconv.ParseUint(c.Query("asset_id"), 10, 32)
+	filter := services.TransactionFilter{
+		AssetType: models.AssetType(c.Query("asset_type")),
+		AssetID:   uint(assetID),
+		Type:      models.TransactionType(c.Query("type")),
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(param); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				response.BadRequest(c, "Invalid "+param+", expected YYYY-MM-DD")
+				return
+			}
+			*target = &date
+		}
+	}
+
+	transactions, err := transactionService.List(filter)
+	if err != nil {
+		logger.Error("Failed to get transactions", zap.Error(err))
+		response.InternalError(c, "Failed to get transactions")
+		return
+	}
+
+	response.Success(c, transactions)
+}
+
+// CreateTransaction records a transaction
+// @Summary Create transaction
+// @Description Record a transaction; the holding's quantity and purchase price are re-derived from its ledger
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param transaction body CreateTransactionRequest true "Transaction"
+// @Success 200 {object} response.Response{data=models.Transaction}
+// @Router /api/transactions [post]
+func CreateTransaction(c *gin.Context) {
+	var req CreateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	date, err := parseTransactionDate(req.Date)
+	if err != nil {
+		response.BadRequest(c, "Invalid date, expected YYYY-MM-DD")
+		return
+	}
+
+	transaction := models.Transaction{
+		AssetType: req.AssetType,
+		AssetID:   req.AssetID,
+		Type:      req.Type,
+		Date:      date,
+		Quantity:  req.Quantity,
+		Price:     req.Price,
+		Fees:      req.Fees,
+		Ratio:     req.Ratio,
+		Currency:  req.Currency,
+		Note:      req.Note,
+		LotID:     req.LotID,
+	}
+
+	if err := transactionService.Create(&transaction); err != nil {
+		respondTransactionError(c, "Failed to create transaction", err)
+		return
+	}
+
+	logger.Info("Transaction created", zap.Uint("id", transaction.ID), zap.String("type", string(transaction.Type)))
+	response.Success(c, transaction)
+}
+
+// UpdateTransaction updates a transaction
+// @Summary Update transaction
+// @Description Update a transaction; the holding's quantity and purchase price are re-derived from its ledger
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Param transaction body UpdateTransactionRequest true "Transaction"
+// @Success 200 {object} response.Response{data=models.Transaction}
+// @Router /api/transactions/{id} [put]
+func UpdateTransaction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid transaction ID")
+		return
+	}
+
+	var req UpdateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request", zap.Error(err))
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	transaction, err := transactionService.Get(uint(id))
+	if err != nil {
+		logger.Error("Transaction not found", zap.Error(err))
+		response.NotFound(c, "Transaction not found")
+		return
+	}
+
+	if req.Type != nil {
+		transaction.Type = *req.Type
+	}
+	if req.Date != nil {
+		date, err := time.Parse("2006-01-02", *req.Date)
+		if err != nil {
+			response.BadRequest(c, "Invalid date, expected YYYY-MM-DD")
+			return
+		}
+		transaction.Date = date
+	}
+	if req.Quantity != nil {
+		transaction.Quantity = *req.Quantity
+	}
+	if req.Price != nil {
+		transaction.Price = *req.Price
+	}
+	if req.Fees != nil {
+		transaction.Fees = *req.Fees
+	}
+	if req.Ratio != nil {
+		transaction.Ratio = *req.Ratio
+	}
+	if req.Currency != nil {
+		transaction.Currency = *req.Currency
+	}
+	if req.Note != nil {
+		transaction.Note = *req.Note
+	}
+	if req.LotID != nil {
+		transaction.LotID = req.LotID
+	}
+
+	if err := transactionService.Update(transaction); err != nil {
+		respondTransactionError(c, "Failed to update transaction", err)
+		return
+	}
+
+	logger.Info("Transaction updated", zap.Uint("id", transaction.ID))
+	response.Success(c, transaction)
+}
+
+// DeleteTransaction deletes a transaction
+// @Summary Delete transaction
+// @Description Delete a transaction; the holding's quantity and purchase price are re-derived from its ledger
+// @Tags transactions
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} response.Response
+// @Router /api/transactions/{id} [delete]
+func DeleteTransaction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "Invalid transaction ID")
+		return
+	}
+
+	transaction, err := transactionService.Get(uint(id))
+	if err != nil {
+		logger.Error("Transaction not found", zap.Error(err))
+		response.NotFound(c, "Transaction not found")
+		return
+	}
+
+	if err := transactionService.Delete(transaction); err != nil {
+		respondTransactionError(c, "Failed to delete transaction", err)
+		return
+	}
+
+	logger.Info("Transaction deleted", zap.Uint("id", uint(id)))
+	response.Success(c, gin.H{"message": "Transaction deleted successfully"})
+}
+
+// respondTransactionError maps ledger errors to client errors and logs the rest
+func respondTransactionError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrHoldingNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, services.ErrInvalidTransaction),
+		errors.Is(err, services.ErrInsufficientQuantity),
+		errors.Is(err, services.ErrGeneratedTransaction):
+		response.BadRequest(c, err.Error())
+	default:
+		logger.Error(message, zap.Error(err))
+		response.InternalError(c, message)
+	}
+}
+
+// parseTransactionDate parses a YYYY-MM-DD date, defaulting to today
+func parseTransactionDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
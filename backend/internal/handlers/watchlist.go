@@ -24,11 +24,15 @@ type CreateWatchlistRequest struct {
 	Name      string `json:"name" binding:"required"`
 	AssetType string `json:"asset_type" binding:"required"`
 	Notes     string `json:"notes"`
+
+	DataSourceID *uint `json:"data_source_id"`
 }
 
 // UpdateWatchlistRequest represents a request to update a watchlist item
 type UpdateWatchlistRequest struct {
 	Notes string `json:"notes"`
+
+	DataSourceID *uint `json:"data_source_id"` // 0 unbinds the data source
 }
 
 // CreateWatchlist godoc
@@ -59,6 +63,13 @@ func CreateWatchlist(c *gin.Context) {
 		Name:      req.Name,
 		AssetType: req.AssetType,
 		Notes:     req.Notes,
+
+		DataSourceID: req.DataSourceID,
+	}
+
+	if err := validateDataSourceID(watchlist.DataSourceID); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	if err := watchlistService.Create(watchlist); err != nil {
@@ -104,9 +115,9 @@ func GetWatchlistWithQuotes(c *gin.Context) {
 	// TODO: Get user ID from auth context
 	userID := uint(1)
 
-	result, err := watchlistService.GetWatchlistWithQuotes(userID)
+	result, err := watchlistService.GetWatchlistWithQuotes(c.Request.Context(), userID)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, err.Error())
+		marketError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -142,7 +153,7 @@ func GetWatchlistItem(c *gin.Context) {
 
 // UpdateWatchlist godoc
 // @Summary Update watchlist item
-// @Description Update a watchlist item (currently only notes)
+// @Description Update a watchlist item (notes and data source binding)
 // @Tags Watchlist
 // @Accept json
 // @Produce json
@@ -169,6 +180,18 @@ func UpdateWatchlist(c *gin.Context) {
 		"notes": req.Notes,
 	}
 
+	if req.DataSourceID != nil {
+		if *req.DataSourceID == 0 {
+			updates["data_source_id"] = nil
+		} else {
+			if err := validateDataSourceID(req.DataSourceID); err != nil {
+				response.Error(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			updates["data_source_id"] = *req.DataSourceID
+		}
+	}
+
 	if err := watchlistService.Update(uint(id), updates); err != nil {
 		response.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"trackmymoney/internal/config"
+	"trackmymoney/internal/middleware"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
+)
+
+var wsTicketService *services.WSTicketService
+
+// SetWSTicketService sets the WebSocket ticket service instance
+func SetWSTicketService(service *services.WSTicketService) {
+	wsTicketService = service
+}
+
+// Application close codes; RFC 6455 reserves 4000-4999 for applications
+const (
+	CloseUnauthorized = 4401 // Missing, invalid, expired or reused credentials
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: checkWebSocketOrigin,
+}
+
+// IssueWebSocketTicket godoc
+// @Summary Issue WebSocket ticket
+// @Description Issue a short-lived, single-use ticket for opening a WebSocket. Pass it as the ticket query parameter of the upgrade request, e.g. /api/ws/market?ticket=...
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=services.WSTicket}
+// @Router /api/ws/ticket [post]
+func IssueWebSocketTicket(c *gin.Context) {
+	ticket, err := wsTicketService.Issue(c.GetString("username"))
+	if err != nil {
+		logger.Error("Failed to issue WebSocket ticket", zap.Error(err))
+		response.InternalError(c, "Failed to issue WebSocket ticket")
+		return
+	}
+
+	response.Success(c, ticket)
+}
+
+// webSocketConfig returns the WebSocket settings with defaults filled in
+func webSocketConfig() config.WebSocketConfig {
+	var wsConfig config.WebSocketConfig
+	if cfg != nil {
+		wsConfig = cfg.WebSocket
+	}
+	if wsConfig.MessageRate <= 0 {
+		wsConfig.MessageRate = 5
+	}
+	if wsConfig.MessageBurst <= 0 {
+		wsConfig.MessageBurst = 20
+	}
+	if wsConfig.MaxMessageSize <= 0 {
+		wsConfig.MaxMessageSize = 4096
+	}
+	return wsConfig
+}
+
+// checkWebSocketOrigin allows clients that send no Origin (non-browser clients, which still
+// need credentials), the API's own origin and the origins in the configured allowlist
+func checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range webSocketConfig().AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	logger.Warn("WebSocket origin rejected", zap.String("origin", origin), zap.String("client_remote", r.RemoteAddr))
+	return false
+}
+
+// authenticateWebSocket returns the user of an upgrade request, authenticated by a ticket
+// query parameter (browsers) or a bearer token in the Authorization header (other clients)
+func authenticateWebSocket(c *gin.Context) (string, error) {
+	if ticket := c.Query("ticket"); ticket != "" {
+		return wsTicketService.Redeem(ticket)
+	}
+
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" || tokenString == c.GetHeader("Authorization") {
+		return "", errors.New("missing WebSocket ticket")
+	}
+	return middleware.ParseToken(cfg.Auth.JWTSecret, tokenString)
+}
+
+// upgradeWebSocket upgrades an authenticated WebSocket request. Requests from origins outside
+// the allowlist are refused with 403; unauthenticated sockets are upgraded and then closed with
+// CloseUnauthorized so that browsers, which cannot see the HTTP status, learn why.
+func upgradeWebSocket(c *gin.Context) (*websocket.Conn, string, bool) {
+	username, authErr := authenticateWebSocket(c)
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Debug("Failed to upgrade client connection", zap.Error(err))
+		return nil, "", false
+	}
+
+	if authErr != nil {
+		logger.Debug("WebSocket authentication failed",
+			zap.String("client_remote", c.Request.RemoteAddr),
+			zap.Error(authErr))
+		closeWebSocket(conn, CloseUnauthorized, authErr.Error())
+		conn.Close()
+		return nil, "", false
+	}
+
+	// Larger messages fail the read and close the socket with CloseMessageTooBig
+	conn.SetReadLimit(webSocketConfig().MaxMessageSize)
+	return conn, username, true
+}
+
+// closeWebSocket sends a close frame; safe to call alongside a writer goroutine
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
+
+// wsMessageLimiter is a token bucket limiting the messages a single client may send
+type wsMessageLimiter struct {
+	rate     float64 // Messages per second
+	burst    float64
+	tokens   float64
+	lastTime time.Time
+}
+
+func newWSMessageLimiter() *wsMessageLimiter {
+	wsConfig := webSocketConfig()
+	return &wsMessageLimiter{
+		rate:     wsConfig.MessageRate,
+		burst:    float64(wsConfig.MessageBurst),
+		tokens:   float64(wsConfig.MessageBurst),
+		lastTime: time.Now(),
+	}
+}
+
+// Allow takes a token for one message, reporting false when the client is over its rate
+func (l *wsMessageLimiter) Allow() bool {
+	now := time.Now()
+	l.tokens += now.Sub(l.lastTime).Seconds() * l.rate
+	l.lastTime = now
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
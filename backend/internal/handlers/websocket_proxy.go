@@ -1,135 +1,241 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
-	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/internal/services/symbol"
 	"trackmymoney/pkg/logger"
+	"trackmymoney/pkg/response"
 )
 
-var (
-	upgrader = websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			// Allow all origins for WebSocket proxy
-			return true
-		},
+var marketHub *services.MarketHub
+
+// SetMarketHub sets the market stream hub instance
+func SetMarketHub(hub *services.MarketHub) {
+	marketHub = hub
+}
+
+// maxMarketSubscriptionsPer is the number of symbols a single connection may subscribe to
+const maxMarketSubscriptionsPer = 50
+
+// marketClient is a single WebSocket connection subscribed to the market hub
+type marketClient struct {
+	*wsWriter
+	symbols map[string]struct{} // Owned by the read loop
+}
+
+// marketClientMessage is a message sent by a WebSocket client:
+//
+//	{"type": "subscribe", "symbols": ["AAPL", "0700.HK"]}
+//	{"type": "unsubscribe", "symbols": ["AAPL"]}
+//	{"type": "ping", "timestamp": 1700000000000}
+//
+// "symbol" may be used instead of "symbols" for a single symbol.
+type marketClientMessage struct {
+	Type      string      `json:"type"`
+	Symbol    string      `json:"symbol"`
+	Symbols   []string    `json:"symbols"`
+	Timestamp interface{} `json:"timestamp"`
+}
+
+func (m marketClientMessage) symbolList() []string {
+	if m.Symbol != "" {
+		return append(m.Symbols, m.Symbol)
 	}
-)
+	return m.Symbols
+}
+
+// Deliver queues a tick, dropping it if the client is not keeping up
+func (c *marketClient) Deliver(tick models.MarketTick) {
+	c.offer(tick)
+}
+
+// subscriptions returns the client's symbols, sorted
+func (c *marketClient) subscriptions() []string {
+	symbols := make([]string, 0, len(c.symbols))
+	for sym := range c.symbols {
+		symbols = append(symbols, sym)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
 
-// ProxyMarketWebSocket godoc
-// @Summary WebSocket proxy for market data
-// @Description Proxy WebSocket connection to yfinanceAPI service
+// subscribe subscribes the client to symbols, keeping within the per-connection limit
+func (c *marketClient) subscribe(symbols []string) ([]string, error) {
+	if len(symbols) == 0 {
+		return nil, errors.New("symbols are required")
+	}
+	if len(c.symbols)+len(symbols) > maxMarketSubscriptionsPer {
+		return nil, fmt.Errorf("a connection may subscribe to at most %d symbols", maxMarketSubscriptionsPer)
+	}
+
+	subscribed, err := marketHub.Subscribe(c, symbols...)
+	if err != nil {
+		return nil, err
+	}
+	for _, sym := range subscribed {
+		c.symbols[sym] = struct{}{}
+	}
+	return subscribed, nil
+}
+
+// unsubscribe removes symbols from the client's subscriptions
+func (c *marketClient) unsubscribe(symbols []string) []string {
+	unsubscribed := marketHub.Unsubscribe(c, symbols...)
+	for _, sym := range unsubscribed {
+		delete(c.symbols, sym)
+	}
+	return unsubscribed
+}
+
+// MarketWebSocket godoc
+// @Summary WebSocket stream of market data
+// @Description Stream real-time ticks for any number of symbols over one connection. Upstream streams are shared by all clients through the market hub.
+// @Description Client messages: {"type":"subscribe","symbols":[...]}, {"type":"unsubscribe","symbols":[...]}, {"type":"ping"}.
+// @Description Server messages: ticks {symbol, price, change_percent, volume, timestamp}, and connection, subscribed, unsubscribed, pong and error messages.
+// @Description Close codes: 4401 unauthenticated, 1008 message rate or subscription limit exceeded, 1009 message too large, 1013 no market stream available.
 // @Tags Market
-// @Accept json
-// @Produce json
-// @Param symbol path string true "Stock or crypto symbol to subscribe"
+// @Param symbol path string false "Symbol to subscribe on connect"
+// @Param symbols query string false "Comma-separated symbols to subscribe on connect"
+// @Param ticket query string false "Ticket from POST /api/ws/ticket; non-browser clients may send a bearer token instead"
 // @Success 101 {string} string "Switching Protocols"
 // @Router /ws/market/{symbol} [get]
-func ProxyMarketWebSocket(c *gin.Context) {
-	// Get symbol from path
-	symbol := c.Param("symbol")
-	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol is required"})
-		return
+func MarketWebSocket(c *gin.Context) {
+	initial := splitSymbols(c.Query("symbols"))
+	if sym := c.Param("symbol"); sym != "" {
+		initial = append(initial, sym)
+	}
+	if sym := c.Query("symbol"); sym != "" {
+		initial = append(initial, sym)
 	}
 
-	// Build yfinanceAPI WebSocket URL
-	yfinanceURL := "ws://127.0.0.1:5000/ws/market/" + url.PathEscape(symbol)
-
-	// Upgrade client connection
-	clientConn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		logger.Error("Failed to upgrade client connection", zap.Error(err))
+	conn, username, ok := upgradeWebSocket(c)
+	if !ok {
 		return
 	}
-	defer clientConn.Close()
+	defer conn.Close()
 
-	// Connect to yfinanceAPI WebSocket
-	yfinanceConn, _, err := websocket.DefaultDialer.Dial(yfinanceURL, nil)
-	if err != nil {
-		logger.Error("Failed to connect to yfinanceAPI WebSocket",
-			zap.String("url", yfinanceURL),
-			zap.Error(err))
-		clientConn.WriteMessage(websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Failed to connect to market service"))
+	client := &marketClient{
+		wsWriter: newWSWriter(conn),
+		symbols:  make(map[string]struct{}),
+	}
+	defer client.close()
+	defer marketHub.UnsubscribeAll(client)
+
+	// Announce the connection before subscribing so that cached ticks follow it
+	symbols := canonicalSymbols(initial)
+	if !client.reply(gin.H{"type": "connection", "status": "connected", "symbols": symbols, "message": fmt.Sprintf("Subscribed to %d symbols", len(symbols))}) {
 		return
 	}
-	defer yfinanceConn.Close()
+	if len(initial) > 0 {
+		if _, err := client.subscribe(initial); err != nil {
+			closeCode := websocket.ClosePolicyViolation
+			if errors.Is(err, services.ErrMarketStreamUnavailable) {
+				closeCode = websocket.CloseTryAgainLater
+			}
+			closeWebSocket(conn, closeCode, err.Error())
+			return
+		}
+	}
 
-	logger.Info("WebSocket proxy established",
-		zap.String("symbol", symbol),
+	logger.Info("WebSocket market stream established",
+		zap.String("username", username),
+		zap.Strings("symbols", symbols),
 		zap.String("client_remote", c.Request.RemoteAddr))
 
-	// Create channels for error handling
-	errChan := make(chan error, 2)
-
-	// Proxy: yfinanceAPI -> client
-	go func() {
-		for {
-			messageType, message, err := yfinanceConn.ReadMessage()
-			if err != nil {
-				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
-					logger.Error("Error reading from yfinanceAPI",
-						zap.String("symbol", symbol),
-						zap.Error(err))
-				}
-				errChan <- err
-				return
-			}
-
-			if err := clientConn.WriteMessage(messageType, message); err != nil {
-				logger.Error("Error writing to client",
-					zap.String("symbol", symbol),
-					zap.Error(err))
-				errChan <- err
-				return
+	limiter := newWSMessageLimiter()
+	for {
+		var msg marketClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !errors.Is(err, net.ErrClosed) {
+				logger.Debug("WebSocket market stream read failed", zap.Error(err))
 			}
+			break
+		}
+		if !limiter.Allow() {
+			logger.Warn("WebSocket client exceeded message rate", zap.String("username", username))
+			closeWebSocket(conn, websocket.ClosePolicyViolation, "message rate exceeded")
+			break
 		}
-	}()
 
-	// Proxy: client -> yfinanceAPI
-	go func() {
-		for {
-			messageType, message, err := clientConn.ReadMessage()
+		var reply gin.H
+		switch msg.Type {
+		case "ping":
+			reply = gin.H{"type": "pong", "timestamp": msg.Timestamp}
+		case "subscribe":
+			subscribed, err := client.subscribe(msg.symbolList())
 			if err != nil {
-				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
-					logger.Error("Error reading from client",
-						zap.String("symbol", symbol),
-						zap.Error(err))
-				}
-				errChan <- err
-				return
+				reply = gin.H{"type": "error", "message": err.Error()}
+				break
 			}
+			reply = gin.H{"type": "subscribed", "symbols": subscribed, "subscriptions": client.subscriptions()}
+		case "unsubscribe":
+			unsubscribed := client.unsubscribe(msg.symbolList())
+			reply = gin.H{"type": "unsubscribed", "symbols": unsubscribed, "subscriptions": client.subscriptions()}
+		default:
+			reply = gin.H{"type": "error", "message": fmt.Sprintf("unknown message type %q", msg.Type)}
+		}
+		if !client.reply(reply) {
+			break
+		}
+	}
 
-			if err := yfinanceConn.WriteMessage(messageType, message); err != nil {
-				logger.Error("Error writing to yfinanceAPI",
-					zap.String("symbol", symbol),
-					zap.Error(err))
-				errChan <- err
-				return
-			}
+	logger.Info("WebSocket market stream closed",
+		zap.Strings("symbols", client.subscriptions()),
+		zap.Int64("dropped_ticks", client.dropped.Load()))
+}
+
+// splitSymbols splits a comma-separated symbol list, skipping blanks
+func splitSymbols(raw string) []string {
+	var symbols []string
+	for _, sym := range strings.Split(raw, ",") {
+		if sym = strings.TrimSpace(sym); sym != "" {
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols
+}
+
+// canonicalSymbols returns the distinct canonical forms of symbols, sorted
+func canonicalSymbols(raw []string) []string {
+	seen := make(map[string]struct{}, len(raw))
+	symbols := make([]string, 0, len(raw))
+	for _, sym := range raw {
+		canonical := symbol.Parse(sym).String()
+		if _, ok := seen[canonical]; ok {
+			continue
 		}
-	}()
-
-	// Wait for either connection to close
-	err = <-errChan
-	if err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
-		logger.Warn("WebSocket proxy closed with error",
-			zap.String("symbol", symbol),
-			zap.Error(err))
-	} else {
-		logger.Info("WebSocket proxy closed normally",
-			zap.String("symbol", symbol))
+		seen[canonical] = struct{}{}
+		symbols = append(symbols, canonical)
 	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// GetMarketStreamStats godoc
+// @Summary Get market stream statistics
+// @Description Get the upstream streams held by the market hub and how many clients share each
+// @Tags Market
+// @Produce json
+// @Success 200 {object} response.Response{data=services.MarketHubStats}
+// @Router /market/streams [get]
+func GetMarketStreamStats(c *gin.Context) {
+	response.Success(c, marketHub.Stats())
 }
 
 // GetMarketWebSocketURL godoc
 // @Summary Get WebSocket URL for market data
-// @Description Get the WebSocket URL for subscribing to real-time market data
+// @Description Get the WebSocket URL for subscribing to real-time market data; symbols are subscribed on connect or with subscribe messages. Browsers authenticate with a ticket from ticket_url.
 // @Tags Market
 // @Accept json
 // @Produce json
@@ -153,10 +259,11 @@ func GetMarketWebSocketURL(c *gin.Context) {
 		}
 	}
 
-	wsURL := scheme + "://" + host + "/ws/market/{symbol}"
+	wsURL := scheme + "://" + host + "/ws/market"
 
 	c.JSON(http.StatusOK, gin.H{
 		"ws_url": wsURL,
-		"example": scheme + "://" + host + "/ws/market/AAPL",
+		"example": wsURL + "?symbols=AAPL,0700.HK&ticket={ticket}",
+		"ticket_url": "/api/ws/ticket",
 	})
 }
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsSendBuffer = 64               // Queued messages per connection before pushed data is dropped
+	wsWriteWait  = 10 * time.Second // Deadline for writing a single message
+)
+
+// wsWriter owns the writes of a WebSocket connection. gorilla/websocket allows a
+// single concurrent writer, so every message is queued and written by one goroutine.
+type wsWriter struct {
+	send    chan interface{}
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// newWSWriter starts the writer goroutine of a connection; call close when the connection ends
+func newWSWriter(conn *websocket.Conn) *wsWriter {
+	w := &wsWriter{
+		send: make(chan interface{}, wsSendBuffer),
+		done: make(chan struct{}),
+	}
+	go w.writeLoop(conn)
+	return w
+}
+
+// offer queues pushed data, dropping it if the client is not keeping up
+func (w *wsWriter) offer(msg interface{}) {
+	select {
+	case w.send <- msg:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// reply queues a control message, waiting for room unless the connection is closing
+func (w *wsWriter) reply(msg interface{}) bool {
+	select {
+	case w.send <- msg:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// close stops the writer goroutine
+func (w *wsWriter) close() {
+	close(w.done)
+}
+
+// writeLoop writes queued messages to the connection until it fails or the writer is closed
+func (w *wsWriter) writeLoop(conn *websocket.Conn) {
+	for {
+		select {
+		case msg := <-w.send:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(msg); err != nil {
+				// Unblock the read loop
+				conn.Close()
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/database"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/notification"
+	"trackmymoney/pkg/logger"
+)
+
+// sendAlert sends an event alert to every enabled notification that subscribes to its kind,
+// regardless of the notification's report schedule
+func sendAlert(ctx context.Context, notificationService *notification.Service, kind models.AlertKind, title, message string) {
+	var notifications []models.Notification
+	if err := database.GetDB().Where("enabled = ?", true).Find(&notifications).Error; err != nil {
+		logger.Error("Failed to fetch notifications", zap.Error(err))
+		return
+	}
+
+	for _, notif := range notifications {
+		if !notif.ReceivesAlert(kind) {
+			continue
+		}
+		if err := notificationService.SendNotification(ctx, &notif, title, message); err != nil {
+			logger.Error("Failed to send alert",
+				zap.String("alert", string(kind)),
+				zap.Uint("notification_id", notif.ID),
+				zap.String("channel", string(notif.Channel)),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/internal/services/notification"
+	"trackmymoney/pkg/logger"
+)
+
+// CollateralJob checks the loan-to-value ratios of secured debts and alerts about those
+// that crossed their threshold, such as a margin call on a broker account
+type CollateralJob struct {
+	collateralService   *services.CollateralService
+	notificationService *notification.Service
+}
+
+// NewCollateralJob creates a new collateral job
+func NewCollateralJob(collateralService *services.CollateralService, notificationService *notification.Service) *CollateralJob {
+	return &CollateralJob{
+		collateralService:   collateralService,
+		notificationService: notificationService,
+	}
+}
+
+// Name returns the job name
+func (j *CollateralJob) Name() string {
+	return "collateral_ltv_check"
+}
+
+// Execute runs the job
+func (j *CollateralJob) Execute(ctx context.Context) error {
+	logger.Info("Starting collateral LTV check job")
+
+	warnings, err := j.collateralService.Check(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to check collateral: %w", err)
+	}
+
+	logger.Info("Collateral LTV check job completed", zap.Int("warnings", len(warnings)))
+
+	if len(warnings) == 0 {
+		return nil
+	}
+	sendAlert(ctx, j.notificationService, models.AlertCollateral, "TrackMyMoney 抵押率预警", formatCollateralWarnings(warnings))
+	return nil
+}
+
+// formatCollateralWarnings formats collateral warnings as a message
+func formatCollateralWarnings(warnings []models.CollateralWarning) string {
+	msg := "⚠️ 抵押率预警\n\n"
+	for _, warning := range warnings {
+		symbol := currencySymbol(warning.Currency)
+		if warning.Underwater {
+			msg += fmt.Sprintf("❗ %s 抵押物已无价值，仍有负债 %s%.2f，超过预警线 %.2f%%\n",
+				warning.Name, symbol, warning.Debt, warning.Threshold)
+			continue
+		}
+		msg += fmt.Sprintf("❗ %s 抵押率 %.2f%%，超过预警线 %.2f%%：市值 %s%.2f，负债 %s%.2f，权益 %s%.2f\n",
+			warning.Name, warning.LTV, warning.Threshold,
+			symbol, warning.Value, symbol, warning.Debt, symbol, warning.Value-warning.Debt)
+	}
+	return msg
+}
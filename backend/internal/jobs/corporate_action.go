@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+)
+
+// CorporateActionSyncJob fetches splits and symbol changes of held stocks and applies them
+type CorporateActionSyncJob struct {
+	corporateActionService *services.CorporateActionService
+}
+
+// NewCorporateActionSyncJob creates a new corporate action sync job
+func NewCorporateActionSyncJob(corporateActionService *services.CorporateActionService) *CorporateActionSyncJob {
+	return &CorporateActionSyncJob{
+		corporateActionService: corporateActionService,
+	}
+}
+
+// Name returns the job name
+func (j *CorporateActionSyncJob) Name() string {
+	return "corporate_action_sync"
+}
+
+// Execute runs the job
+func (j *CorporateActionSyncJob) Execute(ctx context.Context) error {
+	logger.Info("Starting corporate action sync job")
+
+	result, err := j.corporateActionService.Sync(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to sync corporate actions: %w", err)
+	}
+
+	logger.Info("Corporate action sync job completed",
+		zap.Int("symbols", result.Symbols),
+		zap.Int("added", result.Added),
+		zap.Int("applied", result.Applied),
+		zap.Strings("failed", result.Failed))
+	return nil
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/internal/services/notification"
+	"trackmymoney/pkg/logger"
+)
+
+// CreditCardJob advances credit card billing cycles and alerts about payments due soon or overdue
+type CreditCardJob struct {
+	creditCardService   *services.CreditCardService
+	notificationService *notification.Service
+}
+
+// NewCreditCardJob creates a new credit card job
+func NewCreditCardJob(creditCardService *services.CreditCardService, notificationService *notification.Service) *CreditCardJob {
+	return &CreditCardJob{
+		creditCardService:   creditCardService,
+		notificationService: notificationService,
+	}
+}
+
+// Name returns the job name
+func (j *CreditCardJob) Name() string {
+	return "credit_card_cycle"
+}
+
+// Execute runs the job
+func (j *CreditCardJob) Execute(ctx context.Context) error {
+	logger.Info("Starting credit card cycle job")
+
+	reminders, err := j.creditCardService.Cycle(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to run credit card cycle: %w", err)
+	}
+
+	logger.Info("Credit card cycle job completed", zap.Int("reminders", len(reminders)))
+
+	if len(reminders) == 0 {
+		return nil
+	}
+	sendAlert(ctx, j.notificationService, models.AlertCreditCard, "TrackMyMoney 还款提醒", formatCreditCardReminders(reminders))
+	return nil
+}
+
+// formatCreditCardReminders formats credit card reminders as a message
+func formatCreditCardReminders(reminders []models.CreditCardReminder) string {
+	msg := "💳 信用卡还款\n\n"
+	for _, reminder := range reminders {
+		date := reminder.DueDate.Format("2006-01-02")
+		if reminder.Kind == models.ReminderOverdue {
+			msg += fmt.Sprintf("❗ %s 已于 %s 逾期，未还 %.2f %s，最低还款 %.2f\n", reminder.Name, date, reminder.Remaining, reminder.Currency, reminder.MinimumDue)
+			continue
+		}
+		msg += fmt.Sprintf("⏰ %s 将于 %s 到期，应还 %.2f %s，最低还款 %.2f\n", reminder.Name, date, reminder.Remaining, reminder.Currency, reminder.MinimumDue)
+	}
+	return msg
+}
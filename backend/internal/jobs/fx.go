@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+)
+
+// FXRateSyncJob stores today's exchange rate of every held currency against the base currency
+type FXRateSyncJob struct {
+	fxService *services.FXService
+}
+
+// NewFXRateSyncJob creates a new FX rate sync job
+func NewFXRateSyncJob(fxService *services.FXService) *FXRateSyncJob {
+	return &FXRateSyncJob{
+		fxService: fxService,
+	}
+}
+
+// Name returns the job name
+func (j *FXRateSyncJob) Name() string {
+	return "fx_rate_sync"
+}
+
+// Execute runs the job
+func (j *FXRateSyncJob) Execute(ctx context.Context) error {
+	logger.Info("Starting FX rate sync job")
+
+	count, failed, err := j.fxService.Sync(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to sync exchange rates: %w", err)
+	}
+	if len(failed) > 0 {
+		logger.Warn("Exchange rates not available, the last stored rates stay in effect", zap.Strings("currencies", failed))
+	}
+
+	logger.Info("FX rate sync job completed", zap.Int("rates", count), zap.String("base_currency", j.fxService.Base()))
+	return nil
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+)
+
+// IncomeImportJob records the dividends paid on held stocks from the provider's dividend history
+type IncomeImportJob struct {
+	incomeService *services.IncomeService
+}
+
+// NewIncomeImportJob creates a new income import job
+func NewIncomeImportJob(incomeService *services.IncomeService) *IncomeImportJob {
+	return &IncomeImportJob{
+		incomeService: incomeService,
+	}
+}
+
+// Name returns the job name
+func (j *IncomeImportJob) Name() string {
+	return "income_import"
+}
+
+// Execute runs the job
+func (j *IncomeImportJob) Execute(ctx context.Context) error {
+	logger.Info("Starting income import job")
+
+	result, err := j.incomeService.Import(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to import dividends: %w", err)
+	}
+
+	logger.Info("Income import job completed",
+		zap.Int("symbols", result.Symbols),
+		zap.Int("added", result.Added),
+		zap.Strings("failed", result.Failed))
+	return nil
+}
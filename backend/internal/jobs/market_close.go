@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/database"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+)
+
+// MarketCloseRefreshJob records the closing prices of stock assets once their market has closed.
+// It runs frequently and only refreshes holdings whose market closed after they were last priced,
+// so each market is refreshed shortly after its own close.
+type MarketCloseRefreshJob struct {
+	assetMarketService *services.AssetMarketService
+}
+
+// NewMarketCloseRefreshJob creates a new market close refresh job
+func NewMarketCloseRefreshJob(assetMarketService *services.AssetMarketService) *MarketCloseRefreshJob {
+	return &MarketCloseRefreshJob{
+		assetMarketService: assetMarketService,
+	}
+}
+
+// Name returns the job name
+func (j *MarketCloseRefreshJob) Name() string {
+	return "market_close_refresh"
+}
+
+// Execute runs the job
+func (j *MarketCloseRefreshJob) Execute(ctx context.Context) error {
+	db := database.GetDB()
+
+	var allStockAssets []models.StockAsset
+	if err := db.Find(&allStockAssets).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var stockAssets []models.StockAsset
+	for i := range allStockAssets {
+		if j.assetMarketService.StockCloseMissing(&allStockAssets[i], now) {
+			stockAssets = append(stockAssets, allStockAssets[i])
+		}
+	}
+
+	if len(stockAssets) == 0 {
+		logger.Debug("No closing prices to record")
+		return nil
+	}
+
+	logger.Info("Recording closing prices", zap.Int("assets", len(stockAssets)))
+	return updateStockPrices(ctx, db, j.assetMarketService, stockAssets, len(allStockAssets)-len(stockAssets))
+}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services"
+	"trackmymoney/internal/services/notification"
+	"trackmymoney/pkg/logger"
+)
+
+// MaturityJob applies the maturity policies of deposits and bonds and alerts about
+// upcoming and processed maturities
+type MaturityJob struct {
+	maturityService     *services.MaturityService
+	notificationService *notification.Service
+}
+
+// NewMaturityJob creates a new maturity job
+func NewMaturityJob(maturityService *services.MaturityService, notificationService *notification.Service) *MaturityJob {
+	return &MaturityJob{
+		maturityService:     maturityService,
+		notificationService: notificationService,
+	}
+}
+
+// Name returns the job name
+func (j *MaturityJob) Name() string {
+	return "maturity_processing"
+}
+
+// Execute runs the job
+func (j *MaturityJob) Execute(ctx context.Context) error {
+	logger.Info("Starting maturity processing job")
+	now := time.Now()
+
+	processed, err := j.maturityService.Process(now)
+	if err != nil {
+		return fmt.Errorf("failed to process maturities: %w", err)
+	}
+
+	notices, err := j.maturityService.Notices(now)
+	if err != nil {
+		return fmt.Errorf("failed to record maturity notices: %w", err)
+	}
+
+	logger.Info("Maturity processing job completed",
+		zap.Int("processed", len(processed)),
+		zap.Int("notices", len(notices)))
+
+	events := append(processed, notices...)
+	if len(events) == 0 {
+		return nil
+	}
+	sendAlert(ctx, j.notificationService, models.AlertMaturity, "TrackMyMoney 到期提醒", formatMaturityEvents(events))
+	return nil
+}
+
+// formatMaturityEvents formats maturity events as a message
+func formatMaturityEvents(events []models.MaturityEvent) string {
+	msg := "🏦 存款/债券到期\n\n"
+	for _, event := range events {
+		date := event.MaturityDate.Format("2006-01-02")
+		switch event.Action {
+		case models.MaturityActionNotice:
+			msg += fmt.Sprintf("⏰ %s 将于 %s 到期，本金 %.2f %s，预计利息 %.2f\n", event.Name, date, event.Principal, event.Currency, event.Interest)
+		case models.MaturityActionRolled:
+			msg += fmt.Sprintf("🔁 %s 已于 %s 到期并自动续存至 %s，利率 %.2f%%，利息 %.2f\n", event.Name, date, event.NewMaturityDate.Format("2006-01-02"), *event.NewRate, event.Interest)
+		case models.MaturityActionPaidOut:
+			msg += fmt.Sprintf("💰 %s 已于 %s 到期，本息 %.2f %s 已转入现金账户\n", event.Name, date, event.Principal+event.Interest, event.Currency)
+		default:
+			msg += fmt.Sprintf("⚠️ %s 已于 %s 到期，本息 %.2f %s 待处理\n", event.Name, date, event.Principal+event.Interest, event.Currency)
+		}
+		if event.Note != "" && event.Action == models.MaturityActionFlagged {
+			msg += fmt.Sprintf("   %s\n", event.Note)
+		}
+	}
+	return msg
+}
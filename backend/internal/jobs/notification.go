@@ -9,6 +9,7 @@ import (
 	"trackmymoney/internal/database"
 	"trackmymoney/internal/models"
 	"trackmymoney/internal/scheduler"
+	"trackmymoney/internal/services"
 	"trackmymoney/internal/services/notification"
 	"trackmymoney/pkg/logger"
 )
@@ -16,12 +17,14 @@ import (
 // NotificationDispatchJob sends notifications based on configuration
 type NotificationDispatchJob struct {
 	notificationService *notification.Service
+	fxService           *services.FXService
 }
 
 // NewNotificationDispatchJob creates a new notification dispatch job
-func NewNotificationDispatchJob(notificationService *notification.Service) *NotificationDispatchJob {
+func NewNotificationDispatchJob(notificationService *notification.Service, fxService *services.FXService) *NotificationDispatchJob {
 	return &NotificationDispatchJob{
 		notificationService: notificationService,
+		fxService:           fxService,
 	}
 }
 
@@ -125,17 +128,27 @@ func (j *NotificationDispatchJob) shouldSendNow(scheduleExpr string, now time.Ti
 
 // AssetSummaryData represents asset summary data
 type AssetSummaryData struct {
-	TotalAssets float64
-	TotalDebt   float64
-	NetAssets   float64
-	Categories  map[string]float64
+	BaseCurrency string // Currency of all amounts
+	TotalAssets  float64
+	TotalDebt    float64
+	NetAssets    float64
+	Categories   map[string]float64
+
+	AccruedInterest   float64 // Interest accrued on interest-bearing assets, included in their value
+	CreditUtilization float64 // Credit card balances as a percentage of their limits
 }
 
 // getAssetSummary calculates current asset summary
 func (j *NotificationDispatchJob) getAssetSummary(ctx context.Context) (*AssetSummaryData, error) {
 	db := database.GetDB()
+	now := time.Now()
+	fx, err := j.fxService.Converter(now)
+	if err != nil {
+		return nil, err
+	}
 	summary := &AssetSummaryData{
-		Categories: make(map[string]float64),
+		BaseCurrency: fx.Base(),
+		Categories:   make(map[string]float64),
 	}
 
 	// Cash assets
@@ -144,8 +157,9 @@ func (j *NotificationDispatchJob) getAssetSummary(ctx context.Context) (*AssetSu
 		return nil, err
 	}
 	for _, asset := range cashAssets {
-		summary.TotalAssets += asset.Amount
-		summary.Categories["现金"] += asset.Amount
+		value := fx.Convert(asset.Amount, asset.Currency)
+		summary.TotalAssets += value
+		summary.Categories["现金"] += value
 	}
 
 	// Interest-bearing assets
@@ -153,9 +167,13 @@ func (j *NotificationDispatchJob) getAssetSummary(ctx context.Context) (*AssetSu
 	if err := db.Find(&interestBearingAssets).Error; err != nil {
 		return nil, err
 	}
-	for _, asset := range interestBearingAssets {
-		summary.TotalAssets += asset.Amount
-		summary.Categories["计息资产"] += asset.Amount
+	for i := range interestBearingAssets {
+		asset := &interestBearingAssets[i]
+		accrual := services.AccrueInterest(asset, now)
+		value := fx.Convert(accrual.Value, asset.Currency)
+		summary.TotalAssets += value
+		summary.Categories["计息资产"] += value
+		summary.AccruedInterest += fx.Convert(accrual.AccruedInterest, asset.Currency)
 	}
 
 	// Stock assets
@@ -168,6 +186,7 @@ func (j *NotificationDispatchJob) getAssetSummary(ctx context.Context) (*AssetSu
 		if asset.CurrentPrice == 0 {
 			value = asset.Quantity * asset.PurchasePrice
 		}
+		value = fx.Convert(value, asset.Currency)
 		summary.TotalAssets += value
 		summary.Categories["股票"] += value
 	}
@@ -182,19 +201,35 @@ func (j *NotificationDispatchJob) getAssetSummary(ctx context.Context) (*AssetSu
 		if asset.CurrentPrice == 0 {
 			value = asset.Quantity * asset.PurchasePrice
 		}
+		value = fx.Convert(value, asset.QuoteCurrency)
 		summary.TotalAssets += value
 		summary.Categories["加密货币"] += value
 	}
 
-	// Debt assets
-	var debtAssets []models.DebtAsset
-	if err := db.Find(&debtAssets).Error; err != nil {
+	// Other assets (real estate, vehicles, collectibles)
+	otherAssets, err := services.SumOtherAssets(db, now, fx)
+	if err != nil {
 		return nil, err
 	}
-	for _, asset := range debtAssets {
-		summary.TotalDebt += asset.Amount
-		summary.Categories["债务"] += asset.Amount
+	summary.TotalAssets += otherAssets.Value
+	summary.Categories["其他资产"] += otherAssets.Value
+
+	// Debt assets (loans at their amortized balance)
+	debtTotal, err := services.SumDebts(db, now, fx)
+	if err != nil {
+		return nil, err
 	}
+	summary.TotalDebt += debtTotal
+	summary.Categories["债务"] += debtTotal
+
+	// Credit cards
+	creditCards, err := services.SumCreditCards(db, fx)
+	if err != nil {
+		return nil, err
+	}
+	summary.TotalDebt += creditCards.Balance
+	summary.Categories["信用卡"] += creditCards.Balance
+	summary.CreditUtilization = creditCards.Utilization
 
 	summary.NetAssets = summary.TotalAssets - summary.TotalDebt
 
@@ -203,20 +238,46 @@ func (j *NotificationDispatchJob) getAssetSummary(ctx context.Context) (*AssetSu
 
 // formatAssetSummary formats asset summary as a message
 func (j *NotificationDispatchJob) formatAssetSummary(summary *AssetSummaryData) string {
+	symbol := currencySymbol(summary.BaseCurrency)
 	msg := fmt.Sprintf("📊 资产概览 (截至 %s)\n\n", time.Now().Format("2006-01-02 15:04"))
-	msg += fmt.Sprintf("💰 总资产: ¥%.2f\n", summary.TotalAssets)
-	msg += fmt.Sprintf("💳 总负债: ¥%.2f\n", summary.TotalDebt)
-	msg += fmt.Sprintf("📈 净资产: ¥%.2f\n\n", summary.NetAssets)
+	msg += fmt.Sprintf("💰 总资产: %s%.2f\n", symbol, summary.TotalAssets)
+	msg += fmt.Sprintf("💳 总负债: %s%.2f\n", symbol, summary.TotalDebt)
+	msg += fmt.Sprintf("📈 净资产: %s%.2f\n", symbol, summary.NetAssets)
+	if summary.AccruedInterest > 0 {
+		msg += fmt.Sprintf("🏦 应计利息: %s%.2f\n", symbol, summary.AccruedInterest)
+	}
+	if summary.CreditUtilization > 0 {
+		msg += fmt.Sprintf("💳 信用卡使用率: %.1f%%\n", summary.CreditUtilization)
+	}
+	msg += "\n"
 
 	if len(summary.Categories) > 0 {
 		msg += "📁 分类明细:\n"
 		for category, amount := range summary.Categories {
 			if amount > 0 {
 				percentage := (amount / summary.TotalAssets) * 100
-				msg += fmt.Sprintf("  • %s: ¥%.2f (%.1f%%)\n", category, amount, percentage)
+				msg += fmt.Sprintf("  • %s: %s%.2f (%.1f%%)\n", category, symbol, amount, percentage)
 			}
 		}
 	}
 
 	return msg
 }
+
+// currencySymbols are the symbols amounts are printed with, by currency code
+var currencySymbols = map[string]string{
+	"CNY": "¥",
+	"JPY": "¥",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"HKD": "HK$",
+}
+
+// currencySymbol returns the symbol of a currency, or its code followed by a space
+func currencySymbol(currency string) string {
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol
+	}
+	return currency + " "
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+)
+
+// PriceHistorySyncJob keeps stored price bars of held and watched symbols topped up
+type PriceHistorySyncJob struct {
+	priceHistoryService *services.PriceHistoryService
+}
+
+// NewPriceHistorySyncJob creates a new price history sync job
+func NewPriceHistorySyncJob(priceHistoryService *services.PriceHistoryService) *PriceHistorySyncJob {
+	return &PriceHistorySyncJob{
+		priceHistoryService: priceHistoryService,
+	}
+}
+
+// Name returns the job name
+func (j *PriceHistorySyncJob) Name() string {
+	return "price_history_sync"
+}
+
+// Execute runs the job
+func (j *PriceHistorySyncJob) Execute(ctx context.Context) error {
+	logger.Info("Starting price history sync job")
+
+	symbols, err := j.priceHistoryService.TrackedSymbols()
+	if err != nil {
+		return fmt.Errorf("failed to collect tracked symbols: %w", err)
+	}
+
+	if len(symbols) == 0 {
+		logger.Debug("No symbols to sync price history for")
+		return nil
+	}
+
+	synced := 0
+	var failed []string
+
+	for _, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Newly tracked symbols get one year of daily bars
+		if err := j.priceHistoryService.Sync(ctx, symbol, "1d", "1y"); err != nil {
+			logger.Warn("Failed to sync price history",
+				zap.String("symbol", symbol),
+				zap.Error(err))
+			failed = append(failed, symbol)
+			continue
+		}
+		synced++
+	}
+
+	logger.Info("Price history sync job completed",
+		zap.Int("total", len(symbols)),
+		zap.Int("synced", synced),
+		zap.Int("failed", len(failed)))
+
+	if len(failed) > 0 {
+		logger.Warn("Some symbols failed to sync", zap.Strings("symbols", failed))
+	}
+
+	return nil
+}
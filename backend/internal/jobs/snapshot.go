@@ -3,6 +3,7 @@ package jobs
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -72,26 +73,47 @@ func (j *DailySnapshotJob) Execute(ctx context.Context) error {
 	return err
 }
 
-// refreshStockPrices refreshes all stock asset prices
+// refreshStockPrices refreshes the prices of stock assets whose market traded since they were last priced
 func (j *DailySnapshotJob) refreshStockPrices(ctx context.Context, db *gorm.DB) error {
-	var stockAssets []models.StockAsset
-	if err := db.Find(&stockAssets).Error; err != nil {
+	var allStockAssets []models.StockAsset
+	if err := db.Find(&allStockAssets).Error; err != nil {
 		return err
 	}
 
+	now := time.Now()
+	var stockAssets []models.StockAsset
+	for i := range allStockAssets {
+		if j.assetMarketService.StockPriceOutdated(&allStockAssets[i], now) {
+			stockAssets = append(stockAssets, allStockAssets[i])
+		}
+	}
+
 	if len(stockAssets) == 0 {
-		logger.Debug("No stock assets to refresh")
+		logger.Debug("No stock assets to refresh", zap.Int("skipped", len(allStockAssets)))
 		return nil
 	}
 
-	updated, failed, err := j.assetMarketService.UpdateStockAssetsPrices(stockAssets)
+	return updateStockPrices(ctx, db, j.assetMarketService, stockAssets, len(allStockAssets)-len(stockAssets))
+}
+
+// updateStockPrices fetches and saves the prices of stock assets
+func updateStockPrices(ctx context.Context, db *gorm.DB, assetMarketService *services.AssetMarketService, stockAssets []models.StockAsset, skipped int) error {
+	updated, failed, err := assetMarketService.UpdateStockAssetsPrices(ctx, stockAssets)
 	if err != nil {
 		return err
 	}
 
-	// Save updated prices
+	failedSymbols := make(map[string]bool, len(failed))
+	for _, symbol := range failed {
+		failedSymbols[symbol] = true
+	}
+
+	// Save updated prices; failed assets keep their last price time so they are retried
 	for i := range stockAssets {
-		if err := db.Model(&stockAssets[i]).Update("current_price", stockAssets[i].CurrentPrice).Error; err != nil {
+		if failedSymbols[stockAssets[i].Symbol] || failedSymbols[services.StockSymbol(&stockAssets[i])] {
+			continue
+		}
+		if err := db.Model(&stockAssets[i]).Select("current_price", "price_updated_at").Updates(&stockAssets[i]).Error; err != nil {
 			logger.Warn("Failed to update stock asset price",
 				zap.Uint("asset_id", stockAssets[i].ID),
 				zap.Error(err))
@@ -101,7 +123,8 @@ func (j *DailySnapshotJob) refreshStockPrices(ctx context.Context, db *gorm.DB)
 	logger.Info("Stock prices refreshed",
 		zap.Int("total", len(stockAssets)),
 		zap.Int("updated", updated),
-		zap.Int("failed", len(failed)))
+		zap.Int("failed", len(failed)),
+		zap.Int("skipped", skipped))
 
 	if len(failed) > 0 {
 		logger.Warn("Some stock prices failed to refresh", zap.Strings("symbols", failed))
@@ -122,7 +145,7 @@ func (j *DailySnapshotJob) refreshCryptoPrices(ctx context.Context, db *gorm.DB)
 		return nil
 	}
 
-	updated, failed, err := j.assetMarketService.UpdateCryptoAssetsPrices(cryptoAssets)
+	updated, failed, err := j.assetMarketService.UpdateCryptoAssetsPrices(ctx, cryptoAssets)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"trackmymoney/internal/services"
+	"trackmymoney/pkg/logger"
+)
+
+// SymbolSyncJob adds the symbols of held and watched assets to the local symbol master
+type SymbolSyncJob struct {
+	symbolService *services.SymbolService
+}
+
+// NewSymbolSyncJob creates a new symbol sync job
+func NewSymbolSyncJob(symbolService *services.SymbolService) *SymbolSyncJob {
+	return &SymbolSyncJob{
+		symbolService: symbolService,
+	}
+}
+
+// Name returns the job name
+func (j *SymbolSyncJob) Name() string {
+	return "symbol_sync"
+}
+
+// Execute runs the job
+func (j *SymbolSyncJob) Execute(ctx context.Context) error {
+	logger.Info("Starting symbol sync job")
+
+	count, err := j.symbolService.SyncHoldings()
+	if err != nil {
+		return fmt.Errorf("failed to sync held symbols: %w", err)
+	}
+
+	logger.Info("Symbol sync job completed", zap.Int("symbols", count))
+	return nil
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -31,49 +32,45 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		// Parse and validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtSecret), nil
-		})
-
+		username, err := ParseToken(jwtSecret, tokenString)
 		if err != nil {
-			logger.Debug("Failed to parse JWT token", zap.Error(err))
+			logger.Debug("Invalid JWT token", zap.Error(err))
 			response.Error(c, 401, "Invalid or expired token")
 			c.Abort()
 			return
 		}
 
-		if !token.Valid {
-			logger.Debug("Invalid JWT token")
-			response.Error(c, 401, "Invalid or expired token")
-			c.Abort()
-			return
-		}
+		// Store username in context for later use
+		c.Set("username", username)
+		logger.Debug("User authenticated", zap.String("username", username))
 
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			logger.Debug("Failed to extract JWT claims")
-			response.Error(c, 401, "Invalid token claims")
-			c.Abort()
-			return
-		}
+		c.Next()
+	}
+}
 
-		// Store username in context for later use
-		if username, ok := claims["username"].(string); ok {
-			c.Set("username", username)
-			logger.Debug("User authenticated", zap.String("username", username))
-		} else {
-			logger.Debug("Username not found in JWT claims")
-			response.Error(c, 401, "Invalid token claims")
-			c.Abort()
-			return
+// ParseToken validates a JWT and returns the username it was issued to
+func ParseToken(jwtSecret, tokenString string) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Validate signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
 		}
+		return []byte(jwtSecret), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if !token.Valid {
+		return "", errors.New("token is not valid")
+	}
 
-		c.Next()
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", errors.New("invalid token claims")
+	}
+	username, ok := claims["username"].(string)
+	if !ok {
+		return "", errors.New("username not found in token claims")
 	}
+	return username, nil
 }
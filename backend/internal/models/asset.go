@@ -11,6 +11,8 @@ const (
 	AssetTypeStock            AssetType = "stock"
 	AssetTypeDebt             AssetType = "debt"
 	AssetTypeCrypto           AssetType = "crypto"
+	AssetTypeCreditCard       AssetType = "credit_card"
+	AssetTypeOther            AssetType = "other" // Manually valued assets such as real estate, vehicles and collectibles
 )
 
 // CashAsset represents a cash asset
@@ -27,16 +29,59 @@ func (CashAsset) TableName() string {
 	return "cash_assets"
 }
 
+// InterestType represents how interest accrues on an interest-bearing asset
+type InterestType string
+
+const (
+	InterestSimple   InterestType = "simple"
+	InterestCompound InterestType = "compound"
+)
+
+// CompoundingFrequency represents how often compound interest is capitalized
+type CompoundingFrequency string
+
+const (
+	CompoundDaily      CompoundingFrequency = "daily"
+	CompoundMonthly    CompoundingFrequency = "monthly"
+	CompoundQuarterly  CompoundingFrequency = "quarterly"
+	CompoundSemiannual CompoundingFrequency = "semiannual"
+	CompoundAnnual     CompoundingFrequency = "annual"
+)
+
+// DayCount represents the day-count convention used to measure accrual periods
+type DayCount string
+
+const (
+	DayCountACT365 DayCount = "ACT/365"
+	DayCount30360  DayCount = "30/360"
+)
+
+// MaturityPolicy represents what happens when an interest-bearing asset matures
+type MaturityPolicy string
+
+const (
+	MaturityFlag MaturityPolicy = "flag" // Record the maturity and leave the asset for manual handling
+	MaturityRoll MaturityPolicy = "roll" // Renew principal plus interest for the same term
+	MaturityCash MaturityPolicy = "cash" // Pay principal plus interest into a cash asset
+)
+
 // InterestBearingAsset represents an interest-bearing asset (e.g., time deposit, bonds)
 type InterestBearingAsset struct {
 	BaseModel
-	Name         string     `gorm:"type:varchar(255);not null" json:"name"`
-	Amount       float64    `gorm:"type:decimal(20,2);not null" json:"amount"`
-	Currency     string     `gorm:"type:varchar(10);default:'CNY'" json:"currency"`
-	Description  string     `gorm:"type:text" json:"description"`
-	InterestRate float64    `gorm:"type:decimal(5,4);not null" json:"interest_rate"` // Annual interest rate in percentage
-	StartDate    time.Time  `gorm:"not null" json:"start_date"`
-	MaturityDate *time.Time `json:"maturity_date,omitempty"`
+	Name                 string               `gorm:"type:varchar(255);not null" json:"name"`
+	Amount               float64              `gorm:"type:decimal(20,2);not null" json:"amount"` // Principal
+	Currency             string               `gorm:"type:varchar(10);default:'CNY'" json:"currency"`
+	Description          string               `gorm:"type:text" json:"description"`
+	InterestRate         float64              `gorm:"type:decimal(5,4);not null" json:"interest_rate"` // Annual interest rate in percentage
+	StartDate            time.Time            `gorm:"not null" json:"start_date"`
+	MaturityDate         *time.Time           `json:"maturity_date,omitempty"`
+	InterestType         InterestType         `gorm:"type:varchar(20);default:'simple'" json:"interest_type"`
+	CompoundingFrequency CompoundingFrequency `gorm:"type:varchar(20)" json:"compounding_frequency,omitempty"` // For compound interest
+	DayCount             DayCount             `gorm:"type:varchar(10);default:'ACT/365'" json:"day_count"`
+
+	MaturityPolicy    MaturityPolicy `gorm:"type:varchar(20);default:'flag'" json:"maturity_policy"`
+	RollRate          *float64       `gorm:"type:decimal(5,4)" json:"roll_rate,omitempty"` // Annual rate in percentage of renewed terms; the current rate if empty
+	PayoutCashAssetID *uint          `json:"payout_cash_asset_id,omitempty"`               // Cash asset credited at maturity, for the cash policy
 }
 
 // TableName specifies the table name for InterestBearingAsset
@@ -50,11 +95,15 @@ type StockAsset struct {
 	Name          string  `gorm:"type:varchar(255);not null" json:"name"`
 	Description   string  `gorm:"type:text" json:"description"`
 	BrokerAccount string  `gorm:"type:varchar(255);not null" json:"broker_account"`
-	Symbol        string  `gorm:"type:varchar(50);not null" json:"symbol"`
+	Symbol        string  `gorm:"type:varchar(50);not null" json:"symbol"`   // Code as listed, e.g. AAPL, 600519, 0700
+	Exchange      string  `gorm:"type:varchar(20)" json:"exchange"`         // Listing exchange (SSE, SZSE, HKEX, ...), empty for US listings
 	Quantity      float64 `gorm:"type:decimal(20,8);not null" json:"quantity"`
-	PurchasePrice float64 `gorm:"type:decimal(20,2);not null" json:"purchase_price"` // Average purchase price
+	PurchasePrice float64 `gorm:"type:decimal(20,2);not null" json:"purchase_price"` // Average cost of the open lots, derived from the ledger
 	CurrentPrice  float64 `gorm:"type:decimal(20,2)" json:"current_price"`           // Can be updated from market API
 	Currency      string  `gorm:"type:varchar(10);default:'CNY'" json:"currency"`
+	DataSourceID  *uint   `gorm:"index" json:"data_source_id,omitempty"` // Optional market data source binding
+	CostBasisMethod CostBasisMethod `gorm:"type:varchar(20)" json:"cost_basis_method"` // Lot matching for sales; the configured default if empty
+	PriceUpdatedAt  *time.Time      `json:"price_updated_at,omitempty"`                // When CurrentPrice was last refreshed from market data; set by price refreshes only
 }
 
 // TableName specifies the table name for StockAsset
@@ -62,16 +111,48 @@ func (StockAsset) TableName() string {
 	return "stock_assets"
 }
 
+// AmortizationType represents how loan installments are split between principal and interest
+type AmortizationType string
+
+const (
+	AmortizationEqualInstallment AmortizationType = "equal_installment" // Fixed installment with a decreasing interest share
+	AmortizationEqualPrincipal   AmortizationType = "equal_principal"   // Fixed principal repayment with decreasing installments
+)
+
+// PaymentFrequency represents how often loan installments are due
+type PaymentFrequency string
+
+const (
+	PaymentMonthly    PaymentFrequency = "monthly"
+	PaymentQuarterly  PaymentFrequency = "quarterly"
+	PaymentSemiannual PaymentFrequency = "semiannual"
+	PaymentAnnual     PaymentFrequency = "annual"
+)
+
 // DebtAsset represents a debt/liability
 type DebtAsset struct {
 	BaseModel
 	Name         string     `gorm:"type:varchar(255);not null" json:"name"`
-	Amount       float64    `gorm:"type:decimal(20,2);not null" json:"amount"` // Negative value for liabilities
+	Amount       float64    `gorm:"type:decimal(20,2);not null" json:"amount"` // Negative value for liabilities; loans are valued at their amortized balance instead
 	Currency     string     `gorm:"type:varchar(10);default:'CNY'" json:"currency"`
 	Description  string     `gorm:"type:text" json:"description"`
 	Creditor     string     `gorm:"type:varchar(255);not null" json:"creditor"`
 	InterestRate *float64   `gorm:"type:decimal(5,4)" json:"interest_rate,omitempty"`
 	DueDate      *time.Time `json:"due_date,omitempty"`
+
+	// Loan terms; a debt without an amortization type is a static balance
+	Amortization     AmortizationType `gorm:"type:varchar(20)" json:"amortization,omitempty"`
+	Principal        float64          `gorm:"type:decimal(20,2)" json:"principal,omitempty"` // Amount borrowed
+	TermMonths       int              `json:"term_months,omitempty"`
+	PaymentFrequency PaymentFrequency `gorm:"type:varchar(20)" json:"payment_frequency,omitempty"`
+	LoanStartDate    *time.Time       `json:"loan_start_date,omitempty"` // The first installment is due one period later
+
+	// Collateral: an asset (collateral_type and collateral_id) or the stock holdings of a broker
+	// account (collateral_account); a debt with neither is unsecured
+	CollateralType    AssetType `gorm:"type:varchar(50)" json:"collateral_type,omitempty"`
+	CollateralID      *uint     `gorm:"index" json:"collateral_id,omitempty"`
+	CollateralAccount string    `gorm:"type:varchar(255)" json:"collateral_account,omitempty"` // Broker account, e.g. for a margin loan
+	LTVThreshold      *float64  `gorm:"type:decimal(6,2)" json:"ltv_threshold,omitempty"`      // Loan-to-value percentage that triggers a warning; the configured default if empty
 }
 
 // TableName specifies the table name for DebtAsset
@@ -85,9 +166,12 @@ type CryptoAsset struct {
 	Name          string  `gorm:"type:varchar(255);not null" json:"name"`
 	Description   string  `gorm:"type:text" json:"description"`
 	Symbol        string  `gorm:"type:varchar(50);not null" json:"symbol"` // e.g., BTC, ETH
+	QuoteCurrency string  `gorm:"type:varchar(10);default:'USD'" json:"quote_currency"` // Currency the pair is quoted in, e.g. USD, CNY
 	Quantity      float64 `gorm:"type:decimal(20,8);not null" json:"quantity"`
-	PurchasePrice float64 `gorm:"type:decimal(20,2);not null" json:"purchase_price"` // Average purchase price
+	PurchasePrice float64 `gorm:"type:decimal(20,2);not null" json:"purchase_price"` // Average cost of the open lots, derived from the ledger
 	CurrentPrice  float64 `gorm:"type:decimal(20,2)" json:"current_price"`           // Can be updated from market API
+	DataSourceID  *uint   `gorm:"index" json:"data_source_id,omitempty"` // Optional market data source binding
+	CostBasisMethod CostBasisMethod `gorm:"type:varchar(20)" json:"cost_basis_method"` // Lot matching for sales; the configured default if empty
 }
 
 // TableName specifies the table name for CryptoAsset
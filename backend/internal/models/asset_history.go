@@ -9,9 +9,14 @@ type AssetHistory struct {
 	TotalAssets  float64   `gorm:"type:decimal(20,2);not null" json:"total_assets"`
 	TotalDebt    float64   `gorm:"type:decimal(20,2);not null" json:"total_debt"`
 	NetAssets    float64   `gorm:"type:decimal(20,2);not null" json:"net_assets"`
+	Currency     string    `gorm:"type:varchar(10)" json:"currency"` // Base currency of the totals; empty for snapshots taken before currency conversion
 
 	// Category breakdown (stored as JSON for flexibility)
 	CategoryBreakdown string `gorm:"type:text" json:"category_breakdown"`
+	// Assets and debts by original currency (JSON)
+	CurrencyBreakdown string `gorm:"type:text" json:"currency_breakdown"`
+	// Category amounts by original currency (JSON); empty for snapshots taken before it was kept
+	CategoryCurrencies string `gorm:"type:text" json:"category_currencies"`
 }
 
 // AssetSnapshot represents a snapshot of an asset at a specific time
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// CollateralWarning records a collateral position whose loan-to-value ratio crossed its threshold.
+// It stays open until the ratio falls back below the threshold, so each crossing warns once.
+type CollateralWarning struct {
+	BaseModel
+	CollateralKey     string     `gorm:"type:varchar(300);not null;index" json:"collateral_key"` // e.g. "other:3" or "account:Futu"
+	CollateralType    AssetType  `gorm:"type:varchar(50)" json:"collateral_type"`
+	CollateralID      *uint      `json:"collateral_id,omitempty"`
+	CollateralAccount string     `gorm:"type:varchar(255)" json:"collateral_account,omitempty"`
+	Name              string     `gorm:"type:varchar(255)" json:"name"` // Collateral name
+	Value             float64    `gorm:"type:decimal(20,2)" json:"value"`
+	Debt              float64    `gorm:"type:decimal(20,2)" json:"debt"`
+	LTV               float64    `gorm:"type:decimal(8,2)" json:"ltv"`    // Debt as a percentage of value; 0 when underwater
+	Underwater        bool       `gorm:"default:false" json:"underwater"` // Debt was owed on collateral worth nothing
+	Threshold         float64    `gorm:"type:decimal(6,2)" json:"threshold"`
+	Currency          string     `gorm:"type:varchar(10)" json:"currency"` // Base currency of the value and debt
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty"`            // When the ratio fell back below the threshold
+}
+
+// TableName specifies the table name for CollateralWarning
+func (CollateralWarning) TableName() string {
+	return "collateral_warnings"
+}
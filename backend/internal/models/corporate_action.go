@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// CorporateActionType represents the kind of a corporate action
+type CorporateActionType string
+
+const (
+	CorporateActionSplit        CorporateActionType = "split"         // Stock split or reverse split
+	CorporateActionSymbolChange CorporateActionType = "symbol_change" // Ticker change
+)
+
+// CorporateActionSource records where a corporate action came from
+type CorporateActionSource string
+
+const (
+	CorporateActionSourceProvider CorporateActionSource = "provider" // Fetched from the market data provider
+	CorporateActionSourceManual   CorporateActionSource = "manual"   // Entered by the user
+)
+
+// CorporateAction represents a split or symbol change of a listed stock
+type CorporateAction struct {
+	BaseModel
+	Symbol    string                `gorm:"type:varchar(50);not null;uniqueIndex:idx_corporate_action_key" json:"symbol"` // Canonical symbol before the action
+	Type      CorporateActionType   `gorm:"type:varchar(20);not null;uniqueIndex:idx_corporate_action_key" json:"type"`
+	ExDate    time.Time             `gorm:"type:date;not null;uniqueIndex:idx_corporate_action_key" json:"ex_date"`
+	Ratio     float64               `gorm:"type:decimal(20,8)" json:"ratio"`    // New shares per old share, e.g. 10 for 10:1, 0.1 for 1:10
+	NewSymbol string                `gorm:"type:varchar(50)" json:"new_symbol"` // Canonical symbol after a symbol change
+	Source    CorporateActionSource `gorm:"type:varchar(20);not null" json:"source"`
+	Note      string                `gorm:"type:text" json:"note"`
+	AppliedAt *time.Time            `json:"applied_at,omitempty"` // When the action was applied to holdings
+}
+
+// TableName specifies the table name for CorporateAction
+func (CorporateAction) TableName() string {
+	return "corporate_actions"
+}
+
+// CorporateActionAdjustment is the audit record of a corporate action applied to a stock holding
+type CorporateActionAdjustment struct {
+	BaseModel
+	ActionID         uint    `gorm:"not null;uniqueIndex:idx_corporate_action_adjustment_key" json:"action_id"`
+	StockAssetID     uint    `gorm:"not null;uniqueIndex:idx_corporate_action_adjustment_key;index" json:"stock_asset_id"`
+	OldSymbol        string  `gorm:"type:varchar(50)" json:"old_symbol"`
+	NewSymbol        string  `gorm:"type:varchar(50)" json:"new_symbol"`
+	OldQuantity      float64 `gorm:"type:decimal(20,8)" json:"old_quantity"`
+	NewQuantity      float64 `gorm:"type:decimal(20,8)" json:"new_quantity"`
+	OldPurchasePrice float64 `gorm:"type:decimal(20,8)" json:"old_purchase_price"`
+	NewPurchasePrice float64 `gorm:"type:decimal(20,8)" json:"new_purchase_price"`
+	OldCurrentPrice  float64 `gorm:"type:decimal(20,8)" json:"old_current_price"`
+	NewCurrentPrice  float64 `gorm:"type:decimal(20,8)" json:"new_current_price"`
+}
+
+// TableName specifies the table name for CorporateActionAdjustment
+func (CorporateActionAdjustment) TableName() string {
+	return "corporate_action_adjustments"
+}
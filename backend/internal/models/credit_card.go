@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// StatementStatus represents the repayment state of a credit card statement
+type StatementStatus string
+
+const (
+	StatementOpen        StatementStatus = "open"         // Not paid and not yet due
+	StatementMinimumPaid StatementStatus = "minimum_paid" // At least the minimum payment was made
+	StatementPaid        StatementStatus = "paid"         // Paid in full
+	StatementOverdue     StatementStatus = "overdue"      // Past due without the minimum payment
+)
+
+// ReminderKind represents why a credit card reminder was sent
+type ReminderKind string
+
+const (
+	ReminderDueSoon ReminderKind = "due_soon"
+	ReminderOverdue ReminderKind = "overdue"
+)
+
+// CreditCard is a revolving credit liability billed on a monthly statement cycle
+type CreditCard struct {
+	BaseModel
+	Name              string  `gorm:"type:varchar(255);not null" json:"name"`
+	Issuer            string  `gorm:"type:varchar(255)" json:"issuer"`
+	Currency          string  `gorm:"type:varchar(10);default:'CNY'" json:"currency"`
+	Description       string  `gorm:"type:text" json:"description"`
+	CreditLimit       float64 `gorm:"type:decimal(20,2);not null" json:"credit_limit"`
+	Balance           float64 `gorm:"type:decimal(20,2)" json:"balance"`            // Amount owed now, including spending not yet billed
+	StatementDay      int     `gorm:"not null" json:"statement_day"`                // Day of month the statement closes; clamped to short months
+	DueDay            int     `gorm:"not null" json:"due_day"`                      // Day of month payment is due, in the following month when not after the statement day
+	MinPaymentPercent float64 `gorm:"type:decimal(5,2)" json:"min_payment_percent"` // Minimum payment as a percentage of the statement balance; 10 when no rule is given
+	MinPaymentFloor   float64 `gorm:"type:decimal(20,2)" json:"min_payment_floor"`  // Smallest minimum payment, capped at the statement balance
+
+	// Cycle state, maintained from the statements by the credit card cycle job
+	NextStatementDate *time.Time `json:"next_statement_date,omitempty"`
+	NextDueDate       *time.Time `json:"next_due_date,omitempty"`                     // Earliest due date of an unpaid statement, otherwise of the next one
+	StatementBalance  float64    `gorm:"type:decimal(20,2)" json:"statement_balance"` // Balance of the latest statement
+	RemainingBalance  float64    `gorm:"type:decimal(20,2)" json:"remaining_balance"` // Statement balances not yet paid, across all statements
+	MinimumDue        float64    `gorm:"type:decimal(20,2)" json:"minimum_due"`       // Minimum payments still owed on the unpaid statements
+}
+
+// TableName specifies the table name for CreditCard
+func (CreditCard) TableName() string {
+	return "credit_cards"
+}
+
+// CreditCardStatement is the balance billed by a credit card at the close of a cycle
+type CreditCardStatement struct {
+	BaseModel
+	CreditCardID   uint            `gorm:"not null;index:idx_statement_card" json:"credit_card_id"`
+	StatementDate  time.Time       `gorm:"not null;index:idx_statement_card" json:"statement_date"`
+	DueDate        time.Time       `gorm:"not null" json:"due_date"`
+	Balance        float64         `gorm:"type:decimal(20,2);not null" json:"balance"`
+	MinimumPayment float64         `gorm:"type:decimal(20,2)" json:"minimum_payment"`
+	PaidAmount     float64         `gorm:"type:decimal(20,2)" json:"paid_amount"`
+	Status         StatementStatus `gorm:"type:varchar(20)" json:"status"`
+	Note           string          `gorm:"type:text" json:"note"`
+}
+
+// TableName specifies the table name for CreditCardStatement
+func (CreditCardStatement) TableName() string {
+	return "credit_card_statements"
+}
+
+// CreditCardReminder records a due-soon or overdue reminder about a statement, sent once
+type CreditCardReminder struct {
+	BaseModel
+	CreditCardID uint         `gorm:"not null;index" json:"credit_card_id"`
+	StatementID  uint         `gorm:"not null;index" json:"statement_id"`
+	Kind         ReminderKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Name         string       `gorm:"type:varchar(255)" json:"name"` // Card name
+	DueDate      time.Time    `gorm:"not null" json:"due_date"`
+	Remaining    float64      `gorm:"type:decimal(20,2)" json:"remaining"`
+	MinimumDue   float64      `gorm:"type:decimal(20,2)" json:"minimum_due"`
+	Currency     string       `gorm:"type:varchar(10)" json:"currency"`
+}
+
+// TableName specifies the table name for CreditCardReminder
+func (CreditCardReminder) TableName() string {
+	return "credit_card_reminders"
+}
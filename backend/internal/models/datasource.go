@@ -0,0 +1,47 @@
+package models
+
+import "strings"
+
+// ProviderType represents the market data provider type
+type ProviderType string
+
+const (
+	ProviderYFinance ProviderType = "yfinance"
+	ProviderDemo     ProviderType = "demo" // Offline synthetic data for development and demos
+)
+
+// DataSource represents a user-managed market data source (a provider plus its credentials)
+type DataSource struct {
+	BaseModel
+	Name        string       `gorm:"type:varchar(255);not null" json:"name"`
+	Provider    ProviderType `gorm:"type:varchar(50);not null" json:"provider"`
+	Description string       `gorm:"type:text" json:"description"`
+	BaseURL     string       `gorm:"type:varchar(255)" json:"base_url"` // Optional, falls back to market.base_url
+	Credentials string       `gorm:"type:text" json:"-"`                // JSON string of provider credentials; write-only
+	Enabled     bool         `gorm:"default:true" json:"enabled"`
+
+	// Failover chain settings
+	Priority     int    `gorm:"default:0" json:"priority"`              // Lower values are tried first
+	AssetClasses string `gorm:"type:varchar(255)" json:"asset_classes"` // Comma-separated asset classes (stock,crypto), empty = all
+
+	// Outbound rate limit
+	RateLimit float64 `gorm:"default:0" json:"rate_limit"` // Requests per minute, 0 = market.rate_limit
+}
+
+// TableName specifies the table name for DataSource
+func (DataSource) TableName() string {
+	return "data_sources"
+}
+
+// ServesAssetClass reports whether the data source is part of the chain for an asset class
+func (d *DataSource) ServesAssetClass(assetClass AssetType) bool {
+	if d.AssetClasses == "" || assetClass == "" {
+		return true
+	}
+	for _, class := range strings.Split(d.AssetClasses, ",") {
+		if AssetType(strings.TrimSpace(class)) == assetClass {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// FXRateSource represents where an exchange rate came from
+type FXRateSource string
+
+const (
+	FXSourceProvider FXRateSource = "provider" // Fetched from the market data provider
+	FXSourceManual   FXRateSource = "manual"   // Entered by the user; overrides the provider rate of the same date
+)
+
+// FXRate is the exchange rate of a currency pair on a date: one unit of the base currency
+// is worth Rate units of the quote currency
+type FXRate struct {
+	BaseModel
+	BaseCurrency  string       `gorm:"type:varchar(10);not null;index:idx_fx_pair" json:"base_currency"`
+	QuoteCurrency string       `gorm:"type:varchar(10);not null;index:idx_fx_pair" json:"quote_currency"`
+	Date          time.Time    `gorm:"not null;index" json:"date"`
+	Rate          float64      `gorm:"type:decimal(20,8);not null" json:"rate"`
+	Source        FXRateSource `gorm:"type:varchar(20);not null" json:"source"`
+	Note          string       `gorm:"type:text" json:"note"`
+}
+
+// TableName specifies the table name for FXRate
+func (FXRate) TableName() string {
+	return "fx_rates"
+}
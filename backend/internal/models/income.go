@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// IncomeType represents the kind of an income event
+type IncomeType string
+
+const (
+	IncomeDividend IncomeType = "dividend" // Cash dividend of a stock or fund
+	IncomeCoupon   IncomeType = "coupon"   // Bond coupon
+	IncomeInterest IncomeType = "interest" // Interest paid on a deposit or interest-bearing asset
+	IncomeStaking  IncomeType = "staking"  // Staking reward of a crypto holding, valued when received
+	IncomeOther    IncomeType = "other"
+)
+
+// Income event sources
+const (
+	IncomeSourceManual   = "manual"
+	IncomeSourceProvider = "provider" // Imported from the provider's dividend history
+	IncomeSourceMaturity = "maturity" // Interest of a deposit or bond that matured
+)
+
+// IncomeEvent is income received from an asset
+type IncomeEvent struct {
+	BaseModel
+	AssetType      AssetType  `gorm:"type:varchar(20);not null;index:idx_income_asset" json:"asset_type"`
+	AssetID        uint       `gorm:"not null;index:idx_income_asset" json:"asset_id"`
+	Type           IncomeType `gorm:"type:varchar(20);not null" json:"type"`
+	Amount         float64    `gorm:"type:decimal(20,2);not null" json:"amount"` // Gross amount, before withholding tax
+	WithholdingTax float64    `gorm:"type:decimal(20,2)" json:"withholding_tax"`
+	Currency       string     `gorm:"type:varchar(10)" json:"currency"`
+	PayDate        time.Time  `gorm:"not null;index" json:"pay_date"`
+	ExDate         *time.Time `json:"ex_date,omitempty"`                               // Ex-dividend date, for dividends
+	PerUnit        float64    `gorm:"type:decimal(20,8)" json:"per_unit,omitempty"`    // Amount per unit held, for imported dividends
+	Quantity       float64    `gorm:"type:decimal(20,8)" json:"quantity,omitempty"`    // Units held on the ex-date, for imported dividends
+	Source         string     `gorm:"type:varchar(20);default:'manual'" json:"source"` // manual, provider or maturity
+	Note           string     `gorm:"type:text" json:"note"`
+}
+
+// TableName specifies the table name for IncomeEvent
+func (IncomeEvent) TableName() string {
+	return "income_events"
+}
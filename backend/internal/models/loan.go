@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// PrepaymentMode represents how a loan's remaining schedule changes after a prepayment
+type PrepaymentMode string
+
+const (
+	PrepaymentReduceTerm    PrepaymentMode = "reduce_term"    // Keep the installment and pay off sooner
+	PrepaymentReducePayment PrepaymentMode = "reduce_payment" // Keep the term and lower the installments
+)
+
+// LoanPrepayment is an extra principal repayment of a loan
+type LoanPrepayment struct {
+	BaseModel
+	DebtAssetID uint           `gorm:"not null;index" json:"debt_asset_id"`
+	Date        time.Time      `gorm:"not null" json:"date"`
+	Amount      float64        `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Mode        PrepaymentMode `gorm:"type:varchar(20);not null" json:"mode"`
+	Note        string         `gorm:"type:text" json:"note"`
+}
+
+// TableName specifies the table name for LoanPrepayment
+func (LoanPrepayment) TableName() string {
+	return "loan_prepayments"
+}
@@ -12,8 +12,18 @@ type Quote struct {
 	MarketCap     *int64   `json:"market_cap,omitempty"`
 	Currency      *string  `json:"currency,omitempty"`
 	Timestamp     *int64   `json:"timestamp,omitempty"`
+	Provider      string   `json:"provider,omitempty"` // Provider that answered the quote
+	PriceStatus   string   `json:"price_status,omitempty"` // live while the market trades or pauses midday, last_close when it has closed for the day
+	Stale         bool     `json:"stale,omitempty"`       // Served from cache because a refresh failed
+	AgeSeconds    *int64   `json:"age_seconds,omitempty"` // Age of a cached quote
 }
 
+// Price statuses of a quote
+const (
+	PriceStatusLive      = "live"
+	PriceStatusLastClose = "last_close"
+)
+
 // QuotesRequest represents a request to get multiple quotes
 type QuotesRequest struct {
 	Symbols []string `json:"symbols" binding:"required,min=1"`
@@ -57,6 +67,8 @@ type InfoResponse struct {
 	Currency    *string `json:"currency,omitempty"`
 	Website     *string `json:"website,omitempty"`
 	Country     *string `json:"country,omitempty"`
+	Stale       bool    `json:"stale,omitempty"`       // Served from cache because a refresh failed
+	AgeSeconds  *int64  `json:"age_seconds,omitempty"` // Age of stale info
 }
 
 // SearchResult represents a single search result
@@ -73,3 +85,39 @@ type SearchResponse struct {
 	Results []SearchResult `json:"results"`
 	Count   int            `json:"count"`
 }
+
+// MarketTick represents a real-time price update pushed over the market WebSocket stream
+type MarketTick struct {
+	Symbol        string   `json:"symbol"`
+	Price         *float64 `json:"price,omitempty"`
+	ChangePercent *float64 `json:"change_percent,omitempty"`
+	Volume        *int64   `json:"volume,omitempty"`
+	Timestamp     int64    `json:"timestamp"` // Unix timestamp in milliseconds
+}
+
+// CorporateActionEvent represents a split or symbol change reported by a market data provider
+type CorporateActionEvent struct {
+	Type      string   `json:"type"`                 // split or symbol_change
+	Date      string   `json:"date"`                 // Ex-date, YYYY-MM-DD
+	Ratio     *float64 `json:"ratio,omitempty"`      // New shares per old share for splits
+	NewSymbol *string  `json:"new_symbol,omitempty"` // New symbol for symbol changes
+}
+
+// ActionsResponse represents the corporate actions of a symbol
+type ActionsResponse struct {
+	Symbol  string                 `json:"symbol"`
+	Actions []CorporateActionEvent `json:"actions"`
+}
+
+// DividendEvent represents a cash dividend reported by a market data provider
+type DividendEvent struct {
+	Date   string  `json:"date"`   // Ex-date, YYYY-MM-DD
+	Amount float64 `json:"amount"` // Dividend per share
+}
+
+// DividendsResponse represents the dividend history of a symbol
+type DividendsResponse struct {
+	Symbol    string          `json:"symbol"`
+	Currency  *string         `json:"currency,omitempty"`
+	Dividends []DividendEvent `json:"dividends"` // Oldest first
+}
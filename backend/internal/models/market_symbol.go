@@ -0,0 +1,28 @@
+package models
+
+// SymbolSource records where a symbol master entry came from
+type SymbolSource string
+
+const (
+	SymbolSourceSearch  SymbolSource = "search"  // Upstream search result
+	SymbolSourceHolding SymbolSource = "holding" // Held or watched asset
+	SymbolSourceImport  SymbolSource = "import"  // Imported listing CSV
+)
+
+// MarketSymbol represents an entry of the local symbol master used for offline search
+type MarketSymbol struct {
+	BaseModel
+	Symbol    string       `gorm:"type:varchar(50);not null;uniqueIndex" json:"symbol"` // Canonical symbol, e.g. SSE:600519, AAPL, BTC-USD
+	Code      string       `gorm:"type:varchar(50);not null;index" json:"code"`         // Code as listed, e.g. 600519, AAPL, BTC
+	Exchange  string       `gorm:"type:varchar(20);index" json:"exchange"`
+	Name      string       `gorm:"type:varchar(255)" json:"name"`
+	Aliases   string       `gorm:"type:varchar(255)" json:"aliases"` // Comma-separated alternative names, e.g. pinyin initials (GZMT)
+	AssetType string       `gorm:"type:varchar(20)" json:"asset_type"`
+	Currency  string       `gorm:"type:varchar(10)" json:"currency"`
+	Source    SymbolSource `gorm:"type:varchar(20)" json:"source"`
+}
+
+// TableName specifies the table name for MarketSymbol
+func (MarketSymbol) TableName() string {
+	return "symbols"
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// MaturityAction represents what was done about a maturing interest-bearing asset
+type MaturityAction string
+
+const (
+	MaturityActionNotice  MaturityAction = "notice"   // Maturity is approaching
+	MaturityActionFlagged MaturityAction = "flagged"  // Matured and left for manual handling
+	MaturityActionRolled  MaturityAction = "rolled"   // Renewed for another term
+	MaturityActionPaidOut MaturityAction = "paid_out" // Paid into a cash asset and closed
+)
+
+// MaturityEvent records the handling of an interest-bearing asset's maturity
+type MaturityEvent struct {
+	BaseModel
+	AssetID         uint           `gorm:"not null;index:idx_maturity_asset" json:"asset_id"`
+	MaturityDate    time.Time      `gorm:"not null;index:idx_maturity_asset" json:"maturity_date"`
+	Action          MaturityAction `gorm:"type:varchar(20);not null" json:"action"`
+	Name            string         `gorm:"type:varchar(255)" json:"name"` // Asset name, kept for paid-out assets
+	Principal       float64        `gorm:"type:decimal(20,2)" json:"principal"`
+	Interest        float64        `gorm:"type:decimal(20,2)" json:"interest"` // Interest earned over the term
+	Currency        string         `gorm:"type:varchar(10)" json:"currency"`
+	NewRate         *float64       `gorm:"type:decimal(5,4)" json:"new_rate,omitempty"` // Rate of the renewed term
+	NewMaturityDate *time.Time     `json:"new_maturity_date,omitempty"`
+	CashAssetID     *uint          `json:"cash_asset_id,omitempty"` // Cash asset credited
+	Note            string         `gorm:"type:text" json:"note"`
+}
+
+// TableName specifies the table name for MaturityEvent
+func (MaturityEvent) TableName() string {
+	return "maturity_events"
+}
@@ -1,5 +1,7 @@
 package models
 
+import "strings"
+
 // NotificationChannel represents the notification channel type
 type NotificationChannel string
 
@@ -9,6 +11,15 @@ const (
 	ChannelEmail        NotificationChannel = "email"
 )
 
+// AlertKind represents a kind of event alert a notification can receive besides its scheduled report
+type AlertKind string
+
+const (
+	AlertMaturity   AlertKind = "maturity"    // Deposit and bond maturities
+	AlertCreditCard AlertKind = "credit_card" // Credit card payments due soon or overdue
+	AlertCollateral AlertKind = "collateral"  // Secured debts crossing their loan-to-value threshold
+)
+
 // Notification represents a notification configuration
 type Notification struct {
 	BaseModel
@@ -18,4 +29,18 @@ type Notification struct {
 	Config      string              `gorm:"type:text;not null" json:"config"` // JSON string of channel config
 	Schedule    string              `gorm:"type:varchar(255)" json:"schedule"` // Cron expression
 	Enabled     bool                `gorm:"default:true" json:"enabled"`
+	Alerts      string              `gorm:"type:varchar(255)" json:"alerts"` // Comma-separated alert kinds to receive; empty receives all
+}
+
+// ReceivesAlert reports whether the notification subscribes to an alert kind
+func (n *Notification) ReceivesAlert(kind AlertKind) bool {
+	if strings.TrimSpace(n.Alerts) == "" {
+		return true
+	}
+	for _, alert := range strings.Split(n.Alerts, ",") {
+		if AlertKind(strings.TrimSpace(alert)) == kind {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// OtherAssetKind represents what a manually valued asset is
+type OtherAssetKind string
+
+const (
+	OtherAssetRealEstate  OtherAssetKind = "real_estate"
+	OtherAssetVehicle     OtherAssetKind = "vehicle"
+	OtherAssetCollectible OtherAssetKind = "collectible"
+	OtherAssetOther       OtherAssetKind = "other"
+)
+
+// ValuationMethod represents how a manually valued asset is valued between appraisals
+type ValuationMethod string
+
+const (
+	ValuationManual           ValuationMethod = "manual"            // Holds the latest appraisal
+	ValuationStraightLine     ValuationMethod = "straight_line"     // Depreciates by equal amounts to the salvage value at the end of its useful life
+	ValuationDecliningBalance ValuationMethod = "declining_balance" // Depreciates by a fixed percentage of its value each year
+	ValuationAppreciation     ValuationMethod = "appreciation"      // Holds the latest appraisal; projected to grow by a fixed percentage each year
+)
+
+// OtherAsset is an asset without a market price, such as a flat, a car or a collection,
+// valued from dated appraisals, optionally depreciated between them or projected to appreciate
+type OtherAsset struct {
+	BaseModel
+	Name             string          `gorm:"type:varchar(255);not null" json:"name"`
+	Kind             OtherAssetKind  `gorm:"type:varchar(20);not null" json:"kind"`
+	Currency         string          `gorm:"type:varchar(10);default:'CNY'" json:"currency"`
+	Description      string          `gorm:"type:text" json:"description"`
+	PurchaseDate     *time.Time      `json:"purchase_date,omitempty"`
+	PurchasePrice    float64         `gorm:"type:decimal(20,2)" json:"purchase_price"`
+	ValuationMethod  ValuationMethod `gorm:"type:varchar(20)" json:"valuation_method"`
+	UsefulLifeYears  float64         `gorm:"type:decimal(6,2)" json:"useful_life_years"` // Straight-line: years from the purchase date to the salvage value
+	SalvageValue     float64         `gorm:"type:decimal(20,2)" json:"salvage_value"`    // Depreciation never takes the value below this
+	DepreciationRate float64         `gorm:"type:decimal(7,4)" json:"depreciation_rate"` // Declining balance: percentage of the value lost each year
+	AppreciationRate float64         `gorm:"type:decimal(7,4)" json:"appreciation_rate"` // Appreciation: expected yearly growth in percent, used for projections only
+}
+
+// TableName specifies the table name for OtherAsset
+func (OtherAsset) TableName() string {
+	return "other_assets"
+}
+
+// OtherAssetAppraisal is the value of a manually valued asset on a date
+type OtherAssetAppraisal struct {
+	BaseModel
+	OtherAssetID uint      `gorm:"not null;index:idx_appraisal_asset" json:"other_asset_id"`
+	Date         time.Time `gorm:"not null;index:idx_appraisal_asset" json:"date"`
+	Value        float64   `gorm:"type:decimal(20,2);not null" json:"value"`
+	Source       string    `gorm:"type:varchar(255)" json:"source"` // Who set the value, such as an agent, a price guide or the owner
+	Note         string    `gorm:"type:text" json:"note"`
+}
+
+// TableName specifies the table name for OtherAssetAppraisal
+func (OtherAssetAppraisal) TableName() string {
+	return "other_asset_appraisals"
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// PriceBar represents a stored OHLC bar for a symbol
+type PriceBar struct {
+	BaseModel
+	Symbol   string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_price_bar_key" json:"symbol"`
+	Interval string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_price_bar_key" json:"interval"`
+	Date     time.Time `gorm:"type:date;not null;uniqueIndex:idx_price_bar_key" json:"date"`
+	Open     *float64  `gorm:"type:decimal(20,6)" json:"open,omitempty"`
+	High     *float64  `gorm:"type:decimal(20,6)" json:"high,omitempty"`
+	Low      *float64  `gorm:"type:decimal(20,6)" json:"low,omitempty"`
+	Close    *float64  `gorm:"type:decimal(20,6)" json:"close,omitempty"`
+	Volume   *int64    `json:"volume,omitempty"`
+}
+
+// TableName specifies the table name for PriceBar
+func (PriceBar) TableName() string {
+	return "price_bars"
+}
+
+// PriceBarSync tracks which range of bars has been fetched for a symbol and interval
+type PriceBarSync struct {
+	BaseModel
+	Symbol        string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_price_bar_sync_key" json:"symbol"`
+	Interval      string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_price_bar_sync_key" json:"interval"`
+	CoveredFrom   time.Time `json:"covered_from"` // Earliest date requested from the provider, zero for "max"
+	LastFetchedAt time.Time `json:"last_fetched_at"`
+	Currency      string    `gorm:"type:varchar(10)" json:"currency"`
+}
+
+// TableName specifies the table name for PriceBarSync
+func (PriceBarSync) TableName() string {
+	return "price_bar_syncs"
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// TransactionType represents the kind of a ledger entry
+type TransactionType string
+
+const (
+	TransactionOpeningBalance   TransactionType = "opening_balance"   // Position held before the ledger started
+	TransactionBuy              TransactionType = "buy"               // Units bought for cash
+	TransactionSell             TransactionType = "sell"              // Units sold for cash
+	TransactionTransferIn       TransactionType = "transfer_in"       // Units moved in from another account, at their cost basis
+	TransactionTransferOut      TransactionType = "transfer_out"      // Units moved out to another account
+	TransactionFee              TransactionType = "fee"               // Fee paid in cash (Fees) or in units (Quantity)
+	TransactionSplit            TransactionType = "split"             // Stock split; Ratio new units per old unit
+	TransactionDividendReinvest TransactionType = "dividend_reinvest" // Dividend paid out as new units
+)
+
+// CostBasisMethod selects the lots a sale is matched against
+type CostBasisMethod string
+
+const (
+	CostBasisFIFO        CostBasisMethod = "fifo"         // Oldest lots first
+	CostBasisLIFO        CostBasisMethod = "lifo"         // Newest lots first
+	CostBasisAverage     CostBasisMethod = "average"      // All lots pooled at their average cost
+	CostBasisSpecificLot CostBasisMethod = "specific_lot" // The lot named by the sale, oldest lots first if none is named
+)
+
+// Transaction is a ledger entry of a stock or crypto holding.
+// The holding's quantity and average purchase price are projections of its transactions.
+type Transaction struct {
+	BaseModel
+	AssetType         AssetType       `gorm:"type:varchar(20);not null;index:idx_transaction_asset" json:"asset_type"` // stock or crypto
+	AssetID           uint            `gorm:"not null;index:idx_transaction_asset" json:"asset_id"`
+	Type              TransactionType `gorm:"type:varchar(20);not null" json:"type"`
+	Date              time.Time       `gorm:"not null;index" json:"date"`
+	Quantity          float64         `gorm:"type:decimal(20,8)" json:"quantity"`        // Units moved, always positive; the type gives the direction
+	Price             float64         `gorm:"type:decimal(20,8)" json:"price"`           // Price per unit; cost basis per unit for transfers in
+	Fees              float64         `gorm:"type:decimal(20,2)" json:"fees"`            // Fees paid in cash
+	Ratio             float64         `gorm:"type:decimal(20,8)" json:"ratio,omitempty"` // New units per old unit, for splits
+	Currency          string          `gorm:"type:varchar(10)" json:"currency"`
+	Note              string          `gorm:"type:text" json:"note"`
+	CorporateActionID *uint           `gorm:"index" json:"corporate_action_id,omitempty"` // Corporate action that generated the entry
+	LotID             *uint           `json:"lot_id,omitempty"`                           // Acquisition whose units are disposed of, for the specific-lot method
+}
+
+// TableName specifies the table name for Transaction
+func (Transaction) TableName() string {
+	return "transactions"
+}
@@ -8,6 +8,8 @@ type Watchlist struct {
 	Name      string `gorm:"type:varchar(100);not null" json:"name"`
 	AssetType string `gorm:"type:varchar(20);not null;index" json:"asset_type"` // "stock", "etf", "crypto"
 	Notes     string `gorm:"type:text" json:"notes"`
+
+	DataSourceID *uint `gorm:"index" json:"data_source_id,omitempty"` // Optional market data source binding
 }
 
 // TableName specifies the table name for Watchlist
@@ -1,10 +1,13 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"time"
 
 	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/calendar"
+	"trackmymoney/internal/services/symbol"
 	"trackmymoney/pkg/logger"
 )
 
@@ -20,22 +23,67 @@ func NewAssetMarketService(marketService *MarketService) *AssetMarketService {
 	}
 }
 
+// marketFor returns the market service for an asset class, bound to the asset's data source
+func (s *AssetMarketService) marketFor(assetClass models.AssetType, dataSourceID *uint) (*MarketService, error) {
+	return s.marketService.ForAssetClass(assetClass).ForDataSource(dataSourceID)
+}
+
+// StockPriceOutdated reports whether a stock holding's market may have moved since its price
+// was last refreshed: the market is open, or a daily close happened after the refresh
+func (s *AssetMarketService) StockPriceOutdated(asset *models.StockAsset, now time.Time) bool {
+	return s.marketService.Calendar().TradedSince(stockMarket(asset), priceUpdatedAt(asset), now)
+}
+
+// StockCloseMissing reports whether a stock holding's market has closed and settled
+// since its price was last refreshed, so the closing price can be recorded
+func (s *AssetMarketService) StockCloseMissing(asset *models.StockAsset, now time.Time) bool {
+	cal := s.marketService.Calendar()
+	market := stockMarket(asset)
+	if cal.IsOpen(market, now) {
+		return false
+	}
+
+	lastClose := cal.LastClose(market, now)
+	return !lastClose.IsZero() && lastClose.After(priceUpdatedAt(asset)) && !now.Before(lastClose.Add(calendar.SettleDelay))
+}
+
+// priceUpdatedAt returns when a stock holding was last priced from market data, zero if never.
+// Other writes to the holding do not count, so they never pass an intraday price off as the close.
+func priceUpdatedAt(asset *models.StockAsset) time.Time {
+	if asset.PriceUpdatedAt == nil {
+		return time.Time{}
+	}
+	return *asset.PriceUpdatedAt
+}
+
+// stockMarket returns the market a stock holding trades on
+func stockMarket(asset *models.StockAsset) calendar.Market {
+	return calendar.MarketOf(symbol.New(asset.Symbol, asset.Exchange))
+}
+
 // ValidateAndEnrichStockAsset validates symbol and enriches asset with market data
-func (s *AssetMarketService) ValidateAndEnrichStockAsset(asset *models.StockAsset) error {
+func (s *AssetMarketService) ValidateAndEnrichStockAsset(ctx context.Context, asset *models.StockAsset) error {
 	if asset.Symbol == "" {
 		return fmt.Errorf("symbol is required")
 	}
 
+	market, err := s.marketFor(models.AssetTypeStock, asset.DataSourceID)
+	if err != nil {
+		return err
+	}
+
 	// Get quote from market
-	quote, err := s.marketService.GetQuote(asset.Symbol)
+	symbol := StockSymbol(asset)
+	quote, err := market.GetQuote(ctx, symbol)
 	if err != nil {
-		logger.Warn(fmt.Sprintf("Failed to get quote for symbol %s: %v", asset.Symbol, err))
+		logger.Warn(fmt.Sprintf("Failed to get quote for symbol %s: %v", symbol, err))
 		return fmt.Errorf("invalid symbol or market data unavailable: %s", asset.Symbol)
 	}
 
 	// Enrich asset with market data
 	if asset.CurrentPrice == 0 && quote.Price != nil {
 		asset.CurrentPrice = *quote.Price
+		markPriced(asset)
 	}
 
 	if asset.Name == "" && quote.Name != nil {
@@ -50,16 +98,21 @@ func (s *AssetMarketService) ValidateAndEnrichStockAsset(asset *models.StockAsse
 }
 
 // ValidateAndEnrichCryptoAsset validates symbol and enriches asset with market data
-func (s *AssetMarketService) ValidateAndEnrichCryptoAsset(asset *models.CryptoAsset) error {
+func (s *AssetMarketService) ValidateAndEnrichCryptoAsset(ctx context.Context, asset *models.CryptoAsset) error {
 	if asset.Symbol == "" {
 		return fmt.Errorf("symbol is required")
 	}
 
-	// Normalize crypto symbol to Yahoo Finance format (e.g., BTC -> BTC-USD)
-	symbol := s.normalizeCryptoSymbol(asset.Symbol)
+	// Crypto assets are quoted as a pair (e.g., BTC -> BTC-USD)
+	symbol := CryptoSymbol(asset)
+
+	market, err := s.marketFor(models.AssetTypeCrypto, asset.DataSourceID)
+	if err != nil {
+		return err
+	}
 
 	// Get quote from market
-	quote, err := s.marketService.GetQuote(symbol)
+	quote, err := market.GetQuote(ctx, symbol)
 	if err != nil {
 		logger.Warn(fmt.Sprintf("Failed to get quote for symbol %s: %v", symbol, err))
 		return fmt.Errorf("invalid symbol or market data unavailable: %s", asset.Symbol)
@@ -78,26 +131,38 @@ func (s *AssetMarketService) ValidateAndEnrichCryptoAsset(asset *models.CryptoAs
 }
 
 // UpdateStockAssetPrice updates a single stock asset price
-func (s *AssetMarketService) UpdateStockAssetPrice(asset *models.StockAsset) error {
-	quote, err := s.marketService.GetQuote(asset.Symbol)
+func (s *AssetMarketService) UpdateStockAssetPrice(ctx context.Context, asset *models.StockAsset) error {
+	market, err := s.marketFor(models.AssetTypeStock, asset.DataSourceID)
 	if err != nil {
-		return fmt.Errorf("failed to get quote for %s: %w", asset.Symbol, err)
+		return err
+	}
+
+	symbol := StockSymbol(asset)
+	quote, err := market.GetQuote(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get quote for %s: %w", symbol, err)
 	}
 
 	if quote.Price != nil {
 		asset.CurrentPrice = *quote.Price
+		markPriced(asset)
 	} else {
-		return fmt.Errorf("no price data available for %s", asset.Symbol)
+		return fmt.Errorf("no price data available for %s", symbol)
 	}
 
 	return nil
 }
 
 // UpdateCryptoAssetPrice updates a single crypto asset price
-func (s *AssetMarketService) UpdateCryptoAssetPrice(asset *models.CryptoAsset) error {
-	symbol := s.normalizeCryptoSymbol(asset.Symbol)
+func (s *AssetMarketService) UpdateCryptoAssetPrice(ctx context.Context, asset *models.CryptoAsset) error {
+	symbol := CryptoSymbol(asset)
+
+	market, err := s.marketFor(models.AssetTypeCrypto, asset.DataSourceID)
+	if err != nil {
+		return err
+	}
 
-	quote, err := s.marketService.GetQuote(symbol)
+	quote, err := market.GetQuote(ctx, symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get quote for %s: %w", symbol, err)
 	}
@@ -112,43 +177,74 @@ func (s *AssetMarketService) UpdateCryptoAssetPrice(asset *models.CryptoAsset) e
 }
 
 // UpdateStockAssetsPrices updates prices for multiple stock assets
-func (s *AssetMarketService) UpdateStockAssetsPrices(assets []models.StockAsset) (int, []string, error) {
+func (s *AssetMarketService) UpdateStockAssetsPrices(ctx context.Context, assets []models.StockAsset) (int, []string, error) {
 	if len(assets) == 0 {
 		return 0, nil, nil
 	}
 
-	// Collect symbols
-	symbols := make([]string, len(assets))
+	// Group assets by their bound data source
+	groups := make(map[uint][]int)
 	for i, asset := range assets {
-		symbols[i] = asset.Symbol
-	}
-
-	// Get quotes in batch
-	quotesResp, err := s.marketService.GetQuotes(symbols)
-	if err != nil {
-		return 0, nil, fmt.Errorf("failed to get batch quotes: %w", err)
+		key := dataSourceKey(asset.DataSourceID)
+		groups[key] = append(groups[key], i)
 	}
 
-	// Create a map for quick lookup
-	quoteMap := make(map[string]*models.Quote)
-	for i := range quotesResp.Quotes {
-		quote := &quotesResp.Quotes[i]
-		quoteMap[quote.Symbol] = quote
-	}
-
-	// Update assets
 	updated := 0
 	var failed []string
 
-	for i := range assets {
-		asset := &assets[i]
-		quote, found := quoteMap[asset.Symbol]
+	for _, indexes := range groups {
+		if err := ctx.Err(); err != nil {
+			return updated, failed, fmt.Errorf("price update aborted: %w", err)
+		}
+
+		market, err := s.marketFor(models.AssetTypeStock, assets[indexes[0]].DataSourceID)
+		if err != nil {
+			if len(groups) == 1 {
+				return 0, nil, err
+			}
+			logger.Warn(fmt.Sprintf("Skipping stock assets bound to unavailable data source: %v", err))
+			for _, i := range indexes {
+				failed = append(failed, assets[i].Symbol)
+			}
+			continue
+		}
+
+		// Collect canonical symbols
+		symbols := make([]string, len(indexes))
+		for j, i := range indexes {
+			symbols[j] = StockSymbol(&assets[i])
+		}
+
+		// Get quotes in batch
+		quotesResp, err := market.GetQuotes(ctx, symbols)
+		if err != nil {
+			if len(groups) == 1 {
+				return 0, nil, fmt.Errorf("failed to get batch quotes: %w", err)
+			}
+			logger.Warn(fmt.Sprintf("Failed to get batch quotes: %v", err))
+			failed = append(failed, symbols...)
+			continue
+		}
+
+		// Create a map for quick lookup
+		quoteMap := make(map[string]*models.Quote)
+		for i := range quotesResp.Quotes {
+			quote := &quotesResp.Quotes[i]
+			quoteMap[quote.Symbol] = quote
+		}
 
-		if found && quote.Price != nil {
-			asset.CurrentPrice = *quote.Price
-			updated++
-		} else {
-			failed = append(failed, asset.Symbol)
+		// Update assets
+		for _, i := range indexes {
+			asset := &assets[i]
+			quote, found := quoteMap[StockSymbol(asset)]
+
+			if found && quote.Price != nil {
+				asset.CurrentPrice = *quote.Price
+				markPriced(asset)
+				updated++
+			} else {
+				failed = append(failed, asset.Symbol)
+			}
 		}
 	}
 
@@ -156,72 +252,117 @@ func (s *AssetMarketService) UpdateStockAssetsPrices(assets []models.StockAsset)
 }
 
 // UpdateCryptoAssetsPrices updates prices for multiple crypto assets
-func (s *AssetMarketService) UpdateCryptoAssetsPrices(assets []models.CryptoAsset) (int, []string, error) {
+func (s *AssetMarketService) UpdateCryptoAssetsPrices(ctx context.Context, assets []models.CryptoAsset) (int, []string, error) {
 	if len(assets) == 0 {
 		return 0, nil, nil
 	}
 
-	// Collect and normalize symbols
-	symbols := make([]string, len(assets))
-	symbolMap := make(map[string]string) // normalized -> original
-
+	// Group assets by their bound data source
+	groups := make(map[uint][]int)
 	for i, asset := range assets {
-		normalized := s.normalizeCryptoSymbol(asset.Symbol)
-		symbols[i] = normalized
-		symbolMap[normalized] = asset.Symbol
+		key := dataSourceKey(asset.DataSourceID)
+		groups[key] = append(groups[key], i)
 	}
 
-	// Get quotes in batch
-	quotesResp, err := s.marketService.GetQuotes(symbols)
-	if err != nil {
-		return 0, nil, fmt.Errorf("failed to get batch quotes: %w", err)
-	}
+	updated := 0
+	var failed []string
 
-	// Create a map for quick lookup
-	quoteMap := make(map[string]*models.Quote)
-	for i := range quotesResp.Quotes {
-		quote := &quotesResp.Quotes[i]
-		// Map back to original symbol
-		if original, found := symbolMap[quote.Symbol]; found {
-			quoteMap[original] = quote
+	for _, indexes := range groups {
+		if err := ctx.Err(); err != nil {
+			return updated, failed, fmt.Errorf("price update aborted: %w", err)
 		}
-	}
 
-	// Update assets
-	updated := 0
-	var failed []string
+		market, err := s.marketFor(models.AssetTypeCrypto, assets[indexes[0]].DataSourceID)
+		if err != nil {
+			if len(groups) == 1 {
+				return 0, nil, err
+			}
+			logger.Warn(fmt.Sprintf("Skipping crypto assets bound to unavailable data source: %v", err))
+			for _, i := range indexes {
+				failed = append(failed, assets[i].Symbol)
+			}
+			continue
+		}
 
-	for i := range assets {
-		asset := &assets[i]
-		normalized := s.normalizeCryptoSymbol(asset.Symbol)
-		quote, found := quoteMap[asset.Symbol]
+		// Collect canonical pair symbols
+		symbols := make([]string, len(indexes))
+		for j, i := range indexes {
+			symbols[j] = CryptoSymbol(&assets[i])
+		}
+
+		// Get quotes in batch
+		quotesResp, err := market.GetQuotes(ctx, symbols)
+		if err != nil {
+			if len(groups) == 1 {
+				return 0, nil, fmt.Errorf("failed to get batch quotes: %w", err)
+			}
+			logger.Warn(fmt.Sprintf("Failed to get batch quotes: %v", err))
+			for _, i := range indexes {
+				failed = append(failed, assets[i].Symbol)
+			}
+			continue
+		}
 
-		if !found {
-			// Try normalized symbol
-			quote, found = quoteMap[normalized]
+		// Create a map for quick lookup
+		quoteMap := make(map[string]*models.Quote)
+		for i := range quotesResp.Quotes {
+			quote := &quotesResp.Quotes[i]
+			quoteMap[quote.Symbol] = quote
 		}
 
-		if found && quote.Price != nil {
-			asset.CurrentPrice = *quote.Price
-			updated++
-		} else {
-			failed = append(failed, asset.Symbol)
+		// Update assets
+		for _, i := range indexes {
+			asset := &assets[i]
+			quote, found := quoteMap[CryptoSymbol(asset)]
+
+			if found && quote.Price != nil {
+				asset.CurrentPrice = *quote.Price
+				updated++
+			} else {
+				failed = append(failed, asset.Symbol)
+			}
 		}
 	}
 
 	return updated, failed, nil
 }
 
-// normalizeCryptoSymbol converts crypto symbol to Yahoo Finance format
-// Examples: BTC -> BTC-USD, ETH -> ETH-USD
-func (s *AssetMarketService) normalizeCryptoSymbol(symbol string) string {
-	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+// markPriced records that a stock holding's price was just refreshed from market data
+func markPriced(asset *models.StockAsset) {
+	now := time.Now()
+	asset.PriceUpdatedAt = &now
+}
 
-	// If already in correct format (e.g., BTC-USD), return as-is
-	if strings.Contains(symbol, "-") {
-		return symbol
+// dataSourceKey maps an optional data source ID to a grouping key (0 = default source)
+func dataSourceKey(dataSourceID *uint) uint {
+	if dataSourceID == nil {
+		return 0
 	}
+	return *dataSourceID
+}
+
+// StockSymbol returns the canonical market symbol of a stock asset
+func StockSymbol(asset *models.StockAsset) string {
+	return symbol.New(asset.Symbol, asset.Exchange).String()
+}
+
+// CryptoSymbol returns the canonical market symbol of a crypto asset (the pair in its quote currency)
+func CryptoSymbol(asset *models.CryptoAsset) string {
+	return symbol.Crypto(asset.Symbol, asset.QuoteCurrency).String()
+}
+
+// NormalizeStockAsset stores the symbol of a stock asset in canonical form:
+// the code as listed plus its exchange (600519.SS -> 600519 on SSE)
+func NormalizeStockAsset(asset *models.StockAsset) {
+	sym := symbol.New(asset.Symbol, asset.Exchange)
+	asset.Symbol = sym.Code
+	asset.Exchange = string(sym.Exchange)
+}
 
-	// Common crypto symbols - add -USD suffix
-	return symbol + "-USD"
+// NormalizeCryptoAsset stores the symbol of a crypto asset in canonical form:
+// the base currency plus its quote currency (BTC-CNY -> BTC quoted in CNY)
+func NormalizeCryptoAsset(asset *models.CryptoAsset) {
+	sym := symbol.Crypto(asset.Symbol, asset.QuoteCurrency)
+	asset.Symbol = sym.Code
+	asset.QuoteCurrency = sym.QuoteCurrency
 }
@@ -12,21 +12,33 @@ import (
 // AssetService handles asset-related business logic
 type AssetService struct {
 	db *gorm.DB
+	fx *FXService
 }
 
-// NewAssetService creates a new asset service
-func NewAssetService(db *gorm.DB) *AssetService {
+// NewAssetService creates a new asset service reporting in the FX service's base currency
+func NewAssetService(db *gorm.DB, fx *FXService) *AssetService {
 	return &AssetService{
 		db: db,
+		fx: fx,
 	}
 }
 
-// AssetSummary represents the summary of all assets
+// AssetSummary represents the summary of all assets in the base currency
 type AssetSummary struct {
+	BaseCurrency string            `json:"base_currency"`
 	TotalAssets float64            `json:"total_assets"`
 	TotalDebt   float64            `json:"total_debt"`
 	NetAssets   float64            `json:"net_assets"`
 	Categories  map[string]float64 `json:"categories"`
+	Income      IncomeSummary      `json:"income"`
+
+	InterestBearing InterestBearingTotals `json:"interest_bearing"` // Principal and accrued interest of deposits and bonds
+	CreditCards     CreditCardTotals      `json:"credit_cards"`     // Balances, limits and utilization of credit cards
+	OtherAssets     OtherAssetTotals      `json:"other_assets"`     // Appraised or depreciated value of manually valued assets
+
+	Currencies         []CurrencyBreakdown           `json:"currencies"`          // Assets and debts by original currency
+	CategoryCurrencies map[string]map[string]float64 `json:"category_currencies"` // Category amounts by original currency
+	MissingRates       []string                      `json:"missing_rates,omitempty"` // Currencies counted unconverted for lack of a rate
 }
 
 // CalculateAssetSummary calculates the total value of all assets
@@ -35,25 +47,36 @@ type AssetSummary struct {
 // - handlers.GetAssetsHistory
 // - jobs.calculateAssetSummary
 func (s *AssetService) CalculateAssetSummary() (*AssetSummary, error) {
+	rates, err := s.fx.History()
+	if err != nil {
+		return nil, err
+	}
+	fx := rates.At(time.Now())
 	summary := &AssetSummary{
-		Categories: make(map[string]float64),
+		BaseCurrency: fx.Base(),
+		Categories:   make(map[string]float64),
 	}
 
 	// Cash assets
+	var cashAssets []models.CashAsset
+	if err := s.db.Find(&cashAssets).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve cash assets: %w", err)
+	}
 	var cashTotal float64
-	if err := s.db.Model(&models.CashAsset{}).Select("COALESCE(SUM(amount), 0)").Scan(&cashTotal).Error; err != nil {
-		return nil, fmt.Errorf("failed to sum cash assets: %w", err)
+	for _, asset := range cashAssets {
+		cashTotal += fx.In("cash").Asset(asset.Amount, asset.Currency)
 	}
 	summary.TotalAssets += cashTotal
 	summary.Categories["cash"] = cashTotal
 
-	// Interest-bearing assets
-	var interestBearingTotal float64
-	if err := s.db.Model(&models.InterestBearingAsset{}).Select("COALESCE(SUM(amount), 0)").Scan(&interestBearingTotal).Error; err != nil {
-		return nil, fmt.Errorf("failed to sum interest-bearing assets: %w", err)
+	// Interest-bearing assets (principal plus interest accrued to date)
+	interestBearing, err := SumInterestBearing(s.db, time.Now(), fx.In("interest_bearing"))
+	if err != nil {
+		return nil, err
 	}
-	summary.TotalAssets += interestBearingTotal
-	summary.Categories["interest_bearing"] = interestBearingTotal
+	summary.InterestBearing = interestBearing
+	summary.TotalAssets += interestBearing.Value
+	summary.Categories["interest_bearing"] = interestBearing.Value
 
 	// Stock assets (quantity * current_price, fallback to purchase_price if current_price is 0)
 	var stockAssets []models.StockAsset
@@ -67,7 +90,7 @@ func (s *AssetService) CalculateAssetSummary() (*AssetSummary, error) {
 			price = asset.PurchasePrice
 		}
 		value := asset.Quantity * price
-		stockTotal += value
+		stockTotal += fx.In("stock").Asset(value, asset.Currency)
 	}
 	summary.TotalAssets += stockTotal
 	summary.Categories["stock"] = stockTotal
@@ -84,21 +107,55 @@ func (s *AssetService) CalculateAssetSummary() (*AssetSummary, error) {
 			price = asset.PurchasePrice
 		}
 		value := asset.Quantity * price
-		cryptoTotal += value
+		cryptoTotal += fx.In("crypto").Asset(value, asset.QuoteCurrency)
 	}
 	summary.TotalAssets += cryptoTotal
 	summary.Categories["crypto"] = cryptoTotal
 
-	// Debt assets
-	var debtTotal float64
-	if err := s.db.Model(&models.DebtAsset{}).Select("COALESCE(SUM(amount), 0)").Scan(&debtTotal).Error; err != nil {
-		return nil, fmt.Errorf("failed to sum debt assets: %w", err)
+	// Other assets (latest appraisal carried forward by their valuation method)
+	otherAssets, err := SumOtherAssets(s.db, time.Now(), fx.In("other"))
+	if err != nil {
+		return nil, err
+	}
+	summary.OtherAssets = otherAssets
+	summary.TotalAssets += otherAssets.Value
+	summary.Categories["other"] = otherAssets.Value
+
+	// Debt assets (loans at their amortized balance)
+	debtTotal, err := SumDebts(s.db, time.Now(), fx.In("debt"))
+	if err != nil {
+		return nil, err
 	}
 	summary.TotalDebt = debtTotal
 	summary.Categories["debt"] = debtTotal
 
+	// Credit cards (current balance, billed or not)
+	creditCards, err := SumCreditCards(s.db, fx.In("credit_card"))
+	if err != nil {
+		return nil, err
+	}
+	summary.CreditCards = creditCards
+	summary.TotalDebt += creditCards.Balance
+	summary.Categories["credit_card"] = creditCards.Balance
+
 	summary.NetAssets = summary.TotalAssets - summary.TotalDebt
 
+	// Income received (dividends, interest, staking rewards)
+	income, err := summarizeIncome(s.db, time.Now(), rates)
+	if err != nil {
+		return nil, err
+	}
+	summary.Income = income
+
+	summary.Currencies = fx.Breakdown()
+	summary.CategoryCurrencies = fx.CategoryBreakdown()
+	missing := missingRates{}
+	missing.add(fx)
+	for _, currency := range income.MissingRates {
+		missing[currency] = true
+	}
+	summary.MissingRates = missing.list()
+
 	return summary, nil
 }
 
@@ -111,6 +168,14 @@ func (s *AssetService) SaveAssetHistory(summary *AssetSummary) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal category breakdown: %w", err)
 	}
+	currencyJSON, err := json.Marshal(summary.Currencies)
+	if err != nil {
+		return fmt.Errorf("failed to marshal currency breakdown: %w", err)
+	}
+	categoryCurrencyJSON, err := json.Marshal(summary.CategoryCurrencies)
+	if err != nil {
+		return fmt.Errorf("failed to marshal category currencies: %w", err)
+	}
 
 	// Check if history for today already exists
 	var existing models.AssetHistory
@@ -120,7 +185,10 @@ func (s *AssetService) SaveAssetHistory(summary *AssetSummary) error {
 		existing.TotalAssets = summary.TotalAssets
 		existing.TotalDebt = summary.TotalDebt
 		existing.NetAssets = summary.NetAssets
+		existing.Currency = summary.BaseCurrency
 		existing.CategoryBreakdown = string(categoryJSON)
+		existing.CurrencyBreakdown = string(currencyJSON)
+		existing.CategoryCurrencies = string(categoryCurrencyJSON)
 		return s.db.Save(&existing).Error
 	}
 
@@ -134,29 +202,20 @@ func (s *AssetService) SaveAssetHistory(summary *AssetSummary) error {
 		TotalAssets:       summary.TotalAssets,
 		TotalDebt:         summary.TotalDebt,
 		NetAssets:         summary.NetAssets,
-		CategoryBreakdown: string(categoryJSON),
+		Currency:          summary.BaseCurrency,
+		CategoryBreakdown:  string(categoryJSON),
+		CurrencyBreakdown:  string(currencyJSON),
+		CategoryCurrencies: string(categoryCurrencyJSON),
 	}
 
 	return s.db.Create(&history).Error
 }
 
-// GetAssetHistory retrieves historical asset data for a given period
+// GetAssetHistory retrieves historical asset data for a given period in the base currency.
+// Each snapshot is revalued from its currency breakdown at the rates of its own date.
 func (s *AssetService) GetAssetHistory(period string) ([]models.AssetHistory, error) {
-	var startDate time.Time
 	now := time.Now()
-
-	switch period {
-	case "7d":
-		startDate = now.AddDate(0, 0, -7)
-	case "30d":
-		startDate = now.AddDate(0, 0, -30)
-	case "90d":
-		startDate = now.AddDate(0, 0, -90)
-	case "1y":
-		startDate = now.AddDate(-1, 0, 0)
-	default:
-		startDate = now.AddDate(0, 0, -30)
-	}
+	startDate := historyStart(period, now)
 
 	var historyRecords []models.AssetHistory
 	err := s.db.Where("date >= ?", startDate).Order("date ASC").Find(&historyRecords).Error
@@ -172,20 +231,128 @@ func (s *AssetService) GetAssetHistory(period string) ([]models.AssetHistory, er
 		}
 
 		categoryJSON, _ := json.Marshal(summary.Categories)
+		currencyJSON, _ := json.Marshal(summary.Currencies)
+		categoryCurrencyJSON, _ := json.Marshal(summary.CategoryCurrencies)
 		currentSnapshot := models.AssetHistory{
-			Date:              now.Truncate(24 * time.Hour),
-			TotalAssets:       summary.TotalAssets,
-			TotalDebt:         summary.TotalDebt,
-			NetAssets:         summary.NetAssets,
-			CategoryBreakdown: string(categoryJSON),
+			Date:               now.Truncate(24 * time.Hour),
+			TotalAssets:        summary.TotalAssets,
+			TotalDebt:          summary.TotalDebt,
+			NetAssets:          summary.NetAssets,
+			Currency:           summary.BaseCurrency,
+			CategoryBreakdown:  string(categoryJSON),
+			CurrencyBreakdown:  string(currencyJSON),
+			CategoryCurrencies: string(categoryCurrencyJSON),
 		}
 
 		historyRecords = append(historyRecords, currentSnapshot)
+		return historyRecords, nil
+	}
+
+	rates, err := s.fx.History()
+	if err != nil {
+		return nil, err
+	}
+	for i := range historyRecords {
+		revalueSnapshot(&historyRecords[i], rates.At(historyRecords[i].Date))
 	}
 
 	return historyRecords, nil
 }
 
+// historyStart returns the first date of a history period (7d, 30d, 90d, 1y; 30d by default)
+func historyStart(period string, now time.Time) time.Time {
+	switch period {
+	case "7d":
+		return now.AddDate(0, 0, -7)
+	case "30d":
+		return now.AddDate(0, 0, -30)
+	case "90d":
+		return now.AddDate(0, 0, -90)
+	case "1y":
+		return now.AddDate(-1, 0, 0)
+	default:
+		return now.AddDate(0, 0, -30)
+	}
+}
+
+// revalueSnapshot converts a snapshot into the base currency at the given rates. Snapshots
+// with a currency breakdown are revalued from their original currency amounts, and their
+// categories from the category amounts by currency; older ones taken in another base currency
+// have their totals and categories converted from that currency.
+func revalueSnapshot(record *models.AssetHistory, fx *Converter) {
+	categories := snapshotCategories(record, fx)
+	if breakdown := snapshotBreakdown(record, fx); breakdown != nil {
+		record.TotalAssets, record.TotalDebt = 0, 0
+		for _, item := range breakdown {
+			record.TotalAssets += item.AssetsBase
+			record.TotalDebt += item.DebtBase
+		}
+		record.NetAssets = record.TotalAssets - record.TotalDebt
+		if currencyJSON, err := json.Marshal(breakdown); err == nil {
+			record.CurrencyBreakdown = string(currencyJSON)
+		}
+	} else if record.Currency != "" && record.Currency != fx.Base() {
+		record.TotalAssets = fx.Convert(record.TotalAssets, record.Currency)
+		record.TotalDebt = fx.Convert(record.TotalDebt, record.Currency)
+		record.NetAssets = fx.Convert(record.NetAssets, record.Currency)
+	}
+	if categories != nil {
+		if categoryJSON, err := json.Marshal(categories); err == nil {
+			record.CategoryBreakdown = string(categoryJSON)
+		}
+	}
+	record.Currency = fx.Base()
+}
+
+// snapshotCategories returns the categories of a snapshot valued at the given rates, each
+// converted from its amounts by original currency. Snapshots without them have their stored
+// categories converted from the snapshot's base currency, or nil when nothing changes.
+func snapshotCategories(record *models.AssetHistory, fx *Converter) map[string]float64 {
+	if record.CategoryCurrencies != "" {
+		var stored map[string]map[string]float64
+		if err := json.Unmarshal([]byte(record.CategoryCurrencies), &stored); err == nil && len(stored) > 0 {
+			categories := make(map[string]float64, len(stored))
+			for category, amounts := range stored {
+				var total float64
+				for currency, amount := range amounts {
+					total += fx.Convert(amount, currency)
+				}
+				categories[category] = roundCents(total)
+			}
+			return categories
+		}
+	}
+
+	if record.CategoryBreakdown == "" || record.Currency == "" || record.Currency == fx.Base() {
+		return nil
+	}
+	var categories map[string]float64
+	if err := json.Unmarshal([]byte(record.CategoryBreakdown), &categories); err != nil {
+		return nil
+	}
+	for category, amount := range categories {
+		categories[category] = roundCents(fx.Convert(amount, record.Currency))
+	}
+	return categories
+}
+
+// snapshotBreakdown returns the assets and debts of a snapshot by original currency, valued at
+// the given rates. It returns nil for snapshots taken without a currency breakdown.
+func snapshotBreakdown(record *models.AssetHistory, fx *Converter) []CurrencyBreakdown {
+	if record.CurrencyBreakdown == "" {
+		return nil
+	}
+	var stored []CurrencyBreakdown
+	if err := json.Unmarshal([]byte(record.CurrencyBreakdown), &stored); err != nil || len(stored) == 0 {
+		return nil
+	}
+	for _, item := range stored {
+		fx.Asset(item.Assets, item.Currency)
+		fx.Debt(item.Debt, item.Currency)
+	}
+	return fx.Breakdown()
+}
+
 // AssetStatisticsItem represents a single statistics data point
 type AssetStatisticsItem struct {
 	Date         string  `json:"date"`          // Date or period label
@@ -193,6 +360,7 @@ type AssetStatisticsItem struct {
 	Profit       float64 `json:"profit"`        // Profit compared to previous period
 	ProfitRate   float64 `json:"profit_rate"`   // Profit rate in percentage
 	NetAssets    float64 `json:"net_assets"`    // Net assets value
+	Income       float64 `json:"income"`        // Net income received in the period
 }
 
 // GetAssetStatistics retrieves asset statistics aggregated by dimension (daily/weekly/monthly)
@@ -284,6 +452,11 @@ func (s *AssetService) GetAssetStatistics(dimension, period string) ([]AssetStat
 		return s.GetAssetStatistics("daily", period)
 	}
 
+	// Income received in each period
+	if err := s.addPeriodIncome(statistics, dimension); err != nil {
+		return nil, err
+	}
+
 	// Calculate profit and profit rate
 	for i := range statistics {
 		if i > 0 {
@@ -299,6 +472,47 @@ func (s *AssetService) GetAssetStatistics(dimension, period string) ([]AssetStat
 	return statistics, nil
 }
 
+// addPeriodIncome sets the net income received in each period of the statistics, converted
+// into the base currency at the rate of each pay date
+func (s *AssetService) addPeriodIncome(statistics []AssetStatisticsItem, dimension string) error {
+	if len(statistics) == 0 {
+		return nil
+	}
+
+	periodOf := func(date time.Time) string {
+		switch dimension {
+		case "weekly":
+			return getWeekStart(date).Format("2006-01-02")
+		case "monthly":
+			return date.Format("2006-01")
+		default:
+			return date.Format("2006-01-02")
+		}
+	}
+	from := parseDate(statistics[0].Date)
+	if dimension == "monthly" {
+		from, _ = time.Parse("2006-01", statistics[0].Date)
+	}
+
+	var events []models.IncomeEvent
+	if err := s.db.Where("pay_date >= ? AND pay_date <= ?", from, time.Now()).Find(&events).Error; err != nil {
+		return fmt.Errorf("failed to retrieve income events: %w", err)
+	}
+	rates, err := s.fx.History()
+	if err != nil {
+		return err
+	}
+
+	income := make(map[string]float64)
+	for _, event := range events {
+		income[periodOf(event.PayDate)] += rates.At(event.PayDate).Convert(event.Amount-event.WithholdingTax, event.Currency)
+	}
+	for i := range statistics {
+		statistics[i].Income = income[statistics[i].Date]
+	}
+	return nil
+}
+
 // Helper function: get Monday of the week
 func getWeekStart(t time.Time) time.Time {
 	weekday := t.Weekday()
@@ -0,0 +1,403 @@
+// Package calendar knows when exchanges trade: their sessions, time zones and holidays.
+package calendar
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Exchange time zones must resolve on hosts without a zoneinfo database
+
+	"gopkg.in/yaml.v3"
+	"trackmymoney/internal/services/symbol"
+)
+
+// Market identifies a group of exchanges sharing sessions and holidays
+type Market string
+
+const (
+	MarketUS     Market = "US"     // NYSE, Nasdaq
+	MarketCN     Market = "CN"     // Shanghai, Shenzhen, Beijing
+	MarketHK     Market = "HK"     // Hong Kong
+	MarketUK     Market = "UK"     // London
+	MarketJP     Market = "JP"     // Tokyo
+	MarketCA     Market = "CA"     // Toronto
+	MarketCrypto Market = "CRYPTO" // Trades around the clock
+)
+
+// SettleDelay is how long after a session ends its prices are treated as final
+const SettleDelay = 15 * time.Minute
+
+// lookahead bounds searches for the next or previous session, long enough for the longest holiday
+const lookahead = 30
+
+// Session is a trading session in the market's local time, e.g. 09:30-11:30
+type Session struct {
+	Open  string `yaml:"open" json:"open"`
+	Close string `yaml:"close" json:"close"`
+}
+
+// MarketStatus represents whether a market is trading and its surrounding sessions
+type MarketStatus struct {
+	Market    Market     `json:"market"`
+	Name      string     `json:"name"`
+	TimeZone  string     `json:"timezone"`
+	Sessions  []Session  `json:"sessions,omitempty"`
+	Open      bool       `json:"open"`
+	Holiday   string     `json:"holiday,omitempty"` // Name of today's holiday, if any
+	LastClose *time.Time `json:"last_close,omitempty"`
+	NextOpen  *time.Time `json:"next_open,omitempty"`
+	NextClose *time.Time `json:"next_close,omitempty"`
+}
+
+// Calendar holds the trading calendars of all markets
+type Calendar struct {
+	markets map[Market]*marketCalendar
+}
+
+type marketCalendar struct {
+	name       string
+	location   *time.Location
+	sessions   []Session
+	spans      [][2]int          // Sessions as minutes since local midnight
+	holidays   map[string]string // YYYY-MM-DD -> holiday name
+	alwaysOpen bool
+}
+
+// marketFile is the file representation of a market, see Load
+type marketFile struct {
+	Name     string            `yaml:"name"`
+	TimeZone string            `yaml:"timezone"`
+	Sessions []Session         `yaml:"sessions"`
+	Holidays map[string]string `yaml:"holidays"` // YYYY-MM-DD -> holiday name
+}
+
+// defaultMarkets are the built-in sessions, without holidays
+var defaultMarkets = map[Market]marketFile{
+	MarketUS:     {Name: "US equities", TimeZone: "America/New_York", Sessions: []Session{{"09:30", "16:00"}}},
+	MarketCN:     {Name: "China A-shares", TimeZone: "Asia/Shanghai", Sessions: []Session{{"09:30", "11:30"}, {"13:00", "15:00"}}},
+	MarketHK:     {Name: "Hong Kong", TimeZone: "Asia/Hong_Kong", Sessions: []Session{{"09:30", "12:00"}, {"13:00", "16:00"}}},
+	MarketUK:     {Name: "London", TimeZone: "Europe/London", Sessions: []Session{{"08:00", "16:30"}}},
+	MarketJP:     {Name: "Tokyo", TimeZone: "Asia/Tokyo", Sessions: []Session{{"09:00", "11:30"}, {"12:30", "15:30"}}},
+	MarketCA:     {Name: "Toronto", TimeZone: "America/Toronto", Sessions: []Session{{"09:30", "16:00"}}},
+	MarketCrypto: {Name: "Crypto", TimeZone: "UTC"},
+}
+
+// Default returns a calendar with the built-in sessions and no holidays
+func Default() *Calendar {
+	c, err := build(nil)
+	if err != nil {
+		panic(err) // The built-in markets are valid
+	}
+	return c
+}
+
+// Load reads a calendar file and applies it over the built-in sessions.
+// The file lists markets by code; each may override its name, time zone and
+// sessions and adds its holidays:
+//
+//	markets:
+//	  CN:
+//	    sessions: [{open: "09:30", close: "11:30"}, {open: "13:00", close: "15:00"}]
+//	    holidays:
+//	      "2026-10-01": 国庆节
+func Load(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar file: %w", err)
+	}
+
+	var file struct {
+		Markets map[Market]marketFile `yaml:"markets"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse calendar file: %w", err)
+	}
+
+	return build(file.Markets)
+}
+
+// build merges market overrides into the defaults
+func build(overrides map[Market]marketFile) (*Calendar, error) {
+	c := &Calendar{markets: make(map[Market]*marketCalendar, len(defaultMarkets))}
+
+	for market := range overrides {
+		if _, ok := defaultMarkets[market]; !ok {
+			return nil, fmt.Errorf("unknown market %q in calendar", market)
+		}
+	}
+
+	for market, def := range defaultMarkets {
+		override := overrides[market]
+		if override.Name != "" {
+			def.Name = override.Name
+		}
+		if override.TimeZone != "" {
+			def.TimeZone = override.TimeZone
+		}
+		if len(override.Sessions) > 0 {
+			def.Sessions = override.Sessions
+		}
+
+		mc, err := newMarketCalendar(def, override.Holidays)
+		if err != nil {
+			return nil, fmt.Errorf("market %s: %w", market, err)
+		}
+		mc.alwaysOpen = market == MarketCrypto
+		c.markets[market] = mc
+	}
+	return c, nil
+}
+
+func newMarketCalendar(def marketFile, holidays map[string]string) (*marketCalendar, error) {
+	location, err := time.LoadLocation(def.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", def.TimeZone, err)
+	}
+
+	mc := &marketCalendar{
+		name:     def.Name,
+		location: location,
+		sessions: def.Sessions,
+		holidays: make(map[string]string, len(holidays)),
+	}
+
+	for _, session := range def.Sessions {
+		open, err := parseClock(session.Open)
+		if err != nil {
+			return nil, err
+		}
+		closeAt, err := parseClock(session.Close)
+		if err != nil {
+			return nil, err
+		}
+		if closeAt <= open {
+			return nil, fmt.Errorf("session %s-%s closes before it opens", session.Open, session.Close)
+		}
+		mc.spans = append(mc.spans, [2]int{open, closeAt})
+	}
+	sort.Slice(mc.spans, func(i, j int) bool { return mc.spans[i][0] < mc.spans[j][0] })
+
+	for date, name := range holidays {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday date %q", date)
+		}
+		mc.holidays[day.Format("2006-01-02")] = name
+	}
+	return mc, nil
+}
+
+// parseClock parses HH:MM into minutes since midnight
+func parseClock(clock string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(clock), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid session time %q, expected HH:MM", clock)
+	}
+	hour, err1 := strconv.Atoi(parts[0])
+	minute, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || hour < 0 || hour > 24 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid session time %q, expected HH:MM", clock)
+	}
+	return hour*60 + minute, nil
+}
+
+// MarketOf returns the market a symbol trades on
+func MarketOf(s symbol.Symbol) Market {
+	switch s.Exchange {
+	case symbol.ExchangeSSE, symbol.ExchangeSZSE, symbol.ExchangeBSE:
+		return MarketCN
+	case symbol.ExchangeHKEX:
+		return MarketHK
+	case symbol.ExchangeLSE:
+		return MarketUK
+	case symbol.ExchangeTSE:
+		return MarketJP
+	case symbol.ExchangeTSX:
+		return MarketCA
+	case symbol.ExchangeCrypto:
+		return MarketCrypto
+	default:
+		return MarketUS
+	}
+}
+
+// IsTradingDay reports whether a market trades on the local calendar date of t
+func (c *Calendar) IsTradingDay(market Market, t time.Time) bool {
+	mc := c.market(market)
+	if mc.alwaysOpen {
+		return true
+	}
+	return mc.tradingDay(t.In(mc.location))
+}
+
+// IsOpen reports whether a market is in a trading session at t
+func (c *Calendar) IsOpen(market Market, t time.Time) bool {
+	mc := c.market(market)
+	if mc.alwaysOpen {
+		return true
+	}
+
+	for _, session := range mc.sessionsOn(t.In(mc.location)) {
+		if !t.Before(session[0]) && t.Before(session[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// LastSessionEnd returns when the most recent session ended at or before t,
+// including midday breaks. It is zero for markets that never close.
+func (c *Calendar) LastSessionEnd(market Market, t time.Time) time.Time {
+	mc := c.market(market)
+	if mc.alwaysOpen {
+		return time.Time{}
+	}
+
+	day := t.In(mc.location)
+	for i := 0; i < lookahead; i++ {
+		sessions := mc.sessionsOn(day.AddDate(0, 0, -i))
+		for j := len(sessions) - 1; j >= 0; j-- {
+			if !sessions[j][1].After(t) {
+				return sessions[j][1]
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// LastClose returns the most recent daily close at or before t.
+// It is zero for markets that never close.
+func (c *Calendar) LastClose(market Market, t time.Time) time.Time {
+	mc := c.market(market)
+	if mc.alwaysOpen {
+		return time.Time{}
+	}
+
+	day := t.In(mc.location)
+	for i := 0; i < lookahead; i++ {
+		sessions := mc.sessionsOn(day.AddDate(0, 0, -i))
+		if len(sessions) > 0 && !sessions[len(sessions)-1][1].After(t) {
+			return sessions[len(sessions)-1][1]
+		}
+	}
+	return time.Time{}
+}
+
+// NextOpen returns when the next session opens after t, zero if none is known
+func (c *Calendar) NextOpen(market Market, t time.Time) time.Time {
+	return c.next(market, t, 0)
+}
+
+// NextClose returns when the next session closes after t, zero if none is known
+func (c *Calendar) NextClose(market Market, t time.Time) time.Time {
+	return c.next(market, t, 1)
+}
+
+func (c *Calendar) next(market Market, t time.Time, edge int) time.Time {
+	mc := c.market(market)
+	if mc.alwaysOpen {
+		return time.Time{}
+	}
+
+	day := t.In(mc.location)
+	for i := 0; i < lookahead; i++ {
+		for _, session := range mc.sessionsOn(day.AddDate(0, 0, i)) {
+			if session[edge].After(t) {
+				return session[edge]
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Settled reports whether a price fetched at fetchedAt is still current at now:
+// the market is closed and the price was fetched after its last session had settled.
+func (c *Calendar) Settled(market Market, fetchedAt, now time.Time) bool {
+	if c.IsOpen(market, now) {
+		return false
+	}
+	end := c.LastSessionEnd(market, now)
+	return !end.IsZero() && !fetchedAt.Before(end.Add(SettleDelay))
+}
+
+// TradedSince reports whether a market may have moved since a price was written at since:
+// it is open now or a daily close happened after since.
+func (c *Calendar) TradedSince(market Market, since, now time.Time) bool {
+	return c.IsOpen(market, now) || c.LastClose(market, now).After(since)
+}
+
+// Status returns the trading status of a market at t
+func (c *Calendar) Status(market Market, t time.Time) MarketStatus {
+	mc := c.market(market)
+	return MarketStatus{
+		Market:    market,
+		Name:      mc.name,
+		TimeZone:  mc.location.String(),
+		Sessions:  mc.sessions,
+		Open:      c.IsOpen(market, t),
+		Holiday:   mc.holidays[t.In(mc.location).Format("2006-01-02")],
+		LastClose: timePtr(c.LastClose(market, t)),
+		NextOpen:  timePtr(c.NextOpen(market, t)),
+		NextClose: timePtr(c.NextClose(market, t)),
+	}
+}
+
+// Statuses returns the trading status of every market at t
+func (c *Calendar) Statuses(t time.Time) []MarketStatus {
+	markets := make([]Market, 0, len(c.markets))
+	for market := range c.markets {
+		markets = append(markets, market)
+	}
+	sort.Slice(markets, func(i, j int) bool { return markets[i] < markets[j] })
+
+	statuses := make([]MarketStatus, len(markets))
+	for i, market := range markets {
+		statuses[i] = c.Status(market, t)
+	}
+	return statuses
+}
+
+// market returns the calendar of a market, falling back to US equities for unknown markets
+func (c *Calendar) market(market Market) *marketCalendar {
+	if mc, ok := c.markets[market]; ok {
+		return mc
+	}
+	return c.markets[MarketUS]
+}
+
+// tradingDay reports whether the local date of day is a weekday and not a holiday
+func (mc *marketCalendar) tradingDay(day time.Time) bool {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	_, holiday := mc.holidays[day.Format("2006-01-02")]
+	return !holiday
+}
+
+// sessionsOn returns the open and close times of the sessions on the local date of day
+func (mc *marketCalendar) sessionsOn(day time.Time) [][2]time.Time {
+	if !mc.tradingDay(day) {
+		return nil
+	}
+
+	at := func(minutes int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, mc.location)
+	}
+
+	sessions := make([][2]time.Time, len(mc.spans))
+	for i, span := range mc.spans {
+		sessions[i] = [2]time.Time{at(span[0]), at(span[1])}
+	}
+	return sessions
+}
+
+// timePtr returns nil for the zero time
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
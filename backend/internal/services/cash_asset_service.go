@@ -1,6 +1,7 @@
 package services
 
 import (
+	"trackmymoney/internal/database"
 	"trackmymoney/internal/models"
 	"trackmymoney/internal/repository"
 )
@@ -66,12 +67,15 @@ func (s *CashAssetService) Update(id uint, updates map[string]interface{}) (*mod
 	return asset, nil
 }
 
-// Delete deletes a cash asset
+// Delete deletes a cash asset, unless it secures a debt
 func (s *CashAssetService) Delete(id uint) error {
 	// Check if asset exists
 	if _, err := s.repo.GetCashAssetByID(id); err != nil {
 		return err
 	}
+	if err := CheckNotCollateral(database.GetDB(), models.AssetTypeCash, id); err != nil {
+		return err
+	}
 
 	return s.repo.DeleteCashAsset(id)
 }
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+)
+
+// defaultLTVThreshold is the loan-to-value percentage that triggers a warning when not configured
+const defaultLTVThreshold = 80.0
+
+var (
+	// ErrInvalidCollateral is returned when a debt references collateral that is unsupported or missing
+	ErrInvalidCollateral = errors.New("invalid collateral")
+	// ErrAssetSecuresDebt is returned when deleting an asset that a debt is secured by
+	ErrAssetSecuresDebt = errors.New("asset secures a debt; change the debt's collateral before deleting it")
+)
+
+// collateralModels create an empty model of each asset type that can secure a debt
+var collateralModels = map[models.AssetType]func() interface{}{
+	models.AssetTypeCash:            func() interface{} { return &models.CashAsset{} },
+	models.AssetTypeInterestBearing: func() interface{} { return &models.InterestBearingAsset{} },
+	models.AssetTypeStock:           func() interface{} { return &models.StockAsset{} },
+	models.AssetTypeCrypto:          func() interface{} { return &models.CryptoAsset{} },
+	models.AssetTypeOther:           func() interface{} { return &models.OtherAsset{} },
+}
+
+// CollateralService values secured debts against the assets that collateralize them
+// and records warnings when their loan-to-value ratio crosses the threshold
+type CollateralService struct {
+	db           *gorm.DB
+	fx           *FXService
+	ltvThreshold float64
+}
+
+// CollateralDebt is a debt secured by a collateral position
+type CollateralDebt struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name"`
+	Creditor    string  `json:"creditor"`
+	Currency    string  `json:"currency"`
+	Balance     float64 `json:"balance"`      // Outstanding today in the debt's currency
+	BalanceBase float64 `json:"balance_base"` // Outstanding today in the base currency
+}
+
+// CollateralPosition is the equity in an asset or broker account after the debts it secures
+type CollateralPosition struct {
+	Key        string           `json:"key"` // Identifies the collateral, e.g. "other:3" or "account:Futu"
+	Type       models.AssetType `json:"collateral_type"`
+	ID         *uint            `json:"collateral_id,omitempty"`
+	Account    string           `json:"collateral_account,omitempty"`
+	Name       string           `json:"name"`
+	Missing    bool             `json:"missing,omitempty"` // The collateral no longer exists
+	Value      float64          `json:"value"`
+	Debt       float64          `json:"debt"`
+	Equity     float64          `json:"equity"`               // Value less the linked debt
+	LTV        *float64         `json:"ltv,omitempty"`        // Debt as a percentage of value; empty when the collateral has no value
+	Underwater bool             `json:"underwater,omitempty"` // Debt is owed on collateral worth nothing, an unbounded LTV
+	Threshold  float64          `json:"ltv_threshold"`        // The lowest threshold of the linked debts
+	Warning    bool             `json:"warning"`              // The LTV is at or above the threshold
+	Debts      []CollateralDebt `json:"debts"`
+}
+
+// EquityReport lists the collateral positions in the base currency
+type EquityReport struct {
+	BaseCurrency string               `json:"base_currency"`
+	Value        float64              `json:"value"`
+	Debt         float64              `json:"debt"`
+	Equity       float64              `json:"equity"`
+	Positions    []CollateralPosition `json:"positions"`
+	MissingRates []string             `json:"missing_rates,omitempty"`
+}
+
+// NewCollateralService creates a new collateral service
+func NewCollateralService(db *gorm.DB, fx *FXService, ltvThreshold float64) *CollateralService {
+	if ltvThreshold <= 0 {
+		ltvThreshold = defaultLTVThreshold
+	}
+	return &CollateralService{db: db, fx: fx, ltvThreshold: ltvThreshold}
+}
+
+// Equity values every asset or broker account that secures a debt, with the linked debts,
+// the equity left and the loan-to-value ratio
+func (s *CollateralService) Equity(now time.Time) (*EquityReport, error) {
+	fx, err := s.fx.Converter(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var debts []models.DebtAsset
+	if err := s.db.Where("collateral_type <> ''").Order("id ASC").Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve secured debts: %w", err)
+	}
+	byDebt, err := prepaymentsByDebt(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EquityReport{BaseCurrency: fx.Base(), Positions: []CollateralPosition{}}
+	index := make(map[string]int)
+	custom := make(map[string]bool) // Positions with a debt that sets its own threshold
+	for i := range debts {
+		debt := &debts[i]
+		key := collateralKey(debt)
+		n, ok := index[key]
+		if !ok {
+			position := CollateralPosition{
+				Key:       key,
+				Type:      debt.CollateralType,
+				ID:        debt.CollateralID,
+				Account:   debt.CollateralAccount,
+				Threshold: s.ltvThreshold,
+			}
+			if err := s.valueCollateral(&position, now, fx); err != nil {
+				return nil, err
+			}
+			report.Positions = append(report.Positions, position)
+			n = len(report.Positions) - 1
+			index[key] = n
+		}
+
+		position := &report.Positions[n]
+		balance := math.Abs(DebtBalance(debt, byDebt[debt.ID], now))
+		balanceBase := roundCents(fx.Convert(balance, debt.Currency))
+		position.Debt += balanceBase
+		position.Debts = append(position.Debts, CollateralDebt{
+			ID:          debt.ID,
+			Name:        debt.Name,
+			Creditor:    debt.Creditor,
+			Currency:    debt.Currency,
+			Balance:     balance,
+			BalanceBase: balanceBase,
+		})
+		if debt.LTVThreshold != nil {
+			if !custom[key] || *debt.LTVThreshold < position.Threshold {
+				position.Threshold = *debt.LTVThreshold
+			}
+			custom[key] = true
+		}
+	}
+
+	for i := range report.Positions {
+		position := &report.Positions[i]
+		position.Debt = roundCents(position.Debt)
+		position.Equity = roundCents(position.Value - position.Debt)
+		switch {
+		case position.Value > 0:
+			ltv := math.Round(position.Debt/position.Value*10000) / 100
+			position.LTV = &ltv
+			position.Warning = ltv >= position.Threshold
+		case position.Debt > 0 && !position.Missing:
+			// Collateral worth nothing against an outstanding debt is past any threshold
+			position.Underwater = true
+			position.Warning = true
+		}
+		report.Value += position.Value
+		report.Debt += position.Debt
+	}
+	sort.SliceStable(report.Positions, func(i, j int) bool {
+		return report.Positions[i].Name < report.Positions[j].Name
+	})
+	report.Value = roundCents(report.Value)
+	report.Debt = roundCents(report.Debt)
+	report.Equity = roundCents(report.Value - report.Debt)
+	report.MissingRates = fx.Missing()
+	return report, nil
+}
+
+// Check records a warning for each position whose LTV crossed its threshold since the last check
+// and resolves the warnings of positions back below it, of missing collateral and of collateral
+// no debt is secured by anymore. It returns the new warnings.
+func (s *CollateralService) Check(now time.Time) ([]models.CollateralWarning, error) {
+	report, err := s.Equity(now)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []models.CollateralWarning{}
+	keys := make([]string, 0, len(report.Positions))
+	for _, position := range report.Positions {
+		keys = append(keys, position.Key)
+		if position.Missing {
+			position.Warning = false
+		}
+
+		var open models.CollateralWarning
+		err := s.db.Where("collateral_key = ? AND resolved_at IS NULL", position.Key).First(&open).Error
+		found := err == nil
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return warnings, fmt.Errorf("failed to look up collateral warnings: %w", err)
+		}
+
+		switch {
+		case position.Warning && !found:
+			warning := models.CollateralWarning{
+				CollateralKey:     position.Key,
+				CollateralType:    position.Type,
+				CollateralID:      position.ID,
+				CollateralAccount: position.Account,
+				Name:              position.Name,
+				Value:             position.Value,
+				Debt:              position.Debt,
+				Underwater:        position.Underwater,
+				Threshold:         position.Threshold,
+				Currency:          report.BaseCurrency,
+			}
+			if position.LTV != nil {
+				warning.LTV = *position.LTV
+			}
+			if err := s.db.Create(&warning).Error; err != nil {
+				return warnings, fmt.Errorf("failed to record collateral warning: %w", err)
+			}
+			warnings = append(warnings, warning)
+		case !position.Warning && found:
+			if err := s.db.Model(&open).Update("resolved_at", now).Error; err != nil {
+				return warnings, fmt.Errorf("failed to resolve collateral warning: %w", err)
+			}
+		}
+	}
+
+	stale := s.db.Model(&models.CollateralWarning{}).Where("resolved_at IS NULL")
+	if len(keys) > 0 {
+		stale = stale.Where("collateral_key NOT IN ?", keys)
+	}
+	if err := stale.Update("resolved_at", now).Error; err != nil {
+		return warnings, fmt.Errorf("failed to resolve collateral warnings: %w", err)
+	}
+	return warnings, nil
+}
+
+// Warnings returns the recorded warnings, newest first, optionally of a single collateral position
+func (s *CollateralService) Warnings(key string) ([]models.CollateralWarning, error) {
+	query := s.db.Order("created_at DESC, id DESC")
+	if key != "" {
+		query = query.Where("collateral_key = ?", key)
+	}
+	warnings := []models.CollateralWarning{}
+	if err := query.Find(&warnings).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve collateral warnings: %w", err)
+	}
+	return warnings, nil
+}
+
+// valueCollateral fills in the name and base currency value of a position's collateral,
+// flagging it as missing when the asset was deleted or the account holds no stocks
+func (s *CollateralService) valueCollateral(position *CollateralPosition, now time.Time, fx *Converter) error {
+	if position.Account != "" {
+		var stocks []models.StockAsset
+		if err := s.db.Where("broker_account = ?", position.Account).Find(&stocks).Error; err != nil {
+			return fmt.Errorf("failed to retrieve stock assets: %w", err)
+		}
+		position.Name = position.Account
+		position.Missing = len(stocks) == 0
+		var value float64
+		for _, stock := range stocks {
+			value += fx.Convert(stock.Quantity*storedPrice(stock.CurrentPrice, stock.PurchasePrice), stock.Currency)
+		}
+		position.Value = roundCents(value)
+		return nil
+	}
+
+	newModel, ok := collateralModels[position.Type]
+	if !ok || position.ID == nil {
+		position.Missing = true
+		return nil
+	}
+	model := newModel()
+	err := s.db.First(model, *position.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		position.Missing = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve collateral: %w", err)
+	}
+
+	var value float64
+	switch asset := model.(type) {
+	case *models.CashAsset:
+		position.Name = asset.Name
+		value = fx.Convert(asset.Amount, asset.Currency)
+	case *models.InterestBearingAsset:
+		position.Name = asset.Name
+		value = fx.Convert(AccrueInterest(asset, now).Value, asset.Currency)
+	case *models.StockAsset:
+		position.Name = asset.Name
+		value = fx.Convert(asset.Quantity*storedPrice(asset.CurrentPrice, asset.PurchasePrice), asset.Currency)
+	case *models.CryptoAsset:
+		position.Name = asset.Name
+		value = fx.Convert(asset.Quantity*storedPrice(asset.CurrentPrice, asset.PurchasePrice), asset.QuoteCurrency)
+	case *models.OtherAsset:
+		var appraisals []models.OtherAssetAppraisal
+		if err := s.db.Where("other_asset_id = ?", asset.ID).Find(&appraisals).Error; err != nil {
+			return fmt.Errorf("failed to retrieve appraisals: %w", err)
+		}
+		position.Name = asset.Name
+		value = fx.Convert(ValueOtherAsset(asset, appraisals, now).Value, asset.Currency)
+	}
+	position.Value = roundCents(value)
+	return nil
+}
+
+// NormalizeCollateral validates the collateral of a debt. A broker account secures the debt with
+// its stock holdings; otherwise the referenced asset must exist.
+func NormalizeCollateral(db *gorm.DB, debt *models.DebtAsset) error {
+	if debt.LTVThreshold != nil {
+		if *debt.LTVThreshold < 0 {
+			return fmt.Errorf("%w: ltv_threshold must not be negative", ErrInvalidCollateral)
+		}
+		if *debt.LTVThreshold == 0 {
+			debt.LTVThreshold = nil
+		}
+	}
+
+	if debt.CollateralAccount != "" {
+		if debt.CollateralType == "" {
+			debt.CollateralType = models.AssetTypeStock
+		}
+		if debt.CollateralType != models.AssetTypeStock || debt.CollateralID != nil {
+			return fmt.Errorf("%w: a broker account is secured by its stocks and takes no collateral_id", ErrInvalidCollateral)
+		}
+		var count int64
+		if err := db.Model(&models.StockAsset{}).Where("broker_account = ?", debt.CollateralAccount).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to look up broker account: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: broker account %q holds no stocks", ErrInvalidCollateral, debt.CollateralAccount)
+		}
+		return nil
+	}
+
+	if debt.CollateralType == "" {
+		if debt.CollateralID != nil {
+			return fmt.Errorf("%w: collateral_id requires collateral_type", ErrInvalidCollateral)
+		}
+		return nil
+	}
+	newModel, ok := collateralModels[debt.CollateralType]
+	if !ok {
+		return fmt.Errorf("%w: unsupported collateral type %q", ErrInvalidCollateral, debt.CollateralType)
+	}
+	if debt.CollateralID == nil {
+		return fmt.Errorf("%w: collateral_type requires collateral_id or collateral_account", ErrInvalidCollateral)
+	}
+	var count int64
+	if err := db.Model(newModel()).Where("id = ?", *debt.CollateralID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to look up collateral: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s asset %d not found", ErrInvalidCollateral, debt.CollateralType, *debt.CollateralID)
+	}
+	return nil
+}
+
+// CheckNotCollateral returns ErrAssetSecuresDebt when a debt is secured by the asset, or by the
+// broker account of a stock that is the last holding in it, so that deleting the asset would leave
+// the debt linked to nothing
+func CheckNotCollateral(db *gorm.DB, assetType models.AssetType, assetID uint) error {
+	var secured int64
+	if err := db.Model(&models.DebtAsset{}).Where("collateral_type = ? AND collateral_id = ?", assetType, assetID).
+		Count(&secured).Error; err != nil {
+		return fmt.Errorf("failed to look up secured debts: %w", err)
+	}
+	if secured > 0 {
+		return ErrAssetSecuresDebt
+	}
+	if assetType != models.AssetTypeStock {
+		return nil
+	}
+
+	var stock models.StockAsset
+	if err := db.First(&stock, assetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to retrieve stock asset: %w", err)
+	}
+	if stock.BrokerAccount == "" {
+		return nil
+	}
+	var others int64
+	if err := db.Model(&models.StockAsset{}).Where("broker_account = ? AND id <> ?", stock.BrokerAccount, assetID).
+		Count(&others).Error; err != nil {
+		return fmt.Errorf("failed to look up broker account: %w", err)
+	}
+	if others > 0 {
+		return nil
+	}
+	if err := db.Model(&models.DebtAsset{}).Where("collateral_account = ?", stock.BrokerAccount).
+		Count(&secured).Error; err != nil {
+		return fmt.Errorf("failed to look up secured debts: %w", err)
+	}
+	if secured > 0 {
+		return ErrAssetSecuresDebt
+	}
+	return nil
+}
+
+// collateralKey identifies the collateral of a secured debt
+func collateralKey(debt *models.DebtAsset) string {
+	if debt.CollateralAccount != "" {
+		return "account:" + debt.CollateralAccount
+	}
+	if debt.CollateralID == nil {
+		return string(debt.CollateralType)
+	}
+	return fmt.Sprintf("%s:%d", debt.CollateralType, *debt.CollateralID)
+}
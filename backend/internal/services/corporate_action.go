@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+	"trackmymoney/pkg/logger"
+)
+
+// CorporateActionService records splits and symbol changes and applies them to stock holdings.
+// A holding is adjusted by an action when its ledger has entries before the action's ex-date;
+// splits are recorded as ledger entries, so units bought later already reflect them.
+type CorporateActionService struct {
+	db            *gorm.DB
+	marketService *MarketService
+	transactions  *TransactionService
+}
+
+// CorporateActionSyncResult represents the outcome of fetching corporate actions from the provider
+type CorporateActionSyncResult struct {
+	Symbols int      `json:"symbols"` // Held symbols checked
+	Added   int      `json:"added"`   // New actions recorded
+	Applied int      `json:"applied"` // Holdings adjusted
+	Failed  []string `json:"failed"`  // Symbols whose actions could not be fetched
+}
+
+var (
+	// ErrInvalidCorporateAction is returned when an entered action is incomplete or inconsistent
+	ErrInvalidCorporateAction = errors.New("invalid corporate action")
+	// ErrDuplicateCorporateAction is returned when an action of the same type and ex-date is already recorded
+	ErrDuplicateCorporateAction = errors.New("corporate action already recorded")
+)
+
+// NewCorporateActionService creates a new corporate action service
+func NewCorporateActionService(db *gorm.DB, marketService *MarketService, transactions *TransactionService) *CorporateActionService {
+	return &CorporateActionService{
+		db:            db,
+		marketService: marketService,
+		transactions:  transactions,
+	}
+}
+
+// List returns recorded corporate actions, newest first, optionally filtered by symbol and type
+func (s *CorporateActionService) List(sym string, actionType models.CorporateActionType) ([]models.CorporateAction, error) {
+	query := s.db.Order("ex_date DESC, id DESC")
+	if sym != "" {
+		query = query.Where("symbol = ? OR new_symbol = ?", canonicalSymbol(sym), canonicalSymbol(sym))
+	}
+	if actionType != "" {
+		query = query.Where("type = ?", actionType)
+	}
+
+	var actions []models.CorporateAction
+	if err := query.Find(&actions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load corporate actions: %w", err)
+	}
+	return actions, nil
+}
+
+// Adjustments returns the audit records of applied actions, optionally filtered by action and holding
+func (s *CorporateActionService) Adjustments(actionID, stockAssetID uint) ([]models.CorporateActionAdjustment, error) {
+	query := s.db.Order("id DESC")
+	if actionID != 0 {
+		query = query.Where("action_id = ?", actionID)
+	}
+	if stockAssetID != 0 {
+		query = query.Where("stock_asset_id = ?", stockAssetID)
+	}
+
+	var adjustments []models.CorporateActionAdjustment
+	if err := query.Find(&adjustments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load corporate action adjustments: %w", err)
+	}
+	return adjustments, nil
+}
+
+// Create records a manually entered action and applies it if its ex-date has passed.
+// It returns the number of holdings adjusted.
+func (s *CorporateActionService) Create(action *models.CorporateAction) (int, error) {
+	action.Source = models.CorporateActionSourceManual
+	if err := normalizeCorporateAction(action); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidCorporateAction, err)
+	}
+
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(action)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to save corporate action: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrDuplicateCorporateAction
+	}
+
+	logger.Info(fmt.Sprintf("Recorded %s of %s on %s", action.Type, action.Symbol, action.ExDate.Format("2006-01-02")))
+	adjusted, err := s.ApplyPending()
+	if err != nil {
+		return adjusted, err
+	}
+
+	if err := s.db.First(action, action.ID).Error; err != nil {
+		return adjusted, fmt.Errorf("failed to reload corporate action: %w", err)
+	}
+	return adjusted, nil
+}
+
+// Sync fetches the corporate actions of all held stocks, records new ones and applies pending actions.
+// Only actions on or after the first ledger entry of a symbol are recorded, so a holding entered
+// with backdated trades picks up the splits since its first buy.
+func (s *CorporateActionService) Sync(ctx context.Context) (*CorporateActionSyncResult, error) {
+	var stocks []models.StockAsset
+	if err := s.db.Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load stock assets: %w", err)
+	}
+
+	oldest := make(map[string]time.Time) // canonical symbol -> first ledger entry
+	for i := range stocks {
+		since := stocks[i].CreatedAt
+		var dates []time.Time
+		if err := s.db.Model(&models.Transaction{}).
+			Where("asset_type = ? AND asset_id = ?", models.AssetTypeStock, stocks[i].ID).
+			Order("date ASC").Limit(1).Pluck("date", &dates).Error; err != nil {
+			return nil, fmt.Errorf("failed to load stock asset %d transactions: %w", stocks[i].ID, err)
+		}
+		if len(dates) > 0 && dates[0].Before(since) {
+			since = dates[0]
+		}
+
+		sym := StockSymbol(&stocks[i])
+		if first, ok := oldest[sym]; !ok || since.Before(first) {
+			oldest[sym] = since
+		}
+	}
+
+	result := &CorporateActionSyncResult{Symbols: len(oldest), Failed: []string{}}
+	for sym, since := range oldest {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		resp, err := s.marketService.GetActions(ctx, sym)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Failed to fetch corporate actions of %s: %v", sym, err))
+			result.Failed = append(result.Failed, sym)
+			continue
+		}
+
+		for _, event := range resp.Actions {
+			action, err := fromActionEvent(sym, event)
+			if err != nil {
+				logger.Warn(fmt.Sprintf("Skipping corporate action of %s: %v", sym, err))
+				continue
+			}
+			if action.ExDate.Before(truncateDate(since)) {
+				continue
+			}
+
+			created := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(action)
+			if created.Error != nil {
+				return nil, fmt.Errorf("failed to save corporate action: %w", created.Error)
+			}
+			result.Added += int(created.RowsAffected)
+		}
+	}
+
+	applied, err := s.ApplyPending()
+	if err != nil {
+		return nil, err
+	}
+	result.Applied = applied
+	return result, nil
+}
+
+// ApplyPending applies all unapplied actions whose ex-date has passed, oldest first.
+// It returns the number of holdings adjusted.
+func (s *CorporateActionService) ApplyPending() (int, error) {
+	var actions []models.CorporateAction
+	if err := s.db.Where("applied_at IS NULL AND ex_date <= ?", truncateDate(time.Now())).
+		Order("ex_date ASC, id ASC").Find(&actions).Error; err != nil {
+		return 0, fmt.Errorf("failed to load pending corporate actions: %w", err)
+	}
+
+	total := 0
+	for i := range actions {
+		adjusted, err := s.apply(&actions[i])
+		if err != nil {
+			return total, err
+		}
+		total += adjusted
+	}
+	return total, nil
+}
+
+// apply adjusts the holdings affected by an action and records an audit entry for each
+func (s *CorporateActionService) apply(action *models.CorporateAction) (int, error) {
+	adjusted := 0
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var stocks []models.StockAsset
+		if err := tx.Find(&stocks).Error; err != nil {
+			return fmt.Errorf("failed to load stock assets: %w", err)
+		}
+
+		for i := range stocks {
+			stock := &stocks[i]
+			if StockSymbol(stock) != action.Symbol {
+				continue
+			}
+			// Only units held before the ex-date are affected
+			var held int64
+			if err := tx.Model(&models.Transaction{}).
+				Where("asset_type = ? AND asset_id = ? AND date < ?", models.AssetTypeStock, stock.ID, action.ExDate).
+				Count(&held).Error; err != nil {
+				return fmt.Errorf("failed to check stock asset %d transactions: %w", stock.ID, err)
+			}
+			if held == 0 {
+				continue
+			}
+
+			var count int64
+			if err := tx.Model(&models.CorporateActionAdjustment{}).
+				Where("action_id = ? AND stock_asset_id = ?", action.ID, stock.ID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to check corporate action adjustments: %w", err)
+			}
+			if count > 0 {
+				continue
+			}
+
+			adjustment := models.CorporateActionAdjustment{
+				ActionID:         action.ID,
+				StockAssetID:     stock.ID,
+				OldSymbol:        action.Symbol,
+				NewSymbol:        action.Symbol,
+				OldQuantity:      stock.Quantity,
+				NewQuantity:      stock.Quantity,
+				OldPurchasePrice: stock.PurchasePrice,
+				NewPurchasePrice: stock.PurchasePrice,
+				OldCurrentPrice:  stock.CurrentPrice,
+				NewCurrentPrice:  stock.CurrentPrice,
+			}
+
+			switch action.Type {
+			case models.CorporateActionSplit:
+				actionID := action.ID
+				split := models.Transaction{
+					AssetType:         models.AssetTypeStock,
+					AssetID:           stock.ID,
+					Type:              models.TransactionSplit,
+					Date:              action.ExDate,
+					Ratio:             action.Ratio,
+					Currency:          stock.Currency,
+					Note:              fmt.Sprintf("%s split %v:1", action.Symbol, action.Ratio),
+					CorporateActionID: &actionID,
+				}
+				if err := tx.Create(&split).Error; err != nil {
+					return fmt.Errorf("failed to record split of stock asset %d: %w", stock.ID, err)
+				}
+				holding, err := s.transactions.rebuildHolding(tx, models.AssetTypeStock, stock.ID)
+				if err != nil {
+					return fmt.Errorf("failed to rebuild stock asset %d: %w", stock.ID, err)
+				}
+				adjustment.NewQuantity = holding.Quantity
+				adjustment.NewPurchasePrice = holding.PurchasePrice
+				// A price last refreshed before the ex-date, or never, is still pre-split
+				if priceUpdatedAt(stock).Before(action.ExDate) {
+					adjustment.NewCurrentPrice = stock.CurrentPrice / action.Ratio
+				}
+			case models.CorporateActionSymbolChange:
+				adjustment.NewSymbol = action.NewSymbol
+				newSymbol := symbol.Parse(action.NewSymbol)
+				stock.Symbol = newSymbol.Code
+				stock.Exchange = string(newSymbol.Exchange)
+			}
+
+			if err := tx.Model(stock).Updates(map[string]interface{}{
+				"symbol":         stock.Symbol,
+				"exchange":       stock.Exchange,
+				"quantity":       adjustment.NewQuantity,
+				"purchase_price": adjustment.NewPurchasePrice,
+				"current_price":  adjustment.NewCurrentPrice,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to adjust stock asset %d: %w", stock.ID, err)
+			}
+			if err := tx.Create(&adjustment).Error; err != nil {
+				return fmt.Errorf("failed to record corporate action adjustment: %w", err)
+			}
+			adjusted++
+		}
+
+		now := time.Now()
+		action.AppliedAt = &now
+		if err := tx.Model(action).Update("applied_at", now).Error; err != nil {
+			return fmt.Errorf("failed to mark corporate action applied: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info(fmt.Sprintf("Applied %s of %s on %s to %d holdings", action.Type, action.Symbol, action.ExDate.Format("2006-01-02"), adjusted))
+	return adjusted, nil
+}
+
+// fromActionEvent converts a provider event to a corporate action of a symbol
+func fromActionEvent(sym string, event models.CorporateActionEvent) (*models.CorporateAction, error) {
+	exDate, err := time.Parse("2006-01-02", event.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ex-date %q", event.Date)
+	}
+
+	action := &models.CorporateAction{
+		Symbol: sym,
+		Type:   models.CorporateActionType(event.Type),
+		ExDate: exDate,
+		Source: models.CorporateActionSourceProvider,
+	}
+	if event.Ratio != nil {
+		action.Ratio = *event.Ratio
+	}
+	if event.NewSymbol != nil {
+		action.NewSymbol = *event.NewSymbol
+	}
+
+	if err := normalizeCorporateAction(action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// normalizeCorporateAction validates an action and puts its symbols and ex-date in canonical form
+func normalizeCorporateAction(action *models.CorporateAction) error {
+	action.Symbol = canonicalSymbol(strings.TrimSpace(action.Symbol))
+	if action.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	if action.ExDate.IsZero() {
+		return errors.New("ex-date is required")
+	}
+	action.ExDate = truncateDate(action.ExDate)
+
+	switch action.Type {
+	case models.CorporateActionSplit:
+		if action.Ratio <= 0 || action.Ratio == 1 {
+			return fmt.Errorf("invalid split ratio %v", action.Ratio)
+		}
+		action.NewSymbol = ""
+	case models.CorporateActionSymbolChange:
+		if strings.TrimSpace(action.NewSymbol) == "" {
+			return errors.New("new symbol is required for a symbol change")
+		}
+		action.NewSymbol = canonicalSymbol(strings.TrimSpace(action.NewSymbol))
+		if action.NewSymbol == action.Symbol {
+			return errors.New("new symbol must differ from the old symbol")
+		}
+		action.Ratio = 0
+	default:
+		return fmt.Errorf("unsupported corporate action type: %q", action.Type)
+	}
+	return nil
+}
@@ -0,0 +1,449 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"trackmymoney/internal/models"
+)
+
+// HoldingProjection is a holding as derived from its ledger
+type HoldingProjection struct {
+	Method        models.CostBasisMethod `json:"method"`
+	Quantity      float64                `json:"quantity"`
+	Cost          float64                `json:"cost"`           // Cost basis of the units held, including fees
+	PurchasePrice float64                `json:"purchase_price"` // Average cost per unit
+	Lots          []Lot                  `json:"lots"`           // Open lots, oldest first
+	Realized      []RealizedGain         `json:"realized"`       // Gains of all sales, in ledger order
+}
+
+// Lot is the part of an acquisition that is still held
+type Lot struct {
+	ID       uint      `json:"id"` // Acquisition transaction that opened the lot; sales name it as lot_id
+	Date     time.Time `json:"date"`
+	Quantity float64   `json:"quantity"` // Units still held, adjusted for splits
+	Cost     float64   `json:"cost"`     // Cost basis of the units still held
+}
+
+// LotSale is the part of a disposal matched to one lot
+type LotSale struct {
+	LotID    uint      `json:"lot_id"`
+	Acquired time.Time `json:"acquired"`
+	Quantity float64   `json:"quantity"`
+	Cost     float64   `json:"cost"`
+}
+
+// RealizedGain is the gain of a single sale
+type RealizedGain struct {
+	TransactionID uint             `json:"transaction_id"`
+	AssetType     models.AssetType `json:"asset_type"`
+	AssetID       uint             `json:"asset_id"`
+	Date          time.Time        `json:"date"`
+	Quantity      float64          `json:"quantity"`
+	Proceeds      float64          `json:"proceeds"` // Sale value less fees
+	CostBasis     float64          `json:"cost_basis"`
+	Gain          float64          `json:"gain"`
+	Currency      string           `json:"currency"`
+	Lots          []LotSale        `json:"lots"`
+
+	// In the base currency: proceeds at the rate of the sale date, cost basis at the rate of each lot's acquisition date
+	ProceedsBase  float64 `json:"proceeds_base"`
+	CostBasisBase float64 `json:"cost_basis_base"`
+	GainBase      float64 `json:"gain_base"`
+}
+
+// OpenLot is an open lot valued at the holding's current price
+type OpenLot struct {
+	Lot
+	Value                 float64 `json:"value"`
+	UnrealizedGain        float64 `json:"unrealized_gain"`
+	UnrealizedGainPercent float64 `json:"unrealized_gain_percent"`
+}
+
+// HoldingLots is the open lots of a holding with their unrealized gains
+type HoldingLots struct {
+	AssetType      models.AssetType       `json:"asset_type"`
+	AssetID        uint                   `json:"asset_id"`
+	Name           string                 `json:"name"`
+	Symbol         string                 `json:"symbol"`
+	Currency       string                 `json:"currency"`
+	Method         models.CostBasisMethod `json:"method"`
+	Quantity       float64                `json:"quantity"`
+	Cost           float64                `json:"cost"`
+	Price          float64                `json:"price"` // Stored current price
+	Value          float64                `json:"value"`
+	UnrealizedGain float64                `json:"unrealized_gain"`
+	Lots           []OpenLot              `json:"lots"`
+}
+
+// RealizedTotal is the realized gain of a group of sales
+type RealizedTotal struct {
+	AssetType models.AssetType `json:"asset_type,omitempty"`
+	AssetID   uint             `json:"asset_id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Symbol    string           `json:"symbol,omitempty"`
+	Year      int              `json:"year,omitempty"`
+	Currency  string           `json:"currency"`
+	Sales     int              `json:"sales"`
+	Proceeds  float64          `json:"proceeds"`
+	CostBasis float64          `json:"cost_basis"`
+	Gain      float64          `json:"gain"`
+
+	ProceedsBase  float64 `json:"proceeds_base"`
+	CostBasisBase float64 `json:"cost_basis_base"`
+	GainBase      float64 `json:"gain_base"`
+}
+
+// RealizedReport is the realized gains of the sales in a date range
+type RealizedReport struct {
+	From         *time.Time      `json:"from,omitempty"`
+	To           *time.Time      `json:"to,omitempty"`
+	BaseCurrency string          `json:"base_currency"`
+	Total        RealizedTotal   `json:"total"` // All sales, in the base currency
+	Sales        []RealizedGain  `json:"sales"`
+	ByAsset      []RealizedTotal `json:"by_asset"`
+	ByYear       []RealizedTotal `json:"by_year"`                 // Per year and currency
+	MissingRates []string        `json:"missing_rates,omitempty"` // Currencies counted unconverted for lack of a rate
+}
+
+// ValidCostBasisMethod reports whether a cost basis method is known
+func ValidCostBasisMethod(method models.CostBasisMethod) bool {
+	switch method {
+	case models.CostBasisFIFO, models.CostBasisLIFO, models.CostBasisAverage, models.CostBasisSpecificLot:
+		return true
+	}
+	return false
+}
+
+// OpenLots returns the open lots of the stock and crypto holdings, optionally of a single type or holding
+func (s *TransactionService) OpenLots(assetType models.AssetType, assetID uint) ([]HoldingLots, error) {
+	holdings, err := loadHoldings(s.db, assetType, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]HoldingLots, 0, len(holdings))
+	for i := range holdings {
+		holding := &holdings[i]
+		projection, err := s.project(holding)
+		if err != nil {
+			return nil, err
+		}
+
+		lots := HoldingLots{
+			AssetType: holding.AssetType,
+			AssetID:   holding.AssetID,
+			Name:      holding.Name,
+			Symbol:    holding.Symbol,
+			Currency:  holding.Currency,
+			Method:    projection.Method,
+			Quantity:  projection.Quantity,
+			Cost:      projection.Cost,
+			Price:     holding.Price,
+			Lots:      make([]OpenLot, 0, len(projection.Lots)),
+		}
+		for _, lot := range projection.Lots {
+			open := OpenLot{Lot: lot, Value: lot.Quantity * holding.Price}
+			open.UnrealizedGain = open.Value - lot.Cost
+			if lot.Cost > 0 {
+				open.UnrealizedGainPercent = open.UnrealizedGain / lot.Cost * 100
+			}
+			lots.Value += open.Value
+			lots.UnrealizedGain += open.UnrealizedGain
+			lots.Lots = append(lots.Lots, open)
+		}
+		result = append(result, lots)
+	}
+	return result, nil
+}
+
+// RealizedGains returns the realized gains of the sales between from and to (inclusive, either
+// may be nil), optionally of a single type or holding, grouped by holding and by year
+func (s *TransactionService) RealizedGains(from, to *time.Time, assetType models.AssetType, assetID uint) (*RealizedReport, error) {
+	holdings, err := loadHoldings(s.db, assetType, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if from != nil {
+		date := truncateDate(*from)
+		from = &date
+	}
+	if to != nil {
+		date := truncateDate(*to)
+		to = &date
+	}
+
+	rates, err := s.fx.History()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RealizedReport{
+		From:         from,
+		To:           to,
+		BaseCurrency: rates.Base(),
+		Sales:        []RealizedGain{},
+		ByAsset:      []RealizedTotal{},
+		ByYear:       []RealizedTotal{},
+	}
+	type yearKey struct {
+		year     int
+		currency string
+	}
+	byYear := make(map[yearKey]*RealizedTotal)
+	missing := missingRates{}
+
+	for i := range holdings {
+		holding := &holdings[i]
+		projection, err := s.project(holding)
+		if err != nil {
+			return nil, err
+		}
+
+		total := RealizedTotal{
+			AssetType: holding.AssetType,
+			AssetID:   holding.AssetID,
+			Name:      holding.Name,
+			Symbol:    holding.Symbol,
+			Currency:  holding.Currency,
+		}
+		for _, sale := range projection.Realized {
+			if (from != nil && sale.Date.Before(*from)) || (to != nil && sale.Date.After(*to)) {
+				continue
+			}
+			convertSale(&sale, rates, missing)
+			report.Sales = append(report.Sales, sale)
+			total.add(sale)
+			report.Total.Sales++
+			report.Total.ProceedsBase += sale.ProceedsBase
+			report.Total.CostBasisBase += sale.CostBasisBase
+			report.Total.GainBase += sale.GainBase
+
+			key := yearKey{year: sale.Date.Year(), currency: sale.Currency}
+			if byYear[key] == nil {
+				byYear[key] = &RealizedTotal{Year: key.year, Currency: key.currency}
+			}
+			byYear[key].add(sale)
+		}
+		if total.Sales > 0 {
+			report.ByAsset = append(report.ByAsset, total)
+		}
+	}
+
+	for _, total := range byYear {
+		report.ByYear = append(report.ByYear, *total)
+	}
+	report.Total.Currency = report.BaseCurrency
+	report.Total.Proceeds = report.Total.ProceedsBase
+	report.Total.CostBasis = report.Total.CostBasisBase
+	report.Total.Gain = report.Total.GainBase
+	report.MissingRates = missing.list()
+	sort.SliceStable(report.Sales, func(i, j int) bool {
+		return report.Sales[i].Date.Before(report.Sales[j].Date)
+	})
+	sort.Slice(report.ByYear, func(i, j int) bool {
+		if report.ByYear[i].Year != report.ByYear[j].Year {
+			return report.ByYear[i].Year < report.ByYear[j].Year
+		}
+		return report.ByYear[i].Currency < report.ByYear[j].Currency
+	})
+	return report, nil
+}
+
+func (t *RealizedTotal) add(sale RealizedGain) {
+	t.Sales++
+	t.Proceeds += sale.Proceeds
+	t.CostBasis += sale.CostBasis
+	t.Gain += sale.Gain
+	t.ProceedsBase += sale.ProceedsBase
+	t.CostBasisBase += sale.CostBasisBase
+	t.GainBase += sale.GainBase
+}
+
+// convertSale converts a sale into the base currency, its proceeds at the rate of the sale
+// date and the cost of each lot at the rate of the date it was acquired. Currencies without a
+// rate are recorded in missing.
+func convertSale(sale *RealizedGain, rates *FXHistory, missing missingRates) {
+	fx := rates.At(sale.Date)
+	sale.ProceedsBase = fx.Convert(sale.Proceeds, sale.Currency)
+	missing.add(fx)
+	sale.CostBasisBase = 0
+	for _, lot := range sale.Lots {
+		fx := rates.At(lot.Acquired)
+		sale.CostBasisBase += fx.Convert(lot.Cost, sale.Currency)
+		missing.add(fx)
+	}
+	sale.GainBase = sale.ProceedsBase - sale.CostBasisBase
+}
+
+// project derives a holding from its ledger under its cost basis method
+func (s *TransactionService) project(holding *holdingRef) (*HoldingProjection, error) {
+	transactions, err := loadLedger(s.db, holding.AssetType, holding.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := ProjectHolding(transactions, s.methodOf(holding))
+	if err != nil {
+		return nil, fmt.Errorf("%s holding %d: %w", holding.AssetType, holding.AssetID, err)
+	}
+	return projection, nil
+}
+
+// ProjectHolding derives a holding from its transactions in ledger order, matching disposals
+// to lots by the given method. Fees of a sale reduce its proceeds; other fees, including units
+// paid as a fee, add to the cost of the units still held. It fails when a transaction removes
+// more units than are held at its date.
+func ProjectHolding(transactions []models.Transaction, method models.CostBasisMethod) (*HoldingProjection, error) {
+	p := &HoldingProjection{
+		Method:   method,
+		Lots:     []Lot{},
+		Realized: []RealizedGain{},
+	}
+
+	for _, t := range transactions {
+		switch t.Type {
+		case models.TransactionOpeningBalance, models.TransactionBuy, models.TransactionTransferIn, models.TransactionDividendReinvest:
+			p.Lots = append(p.Lots, Lot{ID: t.ID, Date: t.Date, Quantity: t.Quantity, Cost: t.Quantity*t.Price + t.Fees})
+		case models.TransactionSell, models.TransactionTransferOut, models.TransactionFee:
+			var sales []LotSale
+			if t.Quantity > 0 {
+				var err error
+				if sales, err = p.dispose(t); err != nil {
+					return nil, err
+				}
+			}
+			var cost float64
+			for _, sale := range sales {
+				cost += sale.Cost
+			}
+
+			switch t.Type {
+			case models.TransactionSell:
+				proceeds := t.Quantity*t.Price - t.Fees
+				p.Realized = append(p.Realized, RealizedGain{
+					TransactionID: t.ID,
+					AssetType:     t.AssetType,
+					AssetID:       t.AssetID,
+					Date:          t.Date,
+					Quantity:      t.Quantity,
+					Proceeds:      proceeds,
+					CostBasis:     cost,
+					Gain:          proceeds - cost,
+					Currency:      t.Currency,
+					Lots:          sales,
+				})
+			case models.TransactionFee:
+				p.addCost(cost + t.Fees)
+			}
+		case models.TransactionSplit:
+			for i := range p.Lots {
+				p.Lots[i].Quantity *= t.Ratio
+			}
+		}
+
+		if method == models.CostBasisAverage {
+			p.pool()
+		}
+	}
+
+	for _, lot := range p.Lots {
+		p.Quantity += lot.Quantity
+		p.Cost += lot.Cost
+	}
+	if p.Quantity > 0 {
+		p.PurchasePrice = p.Cost / p.Quantity
+	}
+	return p, nil
+}
+
+// dispose removes the units of a disposal from the open lots and returns the lots they came from
+func (p *HoldingProjection) dispose(t models.Transaction) ([]LotSale, error) {
+	var held float64
+	for _, lot := range p.Lots {
+		held += lot.Quantity
+	}
+	if t.Quantity > held+quantityEpsilon {
+		return nil, fmt.Errorf("%w: %s of %v on %s exceeds the %v held", ErrInsufficientQuantity, t.Type, t.Quantity, t.Date.Format("2006-01-02"), held)
+	}
+
+	order := make([]int, 0, len(p.Lots))
+	switch {
+	case p.Method == models.CostBasisSpecificLot && t.LotID != nil:
+		for i, lot := range p.Lots {
+			if lot.ID == *t.LotID {
+				order = append(order, i)
+			}
+		}
+		if len(order) == 0 {
+			return nil, fmt.Errorf("%w: lot %d is not open on %s", ErrInvalidTransaction, *t.LotID, t.Date.Format("2006-01-02"))
+		}
+	case p.Method == models.CostBasisLIFO:
+		for i := len(p.Lots) - 1; i >= 0; i-- {
+			order = append(order, i)
+		}
+	default:
+		for i := range p.Lots {
+			order = append(order, i)
+		}
+	}
+
+	remaining := t.Quantity
+	var sales []LotSale
+	for _, i := range order {
+		if remaining <= quantityEpsilon {
+			break
+		}
+		lot := &p.Lots[i]
+		quantity := remaining
+		if quantity > lot.Quantity {
+			quantity = lot.Quantity
+		}
+		cost := lot.Cost * quantity / lot.Quantity
+		lot.Quantity -= quantity
+		lot.Cost -= cost
+		remaining -= quantity
+		sales = append(sales, LotSale{LotID: lot.ID, Acquired: lot.Date, Quantity: quantity, Cost: cost})
+	}
+	if remaining > quantityEpsilon {
+		return nil, fmt.Errorf("%w: %s of %v on %s exceeds the %v left in lot %d", ErrInsufficientQuantity, t.Type, t.Quantity, t.Date.Format("2006-01-02"), t.Quantity-remaining, *t.LotID)
+	}
+
+	open := p.Lots[:0]
+	for _, lot := range p.Lots {
+		if lot.Quantity >= quantityEpsilon {
+			open = append(open, lot)
+		}
+	}
+	p.Lots = open
+	return sales, nil
+}
+
+// addCost spreads a cost over the open lots by quantity
+func (p *HoldingProjection) addCost(cost float64) {
+	var held float64
+	for _, lot := range p.Lots {
+		held += lot.Quantity
+	}
+	if held <= 0 || cost == 0 {
+		return
+	}
+	for i := range p.Lots {
+		p.Lots[i].Cost += cost * p.Lots[i].Quantity / held
+	}
+}
+
+// pool prices all open lots at their average cost
+func (p *HoldingProjection) pool() {
+	var held, cost float64
+	for _, lot := range p.Lots {
+		held += lot.Quantity
+		cost += lot.Cost
+	}
+	if held <= 0 {
+		return
+	}
+	for i := range p.Lots {
+		p.Lots[i].Cost = cost * p.Lots[i].Quantity / held
+	}
+}
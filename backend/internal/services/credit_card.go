@@ -0,0 +1,483 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+	"trackmymoney/pkg/logger"
+)
+
+// defaultCreditCardReminderDays is how many days before the due date payments are reminded when not configured
+const defaultCreditCardReminderDays = 3
+
+// defaultMinPaymentPercent is the minimum payment rule of a card that sets neither a percentage nor a floor
+const defaultMinPaymentPercent = 10
+
+var (
+	// ErrInvalidCreditCard is returned when a credit card's terms are incomplete or inconsistent
+	ErrInvalidCreditCard = errors.New("invalid credit card")
+	// ErrInvalidStatement is returned when a statement or payment is incomplete or inconsistent
+	ErrInvalidStatement = errors.New("invalid credit card statement")
+)
+
+// CreditCardService maintains credit card billing cycles and reminds about due payments
+type CreditCardService struct {
+	db           *gorm.DB
+	reminderDays int
+}
+
+// CreditCardTotals sums the balances and limits of all credit cards in the base currency
+type CreditCardTotals struct {
+	Balance          float64    `json:"balance"`
+	CreditLimit      float64    `json:"credit_limit"`
+	AvailableCredit  float64    `json:"available_credit"`
+	Utilization      float64    `json:"utilization"`       // Balance as a percentage of the credit limit
+	RemainingBalance float64    `json:"remaining_balance"` // Billed and not yet paid
+	MinimumDue       float64    `json:"minimum_due"`
+	NextDueDate      *time.Time `json:"next_due_date,omitempty"` // Earliest due date with an unpaid statement
+}
+
+// NewCreditCardService creates a new credit card service
+func NewCreditCardService(db *gorm.DB, reminderDays int) *CreditCardService {
+	if reminderDays <= 0 {
+		reminderDays = defaultCreditCardReminderDays
+	}
+	return &CreditCardService{
+		db:           db,
+		reminderDays: reminderDays,
+	}
+}
+
+// SaveCard validates and stores a credit card, then brings its cycle state up to date
+func (s *CreditCardService) SaveCard(card *models.CreditCard, now time.Time) error {
+	if err := NormalizeCreditCard(card); err != nil {
+		return err
+	}
+	if err := s.db.Save(card).Error; err != nil {
+		return fmt.Errorf("failed to save credit card: %w", err)
+	}
+	return s.Refresh(card, now)
+}
+
+// DeleteCard removes a credit card with its statements and reminders
+func (s *CreditCardService) DeleteCard(card *models.CreditCard) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("credit_card_id = ?", card.ID).Delete(&models.CreditCardReminder{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("credit_card_id = ?", card.ID).Delete(&models.CreditCardStatement{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(card).Error
+	})
+}
+
+// Statements returns the statements of a credit card, newest first
+func (s *CreditCardService) Statements(cardID uint) ([]models.CreditCardStatement, error) {
+	statements := []models.CreditCardStatement{}
+	if err := s.db.Where("credit_card_id = ?", cardID).Order("statement_date DESC").Find(&statements).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve statements: %w", err)
+	}
+	return statements, nil
+}
+
+// AddStatement records a statement of a credit card. A missing statement date is the latest cycle
+// close, and a missing due date and minimum payment follow from the card's terms.
+func (s *CreditCardService) AddStatement(card *models.CreditCard, statement *models.CreditCardStatement, now time.Time) error {
+	if statement.StatementDate.IsZero() {
+		statement.StatementDate = lastStatementDate(card, truncateDate(now))
+	}
+	statement.CreditCardID = card.ID
+	if err := s.normalizeStatement(card, statement, now); err != nil {
+		return err
+	}
+	if err := s.db.Create(statement).Error; err != nil {
+		return fmt.Errorf("failed to create statement: %w", err)
+	}
+	return s.Refresh(card, now)
+}
+
+// UpdateStatement stores a corrected statement. Changing its paid amount does not change the card balance.
+func (s *CreditCardService) UpdateStatement(card *models.CreditCard, statement *models.CreditCardStatement, now time.Time) error {
+	if err := s.normalizeStatement(card, statement, now); err != nil {
+		return err
+	}
+	if err := s.db.Save(statement).Error; err != nil {
+		return fmt.Errorf("failed to update statement: %w", err)
+	}
+	return s.Refresh(card, now)
+}
+
+// DeleteStatement removes a statement of a credit card and its reminders
+func (s *CreditCardService) DeleteStatement(card *models.CreditCard, statementID uint, now time.Time) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("credit_card_id = ?", card.ID).Delete(&models.CreditCardStatement{}, statementID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("statement_id = ?", statementID).Delete(&models.CreditCardReminder{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	return s.Refresh(card, now)
+}
+
+// Pay records a payment to a credit card. It lowers the card balance and is applied to the unpaid
+// statements oldest first, each up to its remaining balance; any excess only lowers the unbilled balance.
+func (s *CreditCardService) Pay(card *models.CreditCard, amount float64, now time.Time) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: payment amount must be positive", ErrInvalidStatement)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var statements []models.CreditCardStatement
+		err := tx.Where("credit_card_id = ? AND paid_amount < balance", card.ID).
+			Order("statement_date ASC").Find(&statements).Error
+		if err != nil {
+			return fmt.Errorf("failed to retrieve unpaid statements: %w", err)
+		}
+
+		left := amount
+		for i := range statements {
+			if left <= 0 {
+				break
+			}
+			statement := &statements[i]
+			applied := math.Min(left, statement.Balance-statement.PaidAmount)
+			statement.PaidAmount = roundCents(statement.PaidAmount + applied)
+			if err := tx.Model(statement).Update("paid_amount", statement.PaidAmount).Error; err != nil {
+				return fmt.Errorf("failed to apply payment to statement: %w", err)
+			}
+			left -= applied
+		}
+
+		card.Balance = roundCents(card.Balance - amount)
+		return tx.Model(card).Update("balance", card.Balance).Error
+	})
+	if err != nil {
+		return err
+	}
+	return s.Refresh(card, now)
+}
+
+// Refresh updates the statuses of a card's statements and its cycle state: the next statement and
+// due dates, and what remains to be paid on all of its statements
+func (s *CreditCardService) Refresh(card *models.CreditCard, now time.Time) error {
+	today := truncateDate(now)
+
+	statements, err := s.Statements(card.ID)
+	if err != nil {
+		return err
+	}
+	for i := range statements {
+		status := statementStatus(&statements[i], today)
+		if status == statements[i].Status {
+			continue
+		}
+		statements[i].Status = status
+		if err := s.db.Model(&statements[i]).Update("status", status).Error; err != nil {
+			return fmt.Errorf("failed to update statement status: %w", err)
+		}
+	}
+
+	nextStatement := nextStatementDate(card, today)
+	card.NextStatementDate = &nextStatement
+	card.StatementBalance, card.RemainingBalance, card.MinimumDue = 0, 0, 0
+	if len(statements) > 0 {
+		card.StatementBalance = statements[0].Balance
+	}
+	var nextDue *time.Time
+	for i := range statements {
+		statement := &statements[i]
+		remaining := statement.Balance - statement.PaidAmount
+		if remaining <= balanceEpsilon {
+			continue
+		}
+		card.RemainingBalance += remaining
+		card.MinimumDue += math.Max(statement.MinimumPayment-statement.PaidAmount, 0)
+		if due := truncateDate(statement.DueDate); nextDue == nil || due.Before(*nextDue) {
+			nextDue = &due
+		}
+	}
+	card.RemainingBalance = roundCents(card.RemainingBalance)
+	card.MinimumDue = roundCents(card.MinimumDue)
+	if nextDue == nil {
+		due := dueDate(card, nextStatement)
+		nextDue = &due
+	}
+	card.NextDueDate = nextDue
+
+	err = s.db.Model(card).
+		Select("next_statement_date", "next_due_date", "statement_balance", "remaining_balance", "minimum_due").
+		Updates(card).Error
+	if err != nil {
+		return fmt.Errorf("failed to update credit card cycle: %w", err)
+	}
+	return nil
+}
+
+// Cycle refreshes every credit card and records the reminders that became due
+func (s *CreditCardService) Cycle(now time.Time) ([]models.CreditCardReminder, error) {
+	var cards []models.CreditCard
+	if err := s.db.Find(&cards).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve credit cards: %w", err)
+	}
+
+	reminders := []models.CreditCardReminder{}
+	for i := range cards {
+		if err := s.Refresh(&cards[i], now); err != nil {
+			logger.Error("Failed to refresh credit card",
+				zap.Uint("credit_card_id", cards[i].ID),
+				zap.Error(err))
+			continue
+		}
+		sent, err := s.remind(&cards[i], now)
+		if err != nil {
+			return reminders, err
+		}
+		reminders = append(reminders, sent...)
+	}
+	return reminders, nil
+}
+
+// Reminders returns the recorded reminders, newest first, optionally of a single card
+func (s *CreditCardService) Reminders(cardID uint) ([]models.CreditCardReminder, error) {
+	query := s.db.Order("due_date DESC, id DESC")
+	if cardID != 0 {
+		query = query.Where("credit_card_id = ?", cardID)
+	}
+	reminders := []models.CreditCardReminder{}
+	if err := query.Find(&reminders).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve credit card reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+// remind records a due-soon or overdue reminder for each unpaid statement of a refreshed card,
+// once per statement and kind. It returns the reminders recorded.
+func (s *CreditCardService) remind(card *models.CreditCard, now time.Time) ([]models.CreditCardReminder, error) {
+	if card.RemainingBalance <= balanceEpsilon {
+		return nil, nil
+	}
+
+	var statements []models.CreditCardStatement
+	if err := s.db.Where("credit_card_id = ? AND paid_amount < balance", card.ID).
+		Order("statement_date ASC").Find(&statements).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve unpaid statements: %w", err)
+	}
+
+	today := truncateDate(now)
+	var reminders []models.CreditCardReminder
+	for _, statement := range statements {
+		remaining := statement.Balance - statement.PaidAmount
+		if remaining <= balanceEpsilon {
+			continue
+		}
+
+		due := truncateDate(statement.DueDate)
+		var kind models.ReminderKind
+		switch {
+		case statement.Status == models.StatementOverdue:
+			kind = models.ReminderOverdue
+		case !due.Before(today) && !due.After(today.AddDate(0, 0, s.reminderDays)):
+			kind = models.ReminderDueSoon
+		default:
+			continue
+		}
+
+		var count int64
+		err := s.db.Model(&models.CreditCardReminder{}).
+			Where("statement_id = ? AND kind = ?", statement.ID, kind).
+			Count(&count).Error
+		if err != nil {
+			return reminders, fmt.Errorf("failed to look up credit card reminders: %w", err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		reminder := models.CreditCardReminder{
+			CreditCardID: card.ID,
+			StatementID:  statement.ID,
+			Kind:         kind,
+			Name:         card.Name,
+			DueDate:      due,
+			Remaining:    roundCents(remaining),
+			MinimumDue:   roundCents(math.Max(statement.MinimumPayment-statement.PaidAmount, 0)),
+			Currency:     card.Currency,
+		}
+		if err := s.db.Create(&reminder).Error; err != nil {
+			return reminders, fmt.Errorf("failed to record credit card reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, nil
+}
+
+// normalizeStatement validates a statement against its card and fills in the due date,
+// minimum payment and status
+func (s *CreditCardService) normalizeStatement(card *models.CreditCard, statement *models.CreditCardStatement, now time.Time) error {
+	statement.StatementDate = truncateDate(statement.StatementDate)
+	if statement.StatementDate.After(truncateDate(now)) {
+		return fmt.Errorf("%w: statement date is in the future", ErrInvalidStatement)
+	}
+	if statement.Balance < 0 {
+		return fmt.Errorf("%w: balance must not be negative", ErrInvalidStatement)
+	}
+	if statement.PaidAmount < 0 {
+		return fmt.Errorf("%w: paid amount must not be negative", ErrInvalidStatement)
+	}
+
+	if statement.DueDate.IsZero() {
+		statement.DueDate = dueDate(card, statement.StatementDate)
+	}
+	statement.DueDate = truncateDate(statement.DueDate)
+	if !statement.DueDate.After(statement.StatementDate) {
+		return fmt.Errorf("%w: due date must be after the statement date", ErrInvalidStatement)
+	}
+
+	if statement.MinimumPayment == 0 {
+		statement.MinimumPayment = MinimumPayment(card, statement.Balance)
+	}
+	if statement.MinimumPayment < 0 || statement.MinimumPayment > statement.Balance {
+		return fmt.Errorf("%w: minimum payment must be between 0 and the balance", ErrInvalidStatement)
+	}
+
+	var count int64
+	err := s.db.Model(&models.CreditCardStatement{}).
+		Where("credit_card_id = ? AND statement_date = ? AND id <> ?", card.ID, statement.StatementDate, statement.ID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to look up statements: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: a statement dated %s already exists", ErrInvalidStatement, statement.StatementDate.Format("2006-01-02"))
+	}
+
+	statement.Status = statementStatus(statement, truncateDate(now))
+	return nil
+}
+
+// SumCreditCards sums the balances, limits and amounts due of all credit cards in the converter's base currency
+func SumCreditCards(db *gorm.DB, fx *Converter) (CreditCardTotals, error) {
+	var totals CreditCardTotals
+
+	var cards []models.CreditCard
+	if err := db.Find(&cards).Error; err != nil {
+		return totals, fmt.Errorf("failed to retrieve credit cards: %w", err)
+	}
+	for i := range cards {
+		card := &cards[i]
+		totals.Balance += fx.Debt(card.Balance, card.Currency)
+		totals.CreditLimit += fx.Convert(card.CreditLimit, card.Currency)
+		totals.RemainingBalance += fx.Convert(card.RemainingBalance, card.Currency)
+		totals.MinimumDue += fx.Convert(card.MinimumDue, card.Currency)
+		if card.RemainingBalance > balanceEpsilon && card.NextDueDate != nil &&
+			(totals.NextDueDate == nil || card.NextDueDate.Before(*totals.NextDueDate)) {
+			totals.NextDueDate = card.NextDueDate
+		}
+	}
+	totals.AvailableCredit = totals.CreditLimit - totals.Balance
+	if totals.CreditLimit > 0 {
+		totals.Utilization = totals.Balance / totals.CreditLimit * 100
+	}
+	return totals, nil
+}
+
+// CardUtilization is the balance of a card as a percentage of its credit limit
+func CardUtilization(card *models.CreditCard) float64 {
+	if card.CreditLimit <= 0 {
+		return 0
+	}
+	return card.Balance / card.CreditLimit * 100
+}
+
+// NormalizeCreditCard validates the terms of a credit card and fills in defaults
+func NormalizeCreditCard(card *models.CreditCard) error {
+	if card.CreditLimit < 0 {
+		return fmt.Errorf("%w: credit limit must not be negative", ErrInvalidCreditCard)
+	}
+	if card.StatementDay < 1 || card.StatementDay > 31 {
+		return fmt.Errorf("%w: statement day must be between 1 and 31", ErrInvalidCreditCard)
+	}
+	if card.DueDay < 1 || card.DueDay > 31 {
+		return fmt.Errorf("%w: due day must be between 1 and 31", ErrInvalidCreditCard)
+	}
+	if card.MinPaymentPercent < 0 || card.MinPaymentPercent > 100 {
+		return fmt.Errorf("%w: minimum payment percent must be between 0 and 100", ErrInvalidCreditCard)
+	}
+	if card.MinPaymentFloor < 0 {
+		return fmt.Errorf("%w: minimum payment floor must not be negative", ErrInvalidCreditCard)
+	}
+	if card.MinPaymentPercent == 0 && card.MinPaymentFloor == 0 {
+		card.MinPaymentPercent = defaultMinPaymentPercent
+	}
+	if card.Currency == "" {
+		card.Currency = "CNY"
+	}
+	return nil
+}
+
+// MinimumPayment is the minimum payment a card requires on a statement balance: the card's
+// percentage of the balance, at least its floor, and never more than the balance
+func MinimumPayment(card *models.CreditCard, balance float64) float64 {
+	if balance <= 0 {
+		return 0
+	}
+	minimum := balance * card.MinPaymentPercent / 100
+	if minimum < card.MinPaymentFloor {
+		minimum = card.MinPaymentFloor
+	}
+	return roundCents(math.Min(minimum, balance))
+}
+
+// statementStatus is the repayment state of a statement as of a day
+func statementStatus(statement *models.CreditCardStatement, today time.Time) models.StatementStatus {
+	switch {
+	case statement.PaidAmount >= statement.Balance-balanceEpsilon:
+		return models.StatementPaid
+	case statement.PaidAmount >= statement.MinimumPayment-balanceEpsilon:
+		return models.StatementMinimumPaid
+	case today.After(truncateDate(statement.DueDate)):
+		return models.StatementOverdue
+	default:
+		return models.StatementOpen
+	}
+}
+
+// nextStatementDate is the first statement date of a card on or after a day
+func nextStatementDate(card *models.CreditCard, today time.Time) time.Time {
+	date := dayOfMonth(today.Year(), today.Month(), card.StatementDay)
+	if date.Before(today) {
+		date = dayOfMonth(today.Year(), today.Month()+1, card.StatementDay)
+	}
+	return date
+}
+
+// lastStatementDate is the latest statement date of a card on or before a day
+func lastStatementDate(card *models.CreditCard, today time.Time) time.Time {
+	date := dayOfMonth(today.Year(), today.Month(), card.StatementDay)
+	if date.After(today) {
+		date = dayOfMonth(today.Year(), today.Month()-1, card.StatementDay)
+	}
+	return date
+}
+
+// dueDate is the payment due date of a statement: the card's due day in the statement month
+// when it comes later in the month, otherwise in the month after
+func dueDate(card *models.CreditCard, statementDate time.Time) time.Time {
+	due := dayOfMonth(statementDate.Year(), statementDate.Month(), card.DueDay)
+	if !due.After(statementDate) {
+		due = dayOfMonth(statementDate.Year(), statementDate.Month()+1, card.DueDay)
+	}
+	return due
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+)
+
+// ErrNoCurrencySnapshot is returned when no snapshot with a currency breakdown covers a period
+var ErrNoCurrencySnapshot = errors.New("no snapshot with a currency breakdown in the period")
+
+// CurrencyAttribution splits the change in net assets over a period into the change of the
+// amounts held in each currency and the movement of its exchange rate
+type CurrencyAttribution struct {
+	BaseCurrency string                    `json:"base_currency"`
+	From         time.Time                 `json:"from"` // Date of the starting snapshot
+	To           time.Time                 `json:"to"`
+	StartValue   float64                   `json:"start_value"` // Net assets in the base currency
+	EndValue     float64                   `json:"end_value"`
+	Change       float64                   `json:"change"`
+	LocalEffect  float64                   `json:"local_effect"` // Change of the amounts held, at the starting rates
+	FXEffect     float64                   `json:"fx_effect"`    // Change of the exchange rates, on the amounts held at the end
+	Currencies   []CurrencyAttributionItem `json:"currencies"`
+}
+
+// CurrencyAttributionItem is the change in net assets held in one currency
+type CurrencyAttributionItem struct {
+	Currency    string  `json:"currency"`
+	StartLocal  float64 `json:"start_local"` // Net assets in the currency itself
+	EndLocal    float64 `json:"end_local"`
+	StartRate   float64 `json:"start_rate"` // Base currency per unit
+	EndRate     float64 `json:"end_rate"`
+	RateMissing bool    `json:"rate_missing,omitempty"`
+	StartValue  float64 `json:"start_value"` // Net assets in the base currency
+	EndValue    float64 `json:"end_value"`
+	Change      float64 `json:"change"`
+	LocalEffect float64 `json:"local_effect"`
+	FXEffect    float64 `json:"fx_effect"`
+}
+
+// CurrencyAttribution compares the first snapshot of a period with the current holdings, each
+// valued at the rates of its own date. The local effect of a currency is the change of its
+// net amount at the starting rate and the FX effect is the change of its rate on the ending
+// amount, so that the two add up to the change in the base currency.
+func (s *AssetService) CurrencyAttribution(period string) (*CurrencyAttribution, error) {
+	now := time.Now()
+
+	var start models.AssetHistory
+	err := s.db.Where("date >= ? AND currency_breakdown <> ''", truncateDate(historyStart(period, now))).
+		Order("date ASC").First(&start).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoCurrencySnapshot
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve asset history: %w", err)
+	}
+
+	summary, err := s.CalculateAssetSummary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate current asset summary: %w", err)
+	}
+	rates, err := s.fx.History()
+	if err != nil {
+		return nil, err
+	}
+	startRates, endRates := rates.At(start.Date), rates.At(now)
+	startBreakdown := snapshotBreakdown(&start, startRates)
+	if startBreakdown == nil {
+		return nil, ErrNoCurrencySnapshot
+	}
+
+	items := make(map[string]*CurrencyAttributionItem)
+	item := func(currency string) *CurrencyAttributionItem {
+		if items[currency] == nil {
+			items[currency] = &CurrencyAttributionItem{Currency: currency}
+		}
+		return items[currency]
+	}
+	for _, breakdown := range startBreakdown {
+		item(breakdown.Currency).StartLocal = breakdown.Assets - breakdown.Debt
+	}
+	for _, breakdown := range summary.Currencies {
+		item(breakdown.Currency).EndLocal = breakdown.Assets - breakdown.Debt
+	}
+
+	attribution := &CurrencyAttribution{
+		BaseCurrency: summary.BaseCurrency,
+		From:         truncateDate(start.Date),
+		To:           truncateDate(now),
+		Currencies:   make([]CurrencyAttributionItem, 0, len(items)),
+	}
+	for currency, entry := range items {
+		startRate, startOK := startRates.Rate(currency)
+		endRate, endOK := endRates.Rate(currency)
+		if !startOK || !endOK {
+			// Without a rate amounts are counted unconverted
+			startRate, endRate = 1, 1
+			entry.RateMissing = true
+		}
+		entry.StartRate = startRate
+		entry.EndRate = endRate
+		entry.StartValue = entry.StartLocal * startRate
+		entry.EndValue = entry.EndLocal * endRate
+		entry.Change = entry.EndValue - entry.StartValue
+		entry.LocalEffect = (entry.EndLocal - entry.StartLocal) * startRate
+		entry.FXEffect = entry.EndLocal * (endRate - startRate)
+
+		attribution.StartValue += entry.StartValue
+		attribution.EndValue += entry.EndValue
+		attribution.LocalEffect += entry.LocalEffect
+		attribution.FXEffect += entry.FXEffect
+
+		for _, amount := range []*float64{&entry.StartValue, &entry.EndValue, &entry.Change, &entry.LocalEffect, &entry.FXEffect} {
+			*amount = roundCents(*amount)
+		}
+		attribution.Currencies = append(attribution.Currencies, *entry)
+	}
+	attribution.Change = roundCents(attribution.EndValue - attribution.StartValue)
+	attribution.StartValue = roundCents(attribution.StartValue)
+	attribution.EndValue = roundCents(attribution.EndValue)
+	attribution.LocalEffect = roundCents(attribution.LocalEffect)
+	attribution.FXEffect = roundCents(attribution.FXEffect)
+
+	sort.Slice(attribution.Currencies, func(i, j int) bool {
+		return attribution.Currencies[i].Currency < attribution.Currencies[j].Currency
+	})
+	return attribution, nil
+}
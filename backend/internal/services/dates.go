@@ -0,0 +1,17 @@
+package services
+
+import "time"
+
+// truncateDate returns the calendar date of t as midnight UTC, the form dates are stored in
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dayOfMonth is the given day of a month, or the month's last day when the month is shorter
+func dayOfMonth(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,745 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+	"trackmymoney/pkg/logger"
+)
+
+// defaultBaseCurrency is the currency summaries are reported in when none is configured
+const defaultBaseCurrency = "CNY"
+
+// ErrInvalidFXRate is returned when an exchange rate is incomplete or inconsistent
+var ErrInvalidFXRate = errors.New("invalid exchange rate")
+
+// currencyAliases map currencies that are valued as another one, such as dollar stablecoins
+var currencyAliases = map[string]string{
+	"USDT": "USD",
+	"USDC": "USD",
+}
+
+// FXService stores exchange rates and converts amounts into the base currency
+type FXService struct {
+	db     *gorm.DB
+	market *MarketService
+	base   string
+}
+
+// CurrencyBreakdown is the part of the assets and debts held in one currency
+type CurrencyBreakdown struct {
+	Currency    string  `json:"currency"`
+	Rate        float64 `json:"rate"` // Base currency per unit
+	RateMissing bool    `json:"rate_missing,omitempty"`
+	Assets      float64 `json:"assets"` // In the original currency
+	Debt        float64 `json:"debt"`
+	AssetsBase  float64 `json:"assets_base"` // In the base currency
+	DebtBase    float64 `json:"debt_base"`
+}
+
+// FXQuote is the exchange rate in effect for a currency
+type FXQuote struct {
+	Currency string              `json:"currency"`
+	Rate     float64             `json:"rate"` // Base currency per unit
+	Date     *time.Time          `json:"date,omitempty"`
+	Source   models.FXRateSource `json:"source,omitempty"`
+	Missing  bool                `json:"missing,omitempty"` // No rate stored; amounts are counted unconverted
+}
+
+// NewFXService creates a new FX service reporting in the given base currency
+func NewFXService(db *gorm.DB, market *MarketService, baseCurrency string) *FXService {
+	base := normalizeCurrency(baseCurrency)
+	if base == "" {
+		base = defaultBaseCurrency
+	}
+	return &FXService{
+		db:     db,
+		market: market,
+		base:   base,
+	}
+}
+
+// Base returns the base currency
+func (s *FXService) Base() string {
+	return s.base
+}
+
+// Converter returns a converter using the rates in effect on a date
+func (s *FXService) Converter(asOf time.Time) (*Converter, error) {
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+	return history.At(asOf), nil
+}
+
+// History returns every stored rate against the base currency, for converting amounts at
+// the rate of their own date
+func (s *FXService) History() (*FXHistory, error) {
+	var rates []models.FXRate
+	err := s.db.Where("base_currency = ? OR quote_currency = ?", s.base, s.base).
+		Order("date ASC, id ASC").
+		Find(&rates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve exchange rates: %w", err)
+	}
+
+	history := &FXHistory{base: s.base, rates: make(map[string][]fxRatePoint)}
+	for _, rate := range rates {
+		currency, value, ok := s.toBase(rate)
+		if !ok {
+			continue
+		}
+		history.rates[currency] = append(history.rates[currency], fxRatePoint{
+			date:   truncateDate(rate.Date),
+			rate:   value,
+			manual: rate.Source == models.FXSourceManual,
+		})
+	}
+	return history, nil
+}
+
+// Latest returns the rate in effect today for every currency held or given a manual rate
+func (s *FXService) Latest(now time.Time) ([]FXQuote, error) {
+	currencies, err := s.Currencies()
+	if err != nil {
+		return nil, err
+	}
+	converter, err := s.Converter(now)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, currency := range currencies {
+		seen[currency] = true
+	}
+	for currency := range converter.rates {
+		if !seen[currency] {
+			seen[currency] = true
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+
+	quotes := make([]FXQuote, 0, len(currencies))
+	for _, currency := range currencies {
+		quote := FXQuote{Currency: currency}
+		if rate, ok := converter.Rate(currency); ok {
+			quote.Rate = rate
+			if latest, err := s.latestRate(currency, now); err == nil {
+				quote.Date = &latest.Date
+				quote.Source = latest.Source
+			}
+		} else {
+			quote.Rate = 1
+			quote.Missing = true
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// Rates returns the stored rates, newest first, optionally of the pairs involving a currency
+func (s *FXService) Rates(currency string, limit int) ([]models.FXRate, error) {
+	query := s.db.Order("date DESC, id DESC")
+	if currency = normalizeCurrency(currency); currency != "" {
+		query = query.Where("base_currency = ? OR quote_currency = ?", currency, currency)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	rates := []models.FXRate{}
+	if err := query.Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// SetRate records a manual rate, replacing a manual rate of the same pair and date
+func (s *FXService) SetRate(rate *models.FXRate) error {
+	rate.BaseCurrency = normalizeCurrency(rate.BaseCurrency)
+	rate.QuoteCurrency = normalizeCurrency(rate.QuoteCurrency)
+	if rate.BaseCurrency == "" || rate.QuoteCurrency == "" {
+		return fmt.Errorf("%w: base and quote currencies are required", ErrInvalidFXRate)
+	}
+	if rate.BaseCurrency == rate.QuoteCurrency {
+		return fmt.Errorf("%w: base and quote currencies must differ", ErrInvalidFXRate)
+	}
+	if rate.Rate <= 0 {
+		return fmt.Errorf("%w: rate must be positive", ErrInvalidFXRate)
+	}
+	rate.Date = truncateDate(rate.Date)
+	rate.Source = models.FXSourceManual
+	return s.upsert(rate)
+}
+
+// DeleteRate removes a stored rate
+func (s *FXService) DeleteRate(id uint) error {
+	result := s.db.Delete(&models.FXRate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Sync fetches today's rate of every held currency against the base currency from the market
+// provider and stores it. It returns the currencies that could not be fetched.
+func (s *FXService) Sync(ctx context.Context, now time.Time) (int, []string, error) {
+	currencies, err := s.Currencies()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(currencies) == 0 {
+		return 0, []string{}, nil
+	}
+
+	fetched, err := s.fetch(ctx, currencies, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	// Pairs the provider does not list directly may be listed the other way around
+	var remaining []string
+	for _, currency := range currencies {
+		if _, ok := fetched[currency]; !ok {
+			remaining = append(remaining, currency)
+		}
+	}
+	if len(remaining) > 0 {
+		inverse, err := s.fetch(ctx, remaining, true)
+		if err != nil {
+			return 0, nil, err
+		}
+		for currency, rate := range inverse {
+			fetched[currency] = rate
+		}
+	}
+
+	date := truncateDate(now)
+	failed := []string{}
+	for _, currency := range currencies {
+		value, ok := fetched[currency]
+		if !ok {
+			failed = append(failed, currency)
+			continue
+		}
+		rate := models.FXRate{
+			BaseCurrency:  currency,
+			QuoteCurrency: s.base,
+			Date:          date,
+			Rate:          value,
+			Source:        models.FXSourceProvider,
+		}
+		if err := s.upsert(&rate); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	// Past snapshots, transactions and income are converted at the rate of their own date
+	if from, ok, err := s.EarliestDate(); err != nil {
+		return 0, nil, err
+	} else if ok {
+		if _, missing, err := s.Backfill(ctx, from, now); err != nil {
+			logger.Warn("Failed to backfill exchange rates", zap.Error(err))
+		} else if len(missing) > 0 {
+			logger.Debug("Historical exchange rates not available", zap.Strings("currencies", missing))
+		}
+	}
+	return len(currencies) - len(failed), failed, nil
+}
+
+// Backfill stores the provider's daily rates of every held currency since a date, skipping
+// currencies whose stored provider rates already reach back that far. It returns the number
+// of rates stored and the currencies whose history could not be fetched.
+func (s *FXService) Backfill(ctx context.Context, from, now time.Time) (int, []string, error) {
+	currencies, err := s.Currencies()
+	if err != nil {
+		return 0, nil, err
+	}
+	from = truncateDate(from)
+	period := gapPeriod(now.Sub(from))
+
+	stored := 0
+	failed := []string{}
+	for _, currency := range currencies {
+		var earliest models.FXRate
+		err := s.db.Where("base_currency = ? AND quote_currency = ? AND source = ?", currency, s.base, models.FXSourceProvider).
+			Order("date ASC").First(&earliest).Error
+		if err == nil && !earliest.Date.After(from.AddDate(0, 0, fxBackfillSlack)) {
+			continue
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return stored, nil, fmt.Errorf("failed to look up exchange rates: %w", err)
+		}
+
+		rates, err := s.fetchHistory(ctx, currency, period)
+		if err != nil || len(rates) == 0 {
+			failed = append(failed, currency)
+			continue
+		}
+		count, err := s.storeHistory(currency, rates)
+		if err != nil {
+			return stored, nil, err
+		}
+		stored += count
+	}
+	return stored, failed, nil
+}
+
+// EarliestDate returns the date of the oldest snapshot, transaction or income event, the
+// first date exchange rates are needed for
+func (s *FXService) EarliestDate() (time.Time, bool, error) {
+	sources := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.AssetHistory{}, "date"},
+		{&models.Transaction{}, "date"},
+		{&models.IncomeEvent{}, "pay_date"},
+	}
+
+	var earliest time.Time
+	for _, source := range sources {
+		var dates []time.Time
+		if err := s.db.Model(source.model).Order(source.column+" ASC").Limit(1).Pluck(source.column, &dates).Error; err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to find the earliest date: %w", err)
+		}
+		if len(dates) > 0 && (earliest.IsZero() || dates[0].Before(earliest)) {
+			earliest = dates[0]
+		}
+	}
+	return earliest, !earliest.IsZero(), nil
+}
+
+// Currencies returns the currencies of all assets, debts and income other than the base currency
+func (s *FXService) Currencies() ([]string, error) {
+	sources := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.CashAsset{}, "currency"},
+		{&models.InterestBearingAsset{}, "currency"},
+		{&models.StockAsset{}, "currency"},
+		{&models.CryptoAsset{}, "quote_currency"},
+		{&models.DebtAsset{}, "currency"},
+		{&models.CreditCard{}, "currency"},
+		{&models.OtherAsset{}, "currency"},
+		{&models.IncomeEvent{}, "currency"},
+	}
+
+	seen := make(map[string]bool)
+	currencies := []string{}
+	for _, source := range sources {
+		var values []string
+		if err := s.db.Model(source.model).Distinct().Pluck(source.column, &values).Error; err != nil {
+			return nil, fmt.Errorf("failed to collect currencies: %w", err)
+		}
+		for _, value := range values {
+			currency := valuationCurrency(value)
+			if currency == "" || currency == s.base || seen[currency] {
+				continue
+			}
+			seen[currency] = true
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies, nil
+}
+
+// fetch quotes currency pairs against the base currency, returning the base currency per unit
+func (s *FXService) fetch(ctx context.Context, currencies []string, inverse bool) (map[string]float64, error) {
+	bySymbol := make(map[string]string, len(currencies))
+	symbols := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		pair := symbol.FX(currency, s.base)
+		if inverse {
+			pair = symbol.FX(s.base, currency)
+		}
+		bySymbol[pair.String()] = currency
+		symbols = append(symbols, pair.String())
+	}
+
+	quotes, err := s.market.GetQuotes(ctx, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+
+	rates := make(map[string]float64)
+	for _, quote := range quotes.Quotes {
+		currency, ok := bySymbol[quote.Symbol]
+		if !ok || quote.Price == nil || *quote.Price <= 0 {
+			continue
+		}
+		if inverse {
+			rates[currency] = 1 / *quote.Price
+		} else {
+			rates[currency] = *quote.Price
+		}
+	}
+	if len(quotes.FailedSymbols) > 0 {
+		logger.Debug("Exchange rates not available", zap.Strings("symbols", quotes.FailedSymbols))
+	}
+	return rates, nil
+}
+
+// fetchHistory fetches the daily closing rates of a currency against the base currency,
+// returning the base currency per unit by date
+func (s *FXService) fetchHistory(ctx context.Context, currency, period string) (map[time.Time]float64, error) {
+	for _, inverse := range []bool{false, true} {
+		pair := symbol.FX(currency, s.base)
+		if inverse {
+			pair = symbol.FX(s.base, currency)
+		}
+		history, err := s.market.GetHistory(ctx, pair.String(), period, "1d")
+		if err != nil {
+			logger.Debug("Exchange rate history not available", zap.String("symbol", pair.String()), zap.Error(err))
+			continue
+		}
+
+		rates := make(map[time.Time]float64)
+		for _, point := range history.DataPoints {
+			date, err := parseBarDate(point)
+			if err != nil || point.Close == nil || *point.Close <= 0 {
+				continue
+			}
+			if inverse {
+				rates[date] = 1 / *point.Close
+			} else {
+				rates[date] = *point.Close
+			}
+		}
+		if len(rates) > 0 {
+			return rates, nil
+		}
+	}
+	return nil, fmt.Errorf("no exchange rate history for %s", currency)
+}
+
+// storeHistory stores the provider rates of a currency for the dates not stored yet
+func (s *FXService) storeHistory(currency string, rates map[time.Time]float64) (int, error) {
+	var dates []time.Time
+	if err := s.db.Model(&models.FXRate{}).
+		Where("base_currency = ? AND quote_currency = ? AND source = ?", currency, s.base, models.FXSourceProvider).
+		Pluck("date", &dates).Error; err != nil {
+		return 0, fmt.Errorf("failed to look up exchange rates: %w", err)
+	}
+	stored := make(map[time.Time]bool, len(dates))
+	for _, date := range dates {
+		stored[truncateDate(date)] = true
+	}
+
+	missing := make([]models.FXRate, 0, len(rates))
+	for date, rate := range rates {
+		if stored[date] {
+			continue
+		}
+		missing = append(missing, models.FXRate{
+			BaseCurrency:  currency,
+			QuoteCurrency: s.base,
+			Date:          date,
+			Rate:          rate,
+			Source:        models.FXSourceProvider,
+		})
+	}
+	if len(missing) == 0 {
+		return 0, nil
+	}
+	if err := s.db.CreateInBatches(&missing, 500).Error; err != nil {
+		return 0, fmt.Errorf("failed to store exchange rates: %w", err)
+	}
+	return len(missing), nil
+}
+
+// upsert stores a rate, replacing the rate of the same pair, date and source
+func (s *FXService) upsert(rate *models.FXRate) error {
+	var existing models.FXRate
+	err := s.db.Where("base_currency = ? AND quote_currency = ? AND date = ? AND source = ?",
+		rate.BaseCurrency, rate.QuoteCurrency, rate.Date, rate.Source).First(&existing).Error
+	switch {
+	case err == nil:
+		rate.ID = existing.ID
+		rate.CreatedAt = existing.CreatedAt
+		if err := s.db.Save(rate).Error; err != nil {
+			return fmt.Errorf("failed to update exchange rate: %w", err)
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := s.db.Create(rate).Error; err != nil {
+			return fmt.Errorf("failed to store exchange rate: %w", err)
+		}
+	default:
+		return fmt.Errorf("failed to look up exchange rate: %w", err)
+	}
+	return nil
+}
+
+// latestRate returns the newest stored rate of a currency on or before a date, the manual
+// rate first when both sources have one that day
+func (s *FXService) latestRate(currency string, asOf time.Time) (*models.FXRate, error) {
+	var rate models.FXRate
+	err := s.db.Where("((base_currency = ? AND quote_currency = ?) OR (base_currency = ? AND quote_currency = ?)) AND date < ?",
+		currency, s.base, s.base, currency, truncateDate(asOf).AddDate(0, 0, 1)).
+		Order(fmt.Sprintf("date DESC, CASE WHEN source = '%s' THEN 0 ELSE 1 END, id DESC", models.FXSourceManual)).
+		First(&rate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// toBase reads a stored rate as the base currency per unit of the other currency of the pair
+func (s *FXService) toBase(rate models.FXRate) (string, float64, bool) {
+	if rate.Rate <= 0 {
+		return "", 0, false
+	}
+	switch s.base {
+	case rate.QuoteCurrency:
+		return rate.BaseCurrency, rate.Rate, true
+	case rate.BaseCurrency:
+		return rate.QuoteCurrency, 1 / rate.Rate, true
+	default:
+		return "", 0, false
+	}
+}
+
+// fxBackfillSlack is how many days after the requested start the first stored rate may fall
+// (weekends and holidays) before the history is fetched again
+const fxBackfillSlack = 7
+
+// FXHistory holds the dated rates of every currency against the base currency
+type FXHistory struct {
+	base  string
+	rates map[string][]fxRatePoint // Oldest first
+}
+
+// fxRatePoint is a stored rate as the base currency per unit
+type fxRatePoint struct {
+	date   time.Time
+	rate   float64
+	manual bool
+}
+
+// Base returns the base currency
+func (h *FXHistory) Base() string {
+	return h.base
+}
+
+// At returns a converter using the rates in effect on a date: the newest rate on or before
+// the date, a manual rate winning over a provider rate of the same date. Dates before the
+// first stored rate of a currency use that first rate.
+func (h *FXHistory) At(date time.Time) *Converter {
+	day := truncateDate(date)
+	converter := newConverter(h.base)
+	for currency, points := range h.rates {
+		var latest *fxRatePoint
+		for i := range points {
+			if points[i].date.After(day) {
+				break
+			}
+			// A provider rate never replaces the manual rate of its own date
+			if latest != nil && latest.manual && !points[i].manual && points[i].date.Equal(latest.date) {
+				continue
+			}
+			latest = &points[i]
+		}
+		if latest == nil {
+			latest = &points[0]
+		}
+		converter.rates[currency] = latest.rate
+	}
+	return converter
+}
+
+// Converter converts amounts into the base currency at fixed rates. The amounts converted as
+// assets or debts are also kept in their original currency for the currency breakdown, and by
+// category when converted through a converter returned by In.
+type Converter struct {
+	base       string
+	rates      map[string]float64 // Base currency per unit
+	missing    map[string]bool
+	assets     map[string]float64
+	debts      map[string]float64
+	categories map[string]map[string]float64 // Category -> original currency -> amount
+	category   string
+}
+
+func newConverter(base string) *Converter {
+	return &Converter{
+		base:       base,
+		rates:      make(map[string]float64),
+		missing:    make(map[string]bool),
+		assets:     make(map[string]float64),
+		debts:      make(map[string]float64),
+		categories: make(map[string]map[string]float64),
+	}
+}
+
+// In returns a converter sharing the rates and breakdowns of c that also lists the assets and
+// debts it converts under a snapshot category
+func (c *Converter) In(category string) *Converter {
+	view := *c
+	view.category = category
+	return &view
+}
+
+// Base returns the base currency
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Rate returns the base currency per unit of a currency
+func (c *Converter) Rate(currency string) (float64, bool) {
+	currency = valuationCurrency(currency)
+	if currency == "" || currency == c.base {
+		return 1, true
+	}
+	rate, ok := c.rates[currency]
+	return rate, ok
+}
+
+// Convert converts an amount into the base currency. Amounts in a currency without a rate are
+// counted unconverted, and the currency is reported as missing.
+func (c *Converter) Convert(amount float64, currency string) float64 {
+	rate, ok := c.Rate(currency)
+	if !ok {
+		c.missing[valuationCurrency(currency)] = true
+		return amount
+	}
+	return amount * rate
+}
+
+// Asset converts the value of an asset and adds it to the currency breakdown
+func (c *Converter) Asset(amount float64, currency string) float64 {
+	c.assets[c.breakdownCurrency(currency)] += amount
+	c.addToCategory(amount, currency)
+	return c.Convert(amount, currency)
+}
+
+// Debt converts the balance of a debt and adds it to the currency breakdown
+func (c *Converter) Debt(amount float64, currency string) float64 {
+	c.debts[c.breakdownCurrency(currency)] += amount
+	c.addToCategory(amount, currency)
+	return c.Convert(amount, currency)
+}
+
+// addToCategory lists an amount under the converter's category, if any
+func (c *Converter) addToCategory(amount float64, currency string) {
+	if c.category == "" {
+		return
+	}
+	if c.categories[c.category] == nil {
+		c.categories[c.category] = make(map[string]float64)
+	}
+	c.categories[c.category][c.breakdownCurrency(currency)] += amount
+}
+
+// CategoryBreakdown returns the amounts converted through categorized converters by category
+// and original currency
+func (c *Converter) CategoryBreakdown() map[string]map[string]float64 {
+	breakdown := make(map[string]map[string]float64, len(c.categories))
+	for category, amounts := range c.categories {
+		breakdown[category] = make(map[string]float64, len(amounts))
+		for currency, amount := range amounts {
+			breakdown[category][currency] = amount
+		}
+	}
+	return breakdown
+}
+
+// Missing returns the currencies converted without a rate
+func (c *Converter) Missing() []string {
+	missing := make([]string, 0, len(c.missing))
+	for currency := range c.missing {
+		missing = append(missing, currency)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// missingRates collects the currencies converted without a rate across several converters,
+// such as the dated converters of a report
+type missingRates map[string]bool
+
+// add records the missing currencies of a converter
+func (m missingRates) add(c *Converter) {
+	for currency := range c.missing {
+		m[currency] = true
+	}
+}
+
+// list returns the missing currencies in order
+func (m missingRates) list() []string {
+	missing := make([]string, 0, len(m))
+	for currency := range m {
+		missing = append(missing, currency)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Breakdown returns the assets and debts converted so far by original currency
+func (c *Converter) Breakdown() []CurrencyBreakdown {
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, amounts := range []map[string]float64{c.assets, c.debts} {
+		for currency := range amounts {
+			if !seen[currency] {
+				seen[currency] = true
+				currencies = append(currencies, currency)
+			}
+		}
+	}
+	sort.Strings(currencies)
+
+	breakdown := make([]CurrencyBreakdown, 0, len(currencies))
+	for _, currency := range currencies {
+		item := CurrencyBreakdown{
+			Currency: currency,
+			Assets:   c.assets[currency],
+			Debt:     c.debts[currency],
+		}
+		rate, ok := c.Rate(currency)
+		if !ok {
+			rate = 1
+			item.RateMissing = true
+		}
+		item.Rate = rate
+		item.AssetsBase = item.Assets * rate
+		item.DebtBase = item.Debt * rate
+		breakdown = append(breakdown, item)
+	}
+	return breakdown
+}
+
+// breakdownCurrency is the currency an amount is listed under, the base currency when unset
+func (c *Converter) breakdownCurrency(currency string) string {
+	if currency = valuationCurrency(currency); currency != "" {
+		return currency
+	}
+	return c.base
+}
+
+// normalizeCurrency upper-cases a currency code
+func normalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// valuationCurrency is the currency an amount is valued in, resolving aliases such as stablecoins
+func valuationCurrency(currency string) string {
+	currency = normalizeCurrency(currency)
+	if alias, ok := currencyAliases[currency]; ok {
+		return alias
+	}
+	return currency
+}
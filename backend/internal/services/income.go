@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+	"trackmymoney/pkg/logger"
+)
+
+var (
+	// ErrInvalidIncomeEvent is returned when an income event is incomplete or inconsistent
+	ErrInvalidIncomeEvent = errors.New("invalid income event")
+	// ErrAssetNotFound is returned when an income event refers to a missing asset
+	ErrAssetNotFound = errors.New("asset not found")
+)
+
+// IncomeService records dividends, coupons, interest and staking rewards
+type IncomeService struct {
+	db            *gorm.DB
+	marketService *MarketService
+}
+
+// IncomeFilter selects income events to list
+type IncomeFilter struct {
+	AssetType models.AssetType
+	AssetID   uint
+	Type      models.IncomeType
+	From      *time.Time
+	To        *time.Time
+}
+
+// IncomeImportResult represents the outcome of importing dividends from the provider
+type IncomeImportResult struct {
+	Symbols int      `json:"symbols"` // Held symbols checked
+	Added   int      `json:"added"`   // New dividends recorded
+	Failed  []string `json:"failed"`  // Symbols whose dividends could not be fetched
+}
+
+// IncomeSummary is the net income (after withholding tax) received over standard periods
+type IncomeSummary struct {
+	YearToDate   float64 `json:"year_to_date"`
+	Last12Months float64 `json:"last_12_months"`
+	AllTime      float64 `json:"all_time"`
+
+	MissingRates []string `json:"missing_rates,omitempty"` // Currencies counted unconverted for lack of a rate
+}
+
+// IncomeYear is the income received in a year in one currency
+type IncomeYear struct {
+	Year           int                           `json:"year"`
+	Currency       string                        `json:"currency"`
+	Events         int                           `json:"events"`
+	Gross          float64                       `json:"gross"`
+	WithholdingTax float64                       `json:"withholding_tax"`
+	Net            float64                       `json:"net"`
+	ByType         map[models.IncomeType]float64 `json:"by_type"` // Net income by type
+	ByAsset        []IncomeAssetTotal            `json:"by_asset"`
+}
+
+// IncomeAssetTotal is the income received from one asset
+type IncomeAssetTotal struct {
+	AssetType      models.AssetType `json:"asset_type"`
+	AssetID        uint             `json:"asset_id"`
+	Name           string           `json:"name"`
+	Events         int              `json:"events"`
+	Gross          float64          `json:"gross"`
+	WithholdingTax float64          `json:"withholding_tax"`
+	Net            float64          `json:"net"`
+}
+
+// NewIncomeService creates a new income service
+func NewIncomeService(db *gorm.DB, marketService *MarketService) *IncomeService {
+	return &IncomeService{
+		db:            db,
+		marketService: marketService,
+	}
+}
+
+// List returns income events, newest first, optionally filtered
+func (s *IncomeService) List(filter IncomeFilter) ([]models.IncomeEvent, error) {
+	query := s.incomeQuery(filter)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	var events []models.IncomeEvent
+	if err := query.Order("pay_date DESC, id DESC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Get returns a single income event
+func (s *IncomeService) Get(id uint) (*models.IncomeEvent, error) {
+	var event models.IncomeEvent
+	if err := s.db.First(&event, id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Create records an income event
+func (s *IncomeService) Create(event *models.IncomeEvent) error {
+	if err := normalizeIncomeEvent(event); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidIncomeEvent, err)
+	}
+
+	_, currency, err := incomeAsset(s.db, event.AssetType, event.AssetID)
+	if err != nil {
+		return err
+	}
+	if event.Currency == "" {
+		event.Currency = currency
+	}
+	if event.Source == "" {
+		event.Source = models.IncomeSourceManual
+	}
+
+	return s.db.Create(event).Error
+}
+
+// Update saves an edited income event. The asset of an event cannot change.
+func (s *IncomeService) Update(event *models.IncomeEvent) error {
+	if err := normalizeIncomeEvent(event); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidIncomeEvent, err)
+	}
+	return s.db.Save(event).Error
+}
+
+// Delete removes an income event
+func (s *IncomeService) Delete(event *models.IncomeEvent) error {
+	return s.db.Delete(event).Error
+}
+
+// Import records the dividends paid on held stocks from the provider's dividend history.
+// A dividend is recorded for the units held before its ex-date, paid on the ex-date since
+// providers do not report pay dates. Dividends already recorded or deleted are skipped.
+func (s *IncomeService) Import(ctx context.Context) (*IncomeImportResult, error) {
+	var stocks []models.StockAsset
+	if err := s.db.Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load stock assets: %w", err)
+	}
+
+	result := &IncomeImportResult{Failed: []string{}}
+	today := truncateDate(time.Now())
+
+	for i := range stocks {
+		stock := &stocks[i]
+		transactions, err := loadLedger(s.db, models.AssetTypeStock, stock.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(transactions) == 0 {
+			continue
+		}
+
+		sym := StockSymbol(stock)
+		result.Symbols++
+		dividends, err := s.marketService.GetDividends(ctx, sym)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			logger.Warn("Failed to fetch dividends", zap.String("symbol", sym), zap.Error(err))
+			result.Failed = append(result.Failed, sym)
+			continue
+		}
+
+		currency := stock.Currency
+		if dividends.Currency != nil && *dividends.Currency != "" {
+			currency = strings.ToUpper(*dividends.Currency)
+		}
+
+		for _, dividend := range dividends.Dividends {
+			exDate, err := time.Parse("2006-01-02", dividend.Date)
+			if err != nil || dividend.Amount <= 0 || exDate.After(today) {
+				continue
+			}
+
+			quantity := heldBefore(transactions, exDate)
+			if quantity <= 0 {
+				continue
+			}
+			// Provider dividends are split-adjusted while the quantity is as held on the ex-date
+			perUnit := dividend.Amount * splitFactorSince(transactions, exDate)
+
+			// Deleted dividends count too, so that deleting an imported dividend is final
+			var count int64
+			if err := s.db.Unscoped().Model(&models.IncomeEvent{}).
+				Where("asset_type = ? AND asset_id = ? AND type = ? AND ex_date = ?", models.AssetTypeStock, stock.ID, models.IncomeDividend, exDate).
+				Count(&count).Error; err != nil {
+				return nil, fmt.Errorf("failed to check recorded dividends: %w", err)
+			}
+			if count > 0 {
+				continue
+			}
+
+			event := models.IncomeEvent{
+				AssetType: models.AssetTypeStock,
+				AssetID:   stock.ID,
+				Type:      models.IncomeDividend,
+				Amount:    quantity * perUnit,
+				Currency:  currency,
+				PayDate:   exDate,
+				ExDate:    &exDate,
+				PerUnit:   perUnit,
+				Quantity:  quantity,
+				Source:    models.IncomeSourceProvider,
+			}
+			if err := s.db.Create(&event).Error; err != nil {
+				return nil, fmt.Errorf("failed to record dividend of %s: %w", sym, err)
+			}
+			result.Added++
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Imported %d dividends of %d held symbols", result.Added, result.Symbols))
+	return result, nil
+}
+
+// YearlyReport returns the income received per year and currency, optionally of a single asset type or asset
+func (s *IncomeService) YearlyReport(assetType models.AssetType, assetID uint) ([]IncomeYear, error) {
+	var events []models.IncomeEvent
+	if err := s.incomeQuery(IncomeFilter{AssetType: assetType, AssetID: assetID}).Order("pay_date, id").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to load income events: %w", err)
+	}
+
+	type yearKey struct {
+		year     int
+		currency string
+	}
+	type assetKey struct {
+		assetType models.AssetType
+		assetID   uint
+	}
+	years := make(map[yearKey]*IncomeYear)
+	assets := make(map[yearKey]map[assetKey]*IncomeAssetTotal)
+	names := make(map[assetKey]string)
+
+	for _, event := range events {
+		key := yearKey{year: event.PayDate.Year(), currency: event.Currency}
+		year := years[key]
+		if year == nil {
+			year = &IncomeYear{Year: key.year, Currency: key.currency, ByType: make(map[models.IncomeType]float64), ByAsset: []IncomeAssetTotal{}}
+			years[key] = year
+			assets[key] = make(map[assetKey]*IncomeAssetTotal)
+		}
+		net := event.Amount - event.WithholdingTax
+		year.Events++
+		year.Gross += event.Amount
+		year.WithholdingTax += event.WithholdingTax
+		year.Net += net
+		year.ByType[event.Type] += net
+
+		ak := assetKey{assetType: event.AssetType, assetID: event.AssetID}
+		total := assets[key][ak]
+		if total == nil {
+			if _, ok := names[ak]; !ok {
+				// Income of deleted assets is kept, so their names are looked up too
+				names[ak], _, _ = incomeAsset(s.db.Unscoped(), event.AssetType, event.AssetID)
+			}
+			total = &IncomeAssetTotal{AssetType: event.AssetType, AssetID: event.AssetID, Name: names[ak]}
+			assets[key][ak] = total
+		}
+		total.Events++
+		total.Gross += event.Amount
+		total.WithholdingTax += event.WithholdingTax
+		total.Net += net
+	}
+
+	report := make([]IncomeYear, 0, len(years))
+	for key, year := range years {
+		for _, total := range assets[key] {
+			year.ByAsset = append(year.ByAsset, *total)
+		}
+		sort.Slice(year.ByAsset, func(i, j int) bool {
+			return year.ByAsset[i].Net > year.ByAsset[j].Net
+		})
+		report = append(report, *year)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Year != report[j].Year {
+			return report[i].Year < report[j].Year
+		}
+		return report[i].Currency < report[j].Currency
+	})
+	return report, nil
+}
+
+// incomeQuery selects the income events matching a filter's asset and date range
+func (s *IncomeService) incomeQuery(filter IncomeFilter) *gorm.DB {
+	query := s.db.Model(&models.IncomeEvent{})
+	if filter.AssetType != "" {
+		query = query.Where("asset_type = ?", filter.AssetType)
+	}
+	if filter.AssetID != 0 {
+		query = query.Where("asset_id = ?", filter.AssetID)
+	}
+	if filter.From != nil {
+		query = query.Where("pay_date >= ?", truncateDate(*filter.From))
+	}
+	if filter.To != nil {
+		query = query.Where("pay_date <= ?", truncateDate(*filter.To))
+	}
+	return query
+}
+
+// summarizeIncome returns the net income received up to today over standard periods, converted
+// into the base currency at the rate of each pay date. Currencies without a rate are listed in MissingRates.
+func summarizeIncome(db *gorm.DB, now time.Time, rates *FXHistory) (IncomeSummary, error) {
+	var summary IncomeSummary
+	today := truncateDate(now)
+	missing := missingRates{}
+
+	for _, period := range []struct {
+		from  time.Time
+		total *float64
+	}{
+		{time.Date(today.Year(), 1, 1, 0, 0, 0, 0, time.UTC), &summary.YearToDate},
+		{today.AddDate(-1, 0, 1), &summary.Last12Months},
+		{time.Time{}, &summary.AllTime},
+	} {
+		var events []models.IncomeEvent
+		if err := db.Where("pay_date >= ? AND pay_date <= ?", period.from, today).
+			Find(&events).Error; err != nil {
+			return summary, fmt.Errorf("failed to sum income: %w", err)
+		}
+		for _, event := range events {
+			fx := rates.At(event.PayDate)
+			*period.total += fx.Convert(event.Amount-event.WithholdingTax, event.Currency)
+			missing.add(fx)
+		}
+	}
+	summary.MissingRates = missing.list()
+	return summary, nil
+}
+
+// heldBefore returns the units a ledger holds before a date
+func heldBefore(transactions []models.Transaction, date time.Time) float64 {
+	var before []models.Transaction
+	for _, t := range transactions {
+		if t.Date.Before(date) {
+			before = append(before, t)
+		}
+	}
+	if len(before) == 0 {
+		return 0
+	}
+
+	// Any method gives the same quantity
+	holding, err := ProjectHolding(before, models.CostBasisAverage)
+	if err != nil {
+		return 0
+	}
+	return holding.Quantity
+}
+
+// splitFactorSince returns the number of units each unit held before a date was split into
+// by the splits recorded on or after it
+func splitFactorSince(transactions []models.Transaction, date time.Time) float64 {
+	factor := 1.0
+	for _, t := range transactions {
+		if t.Type == models.TransactionSplit && !t.Date.Before(date) && t.Ratio > 0 {
+			factor *= t.Ratio
+		}
+	}
+	return factor
+}
+
+// incomeAsset returns the name and currency of an asset that can pay income
+func incomeAsset(db *gorm.DB, assetType models.AssetType, assetID uint) (string, string, error) {
+	var name, currency string
+	var err error
+	switch assetType {
+	case models.AssetTypeCash:
+		var asset models.CashAsset
+		err = db.First(&asset, assetID).Error
+		name, currency = asset.Name, asset.Currency
+	case models.AssetTypeInterestBearing:
+		var asset models.InterestBearingAsset
+		err = db.First(&asset, assetID).Error
+		name, currency = asset.Name, asset.Currency
+	case models.AssetTypeStock:
+		var asset models.StockAsset
+		err = db.First(&asset, assetID).Error
+		name, currency = asset.Name, asset.Currency
+	case models.AssetTypeCrypto:
+		var asset models.CryptoAsset
+		err = db.First(&asset, assetID).Error
+		name, currency = asset.Name, asset.QuoteCurrency
+	default:
+		return "", "", fmt.Errorf("%w: income is recorded for cash, interest-bearing, stock and crypto assets, not %q", ErrInvalidIncomeEvent, assetType)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", ErrAssetNotFound
+	}
+	return name, currency, err
+}
+
+// normalizeIncomeEvent validates an income event
+func normalizeIncomeEvent(event *models.IncomeEvent) error {
+	if event.AssetID == 0 {
+		return errors.New("asset ID is required")
+	}
+	switch event.Type {
+	case models.IncomeDividend, models.IncomeCoupon, models.IncomeInterest, models.IncomeStaking, models.IncomeOther:
+	default:
+		return fmt.Errorf("unsupported income type %q", event.Type)
+	}
+	if event.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if event.WithholdingTax < 0 || event.WithholdingTax > event.Amount {
+		return errors.New("withholding tax must be between zero and the amount")
+	}
+	if event.PayDate.IsZero() {
+		return errors.New("pay date is required")
+	}
+	if event.Source == models.IncomeSourceProvider && event.ExDate == nil {
+		// The ex-date identifies imported dividends; without it they would be imported again
+		return errors.New("imported dividends must keep their ex-date")
+	}
+
+	event.PayDate = truncateDate(event.PayDate)
+	if event.ExDate != nil {
+		exDate := truncateDate(*event.ExDate)
+		event.ExDate = &exDate
+	}
+	event.Currency = strings.ToUpper(strings.TrimSpace(event.Currency))
+	return nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+)
+
+// InterestAccrual is the valuation of an interest-bearing asset as of a date
+type InterestAccrual struct {
+	AsOf            time.Time `json:"as_of"`
+	Principal       float64   `json:"principal"`
+	AccruedInterest float64   `json:"accrued_interest"`
+	Value           float64   `json:"value"`                       // Principal plus accrued interest
+	ValueAtMaturity *float64  `json:"value_at_maturity,omitempty"` // Empty for open-ended deposits
+	Matured         bool      `json:"matured"`                     // Accrual stopped at the maturity date
+}
+
+// InterestBearingTotals sums the valuations of all interest-bearing assets in the base currency
+type InterestBearingTotals struct {
+	Principal       float64 `json:"principal"`
+	AccruedInterest float64 `json:"accrued_interest"`
+	Value           float64 `json:"value"`
+	ValueAtMaturity float64 `json:"value_at_maturity"` // Open-ended deposits count at their current value
+}
+
+// compoundingPeriods is the number of times per year interest is capitalized
+var compoundingPeriods = map[models.CompoundingFrequency]float64{
+	models.CompoundDaily:      365,
+	models.CompoundMonthly:    12,
+	models.CompoundQuarterly:  4,
+	models.CompoundSemiannual: 2,
+	models.CompoundAnnual:     1,
+}
+
+// AccrueInterest values an interest-bearing asset as of a date. Interest accrues from the
+// start date up to the as-of date or the maturity date, whichever is earlier.
+func AccrueInterest(asset *models.InterestBearingAsset, asOf time.Time) InterestAccrual {
+	accrual := InterestAccrual{
+		AsOf:      truncateDate(asOf),
+		Principal: asset.Amount,
+	}
+
+	end := accrual.AsOf
+	if asset.MaturityDate != nil && !truncateDate(*asset.MaturityDate).After(end) {
+		end = truncateDate(*asset.MaturityDate)
+		accrual.Matured = true
+	}
+	accrual.AccruedInterest = interestBetween(asset, asset.StartDate, end)
+	accrual.Value = accrual.Principal + accrual.AccruedInterest
+
+	if asset.MaturityDate != nil {
+		atMaturity := asset.Amount + interestBetween(asset, asset.StartDate, *asset.MaturityDate)
+		accrual.ValueAtMaturity = &atMaturity
+	}
+	return accrual
+}
+
+// SumInterestBearing values all interest-bearing assets as of a date in the converter's base currency
+func SumInterestBearing(db *gorm.DB, asOf time.Time, fx *Converter) (InterestBearingTotals, error) {
+	var totals InterestBearingTotals
+
+	var assets []models.InterestBearingAsset
+	if err := db.Find(&assets).Error; err != nil {
+		return totals, fmt.Errorf("failed to retrieve interest-bearing assets: %w", err)
+	}
+	for i := range assets {
+		accrual := AccrueInterest(&assets[i], asOf)
+		currency := assets[i].Currency
+		totals.Principal += fx.Convert(accrual.Principal, currency)
+		totals.AccruedInterest += fx.Convert(accrual.AccruedInterest, currency)
+		totals.Value += fx.Asset(accrual.Value, currency)
+		if accrual.ValueAtMaturity != nil {
+			totals.ValueAtMaturity += fx.Convert(*accrual.ValueAtMaturity, currency)
+		} else {
+			totals.ValueAtMaturity += fx.Convert(accrual.Value, currency)
+		}
+	}
+	return totals, nil
+}
+
+// NormalizeInterestTerms validates the accrual terms of an interest-bearing asset and fills in defaults
+func NormalizeInterestTerms(asset *models.InterestBearingAsset) error {
+	if asset.InterestRate < 0 {
+		return errors.New("interest rate must not be negative")
+	}
+	if asset.MaturityDate != nil && asset.MaturityDate.Before(asset.StartDate) {
+		return errors.New("maturity date must not be before the start date")
+	}
+
+	if asset.DayCount == "" {
+		asset.DayCount = models.DayCountACT365
+	}
+	if asset.DayCount != models.DayCountACT365 && asset.DayCount != models.DayCount30360 {
+		return fmt.Errorf("unsupported day count %q", asset.DayCount)
+	}
+
+	switch asset.InterestType {
+	case "", models.InterestSimple:
+		asset.InterestType = models.InterestSimple
+		asset.CompoundingFrequency = ""
+	case models.InterestCompound:
+		if asset.CompoundingFrequency == "" {
+			asset.CompoundingFrequency = models.CompoundAnnual
+		}
+		if _, ok := compoundingPeriods[asset.CompoundingFrequency]; !ok {
+			return fmt.Errorf("unsupported compounding frequency %q", asset.CompoundingFrequency)
+		}
+	default:
+		return fmt.Errorf("unsupported interest type %q", asset.InterestType)
+	}
+	return nil
+}
+
+// interestBetween is the interest earned on the principal from start to end
+func interestBetween(asset *models.InterestBearingAsset, start, end time.Time) float64 {
+	years := yearFraction(asset.DayCount, truncateDate(start), truncateDate(end))
+	if years <= 0 {
+		return 0
+	}
+	rate := asset.InterestRate / 100
+
+	if asset.InterestType == models.InterestCompound {
+		periods, ok := compoundingPeriods[asset.CompoundingFrequency]
+		if !ok {
+			periods = compoundingPeriods[models.CompoundAnnual]
+		}
+		return asset.Amount * (math.Pow(1+rate/periods, periods*years) - 1)
+	}
+	return asset.Amount * rate * years
+}
+
+// yearFraction measures the period from start to end in years under a day-count convention
+func yearFraction(dayCount models.DayCount, start, end time.Time) float64 {
+	if dayCount == models.DayCount30360 {
+		// 30/360 bond basis: day 31 counts as day 30, and the end day only when the start is the 30th or 31st
+		d1, d2 := start.Day(), end.Day()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 == 30 {
+			d2 = 30
+		}
+		days := 360*(end.Year()-start.Year()) + 30*(int(end.Month())-int(start.Month())) + d2 - d1
+		return float64(days) / 360
+	}
+	return end.Sub(start).Hours() / 24 / 365
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+)
+
+var (
+	// ErrInvalidLoan is returned when loan terms are incomplete or inconsistent
+	ErrInvalidLoan = errors.New("invalid loan terms")
+	// ErrNotALoan is returned when a schedule is requested for a debt without loan terms
+	ErrNotALoan = errors.New("debt has no loan terms")
+	// ErrInvalidPrepayment is returned when a prepayment is incomplete or exceeds the balance
+	ErrInvalidPrepayment = errors.New("invalid prepayment")
+)
+
+// balanceEpsilon is the balance below which a loan counts as repaid
+const balanceEpsilon = 0.005
+
+// paymentPeriodMonths is the number of months between installments
+var paymentPeriodMonths = map[models.PaymentFrequency]int{
+	models.PaymentMonthly:    1,
+	models.PaymentQuarterly:  3,
+	models.PaymentSemiannual: 6,
+	models.PaymentAnnual:     12,
+}
+
+// LoanService generates amortization schedules and records prepayments
+type LoanService struct {
+	db *gorm.DB
+}
+
+// LoanPayment is an installment of a loan schedule
+type LoanPayment struct {
+	Period     int       `json:"period"`
+	Date       time.Time `json:"date"`
+	Payment    float64   `json:"payment"` // Principal plus interest
+	Principal  float64   `json:"principal"`
+	Interest   float64   `json:"interest"`
+	Prepayment float64   `json:"prepayment,omitempty"` // Prepaid since the previous installment
+	Balance    float64   `json:"balance"`              // Outstanding after the installment
+	Paid       bool      `json:"paid"`                 // Due on or before the as-of date
+}
+
+// LoanSchedule is the full payment schedule of a loan, with its state as of a date
+type LoanSchedule struct {
+	AsOf             time.Time     `json:"as_of"`
+	Principal        float64       `json:"principal"`
+	Outstanding      float64       `json:"outstanding"`
+	Installment      float64       `json:"installment"` // Next regular installment
+	PaidPeriods      int           `json:"paid_periods"`
+	RemainingPeriods int           `json:"remaining_periods"`
+	NextPayment      *LoanPayment  `json:"next_payment,omitempty"`
+	PayoffDate       time.Time     `json:"payoff_date"`
+	TotalInterest    float64       `json:"total_interest"`
+	TotalPrepaid     float64       `json:"total_prepaid"`
+	Payments         []LoanPayment `json:"payments"`
+}
+
+// NewLoanService creates a new loan service
+func NewLoanService(db *gorm.DB) *LoanService {
+	return &LoanService{db: db}
+}
+
+// Schedule generates the payment schedule of a loan including its prepayments
+func (s *LoanService) Schedule(debt *models.DebtAsset, asOf time.Time) (*LoanSchedule, error) {
+	if debt.Amortization == "" {
+		return nil, ErrNotALoan
+	}
+	prepayments, err := s.Prepayments(debt.ID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildLoanSchedule(debt, prepayments, asOf), nil
+}
+
+// Balance returns the outstanding balance of a debt: the amortized balance for loans, the amount otherwise
+func (s *LoanService) Balance(debt *models.DebtAsset, asOf time.Time) (float64, error) {
+	if debt.Amortization == "" {
+		return debt.Amount, nil
+	}
+	schedule, err := s.Schedule(debt, asOf)
+	if err != nil {
+		return 0, err
+	}
+	return schedule.Outstanding, nil
+}
+
+// Prepayments returns the prepayments of a loan in date order
+func (s *LoanService) Prepayments(debtID uint) ([]models.LoanPrepayment, error) {
+	var prepayments []models.LoanPrepayment
+	if err := s.db.Where("debt_asset_id = ?", debtID).Order("date ASC, id ASC").Find(&prepayments).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve prepayments: %w", err)
+	}
+	return prepayments, nil
+}
+
+// AddPrepayment records a prepayment; it may not exceed the balance outstanding on its date
+func (s *LoanService) AddPrepayment(debt *models.DebtAsset, prepayment *models.LoanPrepayment) error {
+	if debt.Amortization == "" {
+		return ErrNotALoan
+	}
+	if prepayment.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidPrepayment)
+	}
+	switch prepayment.Mode {
+	case "":
+		prepayment.Mode = models.PrepaymentReduceTerm
+	case models.PrepaymentReduceTerm, models.PrepaymentReducePayment:
+	default:
+		return fmt.Errorf("%w: unsupported mode %q", ErrInvalidPrepayment, prepayment.Mode)
+	}
+	prepayment.Date = truncateDate(prepayment.Date)
+	if prepayment.Date.Before(truncateDate(*debt.LoanStartDate)) {
+		return fmt.Errorf("%w: date is before the loan start", ErrInvalidPrepayment)
+	}
+
+	schedule, err := s.Schedule(debt, prepayment.Date)
+	if err != nil {
+		return err
+	}
+	if prepayment.Amount > schedule.Outstanding+balanceEpsilon {
+		return fmt.Errorf("%w: amount exceeds the outstanding balance of %.2f", ErrInvalidPrepayment, schedule.Outstanding)
+	}
+
+	prepayment.DebtAssetID = debt.ID
+	return s.db.Create(prepayment).Error
+}
+
+// DeletePrepayment removes a prepayment of a loan
+func (s *LoanService) DeletePrepayment(debtID, prepaymentID uint) error {
+	result := s.db.Where("debt_asset_id = ?", debtID).Delete(&models.LoanPrepayment{}, prepaymentID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteDebt deletes a debt with its prepayments. The open collateral warnings of its collateral
+// are deleted too, unless another debt still secures it.
+func (s *LoanService) DeleteDebt(debt *models.DebtAsset) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("debt_asset_id = ?", debt.ID).Delete(&models.LoanPrepayment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(debt).Error; err != nil {
+			return err
+		}
+		if debt.CollateralType == "" {
+			return nil
+		}
+
+		key := collateralKey(debt)
+		var others []models.DebtAsset
+		if err := tx.Where("collateral_type <> ''").Find(&others).Error; err != nil {
+			return err
+		}
+		for i := range others {
+			if collateralKey(&others[i]) == key {
+				return nil
+			}
+		}
+		return tx.Where("collateral_key = ? AND resolved_at IS NULL", key).Delete(&models.CollateralWarning{}).Error
+	})
+}
+
+// SumDebts values all debts as of a date in the converter's base currency, loans at their amortized balance
+func SumDebts(db *gorm.DB, asOf time.Time, fx *Converter) (float64, error) {
+	var debts []models.DebtAsset
+	if err := db.Find(&debts).Error; err != nil {
+		return 0, fmt.Errorf("failed to retrieve debt assets: %w", err)
+	}
+	byDebt, err := prepaymentsByDebt(db)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for i := range debts {
+		total += fx.Debt(DebtBalance(&debts[i], byDebt[debts[i].ID], asOf), debts[i].Currency)
+	}
+	return total, nil
+}
+
+// prepaymentsByDebt loads all loan prepayments in date order, grouped by debt
+func prepaymentsByDebt(db *gorm.DB) (map[uint][]models.LoanPrepayment, error) {
+	var prepayments []models.LoanPrepayment
+	if err := db.Order("date ASC, id ASC").Find(&prepayments).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve prepayments: %w", err)
+	}
+	byDebt := make(map[uint][]models.LoanPrepayment)
+	for _, prepayment := range prepayments {
+		byDebt[prepayment.DebtAssetID] = append(byDebt[prepayment.DebtAssetID], prepayment)
+	}
+	return byDebt, nil
+}
+
+// DebtBalance values a debt as of a date given its prepayments
+func DebtBalance(debt *models.DebtAsset, prepayments []models.LoanPrepayment, asOf time.Time) float64 {
+	if debt.Amortization == "" {
+		return debt.Amount
+	}
+	return BuildLoanSchedule(debt, prepayments, asOf).Outstanding
+}
+
+// NormalizeLoanTerms validates the loan terms of a debt and fills in defaults
+func NormalizeLoanTerms(debt *models.DebtAsset) error {
+	if debt.Amortization == "" {
+		return nil
+	}
+	if debt.Amortization != models.AmortizationEqualInstallment && debt.Amortization != models.AmortizationEqualPrincipal {
+		return fmt.Errorf("%w: unsupported amortization %q", ErrInvalidLoan, debt.Amortization)
+	}
+	if debt.Principal <= 0 {
+		return fmt.Errorf("%w: principal must be positive", ErrInvalidLoan)
+	}
+	if debt.LoanStartDate == nil {
+		return fmt.Errorf("%w: loan_start_date is required", ErrInvalidLoan)
+	}
+	if debt.InterestRate != nil && *debt.InterestRate < 0 {
+		return fmt.Errorf("%w: interest rate must not be negative", ErrInvalidLoan)
+	}
+
+	if debt.PaymentFrequency == "" {
+		debt.PaymentFrequency = models.PaymentMonthly
+	}
+	months, ok := paymentPeriodMonths[debt.PaymentFrequency]
+	if !ok {
+		return fmt.Errorf("%w: unsupported payment frequency %q", ErrInvalidLoan, debt.PaymentFrequency)
+	}
+	if debt.TermMonths <= 0 || debt.TermMonths%months != 0 {
+		return fmt.Errorf("%w: term must be a positive whole number of payment periods", ErrInvalidLoan)
+	}
+	return nil
+}
+
+// BuildLoanSchedule generates the payment schedule of a loan. A prepayment reduces the balance
+// that the next installment's interest is charged on, then either keeps the installment and
+// shortens the term or keeps the term and lowers the installments. Amounts are rounded to cents
+// and the last installment clears the remaining balance.
+func BuildLoanSchedule(debt *models.DebtAsset, prepayments []models.LoanPrepayment, asOf time.Time) *LoanSchedule {
+	periodMonths := paymentPeriodMonths[debt.PaymentFrequency]
+	if periodMonths == 0 {
+		periodMonths = 1
+	}
+	rate := 0.0
+	if debt.InterestRate != nil {
+		rate = *debt.InterestRate / 100 * float64(periodMonths) / 12
+	}
+	start := truncateDate(*debt.LoanStartDate)
+	asOf = truncateDate(asOf)
+
+	prepayments = append([]models.LoanPrepayment(nil), prepayments...)
+	sort.SliceStable(prepayments, func(i, j int) bool {
+		return prepayments[i].Date.Before(prepayments[j].Date)
+	})
+
+	schedule := &LoanSchedule{
+		AsOf:        asOf,
+		Principal:   debt.Principal,
+		Outstanding: debt.Principal,
+		Payments:    []LoanPayment{},
+	}
+
+	balance := debt.Principal
+	remaining := debt.TermMonths / periodMonths
+	installment := annuity(balance, rate, remaining)
+	principalPart := roundCents(balance / float64(remaining))
+
+	next := 0
+	for period := 1; balance > balanceEpsilon && remaining > 0; period++ {
+		// Loans starting late in the month fall due on the last day of shorter months
+		due := dayOfMonth(start.Year(), start.Month()+time.Month(period*periodMonths), start.Day())
+
+		// Prepayments made since the previous installment
+		var prepaid float64
+		for next < len(prepayments) && prepayments[next].Date.Before(due) {
+			prepayment := prepayments[next]
+			next++
+			amount := math.Min(prepayment.Amount, balance)
+			balance = roundCents(balance - amount)
+			prepaid += amount
+			if !truncateDate(prepayment.Date).After(asOf) {
+				schedule.Outstanding = balance
+			}
+			if balance <= balanceEpsilon {
+				schedule.PayoffDate = truncateDate(prepayment.Date)
+				break
+			}
+
+			if prepayment.Mode == models.PrepaymentReducePayment {
+				installment = annuity(balance, rate, remaining)
+				principalPart = roundCents(balance / float64(remaining))
+			} else if debt.Amortization == models.AmortizationEqualPrincipal {
+				remaining = int(math.Ceil(balance/principalPart - 1e-9))
+			} else {
+				remaining = annuityPeriods(balance, rate, installment)
+			}
+		}
+		schedule.TotalPrepaid += prepaid
+		if balance <= balanceEpsilon {
+			break
+		}
+
+		interest := roundCents(balance * rate)
+		principal := principalPart
+		if debt.Amortization == models.AmortizationEqualInstallment {
+			principal = installment - interest
+		}
+		if principal > balance || remaining == 1 {
+			principal = balance
+		}
+		balance = roundCents(balance - principal)
+		remaining--
+
+		payment := LoanPayment{
+			Period:     period,
+			Date:       due,
+			Payment:    roundCents(principal + interest),
+			Principal:  roundCents(principal),
+			Interest:   interest,
+			Prepayment: prepaid,
+			Balance:    balance,
+			Paid:       !due.After(asOf),
+		}
+		schedule.Payments = append(schedule.Payments, payment)
+		schedule.TotalInterest += interest
+		schedule.PayoffDate = due
+		if payment.Paid {
+			schedule.Outstanding = balance
+			schedule.PaidPeriods++
+		}
+	}
+
+	for i := range schedule.Payments {
+		if !schedule.Payments[i].Paid {
+			schedule.NextPayment = &schedule.Payments[i]
+			schedule.Installment = schedule.Payments[i].Payment
+			schedule.RemainingPeriods = len(schedule.Payments) - i
+			break
+		}
+	}
+	schedule.TotalInterest = roundCents(schedule.TotalInterest)
+	return schedule
+}
+
+// annuity is the installment that repays a balance over a number of periods at a periodic rate
+func annuity(balance, rate float64, periods int) float64 {
+	if periods <= 0 {
+		return balance
+	}
+	if rate == 0 {
+		return roundCents(balance / float64(periods))
+	}
+	return roundCents(balance * rate / (1 - math.Pow(1+rate, -float64(periods))))
+}
+
+// annuityPeriods is the number of installments needed to repay a balance at a periodic rate
+func annuityPeriods(balance, rate, installment float64) int {
+	if rate == 0 {
+		return int(math.Ceil(balance/installment - 1e-9))
+	}
+	if installment <= balance*rate {
+		// The installment no longer covers the interest; should not happen after a prepayment
+		return math.MaxInt32
+	}
+	return int(math.Ceil(-math.Log(1-balance*rate/installment)/math.Log(1+rate) - 1e-9))
+}
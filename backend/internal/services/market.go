@@ -1,199 +1,792 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
+	"trackmymoney/internal/database"
 	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/calendar"
+	"trackmymoney/internal/services/provider"
+	"trackmymoney/internal/services/symbol"
 	"trackmymoney/pkg/logger"
 )
 
 // MarketServiceConfig holds configuration for the market service
 type MarketServiceConfig struct {
-	BaseURL    string
-	Timeout    int
-	MaxRetries int
+	Provider    models.ProviderType // Built-in provider, yfinance by default
+	DemoSeed    int64               // Random seed of the demo provider
+	BaseURL     string
+	WSURL       string // Real-time stream URL of the market data service
+	Timeout     int    // Deadline for a single provider request in seconds
+	CallTimeout int    // Deadline for a whole call, including retries and backoff, in seconds
+	MaxRetries  int
+
+	HealthWindow     int     // Rolling health window in seconds
+	HealthMinSamples int     // Samples required before a provider can be demoted
+	HealthThreshold  float64 // Providers scoring below this are demoted
+
+	QuoteCacheTTL int // Quote cache TTL in seconds
+	InfoCacheTTL  int // Info cache TTL in seconds
+	CacheMaxStale int // Seconds past the TTL that stale data may be served when a refresh fails
+
+	BreakerFailureThreshold int     // Consecutive failures that open a provider's circuit breaker
+	BreakerOpenTimeout      int     // Seconds a breaker stays open before a trial call
+	RateLimit               float64 // Default outbound requests per minute for each provider
+	RateBurst               int     // Requests that may be made at once before the rate applies
+
+	Calendar *calendar.Calendar // Exchange trading calendar, built-in sessions without holidays if nil
 }
 
-// MarketService provides market data functionality
+// MarketService provides market data functionality.
+// Requests go through an ordered chain of providers per asset class and fall
+// through to the next provider when one fails.
 type MarketService struct {
-	baseURL    string
-	httpClient *http.Client
-	maxRetries int
+	config     MarketServiceConfig
+	provider   provider.MarketDataProvider // Built-in provider, always last in the chain
+	streamer   provider.Streamer           // Real-time tick source, nil if none is configured
+	sources    *dataSourceProviders
+	health     *HealthTracker
+	guards     *ProviderGuards
+	cache      *marketCache
+	pinned     *uint            // Data source tried first, if bound
+	assetClass models.AssetType // Asset class used to pick the chain, inferred from the symbol if empty
 }
 
-// ApiResponse represents the unified API response from Python service
-type ApiResponse[T any] struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    T      `json:"data"`
+// dataSourceProviders caches providers built from DataSource records
+type dataSourceProviders struct {
+	mu        sync.Mutex
+	providers map[uint]cachedProvider
 }
 
+type cachedProvider struct {
+	updatedAt time.Time
+	provider  provider.MarketDataProvider
+}
+
+// marketCache holds the quote and info caches shared by all market service views
+type marketCache struct {
+	quotes *expiringCache[models.Quote]
+	infos  *expiringCache[models.InfoResponse]
+}
+
+// MarketCacheStats represents the hit and miss counters of the market caches
+type MarketCacheStats struct {
+	Quotes CacheStats `json:"quotes"`
+	Info   CacheStats `json:"info"`
+}
+
+// marketBackend is a single entry in a provider chain
+type marketBackend struct {
+	key       string
+	name      string
+	provider  provider.MarketDataProvider
+	rateLimit float64 // Requests per minute, 0 uses the default
+}
+
+const defaultBackendKey = "default"
+
 // NewMarketService creates a new market service instance
 func NewMarketService(config MarketServiceConfig) *MarketService {
+	if config.QuoteCacheTTL <= 0 {
+		config.QuoteCacheTTL = 15
+	}
+	if config.InfoCacheTTL <= 0 {
+		config.InfoCacheTTL = 3600
+	}
+	if config.CacheMaxStale <= 0 {
+		config.CacheMaxStale = 86400
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30
+	}
+	if config.CallTimeout <= 0 {
+		config.CallTimeout = 60
+	}
+	if config.Calendar == nil {
+		config.Calendar = calendar.Default()
+	}
+	maxStale := time.Duration(config.CacheMaxStale) * time.Second
+
+	builtin, err := provider.New(config.Provider, provider.Config{
+		BaseURL: config.BaseURL,
+		Timeout: config.Timeout,
+		Seed:    config.DemoSeed,
+	})
+	if err != nil {
+		logger.Warn(fmt.Sprintf("%v, falling back to %s", err, models.ProviderYFinance))
+		config.Provider = models.ProviderYFinance
+		builtin, _ = provider.New(config.Provider, provider.Config{
+			BaseURL: config.BaseURL,
+			Timeout: config.Timeout,
+		})
+	}
+
+	streamer, _ := builtin.(provider.Streamer)
+	if streamer == nil && config.WSURL != "" {
+		streamer = provider.NewYFinanceStreamer(config.WSURL, config.Timeout)
+	}
+
 	return &MarketService{
-		baseURL: config.BaseURL,
-		httpClient: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
+		config:   config,
+		provider: builtin,
+		streamer: streamer,
+		sources: &dataSourceProviders{
+			providers: make(map[uint]cachedProvider),
+		},
+		health: NewHealthTracker(HealthTrackerConfig{
+			Window:      time.Duration(config.HealthWindow) * time.Second,
+			MinSamples:  config.HealthMinSamples,
+			Threshold:   config.HealthThreshold,
+			SlowLatency: time.Duration(config.Timeout) * time.Second / 2,
+		}),
+		guards: NewProviderGuards(ProviderGuardConfig{
+			FailureThreshold: config.BreakerFailureThreshold,
+			OpenTimeout:      time.Duration(config.BreakerOpenTimeout) * time.Second,
+			RateLimit:        config.RateLimit,
+			RateBurst:        config.RateBurst,
+		}),
+		cache: &marketCache{
+			quotes: newExpiringCache[models.Quote](time.Duration(config.QuoteCacheTTL)*time.Second, maxStale),
+			infos:  newExpiringCache[models.InfoResponse](time.Duration(config.InfoCacheTTL)*time.Second, maxStale),
 		},
-		maxRetries: config.MaxRetries,
 	}
 }
 
-// GetQuote gets a real-time quote for a single symbol
-func (s *MarketService) GetQuote(symbol string) (*models.Quote, error) {
-	url := fmt.Sprintf("%s/api/market/quote/%s", s.baseURL, symbol)
-	var response ApiResponse[models.Quote]
+// Streamer returns the real-time tick source: the built-in provider when it streams
+// itself (demo), otherwise the market data service WebSocket. Nil if neither is available.
+func (s *MarketService) Streamer() provider.Streamer {
+	return s.streamer
+}
 
-	err := s.doRequest("GET", url, nil, &response)
-	if err != nil {
-		return nil, err
+// ForDataSource returns a market service that tries the given data source first.
+// A nil ID returns the default service.
+func (s *MarketService) ForDataSource(dataSourceID *uint) (*MarketService, error) {
+	if dataSourceID == nil {
+		return s, nil
 	}
 
-	if response.Code != 0 {
-		return nil, fmt.Errorf("market service error: %s", response.Message)
+	var source models.DataSource
+	if err := database.GetDB().First(&source, *dataSourceID).Error; err != nil {
+		return nil, fmt.Errorf("data source %d not found: %w", *dataSourceID, err)
+	}
+	if !source.Enabled {
+		return nil, fmt.Errorf("data source %d is disabled", source.ID)
 	}
 
-	return &response.Data, nil
+	bound := *s
+	bound.pinned = &source.ID
+	return &bound, nil
 }
 
-// GetQuotes gets quotes for multiple symbols
-func (s *MarketService) GetQuotes(symbols []string) (*models.QuotesResponse, error) {
-	url := fmt.Sprintf("%s/api/market/quotes", s.baseURL)
+// ForAssetClass returns a market service that uses the provider chain of the given asset class
+func (s *MarketService) ForAssetClass(assetClass models.AssetType) *MarketService {
+	bound := *s
+	bound.assetClass = assetClass
+	return &bound
+}
+
+// Health returns the rolling health scores of all providers that have been used
+func (s *MarketService) Health() []ProviderHealth {
+	return s.health.Snapshot()
+}
 
-	requestBody := models.QuotesRequest{
-		Symbols: symbols,
+// Breakers returns the circuit breaker state and remaining request quota of all providers that have been used
+func (s *MarketService) Breakers() []ProviderGuardStatus {
+	return s.guards.Snapshot()
+}
+
+// CacheStats returns the hit and miss counters of the quote and info caches
+func (s *MarketService) CacheStats() MarketCacheStats {
+	return MarketCacheStats{
+		Quotes: s.cache.quotes.stats(),
+		Info:   s.cache.infos.stats(),
 	}
+}
+
+// Calendar returns the exchange trading calendar
+func (s *MarketService) Calendar() *calendar.Calendar {
+	return s.config.Calendar
+}
 
-	var response ApiResponse[models.QuotesResponse]
-	err := s.doRequest("POST", url, requestBody, &response)
+// GetQuote gets a real-time quote for a single symbol.
+// Quotes are cached; when a refresh fails a stale quote is served with its age.
+// A quote fetched after a closed market settled is served without asking the provider.
+func (s *MarketService) GetQuote(ctx context.Context, symbol string) (*models.Quote, error) {
+	symbol = canonicalSymbol(symbol)
+	if quote, age, ok := s.cachedQuote(symbol); ok {
+		s.cache.quotes.hit()
+		markAge(&quote, age, false)
+		s.markPriceStatus(&quote)
+		return &quote, nil
+	}
+
+	quote, age, stale, err := s.cache.quotes.get(ctx, s.cacheKey(symbol), func(ctx context.Context) (models.Quote, error) {
+		q, err := s.fetchQuote(ctx, symbol)
+		if err != nil {
+			return models.Quote{}, err
+		}
+		return *q, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if response.Code != 0 {
-		return nil, fmt.Errorf("market service error: %s", response.Message)
-	}
+	markAge(&quote, age, stale)
+	s.markPriceStatus(&quote)
+	return &quote, nil
+}
 
-	return &response.Data, nil
+// fetchQuote gets a quote from the provider chain
+func (s *MarketService) fetchQuote(ctx context.Context, symbol string) (*models.Quote, error) {
+	var quote *models.Quote
+	err := s.execute(ctx, s.classOf(symbol), func(ctx context.Context, b marketBackend) error {
+		q, err := b.provider.GetQuote(ctx, symbol)
+		if err != nil {
+			return err
+		}
+		q.Provider = b.name
+		quote = q
+		return nil
+	})
+	return quote, err
 }
 
-// GetHistory gets historical price data
-func (s *MarketService) GetHistory(symbol, period, interval string) (*models.HistoryResponse, error) {
-	url := fmt.Sprintf("%s/api/market/history/%s?period=%s&interval=%s", s.baseURL, symbol, period, interval)
-	var response ApiResponse[models.HistoryResponse]
+// GetQuotes gets quotes for multiple symbols.
+// Cached quotes are served directly; the rest are fetched in one batch and
+// fall back to stale cached quotes when the refresh fails. Symbols already being
+// fetched by another call, single or batch, wait for that fetch instead.
+func (s *MarketService) GetQuotes(ctx context.Context, symbols []string) (*models.QuotesResponse, error) {
+	result := &models.QuotesResponse{
+		Quotes:        []models.Quote{},
+		FailedSymbols: []string{},
+	}
 
-	err := s.doRequest("GET", url, nil, &response)
-	if err != nil {
-		return nil, err
+	var missing []string
+	for _, raw := range symbols {
+		symbol := canonicalSymbol(raw)
+		if quote, age, ok := s.cachedQuote(symbol); ok {
+			s.cache.quotes.hit()
+			markAge(&quote, age, false)
+			result.Quotes = append(result.Quotes, quote)
+			continue
+		}
+		s.cache.quotes.miss()
+		missing = append(missing, symbol)
 	}
 
-	if response.Code != 0 {
-		return nil, fmt.Errorf("market service error: %s", response.Message)
+	if len(missing) > 0 {
+		keys := make([]string, len(missing))
+		symbolOf := make(map[string]string, len(missing))
+		for i, symbol := range missing {
+			keys[i] = s.cacheKey(symbol)
+			symbolOf[keys[i]] = symbol
+		}
+		fetched := s.cache.quotes.loadMany(ctx, keys, func(ctx context.Context, keys []string) (map[string]models.Quote, error) {
+			symbols := make([]string, len(keys))
+			for i, key := range keys {
+				symbols[i] = symbolOf[key]
+			}
+			resp, err := s.fetchQuotes(ctx, symbols)
+			if err != nil {
+				return nil, err
+			}
+			quotes := make(map[string]models.Quote, len(resp.Quotes))
+			for _, quote := range resp.Quotes {
+				quotes[s.cacheKey(quote.Symbol)] = quote
+			}
+			return quotes, nil
+		})
+
+		var fetchErr error
+		for _, symbol := range missing {
+			loaded := fetched[s.cacheKey(symbol)]
+			if loaded.found {
+				result.Quotes = append(result.Quotes, loaded.value)
+				continue
+			}
+			if loaded.err != nil {
+				fetchErr = loaded.err
+			}
+			if quote, age, ok := s.cache.quotes.stale(s.cacheKey(symbol)); ok {
+				markAge(&quote, age, true)
+				result.Quotes = append(result.Quotes, quote)
+				continue
+			}
+			result.FailedSymbols = append(result.FailedSymbols, symbol)
+		}
+
+		if len(result.Quotes) == 0 && fetchErr != nil {
+			return nil, fetchErr
+		}
 	}
 
-	return &response.Data, nil
+	for i := range result.Quotes {
+		s.markPriceStatus(&result.Quotes[i])
+	}
+	result.SuccessCount = len(result.Quotes)
+	return result, nil
 }
 
-// GetInfo gets basic information about a stock or crypto
-func (s *MarketService) GetInfo(symbol string) (*models.InfoResponse, error) {
-	url := fmt.Sprintf("%s/api/market/info/%s", s.baseURL, symbol)
-	var response ApiResponse[models.InfoResponse]
+// cachedQuote returns a cached quote that is still current: within its TTL,
+// or fetched after the symbol's market closed and settled
+func (s *MarketService) cachedQuote(sym string) (models.Quote, time.Duration, bool) {
+	quote, age, fresh, ok := s.cache.quotes.peek(s.cacheKey(sym))
+	if !ok {
+		return quote, 0, false
+	}
 
-	err := s.doRequest("GET", url, nil, &response)
-	if err != nil {
-		return nil, err
+	now := time.Now()
+	if fresh || s.config.Calendar.Settled(calendar.MarketOf(symbol.Parse(sym)), now.Add(-age), now) {
+		return quote, age, true
 	}
+	return quote, 0, false
+}
 
-	if response.Code != 0 {
-		return nil, fmt.Errorf("market service error: %s", response.Message)
+// markPriceStatus marks a quote as the last close once its market has closed for the day, otherwise
+// as live; during a midday break the latest session ended before the daily close, so it stays live
+func (s *MarketService) markPriceStatus(quote *models.Quote) {
+	cal := s.config.Calendar
+	market := calendar.MarketOf(symbol.Parse(quote.Symbol))
+	now := time.Now()
+	quote.PriceStatus = models.PriceStatusLive
+	if cal.IsOpen(market, now) {
+		return
+	}
+	if lastClose := cal.LastClose(market, now); !lastClose.IsZero() && !lastClose.Before(cal.LastSessionEnd(market, now)) {
+		quote.PriceStatus = models.PriceStatusLastClose
+	}
+}
+
+// fetchQuotes gets quotes for multiple symbols from the provider chains.
+// Symbols are grouped by asset class and each group goes through its own chain;
+// symbols a provider fails to quote fall through to the next provider in the chain.
+func (s *MarketService) fetchQuotes(ctx context.Context, symbols []string) (*models.QuotesResponse, error) {
+	result := &models.QuotesResponse{
+		Quotes:        []models.Quote{},
+		FailedSymbols: []string{},
+	}
+	if len(symbols) == 0 {
+		return result, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.callTimeout())
+	defer cancel()
+
+	groups := make(map[models.AssetType][]string)
+	var classes []models.AssetType
+	for _, symbol := range symbols {
+		class := s.classOf(symbol)
+		if _, ok := groups[class]; !ok {
+			classes = append(classes, class)
+		}
+		groups[class] = append(groups[class], symbol)
+	}
+
+	var lastErr error
+	for _, class := range classes {
+		quotes, remaining, err := s.fetchQuoteGroup(ctx, class, groups[class])
+		if err != nil {
+			lastErr = err
+		}
+		result.Quotes = append(result.Quotes, quotes...)
+		result.FailedSymbols = append(result.FailedSymbols, remaining...)
 	}
 
-	return &response.Data, nil
+	if len(result.Quotes) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("all market data providers failed: %w", lastErr)
+	}
+
+	result.SuccessCount = len(result.Quotes)
+	return result, nil
 }
 
-// Search searches for stocks or crypto
-func (s *MarketService) Search(query string, limit int) (*models.SearchResponse, error) {
-	url := fmt.Sprintf("%s/api/market/search?q=%s&limit=%d", s.baseURL, query, limit)
-	var response ApiResponse[models.SearchResponse]
+// fetchQuoteGroup quotes symbols of one asset class through its provider chain. Like execute,
+// the chain is retried with backoff while providers fail, for the symbols still unquoted; it is
+// not retried when every provider answered, since the symbols left are ones they do not list.
+// It returns the quotes, the symbols left unquoted and the last provider error.
+func (s *MarketService) fetchQuoteGroup(ctx context.Context, assetClass models.AssetType, symbols []string) ([]models.Quote, []string, error) {
+	var quotes []models.Quote
+	remaining := symbols
+	var lastErr error
+
+	for attempt := 0; attempt <= s.config.MaxRetries && len(remaining) > 0; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(attempt*attempt) * time.Second
+			logger.Debug(fmt.Sprintf("Retrying provider chain for %d quotes (attempt %d/%d) after %v", len(remaining), attempt, s.config.MaxRetries, backoff))
+			if err := sleepContext(ctx, backoff); err != nil {
+				return quotes, remaining, fmt.Errorf("request aborted after %d attempts: %w", attempt, err)
+			}
+		}
+
+		failed := 0
+		for _, b := range s.chain(assetClass) {
+			if len(remaining) == 0 {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return quotes, remaining, fmt.Errorf("request aborted: %w", err)
+			}
+
+			var resp *models.QuotesResponse
+			err := s.call(ctx, b, func(ctx context.Context) error {
+				var err error
+				resp, err = b.provider.GetQuotes(ctx, remaining)
+				return err
+			})
+			if err != nil {
+				lastErr = err
+				if IsMarketFastFail(err) {
+					logger.Debug(fmt.Sprintf("Provider %s skipped: %v", b.name, err))
+					continue
+				}
+				failed++
+				logger.Warn(fmt.Sprintf("Provider %s failed to get quotes (attempt %d/%d): %v", b.name, attempt+1, s.config.MaxRetries+1, err))
+				continue
+			}
+
+			quoted := make(map[string]bool, len(resp.Quotes))
+			for _, quote := range resp.Quotes {
+				if quote.Price == nil {
+					continue
+				}
+				quote.Provider = b.name
+				quotes = append(quotes, quote)
+				quoted[quote.Symbol] = true
+			}
+
+			var next []string
+			for _, symbol := range remaining {
+				if !quoted[symbol] {
+					next = append(next, symbol)
+				}
+			}
+			remaining = next
+		}
 
-	err := s.doRequest("GET", url, nil, &response)
+		// Every provider answered or is fast-failing; retrying would only delay the result
+		if failed == 0 {
+			break
+		}
+	}
+	return quotes, remaining, lastErr
+}
+
+// GetHistory gets historical price data
+func (s *MarketService) GetHistory(ctx context.Context, symbol, period, interval string) (*models.HistoryResponse, error) {
+	symbol = canonicalSymbol(symbol)
+	var history *models.HistoryResponse
+	err := s.execute(ctx, s.classOf(symbol), func(ctx context.Context, b marketBackend) error {
+		h, err := b.provider.GetHistory(ctx, symbol, period, interval)
+		if err != nil {
+			return err
+		}
+		history = h
+		return nil
+	})
+	return history, err
+}
+
+// GetInfo gets basic information about a stock or crypto.
+// Info is cached; when a refresh fails stale info is served with its age.
+func (s *MarketService) GetInfo(ctx context.Context, symbol string) (*models.InfoResponse, error) {
+	symbol = canonicalSymbol(symbol)
+	info, age, stale, err := s.cache.infos.get(ctx, s.cacheKey(symbol), func(ctx context.Context) (models.InfoResponse, error) {
+		i, err := s.fetchInfo(ctx, symbol)
+		if err != nil {
+			return models.InfoResponse{}, err
+		}
+		return *i, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if response.Code != 0 {
-		return nil, fmt.Errorf("market service error: %s", response.Message)
+	if stale {
+		info.Stale = true
+		ageSeconds := int64(age.Seconds())
+		info.AgeSeconds = &ageSeconds
 	}
+	return &info, nil
+}
+
+// fetchInfo gets info from the provider chain
+func (s *MarketService) fetchInfo(ctx context.Context, symbol string) (*models.InfoResponse, error) {
+	var info *models.InfoResponse
+	err := s.execute(ctx, s.classOf(symbol), func(ctx context.Context, b marketBackend) error {
+		i, err := b.provider.GetInfo(ctx, symbol)
+		if err != nil {
+			return err
+		}
+		info = i
+		return nil
+	})
+	return info, err
+}
+
+// GetActions gets the corporate actions (splits, symbol changes) of a stock
+func (s *MarketService) GetActions(ctx context.Context, symbol string) (*models.ActionsResponse, error) {
+	symbol = canonicalSymbol(symbol)
+	var actions *models.ActionsResponse
+	err := s.execute(ctx, s.classOf(symbol), func(ctx context.Context, b marketBackend) error {
+		a, err := b.provider.GetActions(ctx, symbol)
+		if err != nil {
+			return err
+		}
+		actions = a
+		return nil
+	})
+	return actions, err
+}
 
-	return &response.Data, nil
+// GetDividends gets the cash dividend history of a stock or fund
+func (s *MarketService) GetDividends(ctx context.Context, symbol string) (*models.DividendsResponse, error) {
+	symbol = canonicalSymbol(symbol)
+	var dividends *models.DividendsResponse
+	err := s.execute(ctx, s.classOf(symbol), func(ctx context.Context, b marketBackend) error {
+		d, err := b.provider.GetDividends(ctx, symbol)
+		if err != nil {
+			return err
+		}
+		dividends = d
+		return nil
+	})
+	return dividends, err
 }
 
-// doRequest performs an HTTP request with retry logic
-func (s *MarketService) doRequest(method, url string, body interface{}, result interface{}) error {
+// Search searches for stocks or crypto
+func (s *MarketService) Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error) {
+	var results *models.SearchResponse
+	err := s.execute(ctx, s.assetClass, func(ctx context.Context, b marketBackend) error {
+		r, err := b.provider.Search(ctx, query, limit)
+		if err != nil {
+			return err
+		}
+		results = r
+		return nil
+	})
+	return results, err
+}
+
+// execute runs a call against the provider chain, retrying the whole chain with backoff.
+// The whole call is bounded by CallTimeout and stops as soon as ctx is done.
+func (s *MarketService) execute(ctx context.Context, assetClass models.AssetType, fn func(ctx context.Context, b marketBackend) error) error {
+	ctx, cancel := context.WithTimeout(ctx, s.callTimeout())
+	defer cancel()
+
 	var lastErr error
 
-	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
 		if attempt > 0 {
 			// Exponential backoff
 			backoff := time.Duration(attempt*attempt) * time.Second
-			logger.Debug(fmt.Sprintf("Retrying request (attempt %d/%d) after %v", attempt, s.maxRetries, backoff))
-			time.Sleep(backoff)
+			logger.Debug(fmt.Sprintf("Retrying provider chain (attempt %d/%d) after %v", attempt, s.config.MaxRetries, backoff))
+			if err := sleepContext(ctx, backoff); err != nil {
+				return fmt.Errorf("request aborted after %d attempts: %w", attempt, err)
+			}
 		}
 
-		err := s.executeRequest(method, url, body, result)
-		if err == nil {
-			return nil
+		backends := s.chain(assetClass)
+		rejected := 0
+		for _, b := range backends {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("request aborted: %w", err)
+			}
+
+			err := s.call(ctx, b, func(ctx context.Context) error {
+				return fn(ctx, b)
+			})
+			if err == nil {
+				return nil
+			}
+
+			lastErr = err
+			if IsMarketFastFail(err) {
+				rejected++
+				logger.Debug(fmt.Sprintf("Provider %s skipped: %v", b.name, err))
+				continue
+			}
+			logger.Warn(fmt.Sprintf("Provider %s failed (attempt %d/%d): %v", b.name, attempt+1, s.config.MaxRetries+1, err))
 		}
 
-		lastErr = err
-		logger.Warn(fmt.Sprintf("Request failed (attempt %d/%d): %v", attempt+1, s.maxRetries+1, err))
+		// Every provider is fast-failing, backing off would only delay the error
+		if rejected == len(backends) && lastErr != nil {
+			return fmt.Errorf("market data unavailable: %w", lastErr)
+		}
 	}
 
-	return fmt.Errorf("request failed after %d attempts: %w", s.maxRetries+1, lastErr)
+	if lastErr == nil {
+		lastErr = errors.New("no market data provider available")
+	}
+	return fmt.Errorf("request failed after %d attempts: %w", s.config.MaxRetries+1, lastErr)
 }
 
-// executeRequest executes a single HTTP request
-func (s *MarketService) executeRequest(method, url string, body interface{}, result interface{}) error {
-	var reqBody io.Reader
+// call runs a single provider request with its own deadline and records its outcome.
+// Calls are rejected without reaching the provider when its breaker is open or its quota is spent.
+// Requests aborted because the caller went away are not held against the provider.
+func (s *MarketService) call(ctx context.Context, b marketBackend, fn func(ctx context.Context) error) error {
+	if err := s.guards.Acquire(b.key, b.name, b.rateLimit); err != nil {
+		return fmt.Errorf("%s: %w", b.name, err)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
 
-	if body != nil {
-		jsonData, err := json.Marshal(body)
+	start := time.Now()
+	err := fn(callCtx)
+	if err != nil && ctx.Err() != nil {
+		s.guards.Release(b.key)
+		return err
+	}
+	s.guards.Report(b.key, err)
+	s.health.Record(b.key, b.name, time.Since(start), err)
+	return err
+}
+
+// callTimeout returns the deadline for a whole market service call
+func (s *MarketService) callTimeout() time.Duration {
+	return time.Duration(s.config.CallTimeout) * time.Second
+}
+
+// sleepContext waits for d, returning early with the context error when ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// chain builds the ordered provider chain for an asset class.
+// Order: pinned data source, enabled data sources by priority, built-in provider.
+// Demoted providers are moved to the end.
+func (s *MarketService) chain(assetClass models.AssetType) []marketBackend {
+	var sources []models.DataSource
+	if err := database.GetDB().Where("enabled = ?", true).Order("priority ASC, id ASC").Find(&sources).Error; err != nil {
+		logger.Warn(fmt.Sprintf("Failed to load data sources, using built-in provider: %v", err))
+	}
+
+	backends := make(map[string]marketBackend)
+	var keys []string
+	add := func(b marketBackend) {
+		if _, exists := backends[b.key]; exists {
+			return
+		}
+		backends[b.key] = b
+		keys = append(keys, b.key)
+	}
+
+	// Pinned source first, regardless of asset class
+	for i := range sources {
+		if s.pinned != nil && sources[i].ID == *s.pinned {
+			if b, err := s.sourceBackend(&sources[i]); err == nil {
+				add(b)
+			}
+		}
+	}
+
+	for i := range sources {
+		if !sources[i].ServesAssetClass(assetClass) {
+			continue
+		}
+		b, err := s.sourceBackend(&sources[i])
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+			logger.Warn(fmt.Sprintf("Skipping data source %d: %v", sources[i].ID, err))
+			continue
 		}
-		reqBody = bytes.NewBuffer(jsonData)
+		add(b)
 	}
 
-	req, err := http.NewRequest(method, url, reqBody)
+	add(marketBackend{key: defaultBackendKey, name: s.provider.Name(), provider: s.provider})
+
+	ordered := s.health.Order(keys)
+	result := make([]marketBackend, len(ordered))
+	for i, key := range ordered {
+		result[i] = backends[key]
+	}
+	return result
+}
+
+// sourceBackend builds the chain entry for a data source
+func (s *MarketService) sourceBackend(source *models.DataSource) (marketBackend, error) {
+	p, err := s.sources.get(source, s.config)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return marketBackend{}, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return marketBackend{
+		key:       "datasource:" + strconv.FormatUint(uint64(source.ID), 10),
+		name:      source.Name,
+		provider:  p,
+		rateLimit: source.RateLimit,
+	}, nil
+}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+// cacheKey scopes a cache key to the pinned data source
+func (s *MarketService) cacheKey(key string) string {
+	if s.pinned == nil {
+		return defaultBackendKey + "|" + key
 	}
-	defer resp.Body.Close()
+	return strconv.FormatUint(uint64(*s.pinned), 10) + "|" + key
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+// markAge annotates a cached quote with its age and whether it is stale
+func markAge(quote *models.Quote, age time.Duration, stale bool) {
+	quote.Stale = stale
+	if age > 0 {
+		ageSeconds := int64(age.Seconds())
+		quote.AgeSeconds = &ageSeconds
+	}
+}
+
+// classOf returns the asset class used to pick the provider chain for a symbol
+func (s *MarketService) classOf(raw string) models.AssetType {
+	if s.assetClass != "" {
+		return s.assetClass
 	}
+	if symbol.Parse(raw).IsCrypto() {
+		return models.AssetTypeCrypto
+	}
+	return models.AssetTypeStock
+}
+
+// canonicalSymbol returns the canonical form of a symbol given in canonical or provider form
+func canonicalSymbol(raw string) string {
+	return symbol.Parse(raw).String()
+}
+
+// get returns the cached provider for a data source, rebuilding it when the record changed
+func (c *dataSourceProviders) get(source *models.DataSource, defaults MarketServiceConfig) (provider.MarketDataProvider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(respBody))
+	if cached, ok := c.providers[source.ID]; ok && cached.updatedAt.Equal(source.UpdatedAt) {
+		return cached.provider, nil
 	}
 
-	if err := json.Unmarshal(respBody, result); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %w", err)
+	baseURL := source.BaseURL
+	if baseURL == "" {
+		baseURL = defaults.BaseURL
+	}
+
+	p, err := provider.New(source.Provider, provider.Config{
+		BaseURL:     baseURL,
+		Credentials: source.Credentials,
+		Timeout:     defaults.Timeout,
+		Seed:        defaults.DemoSeed,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	c.providers[source.ID] = cachedProvider{updatedAt: source.UpdatedAt, provider: p}
+	return p, nil
 }
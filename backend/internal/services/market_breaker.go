@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BreakerState represents the state of a provider circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Calls pass through
+	BreakerOpen     BreakerState = "open"      // Calls fail fast until the open timeout expires
+	BreakerHalfOpen BreakerState = "half_open" // A single trial call decides whether to close or reopen
+)
+
+var (
+	// ErrCircuitOpen is returned when a provider's circuit breaker rejects a call
+	ErrCircuitOpen = errors.New("market data provider circuit is open")
+	// ErrRateLimited is returned when a provider has no outbound request quota left
+	ErrRateLimited = errors.New("market data provider rate limit exceeded")
+)
+
+// IsMarketFastFail reports whether a market call was rejected by a circuit breaker or rate limiter
+func IsMarketFastFail(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrRateLimited)
+}
+
+// ProviderGuardConfig holds configuration for provider circuit breakers and rate limiters
+type ProviderGuardConfig struct {
+	FailureThreshold int           // Consecutive failures that open the breaker
+	OpenTimeout      time.Duration // How long the breaker stays open before a trial call
+	RateLimit        float64       // Default requests per minute for each provider
+	RateBurst        int           // Requests that may be made at once before the rate applies
+}
+
+// ProviderGuards holds a circuit breaker and a token bucket for each market data provider
+type ProviderGuards struct {
+	mu     sync.Mutex
+	config ProviderGuardConfig
+	guards map[string]*providerGuard
+}
+
+type providerGuard struct {
+	name string
+
+	// Circuit breaker
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool // A half-open trial call is in flight
+
+	// Token bucket
+	rateLimit  float64 // Requests per minute
+	tokens     float64
+	refilledAt time.Time
+}
+
+// ProviderGuardStatus represents the breaker state and remaining quota of a provider
+type ProviderGuardStatus struct {
+	Key                 string       `json:"key"`
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"` // When an open breaker lets a trial call through
+	RateLimit           float64      `json:"rate_limit"`         // Requests per minute
+	Burst               int          `json:"burst"`
+	TokensRemaining     float64      `json:"tokens_remaining"`
+}
+
+// NewProviderGuards creates circuit breakers and rate limiters with the given configuration
+func NewProviderGuards(config ProviderGuardConfig) *ProviderGuards {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.RateLimit <= 0 {
+		config.RateLimit = 120
+	}
+	if config.RateBurst <= 0 {
+		config.RateBurst = 10
+	}
+
+	return &ProviderGuards{
+		config: config,
+		guards: make(map[string]*providerGuard),
+	}
+}
+
+// Acquire asks permission for one call to a provider.
+// rateLimit overrides the default requests per minute when positive.
+func (g *ProviderGuards) Acquire(key, name string, rateLimit float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	guard := g.guard(key, name, now)
+	if rateLimit <= 0 {
+		rateLimit = g.config.RateLimit
+	}
+	guard.rateLimit = rateLimit
+
+	switch guard.state {
+	case BreakerOpen:
+		if now.Sub(guard.openedAt) < g.config.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		guard.state = BreakerHalfOpen
+		guard.trial = false
+	case BreakerHalfOpen:
+		if guard.trial {
+			return ErrCircuitOpen
+		}
+	}
+
+	g.refill(guard, now)
+	if guard.tokens < 1 {
+		return ErrRateLimited
+	}
+	guard.tokens--
+
+	if guard.state == BreakerHalfOpen {
+		guard.trial = true
+	}
+	return nil
+}
+
+// Report records the outcome of a call allowed by Acquire
+func (g *ProviderGuards) Report(key string, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	guard, ok := g.guards[key]
+	if !ok {
+		return
+	}
+
+	if err == nil {
+		guard.state = BreakerClosed
+		guard.failures = 0
+		guard.trial = false
+		return
+	}
+
+	guard.failures++
+	if guard.state == BreakerHalfOpen || guard.failures >= g.config.FailureThreshold {
+		guard.state = BreakerOpen
+		guard.openedAt = time.Now()
+		guard.trial = false
+	}
+}
+
+// Release gives up a call allowed by Acquire without counting its outcome,
+// e.g. when the caller went away before the provider answered
+func (g *ProviderGuards) Release(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if guard, ok := g.guards[key]; ok {
+		guard.trial = false
+	}
+}
+
+// Snapshot returns the breaker state and remaining quota of all known providers
+func (g *ProviderGuards) Snapshot() []ProviderGuardStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	result := make([]ProviderGuardStatus, 0, len(g.guards))
+	for key, guard := range g.guards {
+		g.refill(guard, now)
+
+		status := ProviderGuardStatus{
+			Key:                 key,
+			Name:                guard.name,
+			State:               guard.state,
+			ConsecutiveFailures: guard.failures,
+			RateLimit:           guard.rateLimit,
+			Burst:               g.config.RateBurst,
+			TokensRemaining:     guard.tokens,
+		}
+		if guard.state == BreakerOpen {
+			openedAt := guard.openedAt
+			retryAt := openedAt.Add(g.config.OpenTimeout)
+			status.OpenedAt = &openedAt
+			status.RetryAt = &retryAt
+		}
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
+
+// guard returns the guard of a provider, creating it with a full bucket (caller must hold the lock)
+func (g *ProviderGuards) guard(key, name string, now time.Time) *providerGuard {
+	guard, ok := g.guards[key]
+	if !ok {
+		guard = &providerGuard{
+			state:      BreakerClosed,
+			rateLimit:  g.config.RateLimit,
+			tokens:     float64(g.config.RateBurst),
+			refilledAt: now,
+		}
+		g.guards[key] = guard
+	}
+	guard.name = name
+	return guard
+}
+
+// refill adds the tokens earned since the last refill (caller must hold the lock)
+func (g *ProviderGuards) refill(guard *providerGuard, now time.Time) {
+	elapsed := now.Sub(guard.refilledAt)
+	guard.refilledAt = now
+
+	guard.tokens += elapsed.Minutes() * guard.rateLimit
+	if burst := float64(g.config.RateBurst); guard.tokens > burst {
+		guard.tokens = burst
+	}
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errNotLoaded is returned for a key that a shared batch load finished without a value for
+var errNotLoaded = errors.New("no value loaded for key")
+
+// CacheStats represents hit and miss counters of a cache
+type CacheStats struct {
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	StaleHits  int64   `json:"stale_hits"` // Stale entries served because a refresh failed
+	Entries    int     `json:"entries"`
+	TTLSeconds float64 `json:"ttl_seconds"`
+}
+
+// expiringCache is an in-process cache with a TTL and a stale fallback window.
+// Concurrent loads of the same key are coalesced into one call.
+type expiringCache[T any] struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	maxStale time.Duration // How long past the TTL an entry may still be served when a refresh fails
+	entries  map[string]cacheEntry[T]
+	flights  flightGroup[T]
+
+	hits      int64
+	misses    int64
+	staleHits int64
+}
+
+type cacheEntry[T any] struct {
+	value     T
+	fetchedAt time.Time
+}
+
+func newExpiringCache[T any](ttl, maxStale time.Duration) *expiringCache[T] {
+	return &expiringCache[T]{
+		ttl:      ttl,
+		maxStale: maxStale,
+		entries:  make(map[string]cacheEntry[T]),
+	}
+}
+
+// get returns a cached value, loading it when missing or expired.
+// When the load fails and a stale entry exists, the stale entry is returned with stale=true.
+// Concurrent loads of a key share one call, run on a context detached from the callers so
+// that a caller going away does not fail the others; a caller whose ctx is done stops waiting.
+func (c *expiringCache[T]) get(ctx context.Context, key string, load func(ctx context.Context) (T, error)) (value T, age time.Duration, stale bool, err error) {
+	if v, age, fresh, ok := c.peek(key); ok && fresh {
+		c.hit()
+		return v, age, false, nil
+	}
+	c.miss()
+
+	v, err := c.flights.do(ctx, key, func(ctx context.Context) (T, error) {
+		v, err := load(ctx)
+		if err == nil {
+			c.put(key, v)
+		}
+		return v, err
+	})
+	if err == nil {
+		return v, 0, false, nil
+	}
+
+	if v, age, ok := c.stale(key); ok {
+		return v, age, true, nil
+	}
+
+	var zero T
+	return zero, 0, false, err
+}
+
+// loadMany loads several keys at once, sharing the loads of keys already in flight, including
+// those started by get. load is called with the keys no one is loading yet and returns the
+// values it found, which are cached; keys it leaves out are reported as not found.
+func (c *expiringCache[T]) loadMany(ctx context.Context, keys []string, load func(ctx context.Context, keys []string) (map[string]T, error)) map[string]flightResult[T] {
+	return c.flights.doMany(ctx, keys, func(ctx context.Context, keys []string) (map[string]T, error) {
+		values, err := load(ctx, keys)
+		for key, v := range values {
+			c.put(key, v)
+		}
+		return values, err
+	})
+}
+
+// peek returns a cached value without loading it
+func (c *expiringCache[T]) peek(key string) (value T, age time.Duration, fresh bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return value, 0, false, false
+	}
+
+	age = time.Since(entry.fetchedAt)
+	return entry.value, age, age < c.ttl, true
+}
+
+// stale returns an expired entry that is still within the stale window and counts it as a stale hit
+func (c *expiringCache[T]) stale(key string) (value T, age time.Duration, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return value, 0, false
+	}
+
+	age = time.Since(entry.fetchedAt)
+	if age > c.ttl+c.maxStale {
+		delete(c.entries, key)
+		return value, 0, false
+	}
+
+	c.staleHits++
+	return entry.value, age, true
+}
+
+// put stores a freshly loaded value
+func (c *expiringCache[T]) put(key string, value T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry[T]{value: value, fetchedAt: time.Now()}
+}
+
+// hit counts a cache hit
+func (c *expiringCache[T]) hit() {
+	c.mu.Lock()
+	c.hits++
+	c.mu.Unlock()
+}
+
+// miss counts a cache miss
+func (c *expiringCache[T]) miss() {
+	c.mu.Lock()
+	c.misses++
+	c.mu.Unlock()
+}
+
+// stats returns the cache counters
+func (c *expiringCache[T]) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:       c.hits,
+		Misses:     c.misses,
+		StaleHits:  c.staleHits,
+		Entries:    len(c.entries),
+		TTLSeconds: c.ttl.Seconds(),
+	}
+}
+
+// flightGroup coalesces concurrent calls with the same key into one
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[T]
+}
+
+type flightCall[T any] struct {
+	done   chan struct{}
+	result flightResult[T]
+}
+
+// flightResult is the outcome of a call for one key
+type flightResult[T any] struct {
+	value T
+	found bool // The call returned a value for the key
+	err   error
+}
+
+// do runs fn once per key at a time; callers arriving while it runs share its result.
+// fn runs on a context detached from the callers' cancellation, so it is not aborted when
+// the caller that started it goes away. Callers return early with the context error when
+// their ctx is done. A key left out by a batch call it joined fails with errNotLoaded.
+func (g *flightGroup[T]) do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	result := g.doMany(ctx, []string{key}, func(ctx context.Context, keys []string) (map[string]T, error) {
+		v, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]T{key: v}, nil
+	})[key]
+	if !result.found && result.err == nil {
+		result.err = errNotLoaded
+	}
+	return result.value, result.err
+}
+
+// doMany runs fn once for the keys without a call in flight and waits for the calls of all
+// keys, so callers whose keys overlap share the loads of the keys they have in common. fn
+// returns the values it found; keys it leaves out get its error, or none with found=false.
+// Like do, fn runs detached from the callers' cancellation.
+func (g *flightGroup[T]) doMany(ctx context.Context, keys []string, fn func(ctx context.Context, keys []string) (map[string]T, error)) map[string]flightResult[T] {
+	calls := make(map[string]*flightCall[T], len(keys))
+	owned := make(map[string]*flightCall[T])
+	var load []string
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[T])
+	}
+	for _, key := range keys {
+		if _, ok := calls[key]; ok {
+			continue
+		}
+		call, ok := g.calls[key]
+		if !ok {
+			call = &flightCall[T]{done: make(chan struct{})}
+			g.calls[key] = call
+			owned[key] = call
+			load = append(load, key)
+		}
+		calls[key] = call
+	}
+	g.mu.Unlock()
+
+	if len(load) > 0 {
+		go func(ctx context.Context) {
+			values, err := fn(ctx, load)
+			g.mu.Lock()
+			for key, call := range owned {
+				v, found := values[key]
+				call.result = flightResult[T]{value: v, found: found}
+				if !found {
+					call.result.err = err
+				}
+				delete(g.calls, key)
+				close(call.done)
+			}
+			g.mu.Unlock()
+		}(context.WithoutCancel(ctx))
+	}
+
+	results := make(map[string]flightResult[T], len(calls))
+	for key, call := range calls {
+		select {
+		case <-call.done:
+			results[key] = call.result
+		case <-ctx.Done():
+			results[key] = flightResult[T]{err: ctx.Err()}
+		}
+	}
+	return results
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthTrackerConfig holds configuration for provider health scoring
+type HealthTrackerConfig struct {
+	Window      time.Duration // Rolling window for samples
+	MinSamples  int           // Samples required before a provider can be demoted
+	Threshold   float64       // Providers scoring below this are demoted
+	SlowLatency time.Duration // Latency at which the latency penalty is maxed out
+}
+
+// HealthTracker keeps a rolling health score for each market data provider
+type HealthTracker struct {
+	mu     sync.Mutex
+	config HealthTrackerConfig
+	stats  map[string]*providerStats
+}
+
+type providerStats struct {
+	name          string
+	samples       []healthSample
+	lastError     string
+	lastErrorAt   *time.Time
+	lastSuccessAt *time.Time
+}
+
+type healthSample struct {
+	at      time.Time
+	ok      bool
+	latency time.Duration
+}
+
+// ProviderHealth represents the health of a single provider
+type ProviderHealth struct {
+	Key           string     `json:"key"`
+	Name          string     `json:"name"`
+	Score         float64    `json:"score"`
+	ErrorRate     float64    `json:"error_rate"`
+	AvgLatencyMs  int64      `json:"avg_latency_ms"`
+	Samples       int        `json:"samples"`
+	Demoted       bool       `json:"demoted"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+}
+
+// NewHealthTracker creates a new provider health tracker
+func NewHealthTracker(config HealthTrackerConfig) *HealthTracker {
+	if config.Window <= 0 {
+		config.Window = 5 * time.Minute
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = 3
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = 0.5
+	}
+	if config.SlowLatency <= 0 {
+		config.SlowLatency = 10 * time.Second
+	}
+
+	return &HealthTracker{
+		config: config,
+		stats:  make(map[string]*providerStats),
+	}
+}
+
+// Record records the outcome of a single provider call
+func (h *HealthTracker) Record(key, name string, latency time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats, ok := h.stats[key]
+	if !ok {
+		stats = &providerStats{}
+		h.stats[key] = stats
+	}
+	stats.name = name
+
+	now := time.Now()
+	stats.samples = append(stats.samples, healthSample{at: now, ok: err == nil, latency: latency})
+	h.prune(stats, now)
+
+	if err != nil {
+		stats.lastError = err.Error()
+		stats.lastErrorAt = &now
+	} else {
+		stats.lastSuccessAt = &now
+	}
+}
+
+// Order returns the keys with demoted providers moved to the end, keeping the configured order otherwise
+func (h *HealthTracker) Order(keys []string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	healths := make(map[string]ProviderHealth, len(keys))
+	for _, key := range keys {
+		healths[key] = h.health(key)
+	}
+
+	ordered := make([]string, len(keys))
+	copy(ordered, keys)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := healths[ordered[i]], healths[ordered[j]]
+		if a.Demoted != b.Demoted {
+			return !a.Demoted
+		}
+		if a.Demoted {
+			return a.Score > b.Score
+		}
+		return false
+	})
+
+	return ordered
+}
+
+// Snapshot returns the health of all known providers
+func (h *HealthTracker) Snapshot() []ProviderHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]ProviderHealth, 0, len(h.stats))
+	for key := range h.stats {
+		result = append(result, h.health(key))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
+
+// health computes the health of a provider (caller must hold the lock)
+func (h *HealthTracker) health(key string) ProviderHealth {
+	stats, ok := h.stats[key]
+	if !ok {
+		return ProviderHealth{Key: key, Score: 1}
+	}
+	h.prune(stats, time.Now())
+
+	health := ProviderHealth{
+		Key:           key,
+		Name:          stats.name,
+		Score:         1,
+		Samples:       len(stats.samples),
+		LastError:     stats.lastError,
+		LastErrorAt:   stats.lastErrorAt,
+		LastSuccessAt: stats.lastSuccessAt,
+	}
+
+	if len(stats.samples) == 0 {
+		return health
+	}
+
+	var failures int
+	var totalLatency time.Duration
+	for _, sample := range stats.samples {
+		if !sample.ok {
+			failures++
+		}
+		totalLatency += sample.latency
+	}
+
+	avgLatency := totalLatency / time.Duration(len(stats.samples))
+	health.ErrorRate = float64(failures) / float64(len(stats.samples))
+	health.AvgLatencyMs = avgLatency.Milliseconds()
+
+	// Score is the success rate, reduced by up to half for slow providers
+	latencyPenalty := float64(avgLatency) / float64(h.config.SlowLatency)
+	if latencyPenalty > 1 {
+		latencyPenalty = 1
+	}
+	health.Score = (1 - health.ErrorRate) * (1 - 0.5*latencyPenalty)
+	health.Demoted = len(stats.samples) >= h.config.MinSamples && health.Score < h.config.Threshold
+
+	return health
+}
+
+// prune drops samples that fell out of the rolling window
+func (h *HealthTracker) prune(stats *providerStats, now time.Time) {
+	cutoff := now.Add(-h.config.Window)
+	i := 0
+	for i < len(stats.samples) && stats.samples[i].at.Before(cutoff) {
+		i++
+	}
+	stats.samples = stats.samples[i:]
+}
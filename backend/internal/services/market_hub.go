@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+	"trackmymoney/pkg/logger"
+)
+
+// ErrMarketStreamUnavailable is returned when no real-time tick source is configured
+var ErrMarketStreamUnavailable = errors.New("real-time market stream is not available")
+
+const (
+	hubMinBackoff = time.Second
+	hubMaxBackoff = 30 * time.Second
+)
+
+// MarketSubscriber receives ticks from the market hub.
+// Deliver is called from the upstream goroutine and must not block.
+type MarketSubscriber interface {
+	Deliver(tick models.MarketTick)
+}
+
+// MarketHub keeps one upstream stream per symbol and fans its ticks out to any
+// number of subscribers. A symbol's stream starts with its first subscriber,
+// reconnects with backoff when it drops and stops with its last subscriber.
+type MarketHub struct {
+	market *MarketService
+
+	mu     sync.Mutex
+	topics map[string]*hubTopic
+}
+
+// hubTopic is the upstream stream of a single symbol
+type hubTopic struct {
+	symbol      string
+	subscribers map[MarketSubscriber]struct{}
+	cancel      context.CancelFunc
+	last        *models.MarketTick
+	streaming   bool // Ticks received since the last (re)connect
+	reconnects  int
+	lastError   string
+}
+
+// MarketHubStats represents the upstream streams held by the market hub
+type MarketHubStats struct {
+	Topics      []MarketTopicStats `json:"topics"`
+	Subscribers int                `json:"subscribers"` // Distinct subscribers across all topics
+}
+
+// MarketTopicStats represents the upstream stream of a single symbol
+type MarketTopicStats struct {
+	Symbol      string `json:"symbol"`
+	Subscribers int    `json:"subscribers"`
+	Streaming   bool   `json:"streaming"`
+	Reconnects  int    `json:"reconnects"`
+	LastTick    *int64 `json:"last_tick,omitempty"` // Unix timestamp in milliseconds
+	LastError   string `json:"last_error,omitempty"`
+}
+
+// NewMarketHub creates a market hub streaming from the market service's tick source
+func NewMarketHub(market *MarketService) *MarketHub {
+	return &MarketHub{
+		market: market,
+		topics: make(map[string]*hubTopic),
+	}
+}
+
+// Subscribe adds a subscriber to the given symbols and returns their canonical forms.
+// The last tick of a symbol that is already streaming is delivered right away.
+func (h *MarketHub) Subscribe(sub MarketSubscriber, symbols ...string) ([]string, error) {
+	if h.market.Streamer() == nil {
+		return nil, ErrMarketStreamUnavailable
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscribed := make([]string, 0, len(symbols))
+	for _, raw := range symbols {
+		if raw == "" {
+			continue
+		}
+		sym := symbol.Parse(raw).String()
+		subscribed = append(subscribed, sym)
+
+		topic, ok := h.topics[sym]
+		if !ok {
+			topic = h.startTopic(sym)
+		}
+		if _, ok := topic.subscribers[sub]; ok {
+			continue
+		}
+		topic.subscribers[sub] = struct{}{}
+		if topic.last != nil {
+			sub.Deliver(*topic.last)
+		}
+	}
+
+	return subscribed, nil
+}
+
+// Unsubscribe removes a subscriber from the given symbols and returns their canonical forms.
+// A symbol's upstream stream stops when its last subscriber leaves.
+func (h *MarketHub) Unsubscribe(sub MarketSubscriber, symbols ...string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	unsubscribed := make([]string, 0, len(symbols))
+	for _, raw := range symbols {
+		if raw == "" {
+			continue
+		}
+		sym := symbol.Parse(raw).String()
+		unsubscribed = append(unsubscribed, sym)
+		h.leave(sub, sym)
+	}
+
+	return unsubscribed
+}
+
+// UnsubscribeAll removes a subscriber from every symbol, e.g. when its connection closes
+func (h *MarketHub) UnsubscribeAll(sub MarketSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sym := range h.topics {
+		h.leave(sub, sym)
+	}
+}
+
+// Stats returns the upstream streams currently held, sorted by symbol
+func (h *MarketHub) Stats() MarketHubStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscribers := make(map[MarketSubscriber]struct{})
+	stats := MarketHubStats{Topics: make([]MarketTopicStats, 0, len(h.topics))}
+	for _, topic := range h.topics {
+		topicStats := MarketTopicStats{
+			Symbol:      topic.symbol,
+			Subscribers: len(topic.subscribers),
+			Streaming:   topic.streaming,
+			Reconnects:  topic.reconnects,
+			LastError:   topic.lastError,
+		}
+		if topic.last != nil {
+			ts := topic.last.Timestamp
+			topicStats.LastTick = &ts
+		}
+		stats.Topics = append(stats.Topics, topicStats)
+		for sub := range topic.subscribers {
+			subscribers[sub] = struct{}{}
+		}
+	}
+	stats.Subscribers = len(subscribers)
+
+	sort.Slice(stats.Topics, func(i, j int) bool {
+		return stats.Topics[i].Symbol < stats.Topics[j].Symbol
+	})
+	return stats
+}
+
+// startTopic registers a topic and starts its upstream stream. Must be called with h.mu held.
+func (h *MarketHub) startTopic(sym string) *hubTopic {
+	ctx, cancel := context.WithCancel(context.Background())
+	topic := &hubTopic{
+		symbol:      sym,
+		subscribers: make(map[MarketSubscriber]struct{}),
+		cancel:      cancel,
+	}
+	h.topics[sym] = topic
+
+	go h.run(ctx, topic)
+	logger.Info("Market hub stream started", zap.String("symbol", sym))
+	return topic
+}
+
+// leave removes a subscriber from a topic, stopping the topic when it was the last one.
+// Must be called with h.mu held.
+func (h *MarketHub) leave(sub MarketSubscriber, sym string) {
+	topic, ok := h.topics[sym]
+	if !ok {
+		return
+	}
+	delete(topic.subscribers, sub)
+	if len(topic.subscribers) > 0 {
+		return
+	}
+
+	topic.cancel()
+	delete(h.topics, sym)
+	logger.Info("Market hub stream stopped", zap.String("symbol", sym))
+}
+
+// run keeps a topic's upstream stream open until its context is cancelled.
+// Each reconnect opens a new upstream subscription for the symbol.
+func (h *MarketHub) run(ctx context.Context, topic *hubTopic) {
+	streamer := h.market.Streamer()
+	backoff := hubMinBackoff
+
+	for {
+		received := false
+		err := streamer.Stream(ctx, topic.symbol, func(tick models.MarketTick) error {
+			received = true
+			h.publish(topic, tick)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			backoff = hubMinBackoff
+		}
+
+		h.mu.Lock()
+		topic.streaming = false
+		topic.reconnects++
+		if err != nil {
+			topic.lastError = err.Error()
+		}
+		h.mu.Unlock()
+
+		logger.Warn("Market hub stream dropped, reconnecting",
+			zap.String("symbol", topic.symbol),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > hubMaxBackoff {
+			backoff = hubMaxBackoff
+		}
+	}
+}
+
+// publish records a topic's latest tick and delivers it to the topic's subscribers
+func (h *MarketHub) publish(topic *hubTopic, tick models.MarketTick) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// A stopped topic may still be unwinding its stream
+	if h.topics[topic.symbol] != topic {
+		return
+	}
+
+	topic.last = &tick
+	topic.streaming = true
+	topic.lastError = ""
+	for sub := range topic.subscribers {
+		sub.Deliver(tick)
+	}
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+	"trackmymoney/pkg/logger"
+)
+
+// defaultMaturityNoticeDays is how far ahead maturities are reported when not configured
+const defaultMaturityNoticeDays = 30
+
+// ErrInvalidMaturityPolicy is returned when a maturity policy is incomplete or inconsistent
+var ErrInvalidMaturityPolicy = errors.New("invalid maturity policy")
+
+// MaturityService applies the maturity policies of deposits and bonds and records what it did
+type MaturityService struct {
+	db         *gorm.DB
+	noticeDays int
+}
+
+// MaturingAsset is an interest-bearing asset that matures soon or has matured
+type MaturingAsset struct {
+	Asset          models.InterestBearingAsset `json:"asset"`
+	Accrual        InterestAccrual             `json:"accrual"`
+	DaysToMaturity int                         `json:"days_to_maturity"` // Negative once matured
+}
+
+// NewMaturityService creates a new maturity service
+func NewMaturityService(db *gorm.DB, noticeDays int) *MaturityService {
+	if noticeDays <= 0 {
+		noticeDays = defaultMaturityNoticeDays
+	}
+	return &MaturityService{
+		db:         db,
+		noticeDays: noticeDays,
+	}
+}
+
+// Maturing returns the assets maturing within the given number of days, including those that
+// matured and are still held, soonest first. A non-positive days uses the configured notice period.
+func (s *MaturityService) Maturing(days int, now time.Time) ([]MaturingAsset, error) {
+	if days <= 0 {
+		days = s.noticeDays
+	}
+	today := truncateDate(now)
+	horizon := today.AddDate(0, 0, days)
+
+	assets, err := s.withMaturity()
+	if err != nil {
+		return nil, err
+	}
+
+	maturing := []MaturingAsset{}
+	for i := range assets {
+		maturity := truncateDate(*assets[i].MaturityDate)
+		if maturity.After(horizon) {
+			continue
+		}
+		maturing = append(maturing, MaturingAsset{
+			Asset:          assets[i],
+			Accrual:        AccrueInterest(&assets[i], now),
+			DaysToMaturity: int(maturity.Sub(today).Hours() / 24),
+		})
+	}
+	sort.SliceStable(maturing, func(i, j int) bool {
+		return maturing[i].DaysToMaturity < maturing[j].DaysToMaturity
+	})
+	return maturing, nil
+}
+
+// Events returns the recorded maturity events, newest first, optionally of a single asset
+func (s *MaturityService) Events(assetID uint) ([]models.MaturityEvent, error) {
+	query := s.db.Order("maturity_date DESC, id DESC")
+	if assetID != 0 {
+		query = query.Where("asset_id = ?", assetID)
+	}
+
+	var events []models.MaturityEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve maturity events: %w", err)
+	}
+	return events, nil
+}
+
+// Process applies the maturity policy of every asset that has matured by now and returns
+// the events it recorded. Assets are processed independently; a failure is logged and skipped.
+// A policy that could not be applied is retried on every run, so a matured asset whose payout
+// cash asset was re-pointed after being flagged is paid out without closing it by hand.
+func (s *MaturityService) Process(now time.Time) ([]models.MaturityEvent, error) {
+	today := truncateDate(now)
+
+	assets, err := s.withMaturity()
+	if err != nil {
+		return nil, err
+	}
+
+	events := []models.MaturityEvent{}
+	for i := range assets {
+		asset := &assets[i]
+		if truncateDate(*asset.MaturityDate).After(today) {
+			continue
+		}
+
+		var recorded []models.MaturityEvent
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			recorded, err = s.mature(tx, asset, today)
+			return err
+		})
+		if err != nil {
+			logger.Error("Failed to process maturity", zap.Uint("asset_id", asset.ID), zap.Error(err))
+			continue
+		}
+		events = append(events, recorded...)
+	}
+	return events, nil
+}
+
+// Notices records a notice for each asset entering its notice period and returns the new notices.
+// Each maturity date is noticed once.
+func (s *MaturityService) Notices(now time.Time) ([]models.MaturityEvent, error) {
+	maturing, err := s.Maturing(s.noticeDays, now)
+	if err != nil {
+		return nil, err
+	}
+
+	notices := []models.MaturityEvent{}
+	for _, item := range maturing {
+		if item.DaysToMaturity <= 0 {
+			continue
+		}
+		asset := &item.Asset
+		maturity := truncateDate(*asset.MaturityDate)
+
+		recorded, err := s.recorded(s.db, asset.ID, maturity, models.MaturityActionNotice)
+		if err != nil {
+			return nil, err
+		}
+		if recorded {
+			continue
+		}
+
+		notice := maturityEvent(asset, maturity, models.MaturityActionNotice)
+		notice.Interest = roundCents(*item.Accrual.ValueAtMaturity - asset.Amount)
+		notice.Note = fmt.Sprintf("Matures in %d days, policy %s", item.DaysToMaturity, policyOf(asset))
+		if err := s.db.Create(&notice).Error; err != nil {
+			return nil, fmt.Errorf("failed to record maturity notice: %w", err)
+		}
+		notices = append(notices, notice)
+	}
+	return notices, nil
+}
+
+// NormalizeMaturityPolicy validates the maturity policy of an interest-bearing asset and fills in defaults
+func NormalizeMaturityPolicy(db *gorm.DB, asset *models.InterestBearingAsset) error {
+	switch asset.MaturityPolicy {
+	case "":
+		asset.MaturityPolicy = models.MaturityFlag
+	case models.MaturityFlag, models.MaturityRoll:
+	case models.MaturityCash:
+		if asset.PayoutCashAssetID == nil {
+			return fmt.Errorf("%w: the cash policy requires payout_cash_asset_id", ErrInvalidMaturityPolicy)
+		}
+		var cash models.CashAsset
+		if err := db.First(&cash, *asset.PayoutCashAssetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: cash asset %d not found", ErrInvalidMaturityPolicy, *asset.PayoutCashAssetID)
+			}
+			return fmt.Errorf("failed to look up cash asset: %w", err)
+		}
+		if normalizeCurrency(cash.Currency) != normalizeCurrency(asset.Currency) {
+			return fmt.Errorf("%w: cash asset %d is in %s, not %s", ErrInvalidMaturityPolicy, cash.ID, cash.Currency, asset.Currency)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported policy %q", ErrInvalidMaturityPolicy, asset.MaturityPolicy)
+	}
+
+	if asset.RollRate != nil && *asset.RollRate < 0 {
+		return fmt.Errorf("%w: roll rate must not be negative", ErrInvalidMaturityPolicy)
+	}
+	return nil
+}
+
+// withMaturity loads the interest-bearing assets that have a maturity date
+func (s *MaturityService) withMaturity() ([]models.InterestBearingAsset, error) {
+	var assets []models.InterestBearingAsset
+	if err := s.db.Where("maturity_date IS NOT NULL").Find(&assets).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve interest-bearing assets: %w", err)
+	}
+	return assets, nil
+}
+
+// mature applies the asset's policy to its matured terms
+func (s *MaturityService) mature(tx *gorm.DB, asset *models.InterestBearingAsset, today time.Time) ([]models.MaturityEvent, error) {
+	switch asset.MaturityPolicy {
+	case models.MaturityRoll:
+		// Roll every term that has ended, in case the job has not run for a while
+		var events []models.MaturityEvent
+		for asset.MaturityDate != nil && !truncateDate(*asset.MaturityDate).After(today) {
+			event, err := s.roll(tx, asset)
+			if err != nil || event == nil {
+				return events, err
+			}
+			events = append(events, *event)
+			if event.Action != models.MaturityActionRolled {
+				break
+			}
+		}
+		return events, nil
+
+	case models.MaturityCash:
+		event, err := s.payOut(tx, asset)
+		if err != nil || event == nil {
+			return nil, err
+		}
+		return []models.MaturityEvent{*event}, nil
+
+	default:
+		event, err := s.flagOnce(tx, asset, "")
+		if err != nil || event == nil {
+			return nil, err
+		}
+		return []models.MaturityEvent{*event}, nil
+	}
+}
+
+// roll renews principal plus interest for the same term at the roll rate
+func (s *MaturityService) roll(tx *gorm.DB, asset *models.InterestBearingAsset) (*models.MaturityEvent, error) {
+	maturity := truncateDate(*asset.MaturityDate)
+	next, ok := renewalDate(asset.StartDate, maturity)
+	if !ok {
+		return s.flagOnce(tx, asset, "Term too short to roll")
+	}
+
+	event := maturityEvent(asset, maturity, models.MaturityActionRolled)
+	event.Interest = roundCents(AccrueInterest(asset, maturity).AccruedInterest)
+	if err := recordMaturityInterest(tx, asset, maturity, event.Interest); err != nil {
+		return nil, err
+	}
+
+	rate := asset.InterestRate
+	if asset.RollRate != nil {
+		rate = *asset.RollRate
+	}
+	asset.Amount = roundCents(asset.Amount + event.Interest)
+	asset.InterestRate = rate
+	asset.StartDate = maturity
+	asset.MaturityDate = &next
+	if err := tx.Save(asset).Error; err != nil {
+		return nil, fmt.Errorf("failed to renew interest-bearing asset: %w", err)
+	}
+
+	event.NewRate = &rate
+	event.NewMaturityDate = &next
+	event.Note = fmt.Sprintf("Renewed %.2f until %s", asset.Amount, next.Format("2006-01-02"))
+	if err := tx.Create(&event).Error; err != nil {
+		return nil, fmt.Errorf("failed to record maturity event: %w", err)
+	}
+	return &event, nil
+}
+
+// payOut credits principal plus interest to the payout cash asset and closes the asset.
+// Debts secured by the asset are moved to the cash asset that now holds the money.
+func (s *MaturityService) payOut(tx *gorm.DB, asset *models.InterestBearingAsset) (*models.MaturityEvent, error) {
+	if asset.PayoutCashAssetID == nil {
+		return s.flagOnce(tx, asset, "No cash asset to pay out to")
+	}
+	var cash models.CashAsset
+	if err := tx.First(&cash, *asset.PayoutCashAssetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.flagOnce(tx, asset, fmt.Sprintf("Cash asset %d not found", *asset.PayoutCashAssetID))
+		}
+		return nil, fmt.Errorf("failed to load cash asset: %w", err)
+	}
+	if normalizeCurrency(cash.Currency) != normalizeCurrency(asset.Currency) {
+		// The currency of either side was changed after the policy was validated
+		return s.flagOnce(tx, asset, fmt.Sprintf("Cash asset %s is in %s, not %s", cash.Name, cash.Currency, asset.Currency))
+	}
+
+	maturity := truncateDate(*asset.MaturityDate)
+	event := maturityEvent(asset, maturity, models.MaturityActionPaidOut)
+	event.Interest = roundCents(AccrueInterest(asset, maturity).AccruedInterest)
+	if err := recordMaturityInterest(tx, asset, maturity, event.Interest); err != nil {
+		return nil, err
+	}
+
+	cash.Amount = roundCents(cash.Amount + asset.Amount + event.Interest)
+	if err := tx.Save(&cash).Error; err != nil {
+		return nil, fmt.Errorf("failed to credit cash asset: %w", err)
+	}
+	moved := tx.Model(&models.DebtAsset{}).
+		Where("collateral_type = ? AND collateral_id = ?", models.AssetTypeInterestBearing, asset.ID).
+		Updates(map[string]interface{}{"collateral_type": models.AssetTypeCash, "collateral_id": cash.ID})
+	if moved.Error != nil {
+		return nil, fmt.Errorf("failed to move collateral to cash asset: %w", moved.Error)
+	}
+	if err := tx.Delete(asset).Error; err != nil {
+		return nil, fmt.Errorf("failed to close interest-bearing asset: %w", err)
+	}
+
+	event.CashAssetID = &cash.ID
+	event.Note = fmt.Sprintf("Paid %.2f into %s", asset.Amount+event.Interest, cash.Name)
+	if moved.RowsAffected > 0 {
+		event.Note += fmt.Sprintf("; %s now secures %d debt(s)", cash.Name, moved.RowsAffected)
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return nil, fmt.Errorf("failed to record maturity event: %w", err)
+	}
+	return &event, nil
+}
+
+// flagOnce records a maturity left for manual handling. It returns nil if the maturity was already
+// flagged since the asset was last edited, so fixing a policy that still fails flags it again.
+func (s *MaturityService) flagOnce(tx *gorm.DB, asset *models.InterestBearingAsset, note string) (*models.MaturityEvent, error) {
+	maturity := truncateDate(*asset.MaturityDate)
+	var count int64
+	err := tx.Model(&models.MaturityEvent{}).
+		Where("asset_id = ? AND maturity_date = ? AND action = ? AND created_at >= ?", asset.ID, maturity, models.MaturityActionFlagged, asset.UpdatedAt).
+		Count(&count).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check maturity events: %w", err)
+	}
+	if count > 0 {
+		return nil, nil
+	}
+
+	event := maturityEvent(asset, maturity, models.MaturityActionFlagged)
+	event.Interest = roundCents(AccrueInterest(asset, maturity).AccruedInterest)
+	event.Note = note
+	if err := tx.Create(&event).Error; err != nil {
+		return nil, fmt.Errorf("failed to record maturity event: %w", err)
+	}
+	return &event, nil
+}
+
+// recorded reports whether an event of the given action exists for an asset's maturity date
+func (s *MaturityService) recorded(db *gorm.DB, assetID uint, maturity time.Time, action models.MaturityAction) (bool, error) {
+	var count int64
+	err := db.Model(&models.MaturityEvent{}).
+		Where("asset_id = ? AND maturity_date = ? AND action = ?", assetID, maturity, action).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check maturity events: %w", err)
+	}
+	return count > 0, nil
+}
+
+// recordMaturityInterest records the interest of a completed term as income
+func recordMaturityInterest(tx *gorm.DB, asset *models.InterestBearingAsset, maturity time.Time, interest float64) error {
+	if interest <= 0 {
+		return nil
+	}
+	income := models.IncomeEvent{
+		AssetType: models.AssetTypeInterestBearing,
+		AssetID:   asset.ID,
+		Type:      models.IncomeInterest,
+		Amount:    interest,
+		Currency:  asset.Currency,
+		PayDate:   maturity,
+		Source:    models.IncomeSourceMaturity,
+		Note:      fmt.Sprintf("Interest of the term from %s", truncateDate(asset.StartDate).Format("2006-01-02")),
+	}
+	if err := tx.Create(&income).Error; err != nil {
+		return fmt.Errorf("failed to record interest income: %w", err)
+	}
+	return nil
+}
+
+// maturityEvent starts an event for an asset's maturity
+func maturityEvent(asset *models.InterestBearingAsset, maturity time.Time, action models.MaturityAction) models.MaturityEvent {
+	return models.MaturityEvent{
+		AssetID:      asset.ID,
+		MaturityDate: maturity,
+		Action:       action,
+		Name:         asset.Name,
+		Principal:    asset.Amount,
+		Currency:     asset.Currency,
+	}
+}
+
+// renewalDate is the maturity of a renewed term of the same length, in whole months when the
+// term is a whole number of months
+func renewalDate(start, maturity time.Time) (time.Time, bool) {
+	start = truncateDate(start)
+	months := (maturity.Year()-start.Year())*12 + int(maturity.Month()) - int(start.Month())
+	if months > 0 && start.AddDate(0, months, 0).Equal(maturity) {
+		return maturity.AddDate(0, months, 0), true
+	}
+	days := int(maturity.Sub(start).Hours() / 24)
+	if days <= 0 {
+		return time.Time{}, false
+	}
+	return maturity.AddDate(0, 0, days), true
+}
+
+// policyOf returns the asset's maturity policy, flag if unset
+func policyOf(asset *models.InterestBearingAsset) models.MaturityPolicy {
+	if asset.MaturityPolicy == "" {
+		return models.MaturityFlag
+	}
+	return asset.MaturityPolicy
+}
+
+// roundCents rounds an amount to two decimals
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
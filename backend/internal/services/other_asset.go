@@ -0,0 +1,335 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+)
+
+// maxProjectionYears bounds the length of a value projection
+const maxProjectionYears = 50
+
+var (
+	// ErrInvalidOtherAsset is returned when a manually valued asset's terms are incomplete or inconsistent
+	ErrInvalidOtherAsset = errors.New("invalid asset")
+	// ErrInvalidAppraisal is returned when an appraisal is incomplete or inconsistent
+	ErrInvalidAppraisal = errors.New("invalid appraisal")
+)
+
+// otherAssetKinds are the known kinds of manually valued assets
+var otherAssetKinds = map[models.OtherAssetKind]bool{
+	models.OtherAssetRealEstate:  true,
+	models.OtherAssetVehicle:     true,
+	models.OtherAssetCollectible: true,
+	models.OtherAssetOther:       true,
+}
+
+// OtherAssetService keeps the appraisals of manually valued assets and values them
+type OtherAssetService struct {
+	db *gorm.DB
+}
+
+// OtherAssetValuation is the value of a manually valued asset as of a date
+type OtherAssetValuation struct {
+	AsOf       time.Time              `json:"as_of"`
+	Value      float64                `json:"value"`
+	Basis      string                 `json:"basis"`                // appraisal, purchase or none
+	BasisDate  *time.Time             `json:"basis_date,omitempty"` // Date of the appraisal or purchase the value is carried from
+	BasisValue float64                `json:"basis_value"`
+	Method     models.ValuationMethod `json:"method"`
+	Gain       float64                `json:"gain"` // Value less the purchase price
+}
+
+// OtherAssetProjection is the projected value of a manually valued asset on a date
+type OtherAssetProjection struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
+}
+
+// OtherAssetTotals sums the valuations of all manually valued assets in the base currency
+type OtherAssetTotals struct {
+	Value         float64 `json:"value"`
+	PurchasePrice float64 `json:"purchase_price"`
+	Gain          float64 `json:"gain"`
+}
+
+// NewOtherAssetService creates a new other asset service
+func NewOtherAssetService(db *gorm.DB) *OtherAssetService {
+	return &OtherAssetService{db: db}
+}
+
+// SaveAsset validates and stores a manually valued asset
+func (s *OtherAssetService) SaveAsset(asset *models.OtherAsset) error {
+	if err := NormalizeOtherAsset(asset); err != nil {
+		return err
+	}
+	if err := s.db.Save(asset).Error; err != nil {
+		return fmt.Errorf("failed to save asset: %w", err)
+	}
+	return nil
+}
+
+// DeleteAsset removes a manually valued asset with its appraisals, unless it secures a debt
+func (s *OtherAssetService) DeleteAsset(asset *models.OtherAsset) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := CheckNotCollateral(tx, models.AssetTypeOther, asset.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("other_asset_id = ?", asset.ID).Delete(&models.OtherAssetAppraisal{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(asset).Error
+	})
+}
+
+// Appraisals returns the appraisals of an asset, newest first
+func (s *OtherAssetService) Appraisals(assetID uint) ([]models.OtherAssetAppraisal, error) {
+	appraisals := []models.OtherAssetAppraisal{}
+	if err := s.db.Where("other_asset_id = ?", assetID).Order("date DESC, id DESC").Find(&appraisals).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve appraisals: %w", err)
+	}
+	return appraisals, nil
+}
+
+// AddAppraisal records the value of an asset on a date, today when none is given
+func (s *OtherAssetService) AddAppraisal(asset *models.OtherAsset, appraisal *models.OtherAssetAppraisal, now time.Time) error {
+	appraisal.OtherAssetID = asset.ID
+	if appraisal.Date.IsZero() {
+		appraisal.Date = now
+	}
+	if err := normalizeAppraisal(appraisal); err != nil {
+		return err
+	}
+	if err := s.db.Create(appraisal).Error; err != nil {
+		return fmt.Errorf("failed to create appraisal: %w", err)
+	}
+	return nil
+}
+
+// UpdateAppraisal stores a corrected appraisal
+func (s *OtherAssetService) UpdateAppraisal(appraisal *models.OtherAssetAppraisal) error {
+	if err := normalizeAppraisal(appraisal); err != nil {
+		return err
+	}
+	if err := s.db.Save(appraisal).Error; err != nil {
+		return fmt.Errorf("failed to update appraisal: %w", err)
+	}
+	return nil
+}
+
+// DeleteAppraisal removes an appraisal of an asset
+func (s *OtherAssetService) DeleteAppraisal(assetID, appraisalID uint) error {
+	result := s.db.Where("other_asset_id = ?", assetID).Delete(&models.OtherAssetAppraisal{}, appraisalID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Value values an asset as of a date from its appraisals
+func (s *OtherAssetService) Value(asset *models.OtherAsset, asOf time.Time) (OtherAssetValuation, error) {
+	appraisals, err := s.Appraisals(asset.ID)
+	if err != nil {
+		return OtherAssetValuation{}, err
+	}
+	return ValueOtherAsset(asset, appraisals, asOf), nil
+}
+
+// Project returns the value of an asset today and on each anniversary of today for a number of
+// years. Appreciating assets are valued at their latest appraisal, so their expected growth is
+// applied here only, compounded yearly from today's value.
+func (s *OtherAssetService) Project(asset *models.OtherAsset, now time.Time, years int) ([]OtherAssetProjection, error) {
+	if years <= 0 || years > maxProjectionYears {
+		return nil, fmt.Errorf("%w: projection must cover 1 to %d years", ErrInvalidOtherAsset, maxProjectionYears)
+	}
+	appraisals, err := s.Appraisals(asset.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := truncateDate(now)
+	current := ValueOtherAsset(asset, appraisals, today).Value
+	projection := make([]OtherAssetProjection, 0, years+1)
+	for year := 0; year <= years; year++ {
+		date := today.AddDate(year, 0, 0)
+		value := ValueOtherAsset(asset, appraisals, date).Value
+		if asset.ValuationMethod == models.ValuationAppreciation {
+			value = roundCents(current * math.Pow(1+asset.AppreciationRate/100, float64(year)))
+		}
+		projection = append(projection, OtherAssetProjection{
+			Date:  date,
+			Value: value,
+		})
+	}
+	return projection, nil
+}
+
+// SumOtherAssets values all manually valued assets as of a date in the converter's base currency
+func SumOtherAssets(db *gorm.DB, asOf time.Time, fx *Converter) (OtherAssetTotals, error) {
+	var totals OtherAssetTotals
+
+	var assets []models.OtherAsset
+	if err := db.Find(&assets).Error; err != nil {
+		return totals, fmt.Errorf("failed to retrieve other assets: %w", err)
+	}
+	if len(assets) == 0 {
+		return totals, nil
+	}
+
+	var appraisals []models.OtherAssetAppraisal
+	if err := db.Order("date DESC, id DESC").Find(&appraisals).Error; err != nil {
+		return totals, fmt.Errorf("failed to retrieve appraisals: %w", err)
+	}
+	byAsset := make(map[uint][]models.OtherAssetAppraisal)
+	for _, appraisal := range appraisals {
+		byAsset[appraisal.OtherAssetID] = append(byAsset[appraisal.OtherAssetID], appraisal)
+	}
+
+	for i := range assets {
+		asset := &assets[i]
+		valuation := ValueOtherAsset(asset, byAsset[asset.ID], asOf)
+		totals.Value += fx.Asset(valuation.Value, asset.Currency)
+		totals.PurchasePrice += fx.Convert(asset.PurchasePrice, asset.Currency)
+	}
+	totals.Gain = roundCents(totals.Value - totals.PurchasePrice)
+	return totals, nil
+}
+
+// ValueOtherAsset values an asset as of a date. The value is carried from the latest appraisal
+// on or before the date, or from the purchase when there is none, under the asset's valuation
+// method. Appreciating assets hold that value; their growth is only projected. An asset
+// purchased after the date is worth nothing on it.
+func ValueOtherAsset(asset *models.OtherAsset, appraisals []models.OtherAssetAppraisal, asOf time.Time) OtherAssetValuation {
+	valuation := OtherAssetValuation{
+		AsOf:   truncateDate(asOf),
+		Basis:  "none",
+		Method: asset.ValuationMethod,
+	}
+
+	var basis *models.OtherAssetAppraisal
+	for i := range appraisals {
+		date := truncateDate(appraisals[i].Date)
+		if date.After(valuation.AsOf) {
+			continue
+		}
+		if basis == nil || date.After(truncateDate(basis.Date)) ||
+			(date.Equal(truncateDate(basis.Date)) && appraisals[i].ID > basis.ID) {
+			basis = &appraisals[i]
+		}
+	}
+
+	switch {
+	case basis != nil:
+		date := truncateDate(basis.Date)
+		valuation.Basis = "appraisal"
+		valuation.BasisDate = &date
+		valuation.BasisValue = basis.Value
+	case asset.PurchaseDate != nil:
+		date := truncateDate(*asset.PurchaseDate)
+		if date.After(valuation.AsOf) {
+			return valuation
+		}
+		valuation.Basis = "purchase"
+		valuation.BasisDate = &date
+		valuation.BasisValue = asset.PurchasePrice
+	default:
+		// Without a purchase date the purchase price is the value until the first appraisal
+		valuation.Basis = "purchase"
+		valuation.BasisValue = asset.PurchasePrice
+		valuation.Value = asset.PurchasePrice
+		return valuation
+	}
+
+	years := yearFraction(models.DayCountACT365, *valuation.BasisDate, valuation.AsOf)
+	valuation.Value = roundCents(carryValue(asset, valuation.BasisValue, *valuation.BasisDate, years))
+	valuation.Gain = roundCents(valuation.Value - asset.PurchasePrice)
+	return valuation
+}
+
+// carryValue carries a value from a basis date forward by a number of years under the asset's
+// depreciation method; other methods hold the value
+func carryValue(asset *models.OtherAsset, value float64, basisDate time.Time, years float64) float64 {
+	if years <= 0 {
+		return value
+	}
+
+	switch asset.ValuationMethod {
+	case models.ValuationStraightLine:
+		if value <= asset.SalvageValue {
+			return value
+		}
+		// Depreciate evenly to the salvage value at the end of the useful life, counted from the purchase
+		start := basisDate
+		if asset.PurchaseDate != nil {
+			start = truncateDate(*asset.PurchaseDate)
+		}
+		remaining := asset.UsefulLifeYears - yearFraction(models.DayCountACT365, start, basisDate)
+		if remaining <= 0 || years >= remaining {
+			return asset.SalvageValue
+		}
+		return value - (value-asset.SalvageValue)*years/remaining
+	case models.ValuationDecliningBalance:
+		if value <= asset.SalvageValue {
+			return value
+		}
+		return math.Max(asset.SalvageValue, value*math.Pow(1-asset.DepreciationRate/100, years))
+	default:
+		return value
+	}
+}
+
+// NormalizeOtherAsset validates the terms of a manually valued asset and fills in defaults
+func NormalizeOtherAsset(asset *models.OtherAsset) error {
+	if asset.Kind == "" {
+		asset.Kind = models.OtherAssetOther
+	}
+	if !otherAssetKinds[asset.Kind] {
+		return fmt.Errorf("%w: unsupported kind %q", ErrInvalidOtherAsset, asset.Kind)
+	}
+	if asset.PurchasePrice < 0 {
+		return fmt.Errorf("%w: purchase price must not be negative", ErrInvalidOtherAsset)
+	}
+	if asset.SalvageValue < 0 {
+		return fmt.Errorf("%w: salvage value must not be negative", ErrInvalidOtherAsset)
+	}
+
+	switch asset.ValuationMethod {
+	case "", models.ValuationManual:
+		asset.ValuationMethod = models.ValuationManual
+	case models.ValuationStraightLine:
+		if asset.UsefulLifeYears <= 0 {
+			return fmt.Errorf("%w: straight-line depreciation requires a useful life", ErrInvalidOtherAsset)
+		}
+	case models.ValuationDecliningBalance:
+		if asset.DepreciationRate <= 0 || asset.DepreciationRate >= 100 {
+			return fmt.Errorf("%w: depreciation rate must be between 0 and 100", ErrInvalidOtherAsset)
+		}
+	case models.ValuationAppreciation:
+		if asset.AppreciationRate <= -100 {
+			return fmt.Errorf("%w: appreciation rate must be above -100", ErrInvalidOtherAsset)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported valuation method %q", ErrInvalidOtherAsset, asset.ValuationMethod)
+	}
+
+	if asset.Currency == "" {
+		asset.Currency = "CNY"
+	}
+	return nil
+}
+
+// normalizeAppraisal validates an appraisal
+func normalizeAppraisal(appraisal *models.OtherAssetAppraisal) error {
+	if appraisal.Value < 0 {
+		return fmt.Errorf("%w: value must not be negative", ErrInvalidAppraisal)
+	}
+	appraisal.Date = truncateDate(appraisal.Date)
+	return nil
+}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"trackmymoney/internal/models"
+	"trackmymoney/pkg/logger"
+)
+
+// portfolioTables are the tables whose edits change the portfolio valuation
+var portfolioTables = map[string]bool{
+	models.CashAsset{}.TableName():            true,
+	models.InterestBearingAsset{}.TableName(): true,
+	models.StockAsset{}.TableName():           true,
+	models.DebtAsset{}.TableName():            true,
+	models.CryptoAsset{}.TableName():          true,
+	models.LoanPrepayment{}.TableName():       true,
+	models.CreditCard{}.TableName():           true,
+	models.OtherAsset{}.TableName():           true,
+	models.OtherAssetAppraisal{}.TableName():  true,
+	models.FXRate{}.TableName():               true,
+}
+
+// PortfolioSubscriber receives portfolio updates. DeliverPortfolio must not block.
+type PortfolioSubscriber interface {
+	DeliverPortfolio(update *PortfolioUpdate)
+}
+
+// PortfolioUpdate is a live valuation of all assets in the base currency
+type PortfolioUpdate struct {
+	BaseCurrency string             `json:"base_currency"`
+	TotalAssets  float64            `json:"total_assets"`
+	TotalDebt    float64            `json:"total_debt"`
+	NetAssets    float64            `json:"net_assets"`
+	Categories   map[string]float64 `json:"categories"`
+	DayChange    float64            `json:"day_change"` // Sum of the holdings' day change in the base currency
+	Holdings     []PortfolioHolding `json:"holdings"`
+	Timestamp    int64              `json:"timestamp"` // Unix timestamp in milliseconds
+}
+
+// PortfolioHolding is the live valuation of a single stock or crypto holding
+type PortfolioHolding struct {
+	AssetType        models.AssetType `json:"asset_type"`
+	AssetID          uint             `json:"asset_id"`
+	Name             string           `json:"name"`
+	Symbol           string           `json:"symbol"`   // Canonical market symbol
+	Currency         string           `json:"currency"` // Currency of the price and value
+	Quantity         float64          `json:"quantity"`
+	Price            float64          `json:"price"`
+	Value            float64          `json:"value"`
+	DayChange        *float64         `json:"day_change,omitempty"` // Value change since the previous close, if known
+	DayChangePercent *float64         `json:"day_change_percent,omitempty"`
+
+	rate float64 // Base currency per unit of the holding's currency
+}
+
+// PortfolioStreamConfig holds configuration for the portfolio stream
+type PortfolioStreamConfig struct {
+	Interval time.Duration // Minimum time between updates; changes within an interval are merged
+}
+
+// PortfolioStream pushes live portfolio valuations to subscribers. Prices come from
+// market hub ticks of the held symbols; asset edits are picked up through gorm callbacks.
+// Changes are debounced so that a burst of ticks produces one update per interval.
+type PortfolioStream struct {
+	db     *gorm.DB
+	hub    *MarketHub
+	market *MarketService
+	fx     *FXService
+	config PortfolioStreamConfig
+
+	mu          sync.Mutex
+	subscribers map[PortfolioSubscriber]struct{}
+	cancel      context.CancelFunc
+	holdings    []PortfolioHolding
+	balances    portfolioBalances
+	prices      map[string]livePrice // Latest price by canonical symbol
+	symbols     []string             // Held symbols subscribed on the hub
+	dirty       bool                 // A price or asset changed since the last update
+	reload      bool                 // Assets changed and must be reloaded
+}
+
+// portfolioBalances are the totals of the assets valued without market prices
+type portfolioBalances struct {
+	base            string // Currency of the balances
+	cash            float64
+	interestBearing float64
+	other           float64
+	debt            float64
+	creditCard      float64
+}
+
+type livePrice struct {
+	price         float64
+	previousClose *float64
+}
+
+// NewPortfolioStream creates a portfolio stream and registers the gorm callbacks that report asset edits
+func NewPortfolioStream(db *gorm.DB, hub *MarketHub, market *MarketService, fx *FXService, config PortfolioStreamConfig) *PortfolioStream {
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+
+	s := &PortfolioStream{
+		db:          db,
+		hub:         hub,
+		market:      market,
+		fx:          fx,
+		config:      config,
+		subscribers: make(map[PortfolioSubscriber]struct{}),
+		prices:      make(map[string]livePrice),
+	}
+
+	callback := func(tx *gorm.DB) {
+		if tx.Error == nil && tx.Statement.Schema != nil && portfolioTables[tx.Statement.Schema.Table] {
+			s.AssetsChanged()
+		}
+	}
+	for _, err := range []error{
+		db.Callback().Create().After("gorm:create").Register("portfolio:assets_changed", callback),
+		db.Callback().Update().After("gorm:update").Register("portfolio:assets_changed", callback),
+		db.Callback().Delete().After("gorm:delete").Register("portfolio:assets_changed", callback),
+	} {
+		if err != nil {
+			logger.Warn("Failed to register portfolio callback", zap.Error(err))
+		}
+	}
+
+	return s
+}
+
+// Subscribe adds a subscriber and sends it the current valuation.
+// The first subscriber starts the stream, which runs until the last one leaves.
+func (s *PortfolioStream) Subscribe(ctx context.Context, sub PortfolioSubscriber) error {
+	s.mu.Lock()
+	start := len(s.subscribers) == 0
+	s.subscribers[sub] = struct{}{}
+	if start {
+		streamCtx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.reload = true
+		s.dirty = true
+		go s.run(streamCtx)
+	}
+	s.mu.Unlock()
+
+	if start {
+		if err := s.refresh(ctx); err != nil {
+			s.Unsubscribe(sub)
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	update := s.snapshot()
+	s.mu.Unlock()
+	sub.DeliverPortfolio(update)
+	return nil
+}
+
+// Unsubscribe removes a subscriber, stopping the stream when it was the last one
+func (s *PortfolioStream) Unsubscribe(sub PortfolioSubscriber) {
+	s.mu.Lock()
+	delete(s.subscribers, sub)
+	if len(s.subscribers) > 0 || s.cancel == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.cancel()
+	s.cancel = nil
+	s.holdings = nil
+	s.symbols = nil
+	s.prices = make(map[string]livePrice)
+	s.mu.Unlock()
+
+	s.hub.UnsubscribeAll(s)
+}
+
+// AssetsChanged marks the assets for reloading before the next update
+func (s *PortfolioStream) AssetsChanged() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.reload = true
+		s.dirty = true
+	}
+}
+
+// Deliver records a market tick of a held symbol; implements MarketSubscriber
+func (s *PortfolioStream) Deliver(tick models.MarketTick) {
+	if tick.Price == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	live := s.prices[tick.Symbol]
+	if live.price == *tick.Price {
+		return
+	}
+	live.price = *tick.Price
+	if tick.ChangePercent != nil && *tick.ChangePercent > -100 {
+		previousClose := *tick.Price / (1 + *tick.ChangePercent/100)
+		live.previousClose = &previousClose
+	}
+	s.prices[tick.Symbol] = live
+	s.dirty = true
+}
+
+// run pushes an update at most once per interval while there are changes
+func (s *PortfolioStream) run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		dirty, reload := s.dirty, s.reload
+		s.dirty = false
+		s.mu.Unlock()
+		if !dirty {
+			continue
+		}
+
+		if reload {
+			if err := s.refresh(ctx); err != nil {
+				if ctx.Err() == nil {
+					logger.Warn("Failed to reload portfolio", zap.Error(err))
+				}
+				continue
+			}
+		}
+
+		s.mu.Lock()
+		update := s.snapshot()
+		subscribers := make([]PortfolioSubscriber, 0, len(s.subscribers))
+		for sub := range s.subscribers {
+			subscribers = append(subscribers, sub)
+		}
+		s.mu.Unlock()
+
+		for _, sub := range subscribers {
+			sub.DeliverPortfolio(update)
+		}
+	}
+}
+
+// refresh reloads the assets, seeds prices of newly held symbols from quotes
+// and moves the hub subscriptions to the held symbols
+func (s *PortfolioStream) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.reload = false
+	s.mu.Unlock()
+
+	holdings, balances, err := s.loadAssets()
+	if err != nil {
+		// Retry on the next interval
+		s.mu.Lock()
+		s.reload = true
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+
+	held := make(map[string]struct{})
+	for _, holding := range holdings {
+		held[holding.Symbol] = struct{}{}
+	}
+	symbols := make([]string, 0, len(held))
+	for sym := range held {
+		symbols = append(symbols, sym)
+	}
+	sort.Strings(symbols)
+
+	s.mu.Lock()
+	previous := s.symbols
+	var added []string
+	for _, sym := range symbols {
+		if _, ok := s.prices[sym]; !ok {
+			added = append(added, sym)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(added) > 0 {
+		s.seedPrices(ctx, added)
+	}
+
+	// Hub calls deliver ticks synchronously, so they are made without holding s.mu
+	var removed []string
+	for _, sym := range previous {
+		if _, ok := held[sym]; !ok {
+			removed = append(removed, sym)
+		}
+	}
+	s.hub.Unsubscribe(s, removed...)
+	if len(symbols) > 0 {
+		if _, err := s.hub.Subscribe(s, symbols...); err != nil && !errors.Is(err, ErrMarketStreamUnavailable) {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	if s.cancel == nil {
+		// The last subscriber left while reloading
+		s.mu.Unlock()
+		s.hub.UnsubscribeAll(s)
+		return nil
+	}
+	defer s.mu.Unlock()
+	s.holdings = holdings
+	s.balances = balances
+	s.symbols = symbols
+	for _, sym := range removed {
+		delete(s.prices, sym)
+	}
+	return nil
+}
+
+// seedPrices fills in the prices of symbols that have no tick yet from market quotes
+func (s *PortfolioStream) seedPrices(ctx context.Context, symbols []string) {
+	quotes, err := s.market.GetQuotes(ctx, symbols)
+	if err != nil {
+		logger.Debug("Failed to seed portfolio prices", zap.Strings("symbols", symbols), zap.Error(err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, quote := range quotes.Quotes {
+		if quote.Price == nil {
+			continue
+		}
+		if _, ok := s.prices[quote.Symbol]; ok {
+			continue
+		}
+		s.prices[quote.Symbol] = livePrice{price: *quote.Price, previousClose: quote.PreviousClose}
+	}
+}
+
+// loadAssets reads the holdings and the balances of the other asset types
+func (s *PortfolioStream) loadAssets() ([]PortfolioHolding, portfolioBalances, error) {
+	var balances portfolioBalances
+	fx, err := s.fx.Converter(time.Now())
+	if err != nil {
+		return nil, balances, err
+	}
+	balances.base = fx.Base()
+
+	var cashAssets []models.CashAsset
+	if err := s.db.Find(&cashAssets).Error; err != nil {
+		return nil, balances, fmt.Errorf("failed to retrieve cash assets: %w", err)
+	}
+	for _, asset := range cashAssets {
+		balances.cash += fx.Convert(asset.Amount, asset.Currency)
+	}
+	interestBearing, err := SumInterestBearing(s.db, time.Now(), fx)
+	if err != nil {
+		return nil, balances, err
+	}
+	balances.interestBearing = interestBearing.Value
+	otherAssets, err := SumOtherAssets(s.db, time.Now(), fx)
+	if err != nil {
+		return nil, balances, err
+	}
+	balances.other = otherAssets.Value
+	if balances.debt, err = SumDebts(s.db, time.Now(), fx); err != nil {
+		return nil, balances, err
+	}
+	creditCards, err := SumCreditCards(s.db, fx)
+	if err != nil {
+		return nil, balances, err
+	}
+	balances.creditCard = creditCards.Balance
+
+	var stockAssets []models.StockAsset
+	if err := s.db.Find(&stockAssets).Error; err != nil {
+		return nil, balances, fmt.Errorf("failed to retrieve stock assets: %w", err)
+	}
+	var cryptoAssets []models.CryptoAsset
+	if err := s.db.Find(&cryptoAssets).Error; err != nil {
+		return nil, balances, fmt.Errorf("failed to retrieve crypto assets: %w", err)
+	}
+
+	holdings := make([]PortfolioHolding, 0, len(stockAssets)+len(cryptoAssets))
+	for i := range stockAssets {
+		asset := &stockAssets[i]
+		holdings = append(holdings, PortfolioHolding{
+			AssetType: models.AssetTypeStock,
+			AssetID:   asset.ID,
+			Name:      asset.Name,
+			Symbol:    StockSymbol(asset),
+			Currency:  asset.Currency,
+			Quantity:  asset.Quantity,
+			Price:     storedPrice(asset.CurrentPrice, asset.PurchasePrice),
+			rate:      fx.Convert(1, asset.Currency),
+		})
+	}
+	for i := range cryptoAssets {
+		asset := &cryptoAssets[i]
+		holdings = append(holdings, PortfolioHolding{
+			AssetType: models.AssetTypeCrypto,
+			AssetID:   asset.ID,
+			Name:      asset.Name,
+			Symbol:    CryptoSymbol(asset),
+			Currency:  asset.QuoteCurrency,
+			Quantity:  asset.Quantity,
+			Price:     storedPrice(asset.CurrentPrice, asset.PurchasePrice),
+			rate:      fx.Convert(1, asset.QuoteCurrency),
+		})
+	}
+
+	return holdings, balances, nil
+}
+
+// snapshot values the holdings at their latest prices (caller must hold the lock).
+// Totals follow AssetService.CalculateAssetSummary.
+func (s *PortfolioStream) snapshot() *PortfolioUpdate {
+	update := &PortfolioUpdate{
+		BaseCurrency: s.balances.base,
+		Categories: map[string]float64{
+			"cash":             s.balances.cash,
+			"interest_bearing": s.balances.interestBearing,
+			"stock":            0,
+			"crypto":           0,
+			"other":            s.balances.other,
+			"debt":             s.balances.debt,
+			"credit_card":      s.balances.creditCard,
+		},
+		Holdings:  make([]PortfolioHolding, 0, len(s.holdings)),
+		Timestamp: time.Now().UnixMilli(),
+	}
+
+	for _, holding := range s.holdings {
+		if live, ok := s.prices[holding.Symbol]; ok {
+			holding.Price = live.price
+			if live.previousClose != nil && *live.previousClose > 0 {
+				dayChange := holding.Quantity * (live.price - *live.previousClose)
+				dayChangePercent := (live.price/(*live.previousClose) - 1) * 100
+				holding.DayChange = &dayChange
+				holding.DayChangePercent = &dayChangePercent
+				update.DayChange += dayChange * holding.rate
+			}
+		}
+		holding.Value = holding.Quantity * holding.Price
+		update.Categories[string(holding.AssetType)] += holding.Value * holding.rate
+		update.Holdings = append(update.Holdings, holding)
+	}
+
+	update.TotalAssets = s.balances.cash + s.balances.interestBearing + update.Categories["stock"] + update.Categories["crypto"] + s.balances.other
+	update.TotalDebt = s.balances.debt + s.balances.creditCard
+	update.NetAssets = update.TotalAssets - update.TotalDebt
+	return update
+}
+
+// storedPrice returns the stored current price, falling back to the purchase price if it was never fetched
+func storedPrice(currentPrice, purchasePrice float64) float64 {
+	if currentPrice == 0 {
+		return purchasePrice
+	}
+	return currentPrice
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"trackmymoney/internal/models"
+	"trackmymoney/pkg/logger"
+)
+
+// PriceHistoryService serves historical prices from the local price_bars table
+// and fetches only missing ranges from the market data providers.
+type PriceHistoryService struct {
+	db            *gorm.DB
+	marketService *MarketService
+}
+
+// NewPriceHistoryService creates a new price history service
+func NewPriceHistoryService(db *gorm.DB, marketService *MarketService) *PriceHistoryService {
+	return &PriceHistoryService{
+		db:            db,
+		marketService: marketService,
+	}
+}
+
+// storedIntervals are the intervals kept in the price_bars table; intraday data is not stored
+var storedIntervals = map[string]bool{
+	"1d":  true,
+	"5d":  true,
+	"1wk": true,
+	"1mo": true,
+	"3mo": true,
+}
+
+// historyRefreshAge is how long stored bars are considered current before topping them up
+const historyRefreshAge = time.Hour
+
+// GetHistory returns historical price data, served from stored bars when possible.
+// When the provider is unavailable, whatever is stored is returned.
+func (s *PriceHistoryService) GetHistory(ctx context.Context, symbol, period, interval string) (*models.HistoryResponse, error) {
+	symbol = canonicalSymbol(symbol)
+	if !storedIntervals[interval] {
+		return s.marketService.GetHistory(ctx, symbol, period, interval)
+	}
+
+	now := time.Now()
+	start := periodStart(period, now)
+
+	if err := s.ensureCoverage(ctx, symbol, interval, period, start, now); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to update price history for %s, serving stored bars: %v", symbol, err))
+	}
+
+	var bars []models.PriceBar
+	if err := s.db.Where("symbol = ? AND interval = ? AND date >= ?", symbol, interval, start).
+		Order("date ASC").Find(&bars).Error; err != nil {
+		return nil, fmt.Errorf("failed to load price bars: %w", err)
+	}
+
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no price history available for %s", symbol)
+	}
+
+	history := &models.HistoryResponse{
+		Symbol:     symbol,
+		Period:     period,
+		Interval:   interval,
+		DataPoints: make([]models.HistoryDataPoint, len(bars)),
+	}
+
+	var sync models.PriceBarSync
+	if err := s.db.Where("symbol = ? AND interval = ?", symbol, interval).First(&sync).Error; err == nil && sync.Currency != "" {
+		history.Currency = &sync.Currency
+	}
+
+	for i, bar := range bars {
+		timestamp := bar.Date.UnixMilli()
+		history.DataPoints[i] = models.HistoryDataPoint{
+			Date:      bar.Date.Format("2006-01-02"),
+			Timestamp: &timestamp,
+			Open:      bar.Open,
+			High:      bar.High,
+			Low:       bar.Low,
+			Close:     bar.Close,
+			Volume:    bar.Volume,
+		}
+	}
+
+	return history, nil
+}
+
+// Sync tops up stored bars for a symbol, backfilling the given period when nothing is stored yet
+func (s *PriceHistoryService) Sync(ctx context.Context, symbol, interval, initialPeriod string) error {
+	now := time.Now()
+	return s.ensureCoverage(ctx, symbol, interval, initialPeriod, periodStart(initialPeriod, now), now)
+}
+
+// TrackedSymbols returns the canonical symbols of all held and watched assets
+func (s *PriceHistoryService) TrackedSymbols() ([]string, error) {
+	seen := make(map[string]bool)
+	var symbols []string
+	add := func(symbol string) {
+		if symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	var stocks []models.StockAsset
+	if err := s.db.Select("symbol", "exchange").Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load stock symbols: %w", err)
+	}
+	for i := range stocks {
+		add(StockSymbol(&stocks[i]))
+	}
+
+	var cryptos []models.CryptoAsset
+	if err := s.db.Select("symbol", "quote_currency").Find(&cryptos).Error; err != nil {
+		return nil, fmt.Errorf("failed to load crypto symbols: %w", err)
+	}
+	for i := range cryptos {
+		add(CryptoSymbol(&cryptos[i]))
+	}
+
+	var watchedSymbols []string
+	if err := s.db.Model(&models.Watchlist{}).Distinct().Pluck("symbol", &watchedSymbols).Error; err != nil {
+		return nil, fmt.Errorf("failed to load watchlist symbols: %w", err)
+	}
+	for _, symbol := range watchedSymbols {
+		add(canonicalSymbol(symbol))
+	}
+
+	return symbols, nil
+}
+
+// ensureCoverage fetches the ranges missing between start and now
+func (s *PriceHistoryService) ensureCoverage(ctx context.Context, symbol, interval, period string, start, now time.Time) error {
+	var sync models.PriceBarSync
+	err := s.db.Where("symbol = ? AND interval = ?", symbol, interval).First(&sync).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load price history sync state: %w", err)
+	}
+	found := err == nil
+
+	// Backfill: the requested range starts before anything fetched so far
+	if !found || start.Before(sync.CoveredFrom) {
+		return s.fetch(ctx, &sync, symbol, interval, period, now, true)
+	}
+
+	// Top up: fetch only the gap since the latest stored bar
+	if now.Sub(sync.LastFetchedAt) < historyRefreshAge {
+		return nil
+	}
+
+	var latest models.PriceBar
+	gapStart := sync.CoveredFrom
+	if err := s.db.Where("symbol = ? AND interval = ?", symbol, interval).Order("date DESC").First(&latest).Error; err == nil {
+		gapStart = latest.Date
+	}
+
+	return s.fetch(ctx, &sync, symbol, interval, gapPeriod(now.Sub(gapStart)), now, false)
+}
+
+// fetch downloads a period from the provider and stores its bars
+func (s *PriceHistoryService) fetch(ctx context.Context, sync *models.PriceBarSync, symbol, interval, period string, now time.Time, backfill bool) error {
+	history, err := s.marketService.GetHistory(ctx, symbol, period, interval)
+	if err != nil {
+		return err
+	}
+
+	bars := make([]models.PriceBar, 0, len(history.DataPoints))
+	for _, point := range history.DataPoints {
+		date, err := parseBarDate(point)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Skipping price bar for %s: %v", symbol, err))
+			continue
+		}
+		bars = append(bars, models.PriceBar{
+			Symbol:   symbol,
+			Interval: interval,
+			Date:     date,
+			Open:     point.Open,
+			High:     point.High,
+			Low:      point.Low,
+			Close:    point.Close,
+			Volume:   point.Volume,
+		})
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if len(bars) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "symbol"}, {Name: "interval"}, {Name: "date"}},
+				DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume", "updated_at"}),
+			}).CreateInBatches(&bars, 500).Error; err != nil {
+				return fmt.Errorf("failed to store price bars: %w", err)
+			}
+		}
+
+		sync.Symbol = symbol
+		sync.Interval = interval
+		sync.LastFetchedAt = now
+		if backfill {
+			sync.CoveredFrom = periodStart(period, now)
+		}
+		if history.Currency != nil {
+			sync.Currency = *history.Currency
+		}
+
+		return tx.Save(sync).Error
+	})
+}
+
+// parseBarDate parses the date of a data point, falling back to its timestamp
+func parseBarDate(point models.HistoryDataPoint) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", point.Date); err == nil {
+		return date, nil
+	}
+	if date, err := time.Parse(time.RFC3339, point.Date); err == nil {
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	if point.Timestamp != nil {
+		date := time.UnixMilli(*point.Timestamp).UTC()
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s", point.Date)
+}
+
+// periodStart returns the first date covered by a provider period (zero time for "max")
+func periodStart(period string, now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case "1d":
+		return today.AddDate(0, 0, -1)
+	case "5d":
+		return today.AddDate(0, 0, -5)
+	case "1mo":
+		return today.AddDate(0, -1, 0)
+	case "3mo":
+		return today.AddDate(0, -3, 0)
+	case "6mo":
+		return today.AddDate(0, -6, 0)
+	case "1y":
+		return today.AddDate(-1, 0, 0)
+	case "2y":
+		return today.AddDate(-2, 0, 0)
+	case "5y":
+		return today.AddDate(-5, 0, 0)
+	case "10y":
+		return today.AddDate(-10, 0, 0)
+	case "ytd":
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case "max":
+		return time.Time{}
+	default:
+		return today.AddDate(0, -1, 0)
+	}
+}
+
+// gapPeriod returns the smallest provider period covering a gap
+func gapPeriod(gap time.Duration) string {
+	days := int(gap.Hours()/24) + 1
+
+	switch {
+	case days <= 5:
+		return "5d"
+	case days <= 28:
+		return "1mo"
+	case days <= 89:
+		return "3mo"
+	case days <= 180:
+		return "6mo"
+	case days <= 365:
+		return "1y"
+	case days <= 730:
+		return "2y"
+	case days <= 1825:
+		return "5y"
+	case days <= 3650:
+		return "10y"
+	default:
+		return "max"
+	}
+}
@@ -0,0 +1,684 @@
+package provider
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+)
+
+// DemoProvider produces deterministic synthetic market data offline.
+// Each symbol follows its own seeded daily random walk, so the same seed and
+// symbol always yield the same history, quotes and ticks for a given time.
+type DemoProvider struct {
+	seed int64
+
+	mu     sync.Mutex
+	walks  map[string]*list.Element // Recently used walks by canonical symbol, elements of recent
+	recent *list.List               // Recently used walks, most recent first
+}
+
+// demoEpoch is the first trading day of every demo random walk
+var demoEpoch = time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)
+
+// demoWalkCacheSize bounds how many walks are kept; evicted walks are regenerated from their seed
+const demoWalkCacheSize = 64
+
+// demoTickInterval is how often the demo stream pushes a tick
+const demoTickInterval = 2 * time.Second
+
+// demoCatalog lists well-known symbols with real names, used for names and search
+var demoCatalog = []struct {
+	symbol string
+	name   string
+}{
+	{"AAPL", "Apple Inc."},
+	{"MSFT", "Microsoft Corporation"},
+	{"GOOGL", "Alphabet Inc."},
+	{"AMZN", "Amazon.com, Inc."},
+	{"NVDA", "NVIDIA Corporation"},
+	{"TSLA", "Tesla, Inc."},
+	{"SPY", "SPDR S&P 500 ETF Trust"},
+	{"SSE:600519", "贵州茅台"},
+	{"SSE:601318", "中国平安"},
+	{"SSE:510300", "沪深300ETF"},
+	{"SZSE:000858", "五粮液"},
+	{"SZSE:300750", "宁德时代"},
+	{"HKEX:0700", "腾讯控股"},
+	{"HKEX:9988", "阿里巴巴-W"},
+	{"BTC-USD", "Bitcoin USD"},
+	{"ETH-USD", "Ethereum USD"},
+	{"SOL-USD", "Solana USD"},
+}
+
+// demoUSDRates are approximate units of each currency per US dollar, the anchors of demo exchange rates
+var demoUSDRates = map[string]float64{
+	"USD": 1,
+	"CNY": 7.1,
+	"HKD": 7.8,
+	"EUR": 0.92,
+	"GBP": 0.79,
+	"JPY": 150,
+	"CAD": 1.36,
+	"AUD": 1.52,
+	"SGD": 1.34,
+	"CHF": 0.88,
+}
+
+// demoBar is one synthetic OHLC bar
+type demoBar struct {
+	date   time.Time
+	open   float64
+	high   float64
+	low    float64
+	close  float64
+	volume int64
+}
+
+// demoWalk is the random walk of one symbol, generated from demoEpoch up to the day before next
+type demoWalk struct {
+	key        string // Canonical symbol
+	rng        *rand.Rand
+	price      float64
+	baseVolume float64
+	volatility float64
+	anchor     float64
+	isFX       bool
+	crypto     bool
+	next       time.Time
+	bars       []demoBar
+}
+
+// NewDemoProvider creates a demo provider; different seeds give different markets
+func NewDemoProvider(cfg Config) *DemoProvider {
+	return &DemoProvider{seed: cfg.Seed, walks: make(map[string]*list.Element), recent: list.New()}
+}
+
+// Name returns the provider name
+func (p *DemoProvider) Name() string {
+	return string(models.ProviderDemo)
+}
+
+// GetQuote gets a synthetic quote for a single symbol at the current time
+func (p *DemoProvider) GetQuote(ctx context.Context, sym string) (*models.Quote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	canonical := symbol.Parse(sym)
+	if canonical.Code == "" {
+		return nil, fmt.Errorf("invalid symbol: %q", sym)
+	}
+
+	now := time.Now()
+	bars := p.walk(canonical, now.AddDate(0, 0, -7), now)
+	today, previous := bars[len(bars)-1], bars[len(bars)-1]
+	if len(bars) > 1 {
+		previous = bars[len(bars)-2]
+	}
+
+	price := intradayPrice(today, dayFraction(now))
+	change := price - previous.close
+	changePercent := change / previous.close * 100
+	volume := int64(float64(today.volume) * math.Max(dayFraction(now), 0.01))
+	marketCap := int64(price * float64(p.shares(canonical)))
+	timestamp := now.UnixMilli()
+
+	return &models.Quote{
+		Symbol:        canonical.String(),
+		Name:          stringPtr(demoName(canonical)),
+		Price:         roundPtr(price),
+		PreviousClose: roundPtr(previous.close),
+		Change:        roundPtr(change),
+		ChangePercent: roundPtr(changePercent),
+		Volume:        &volume,
+		MarketCap:     &marketCap,
+		Currency:      stringPtr(demoCurrency(canonical)),
+		Timestamp:     &timestamp,
+	}, nil
+}
+
+// GetQuotes gets synthetic quotes for multiple symbols
+func (p *DemoProvider) GetQuotes(ctx context.Context, symbols []string) (*models.QuotesResponse, error) {
+	result := &models.QuotesResponse{
+		Quotes:        []models.Quote{},
+		FailedSymbols: []string{},
+	}
+
+	for _, sym := range symbols {
+		quote, err := p.GetQuote(ctx, sym)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.FailedSymbols = append(result.FailedSymbols, sym)
+			continue
+		}
+		result.Quotes = append(result.Quotes, *quote)
+	}
+
+	result.SuccessCount = len(result.Quotes)
+	return result, nil
+}
+
+// GetHistory gets synthetic historical price data.
+// Daily and longer intervals aggregate the daily walk; intraday intervals sample each day's path.
+func (p *DemoProvider) GetHistory(ctx context.Context, sym, period, interval string) (*models.HistoryResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	canonical := symbol.Parse(sym)
+	if canonical.Code == "" {
+		return nil, fmt.Errorf("invalid symbol: %q", sym)
+	}
+
+	now := time.Now()
+	bars := p.walk(canonical, demoPeriodStart(period, now), now)
+
+	var points []models.HistoryDataPoint
+	if step, ok := intradayStep(interval); ok {
+		points = intradayPoints(bars, step, now)
+	} else {
+		points = dailyPoints(aggregateBars(bars, interval))
+	}
+
+	currency := demoCurrency(canonical)
+	return &models.HistoryResponse{
+		Symbol:     canonical.String(),
+		Period:     period,
+		Interval:   interval,
+		Currency:   &currency,
+		DataPoints: points,
+	}, nil
+}
+
+// GetInfo gets synthetic basic information about a stock or crypto
+func (p *DemoProvider) GetInfo(ctx context.Context, sym string) (*models.InfoResponse, error) {
+	quote, err := p.GetQuote(ctx, sym)
+	if err != nil {
+		return nil, err
+	}
+
+	canonical := symbol.Parse(sym)
+	description := fmt.Sprintf("Synthetic demo data for %s, generated offline from a seeded random walk.", canonical.String())
+	sector := "Demo"
+	if canonical.IsCrypto() {
+		sector = "Cryptocurrency"
+	}
+
+	return &models.InfoResponse{
+		Symbol:      quote.Symbol,
+		Name:        quote.Name,
+		Sector:      &sector,
+		MarketCap:   quote.MarketCap,
+		Description: &description,
+		Currency:    quote.Currency,
+	}, nil
+}
+
+// GetActions reports no corporate actions; demo prices are never split
+func (p *DemoProvider) GetActions(ctx context.Context, sym string) (*models.ActionsResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.ActionsResponse{
+		Symbol:  symbol.Parse(sym).String(),
+		Actions: []models.CorporateActionEvent{},
+	}, nil
+}
+
+// GetDividends reports synthetic quarterly dividends of about 0.5% of the price over the last
+// five years, paid on the last trading day of each quarter; crypto pays no dividends
+func (p *DemoProvider) GetDividends(ctx context.Context, sym string) (*models.DividendsResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	canonical := symbol.Parse(sym)
+	if canonical.Code == "" {
+		return nil, fmt.Errorf("invalid symbol: %q", sym)
+	}
+
+	currency := demoCurrency(canonical)
+	result := &models.DividendsResponse{
+		Symbol:    canonical.String(),
+		Currency:  &currency,
+		Dividends: []models.DividendEvent{},
+	}
+	if canonical.IsCrypto() {
+		return result, nil
+	}
+
+	now := time.Now()
+	bars := p.walk(canonical, now.AddDate(-5, 0, 0), now)
+	for i, bar := range bars {
+		// The last bar of a quarter-end month, unless the month is still running
+		if bar.date.Month()%3 != 0 || (i+1 < len(bars) && bars[i+1].date.Month() == bar.date.Month()) || i+1 == len(bars) {
+			continue
+		}
+		result.Dividends = append(result.Dividends, models.DividendEvent{
+			Date:   bar.date.Format("2006-01-02"),
+			Amount: *roundPtr(bar.close * 0.005),
+		})
+	}
+	return result, nil
+}
+
+// Search searches the demo catalog by symbol and name
+func (p *DemoProvider) Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	q := strings.ToUpper(strings.TrimSpace(query))
+	results := []models.SearchResult{}
+
+	for _, entry := range demoCatalog {
+		if len(results) >= limit {
+			break
+		}
+		if !strings.Contains(strings.ToUpper(entry.symbol), q) && !strings.Contains(strings.ToUpper(entry.name), q) {
+			continue
+		}
+		results = append(results, demoSearchResult(symbol.Parse(entry.symbol)))
+	}
+
+	return &models.SearchResponse{
+		Query:   query,
+		Results: results,
+		Count:   len(results),
+	}, nil
+}
+
+// Stream pushes a synthetic tick for a symbol every few seconds until ctx is done or send fails
+func (p *DemoProvider) Stream(ctx context.Context, sym string, send func(models.MarketTick) error) error {
+	ticker := time.NewTicker(demoTickInterval)
+	defer ticker.Stop()
+
+	for {
+		quote, err := p.GetQuote(ctx, sym)
+		if err != nil {
+			return err
+		}
+		if err := send(models.MarketTick{
+			Symbol:        quote.Symbol,
+			Price:         quote.Price,
+			ChangePercent: quote.ChangePercent,
+			Volume:        quote.Volume,
+			Timestamp:     *quote.Timestamp,
+		}); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// walk returns the daily bars of a symbol between from and to (inclusive).
+// The walk always starts at demoEpoch so that every range of the same symbol agrees; it is
+// kept for the most recently used symbols and extended as days pass. Walks of other symbols
+// are dropped and regenerated from their seed when asked for again.
+func (p *DemoProvider) walk(s symbol.Symbol, from, to time.Time) []demoBar {
+	from = truncateDay(from)
+	to = truncateDay(to)
+
+	p.mu.Lock()
+	w := p.cachedWalk(s)
+	w.extend(to)
+	first := sort.Search(len(w.bars), func(i int) bool { return !w.bars[i].date.Before(from) })
+	last := sort.Search(len(w.bars), func(i int) bool { return w.bars[i].date.After(to) })
+	bars := append([]demoBar(nil), w.bars[first:last]...)
+	p.mu.Unlock()
+
+	if len(bars) == 0 {
+		// The range fell on a weekend; report the last trading day
+		return p.walk(s, from.AddDate(0, 0, -3), to)
+	}
+	return bars
+}
+
+// cachedWalk returns the kept walk of a symbol, starting a new one and dropping the least
+// recently used walk when the cache is full. The caller holds p.mu.
+func (p *DemoProvider) cachedWalk(s symbol.Symbol) *demoWalk {
+	key := s.String()
+	if e, ok := p.walks[key]; ok {
+		p.recent.MoveToFront(e)
+		return e.Value.(*demoWalk)
+	}
+
+	w := p.newWalk(s)
+	p.walks[key] = p.recent.PushFront(w)
+	if p.recent.Len() > demoWalkCacheSize {
+		oldest := p.recent.Back()
+		p.recent.Remove(oldest)
+		delete(p.walks, oldest.Value.(*demoWalk).key)
+	}
+	return w
+}
+
+// newWalk starts the random walk of a symbol at demoEpoch
+func (p *DemoProvider) newWalk(s symbol.Symbol) *demoWalk {
+	rng := rand.New(rand.NewSource(p.symbolSeed(s)))
+
+	volatility := 0.015
+	if s.IsCrypto() {
+		volatility = 0.035
+	}
+	// Base price spread log-uniformly: 5-500 for stocks, 0.1-50000 for crypto
+	low, high := math.Log(5), math.Log(500)
+	if s.IsCrypto() {
+		low, high = math.Log(0.1), math.Log(50000)
+	}
+	price := math.Exp(low + rng.Float64()*(high-low))
+	baseVolume := 1e5 + rng.Float64()*5e7
+
+	// Exchange rates revert to their anchor instead of drifting away over the decades of the walk
+	anchor, isFX := demoFXRate(s)
+	if isFX {
+		volatility = 0.004
+		price = anchor
+	}
+
+	return &demoWalk{
+		key:        s.String(),
+		rng:        rng,
+		price:      price,
+		baseVolume: baseVolume,
+		volatility: volatility,
+		anchor:     anchor,
+		isFX:       isFX,
+		crypto:     s.IsCrypto(),
+		next:       demoEpoch,
+	}
+}
+
+// extend generates the bars of the walk up to and including day to
+func (w *demoWalk) extend(to time.Time) {
+	for ; !w.next.After(to); w.next = w.next.AddDate(0, 0, 1) {
+		day := w.next
+		if !w.crypto && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+
+		open := w.price
+		ret := w.rng.NormFloat64()*w.volatility + w.volatility*w.volatility/4
+		if w.isFX {
+			ret -= 0.02 * math.Log(open/w.anchor)
+		}
+		closePrice := open * math.Exp(ret)
+		highPrice := math.Max(open, closePrice) * (1 + math.Abs(w.rng.NormFloat64())*w.volatility/2)
+		lowPrice := math.Min(open, closePrice) * (1 - math.Abs(w.rng.NormFloat64())*w.volatility/2)
+		volume := int64(w.baseVolume * (0.5 + w.rng.Float64()))
+		w.price = closePrice
+
+		w.bars = append(w.bars, demoBar{date: day, open: open, high: highPrice, low: lowPrice, close: closePrice, volume: volume})
+	}
+}
+
+// symbolSeed derives the random walk seed of a symbol
+func (p *DemoProvider) symbolSeed(s symbol.Symbol) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(s.String()))
+	return int64(h.Sum64()) ^ p.seed
+}
+
+// shares returns a stable share count used for market capitalization
+func (p *DemoProvider) shares(s symbol.Symbol) int64 {
+	rng := rand.New(rand.NewSource(p.symbolSeed(s) + 1))
+	return 1e7 + rng.Int63n(5e9)
+}
+
+// intradayPrice places a price on a bar's path for a fraction of the day, staying within its range
+func intradayPrice(bar demoBar, fraction float64) float64 {
+	if fraction >= 1 {
+		return bar.close
+	}
+	base := bar.open + (bar.close-bar.open)*fraction
+	wiggle := (bar.high - bar.low) / 4 * math.Sin(fraction*math.Pi*7+float64(bar.date.YearDay()))
+	return math.Min(math.Max(base+wiggle*math.Sin(fraction*math.Pi), bar.low), bar.high)
+}
+
+// dayFraction returns how far through the UTC day t is
+func dayFraction(t time.Time) float64 {
+	t = t.UTC()
+	return t.Sub(truncateDay(t)).Seconds() / 86400
+}
+
+// intradayStep parses intraday interval names
+func intradayStep(interval string) (time.Duration, bool) {
+	switch interval {
+	case "1m":
+		return time.Minute, true
+	case "2m":
+		return 2 * time.Minute, true
+	case "5m":
+		return 5 * time.Minute, true
+	case "15m":
+		return 15 * time.Minute, true
+	case "30m":
+		return 30 * time.Minute, true
+	case "60m", "1h":
+		return time.Hour, true
+	case "90m":
+		return 90 * time.Minute, true
+	default:
+		return 0, false
+	}
+}
+
+// intradayPoints samples each day's path at the given step, up to now
+func intradayPoints(bars []demoBar, step time.Duration, now time.Time) []models.HistoryDataPoint {
+	var points []models.HistoryDataPoint
+	for _, bar := range bars {
+		for t := bar.date; t.Before(bar.date.Add(24 * time.Hour)); t = t.Add(step) {
+			if t.After(now) {
+				break
+			}
+			price := intradayPrice(bar, t.Sub(bar.date).Hours()/24)
+			volume := int64(float64(bar.volume) * step.Hours() / 24)
+			timestamp := t.UnixMilli()
+			points = append(points, models.HistoryDataPoint{
+				Date:      t.Format(time.RFC3339),
+				Timestamp: &timestamp,
+				Open:      roundPtr(price),
+				High:      roundPtr(price),
+				Low:       roundPtr(price),
+				Close:     roundPtr(price),
+				Volume:    &volume,
+			})
+		}
+	}
+	return points
+}
+
+// aggregateBars groups daily bars into weekly, monthly or quarterly bars
+func aggregateBars(bars []demoBar, interval string) []demoBar {
+	var key func(t time.Time) string
+	switch interval {
+	case "5d", "1wk":
+		key = func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%02d", year, week)
+		}
+	case "1mo":
+		key = func(t time.Time) string { return t.Format("2006-01") }
+	case "3mo":
+		key = func(t time.Time) string { return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3) }
+	default:
+		return bars
+	}
+
+	var result []demoBar
+	groups := make(map[string]int)
+	for _, bar := range bars {
+		k := key(bar.date)
+		i, ok := groups[k]
+		if !ok {
+			groups[k] = len(result)
+			result = append(result, bar)
+			continue
+		}
+		agg := &result[i]
+		agg.high = math.Max(agg.high, bar.high)
+		agg.low = math.Min(agg.low, bar.low)
+		agg.close = bar.close
+		agg.volume += bar.volume
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].date.Before(result[j].date) })
+	return result
+}
+
+// dailyPoints converts bars to history data points
+func dailyPoints(bars []demoBar) []models.HistoryDataPoint {
+	points := make([]models.HistoryDataPoint, len(bars))
+	for i, bar := range bars {
+		timestamp := bar.date.UnixMilli()
+		volume := bar.volume
+		points[i] = models.HistoryDataPoint{
+			Date:      bar.date.Format("2006-01-02"),
+			Timestamp: &timestamp,
+			Open:      roundPtr(bar.open),
+			High:      roundPtr(bar.high),
+			Low:       roundPtr(bar.low),
+			Close:     roundPtr(bar.close),
+			Volume:    &volume,
+		}
+	}
+	return points
+}
+
+// demoPeriodStart returns the first date covered by a period
+func demoPeriodStart(period string, now time.Time) time.Time {
+	today := truncateDay(now)
+
+	switch period {
+	case "1d":
+		return today
+	case "5d":
+		return today.AddDate(0, 0, -5)
+	case "1mo":
+		return today.AddDate(0, -1, 0)
+	case "3mo":
+		return today.AddDate(0, -3, 0)
+	case "6mo":
+		return today.AddDate(0, -6, 0)
+	case "1y":
+		return today.AddDate(-1, 0, 0)
+	case "2y":
+		return today.AddDate(-2, 0, 0)
+	case "5y":
+		return today.AddDate(-5, 0, 0)
+	case "10y":
+		return today.AddDate(-10, 0, 0)
+	case "ytd":
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case "max":
+		return demoEpoch
+	default:
+		return today.AddDate(0, -1, 0)
+	}
+}
+
+// demoName returns the catalog name of a symbol, or a generated one
+func demoName(s symbol.Symbol) string {
+	canonical := s.String()
+	for _, entry := range demoCatalog {
+		if entry.symbol == canonical {
+			return entry.name
+		}
+	}
+	if s.IsCrypto() {
+		return s.Code + " " + s.QuoteCurrency
+	}
+	if base, quote, ok := s.Currencies(); ok {
+		return base + "/" + quote
+	}
+	return "Demo " + s.Code
+}
+
+// demoFXRate returns the anchor rate of a currency pair, derived from the dollar rates
+// of its currencies; unknown currencies trade near parity
+func demoFXRate(s symbol.Symbol) (float64, bool) {
+	base, quote, ok := s.Currencies()
+	if !ok {
+		return 0, false
+	}
+	baseRate, ok1 := demoUSDRates[base]
+	quoteRate, ok2 := demoUSDRates[quote]
+	if !ok1 || !ok2 {
+		return 1, true
+	}
+	return quoteRate / baseRate, true
+}
+
+// demoCurrency returns the trading currency of a symbol
+func demoCurrency(s symbol.Symbol) string {
+	switch s.Exchange {
+	case symbol.ExchangeCrypto:
+		return s.QuoteCurrency
+	case symbol.ExchangeFX:
+		if _, quote, ok := s.Currencies(); ok {
+			return quote
+		}
+		return "USD"
+	case symbol.ExchangeSSE, symbol.ExchangeSZSE, symbol.ExchangeBSE:
+		return "CNY"
+	case symbol.ExchangeHKEX:
+		return "HKD"
+	case symbol.ExchangeLSE:
+		return "GBP"
+	case symbol.ExchangeTSE:
+		return "JPY"
+	case symbol.ExchangeTSX:
+		return "CAD"
+	default:
+		return "USD"
+	}
+}
+
+// demoSearchResult builds a search result for a symbol
+func demoSearchResult(s symbol.Symbol) models.SearchResult {
+	assetType := "stock"
+	if s.IsCrypto() {
+		assetType = "crypto"
+	}
+	result := models.SearchResult{
+		Symbol:    s.String(),
+		Name:      stringPtr(demoName(s)),
+		AssetType: &assetType,
+	}
+	if s.Exchange != symbol.ExchangeNone {
+		result.Exchange = stringPtr(string(s.Exchange))
+	}
+	return result
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func roundPtr(v float64) *float64 {
+	rounded := math.Round(v*10000) / 10000
+	return &rounded
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+)
+
+// MarketDataProvider defines the interface for market data providers
+type MarketDataProvider interface {
+	// Name returns the provider name
+	Name() string
+
+	// GetQuote gets a real-time quote for a single symbol
+	GetQuote(ctx context.Context, symbol string) (*models.Quote, error)
+
+	// GetQuotes gets quotes for multiple symbols
+	GetQuotes(ctx context.Context, symbols []string) (*models.QuotesResponse, error)
+
+	// GetHistory gets historical price data
+	GetHistory(ctx context.Context, symbol, period, interval string) (*models.HistoryResponse, error)
+
+	// GetInfo gets basic information about a stock or crypto
+	GetInfo(ctx context.Context, symbol string) (*models.InfoResponse, error)
+
+	// GetActions gets the corporate actions (splits, symbol changes) of a stock
+	GetActions(ctx context.Context, symbol string) (*models.ActionsResponse, error)
+
+	// GetDividends gets the cash dividend history of a stock or fund
+	GetDividends(ctx context.Context, symbol string) (*models.DividendsResponse, error)
+
+	// Search searches for stocks or crypto
+	Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error)
+}
+
+// Config holds the settings used to build a provider
+type Config struct {
+	BaseURL     string
+	Credentials string // JSON string of provider-specific credentials
+	Timeout     int    // Request timeout in seconds
+	Seed        int64  // Random seed of the demo provider
+}
+
+// Streamer is implemented by providers that push real-time ticks themselves
+type Streamer interface {
+	// Stream sends ticks for a symbol until ctx is done or send returns an error
+	Stream(ctx context.Context, symbol string, send func(models.MarketTick) error) error
+}
+
+// New creates a provider of the given type.
+// Providers built by New accept and return canonical symbols (see package symbol).
+func New(providerType models.ProviderType, cfg Config) (MarketDataProvider, error) {
+	switch providerType {
+	case models.ProviderYFinance, "":
+		return WithSymbols(NewYFinanceProvider(cfg), symbol.Default.Mapper(models.ProviderYFinance)), nil
+	case models.ProviderDemo:
+		// The demo provider works on canonical symbols directly
+		return NewDemoProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported market data provider: %s", providerType)
+	}
+}
+
+// IsSupported reports whether a provider type can be built by New
+func IsSupported(providerType models.ProviderType) bool {
+	switch providerType {
+	case models.ProviderYFinance, models.ProviderDemo:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+)
+
+// symbolProvider translates canonical symbols to provider tickers on the way in
+// and provider tickers back to canonical symbols on the way out
+type symbolProvider struct {
+	provider MarketDataProvider
+	mapper   symbol.Mapper
+}
+
+// WithSymbols wraps a provider so that it accepts and returns canonical symbols
+func WithSymbols(p MarketDataProvider, mapper symbol.Mapper) MarketDataProvider {
+	return &symbolProvider{provider: p, mapper: mapper}
+}
+
+// Name returns the provider name
+func (p *symbolProvider) Name() string {
+	return p.provider.Name()
+}
+
+// GetQuote gets a real-time quote for a single symbol
+func (p *symbolProvider) GetQuote(ctx context.Context, sym string) (*models.Quote, error) {
+	canonical := symbol.Parse(sym)
+
+	quote, err := p.provider.GetQuote(ctx, p.mapper.ToProvider(canonical))
+	if err != nil {
+		return nil, err
+	}
+
+	quote.Symbol = canonical.String()
+	return quote, nil
+}
+
+// GetQuotes gets quotes for multiple symbols
+func (p *symbolProvider) GetQuotes(ctx context.Context, symbols []string) (*models.QuotesResponse, error) {
+	tickers := make([]string, len(symbols))
+	canonical := make(map[string]string, len(symbols)) // ticker -> canonical
+	for i, sym := range symbols {
+		s := symbol.Parse(sym)
+		tickers[i] = p.mapper.ToProvider(s)
+		canonical[tickers[i]] = s.String()
+	}
+
+	resp, err := p.provider.GetQuotes(ctx, tickers)
+	if err != nil {
+		return nil, err
+	}
+
+	back := func(ticker string) string {
+		if c, ok := canonical[ticker]; ok {
+			return c
+		}
+		return p.mapper.FromProvider(ticker).String()
+	}
+	for i := range resp.Quotes {
+		resp.Quotes[i].Symbol = back(resp.Quotes[i].Symbol)
+	}
+	for i := range resp.FailedSymbols {
+		resp.FailedSymbols[i] = back(resp.FailedSymbols[i])
+	}
+
+	return resp, nil
+}
+
+// GetHistory gets historical price data
+func (p *symbolProvider) GetHistory(ctx context.Context, sym, period, interval string) (*models.HistoryResponse, error) {
+	canonical := symbol.Parse(sym)
+
+	history, err := p.provider.GetHistory(ctx, p.mapper.ToProvider(canonical), period, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	history.Symbol = canonical.String()
+	return history, nil
+}
+
+// GetInfo gets basic information about a stock or crypto
+func (p *symbolProvider) GetInfo(ctx context.Context, sym string) (*models.InfoResponse, error) {
+	canonical := symbol.Parse(sym)
+
+	info, err := p.provider.GetInfo(ctx, p.mapper.ToProvider(canonical))
+	if err != nil {
+		return nil, err
+	}
+
+	info.Symbol = canonical.String()
+	return info, nil
+}
+
+// GetActions gets the corporate actions of a stock, with new symbols made canonical
+func (p *symbolProvider) GetActions(ctx context.Context, sym string) (*models.ActionsResponse, error) {
+	canonical := symbol.Parse(sym)
+
+	actions, err := p.provider.GetActions(ctx, p.mapper.ToProvider(canonical))
+	if err != nil {
+		return nil, err
+	}
+
+	actions.Symbol = canonical.String()
+	for i := range actions.Actions {
+		if newSymbol := actions.Actions[i].NewSymbol; newSymbol != nil {
+			translated := p.mapper.FromProvider(*newSymbol).String()
+			actions.Actions[i].NewSymbol = &translated
+		}
+	}
+	return actions, nil
+}
+
+// GetDividends gets the dividend history of a stock or fund
+func (p *symbolProvider) GetDividends(ctx context.Context, sym string) (*models.DividendsResponse, error) {
+	canonical := symbol.Parse(sym)
+
+	dividends, err := p.provider.GetDividends(ctx, p.mapper.ToProvider(canonical))
+	if err != nil {
+		return nil, err
+	}
+
+	dividends.Symbol = canonical.String()
+	return dividends, nil
+}
+
+// Search searches for stocks or crypto, returning canonical symbols
+func (p *symbolProvider) Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error) {
+	results, err := p.provider.Search(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results.Results {
+		results.Results[i].Symbol = p.mapper.FromProvider(results.Results[i].Symbol).String()
+	}
+	return results, nil
+}
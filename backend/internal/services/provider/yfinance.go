@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"trackmymoney/internal/models"
+)
+
+// YFinanceProvider fetches market data from the yfinanceAPI Python service
+type YFinanceProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// ApiResponse represents the unified API response from Python service
+type ApiResponse[T any] struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+// NewYFinanceProvider creates a new yfinance provider instance
+func NewYFinanceProvider(cfg Config) *YFinanceProvider {
+	return &YFinanceProvider{
+		baseURL: cfg.BaseURL,
+		httpClient: &http.Client{
+			Timeout: time.Duration(cfg.Timeout) * time.Second,
+		},
+	}
+}
+
+// Name returns the provider name
+func (p *YFinanceProvider) Name() string {
+	return string(models.ProviderYFinance)
+}
+
+// GetQuote gets a real-time quote for a single symbol
+func (p *YFinanceProvider) GetQuote(ctx context.Context, symbol string) (*models.Quote, error) {
+	reqURL := fmt.Sprintf("%s/api/market/quote/%s", p.baseURL, url.PathEscape(symbol))
+	var response ApiResponse[models.Quote]
+
+	err := p.doRequest(ctx, "GET", reqURL, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Code != 0 {
+		return nil, fmt.Errorf("market service error: %s", response.Message)
+	}
+
+	return &response.Data, nil
+}
+
+// GetQuotes gets quotes for multiple symbols
+func (p *YFinanceProvider) GetQuotes(ctx context.Context, symbols []string) (*models.QuotesResponse, error) {
+	reqURL := fmt.Sprintf("%s/api/market/quotes", p.baseURL)
+
+	requestBody := models.QuotesRequest{
+		Symbols: symbols,
+	}
+
+	var response ApiResponse[models.QuotesResponse]
+	err := p.doRequest(ctx, "POST", reqURL, requestBody, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Code != 0 {
+		return nil, fmt.Errorf("market service error: %s", response.Message)
+	}
+
+	return &response.Data, nil
+}
+
+// GetHistory gets historical price data
+func (p *YFinanceProvider) GetHistory(ctx context.Context, symbol, period, interval string) (*models.HistoryResponse, error) {
+	reqURL := fmt.Sprintf("%s/api/market/history/%s?period=%s&interval=%s",
+		p.baseURL, url.PathEscape(symbol), url.QueryEscape(period), url.QueryEscape(interval))
+	var response ApiResponse[models.HistoryResponse]
+
+	err := p.doRequest(ctx, "GET", reqURL, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Code != 0 {
+		return nil, fmt.Errorf("market service error: %s", response.Message)
+	}
+
+	return &response.Data, nil
+}
+
+// GetInfo gets basic information about a stock or crypto
+func (p *YFinanceProvider) GetInfo(ctx context.Context, symbol string) (*models.InfoResponse, error) {
+	reqURL := fmt.Sprintf("%s/api/market/info/%s", p.baseURL, url.PathEscape(symbol))
+	var response ApiResponse[models.InfoResponse]
+
+	err := p.doRequest(ctx, "GET", reqURL, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Code != 0 {
+		return nil, fmt.Errorf("market service error: %s", response.Message)
+	}
+
+	return &response.Data, nil
+}
+
+// GetActions gets the corporate actions of a stock.
+// Yahoo Finance only reports splits; symbol changes have to be entered manually.
+func (p *YFinanceProvider) GetActions(ctx context.Context, symbol string) (*models.ActionsResponse, error) {
+	reqURL := fmt.Sprintf("%s/api/market/actions/%s", p.baseURL, url.PathEscape(symbol))
+	var response ApiResponse[models.ActionsResponse]
+
+	err := p.doRequest(ctx, "GET", reqURL, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Code != 0 {
+		return nil, fmt.Errorf("market service error: %s", response.Message)
+	}
+
+	return &response.Data, nil
+}
+
+// GetDividends gets the cash dividend history of a stock or fund
+func (p *YFinanceProvider) GetDividends(ctx context.Context, symbol string) (*models.DividendsResponse, error) {
+	reqURL := fmt.Sprintf("%s/api/market/dividends/%s", p.baseURL, url.PathEscape(symbol))
+	var response ApiResponse[models.DividendsResponse]
+
+	err := p.doRequest(ctx, "GET", reqURL, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Code != 0 {
+		return nil, fmt.Errorf("market service error: %s", response.Message)
+	}
+
+	return &response.Data, nil
+}
+
+// Search searches for stocks or crypto
+func (p *YFinanceProvider) Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error) {
+	reqURL := fmt.Sprintf("%s/api/market/search?q=%s&limit=%d", p.baseURL, url.QueryEscape(query), limit)
+	var response ApiResponse[models.SearchResponse]
+
+	err := p.doRequest(ctx, "GET", reqURL, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Code != 0 {
+		return nil, fmt.Errorf("market service error: %s", response.Message)
+	}
+
+	return &response.Data, nil
+}
+
+// doRequest executes a single HTTP request, aborted when ctx is done.
+// Retries and failover are handled by MarketService.
+func (p *YFinanceProvider) doRequest(ctx context.Context, method, reqURL string, body interface{}, result interface{}) error {
+	var reqBody io.Reader
+
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
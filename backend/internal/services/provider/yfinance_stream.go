@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+)
+
+// YFinanceStreamer streams real-time ticks from the yfinanceAPI WebSocket.
+// It accepts and returns canonical symbols, translating them to Yahoo tickers on the wire.
+type YFinanceStreamer struct {
+	wsURL  string
+	mapper symbol.Mapper
+	dialer *websocket.Dialer
+}
+
+// yfinanceTick is a tick message of the yfinanceAPI WebSocket; status messages carry a type instead
+type yfinanceTick struct {
+	Type          string          `json:"type"`
+	Symbol        string          `json:"symbol"`
+	Price         *float64        `json:"price"`
+	ChangePercent *float64        `json:"change_percent"`
+	Volume        *float64        `json:"volume"`
+	Timestamp     json.RawMessage `json:"timestamp"` // Milliseconds, sent as a number or a string
+}
+
+// NewYFinanceStreamer creates a streamer for the yfinanceAPI WebSocket at wsURL (e.g., ws://127.0.0.1:5000)
+func NewYFinanceStreamer(wsURL string, timeout int) *YFinanceStreamer {
+	return &YFinanceStreamer{
+		wsURL:  strings.TrimRight(wsURL, "/"),
+		mapper: symbol.Default.Mapper(models.ProviderYFinance),
+		dialer: &websocket.Dialer{HandshakeTimeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// Stream opens one upstream connection for a symbol and forwards its ticks until ctx is done,
+// the connection drops or send returns an error. Callers reconnect by calling Stream again.
+func (s *YFinanceStreamer) Stream(ctx context.Context, sym string, send func(models.MarketTick) error) error {
+	canonical := symbol.Parse(sym)
+	streamURL := fmt.Sprintf("%s/ws/market/%s", s.wsURL, url.PathEscape(s.mapper.ToProvider(canonical)))
+
+	conn, _, err := s.dialer.DialContext(ctx, streamURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to market stream: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read loop when the subscription is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var msg yfinanceTick
+		if err := conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("market stream closed: %w", err)
+		}
+		if msg.Type != "" || msg.Symbol == "" {
+			continue
+		}
+
+		tick := models.MarketTick{
+			Symbol:        canonical.String(),
+			Price:         msg.Price,
+			ChangePercent: msg.ChangePercent,
+			Timestamp:     parseTickTimestamp(msg.Timestamp),
+		}
+		if msg.Volume != nil {
+			volume := int64(*msg.Volume)
+			tick.Volume = &volume
+		}
+		if err := send(tick); err != nil {
+			return err
+		}
+	}
+}
+
+// parseTickTimestamp reads a millisecond timestamp sent as a number or a string, defaulting to now
+func parseTickTimestamp(raw json.RawMessage) int64 {
+	text := strings.Trim(string(raw), `"`)
+	if ms, err := strconv.ParseFloat(text, 64); err == nil && ms > 0 {
+		return int64(ms)
+	}
+	return time.Now().UnixMilli()
+}
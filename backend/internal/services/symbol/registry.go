@@ -0,0 +1,131 @@
+package symbol
+
+import (
+	"strings"
+	"sync"
+
+	"trackmymoney/internal/models"
+)
+
+// Mapper translates between canonical symbols and the tickers of one provider
+type Mapper interface {
+	// ToProvider returns the provider ticker of a canonical symbol
+	ToProvider(s Symbol) string
+
+	// FromProvider returns the canonical symbol of a provider ticker
+	FromProvider(ticker string) Symbol
+}
+
+// Registry holds the symbol mapper of each provider type
+type Registry struct {
+	mu      sync.RWMutex
+	mappers map[models.ProviderType]Mapper
+}
+
+// NewRegistry creates an empty symbol registry
+func NewRegistry() *Registry {
+	return &Registry{
+		mappers: make(map[models.ProviderType]Mapper),
+	}
+}
+
+// Register sets the mapper used for a provider type
+func (r *Registry) Register(providerType models.ProviderType, mapper Mapper) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.mappers[providerType] = mapper
+}
+
+// Mapper returns the mapper of a provider type, falling back to canonical symbols as-is
+func (r *Registry) Mapper(providerType models.ProviderType) Mapper {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if mapper, ok := r.mappers[providerType]; ok {
+		return mapper
+	}
+	return canonicalMapper{}
+}
+
+// Default is the registry used by the built-in providers
+var Default = NewRegistry()
+
+func init() {
+	Default.Register(models.ProviderYFinance, yahoo)
+}
+
+// canonicalMapper passes canonical symbols through unchanged
+type canonicalMapper struct{}
+
+func (canonicalMapper) ToProvider(s Symbol) string {
+	return s.String()
+}
+
+func (canonicalMapper) FromProvider(ticker string) Symbol {
+	return Parse(ticker)
+}
+
+// yahooFXSuffix marks currency pairs on Yahoo Finance (USDCNY=X)
+const yahooFXSuffix = "=X"
+
+// yahooMapper maps symbols to Yahoo Finance tickers (600519.SS, 0700.HK, BTC-CNY, USDCNY=X, BRK-B)
+type yahooMapper struct {
+	suffixes map[Exchange]string
+}
+
+var yahoo = yahooMapper{
+	suffixes: map[Exchange]string{
+		ExchangeSSE:  ".SS",
+		ExchangeSZSE: ".SZ",
+		ExchangeBSE:  ".BJ",
+		ExchangeHKEX: ".HK",
+		ExchangeLSE:  ".L",
+		ExchangeTSE:  ".T",
+		ExchangeTSX:  ".TO",
+	},
+}
+
+func (m yahooMapper) ToProvider(s Symbol) string {
+	if s.IsCrypto() {
+		return s.Code + "-" + s.QuoteCurrency
+	}
+	if s.IsFX() {
+		return s.Code + yahooFXSuffix
+	}
+	if suffix, ok := m.suffixes[s.Exchange]; ok {
+		return s.Code + suffix
+	}
+	// US share classes are written with a dash on Yahoo (BRK.B -> BRK-B)
+	return strings.ReplaceAll(s.Code, ".", "-")
+}
+
+func (m yahooMapper) FromProvider(ticker string) Symbol {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if code, ok := strings.CutSuffix(ticker, yahooFXSuffix); ok {
+		return Symbol{Code: code, Exchange: ExchangeFX}
+	}
+	if sym, ok := m.parseSuffix(ticker); ok {
+		return sym
+	}
+	if base, quote, ok := splitPair(ticker); ok {
+		return Symbol{Code: base, Exchange: ExchangeCrypto, QuoteCurrency: quote}
+	}
+	return Parse(strings.ReplaceAll(ticker, "-", "."))
+}
+
+// parseSuffix reads a ticker carrying a Yahoo exchange suffix
+func (m yahooMapper) parseSuffix(ticker string) (Symbol, bool) {
+	i := strings.LastIndex(ticker, ".")
+	if i <= 0 {
+		return Symbol{}, false
+	}
+
+	suffix := ticker[i:]
+	for exchange, s := range m.suffixes {
+		if s == suffix {
+			return Symbol{Code: ticker[:i], Exchange: exchange}.normalize(), true
+		}
+	}
+	return Symbol{}, false
+}
@@ -0,0 +1,202 @@
+package symbol
+
+import (
+	"strings"
+)
+
+// Exchange identifies the market a symbol is listed on
+type Exchange string
+
+const (
+	ExchangeNone   Exchange = ""       // Unqualified symbol, treated as a US listing
+	ExchangeNYSE   Exchange = "NYSE"   // New York Stock Exchange
+	ExchangeNASDAQ Exchange = "NASDAQ" // Nasdaq
+	ExchangeSSE    Exchange = "SSE"    // Shanghai Stock Exchange
+	ExchangeSZSE   Exchange = "SZSE"   // Shenzhen Stock Exchange
+	ExchangeBSE    Exchange = "BSE"    // Beijing Stock Exchange
+	ExchangeHKEX   Exchange = "HKEX"   // Hong Kong Stock Exchange
+	ExchangeLSE    Exchange = "LSE"    // London Stock Exchange
+	ExchangeTSE    Exchange = "TSE"    // Tokyo Stock Exchange
+	ExchangeTSX    Exchange = "TSX"    // Toronto Stock Exchange
+	ExchangeCrypto Exchange = "CRYPTO" // Crypto pairs, quoted in QuoteCurrency
+	ExchangeFX     Exchange = "FX"     // Currency pairs, coded BASEQUOTE (USDCNY)
+)
+
+// DefaultQuoteCurrency is the quote currency of crypto pairs when none is given
+const DefaultQuoteCurrency = "USD"
+
+// quoteCurrencies are the currencies recognized as the quote side of a crypto pair (BTC-CNY)
+var quoteCurrencies = map[string]bool{
+	"USD":  true,
+	"CNY":  true,
+	"HKD":  true,
+	"EUR":  true,
+	"GBP":  true,
+	"JPY":  true,
+	"USDT": true,
+	"USDC": true,
+	"BTC":  true,
+	"ETH":  true,
+}
+
+// Symbol is the canonical, provider-independent identifier of a listed instrument
+type Symbol struct {
+	Code          string   // Ticker or security code as listed, e.g. 600519, 0700, AAPL, BTC
+	Exchange      Exchange // Listing exchange, ExchangeCrypto for crypto pairs
+	QuoteCurrency string   // Quote currency of crypto pairs
+}
+
+// New builds a stock symbol from a code and an exchange.
+// When no exchange is given it is taken from the code (600519.SS, SSE:600519) or inferred for A-share codes.
+func New(code string, exchange string) Symbol {
+	exchange = strings.ToUpper(strings.TrimSpace(exchange))
+	if exchange == "" {
+		return Parse(code)
+	}
+	if Exchange(exchange) == ExchangeCrypto {
+		return Crypto(code, "")
+	}
+
+	parsed := Parse(code)
+	parsed.Exchange = Exchange(exchange)
+	return parsed.normalize()
+}
+
+// Crypto builds a crypto pair symbol. An empty quote currency defaults to USD,
+// and a base already written as a pair (BTC-CNY) keeps its own quote currency.
+func Crypto(base string, quoteCurrency string) Symbol {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	base = strings.TrimPrefix(base, string(ExchangeCrypto)+":")
+	quoteCurrency = strings.ToUpper(strings.TrimSpace(quoteCurrency))
+
+	if b, q, ok := splitPair(base); ok {
+		base = b
+		if quoteCurrency == "" {
+			quoteCurrency = q
+		}
+	}
+	if quoteCurrency == "" {
+		quoteCurrency = DefaultQuoteCurrency
+	}
+
+	return Symbol{Code: base, Exchange: ExchangeCrypto, QuoteCurrency: quoteCurrency}
+}
+
+// FX builds the symbol of a currency pair, quoting the base currency in the quote currency
+func FX(base, quote string) Symbol {
+	code := strings.ToUpper(strings.TrimSpace(base)) + strings.ToUpper(strings.TrimSpace(quote))
+	return Symbol{Code: code, Exchange: ExchangeFX}
+}
+
+// Parse reads a symbol in canonical form (SSE:600519, BTC-CNY, FX:USDCNY, AAPL) or in
+// Yahoo Finance form (600519.SS, 0700.HK, BTC-USD, USDCNY=X)
+func Parse(raw string) Symbol {
+	raw = strings.ToUpper(strings.TrimSpace(raw))
+
+	if prefix, code, ok := strings.Cut(raw, ":"); ok {
+		if Exchange(prefix) == ExchangeCrypto {
+			return Crypto(code, "")
+		}
+		return Symbol{Code: code, Exchange: Exchange(prefix)}.normalize()
+	}
+
+	if sym, ok := yahoo.parseSuffix(raw); ok {
+		return sym
+	}
+
+	if code, ok := strings.CutSuffix(raw, yahooFXSuffix); ok {
+		return Symbol{Code: code, Exchange: ExchangeFX}
+	}
+
+	if base, quote, ok := splitPair(raw); ok {
+		return Symbol{Code: base, Exchange: ExchangeCrypto, QuoteCurrency: quote}
+	}
+
+	return Symbol{Code: raw, Exchange: InferExchange(raw)}.normalize()
+}
+
+// InferExchange guesses the exchange of a mainland China A-share code (six digits)
+func InferExchange(code string) Exchange {
+	if len(code) != 6 || !isDigits(code) {
+		return ExchangeNone
+	}
+
+	switch code[0] {
+	case '6', '9', '5':
+		return ExchangeSSE
+	case '0', '2', '3', '1':
+		return ExchangeSZSE
+	case '4', '8':
+		return ExchangeBSE
+	default:
+		return ExchangeNone
+	}
+}
+
+// String returns the canonical form of the symbol: CODE, EXCHANGE:CODE or BASE-QUOTE for crypto pairs
+func (s Symbol) String() string {
+	switch s.Exchange {
+	case ExchangeNone:
+		return s.Code
+	case ExchangeCrypto:
+		return s.Code + "-" + s.QuoteCurrency
+	default:
+		return string(s.Exchange) + ":" + s.Code
+	}
+}
+
+// IsCrypto reports whether the symbol is a crypto pair
+func (s Symbol) IsCrypto() bool {
+	return s.Exchange == ExchangeCrypto
+}
+
+// IsFX reports whether the symbol is a currency pair
+func (s Symbol) IsFX() bool {
+	return s.Exchange == ExchangeFX
+}
+
+// Currencies returns the base and quote currencies of a currency pair
+func (s Symbol) Currencies() (base, quote string, ok bool) {
+	if !s.IsFX() || len(s.Code) != 6 {
+		return "", "", false
+	}
+	return s.Code[:3], s.Code[3:], true
+}
+
+// normalize applies per-exchange code conventions
+func (s Symbol) normalize() Symbol {
+	if s.Exchange == ExchangeHKEX && isDigits(s.Code) {
+		// HKEX codes are written with at least four digits (700 -> 0700, 09988 -> 9988)
+		code := strings.TrimLeft(s.Code, "0")
+		for len(code) < 4 {
+			code = "0" + code
+		}
+		s.Code = code
+	}
+	return s
+}
+
+// splitPair splits a crypto pair written as BASE-QUOTE with a known quote currency
+func splitPair(raw string) (base, quote string, ok bool) {
+	i := strings.LastIndex(raw, "-")
+	if i <= 0 || i == len(raw)-1 {
+		return "", "", false
+	}
+	base, quote = raw[:i], raw[i+1:]
+	if !quoteCurrencies[quote] {
+		return "", "", false
+	}
+	return base, quote, true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
+	"trackmymoney/pkg/logger"
+)
+
+// SymbolService keeps the local symbol master and searches it.
+// Upstream search is only a fallback, and its results are added to the local table.
+type SymbolService struct {
+	db            *gorm.DB
+	marketService *MarketService
+
+	mu       sync.Mutex
+	searched map[string]time.Time // Queries recently sent upstream; they also cover longer queries they prefix
+}
+
+// SymbolImportResult represents the outcome of a symbol listing import
+type SymbolImportResult struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// upstreamSearchTTL is how long a query and the longer queries it prefixes are answered locally
+// after it was searched upstream
+const upstreamSearchTTL = time.Hour
+
+// minUpstreamQueryLength is the fewest characters a query needs before it is searched upstream
+const minUpstreamQueryLength = 2
+
+// NewSymbolService creates a new symbol service
+func NewSymbolService(db *gorm.DB, marketService *MarketService) *SymbolService {
+	return &SymbolService{
+		db:            db,
+		marketService: marketService,
+		searched:      make(map[string]time.Time),
+	}
+}
+
+// Search searches the local symbol master by prefix and fuzzy matching on symbol, name and aliases.
+// Only when nothing matches locally is the query searched upstream, enriching the local table,
+// and not while typing: short queries and queries extending one searched recently stay local.
+func (s *SymbolService) Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error) {
+	query = strings.TrimSpace(query)
+
+	entries, err := s.searchLocal(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 && s.shouldSearchUpstream(query) {
+		upstream, err := s.marketService.Search(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		s.markSearched(query)
+		found := s.fromSearchResults(upstream.Results)
+		if err := s.upsert(found, false); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to store search results: %v", err))
+		}
+		entries = mergeSymbols(entries, found, limit)
+	}
+
+	results := make([]models.SearchResult, len(entries))
+	for i := range entries {
+		results[i] = toSearchResult(&entries[i])
+	}
+
+	return &models.SearchResponse{
+		Query:   query,
+		Results: results,
+		Count:   len(results),
+	}, nil
+}
+
+// SyncHoldings adds the symbols of all held and watched assets to the symbol master
+func (s *SymbolService) SyncHoldings() (int, error) {
+	var entries []models.MarketSymbol
+
+	var stocks []models.StockAsset
+	if err := s.db.Find(&stocks).Error; err != nil {
+		return 0, fmt.Errorf("failed to load stock assets: %w", err)
+	}
+	for i := range stocks {
+		entries = append(entries, newMarketSymbol(StockSymbol(&stocks[i]), stocks[i].Name, string(models.AssetTypeStock), stocks[i].Currency))
+	}
+
+	var cryptos []models.CryptoAsset
+	if err := s.db.Find(&cryptos).Error; err != nil {
+		return 0, fmt.Errorf("failed to load crypto assets: %w", err)
+	}
+	for i := range cryptos {
+		entries = append(entries, newMarketSymbol(CryptoSymbol(&cryptos[i]), cryptos[i].Name, string(models.AssetTypeCrypto), cryptos[i].QuoteCurrency))
+	}
+
+	var watchlist []models.Watchlist
+	if err := s.db.Find(&watchlist).Error; err != nil {
+		return 0, fmt.Errorf("failed to load watchlist: %w", err)
+	}
+	for _, item := range watchlist {
+		entries = append(entries, newMarketSymbol(WatchlistSymbol(item.Symbol, item.AssetType), item.Name, item.AssetType, ""))
+	}
+
+	for i := range entries {
+		entries[i].Source = models.SymbolSourceHolding
+	}
+	entries = mergeSymbols(nil, entries, len(entries))
+
+	if err := s.upsert(entries, false); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// ImportCSV imports a symbol listing.
+// The first row is a header with a required symbol column and optional
+// name, exchange, asset_type, currency and aliases columns.
+// Imported names replace names learned from search results.
+func (s *SymbolService) ImportCSV(r io.Reader) (*SymbolImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff") // Byte order mark written by spreadsheet tools
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["symbol"]; !ok {
+		return nil, errors.New("CSV header must contain a symbol column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	result := &SymbolImportResult{}
+	seen := make(map[string]bool)
+	var entries []models.MarketSymbol
+	line := 1
+
+	for {
+		record, err := reader.Read()
+		line++
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		raw := field(record, "symbol")
+		if raw == "" {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: missing symbol", line))
+			continue
+		}
+
+		assetType := strings.ToLower(field(record, "asset_type"))
+		currency := strings.ToUpper(field(record, "currency"))
+
+		var canonical string
+		if models.AssetType(assetType) == models.AssetTypeCrypto {
+			canonical = symbol.Crypto(raw, currency).String()
+		} else {
+			canonical = symbol.New(raw, field(record, "exchange")).String()
+		}
+		if seen[canonical] {
+			result.Skipped++
+			continue
+		}
+		seen[canonical] = true
+
+		entry := newMarketSymbol(canonical, field(record, "name"), assetType, currency)
+		entry.Aliases = field(record, "aliases")
+		entry.Source = models.SymbolSourceImport
+		entries = append(entries, entry)
+	}
+
+	if err := s.upsert(entries, true); err != nil {
+		return nil, err
+	}
+	result.Imported = len(entries)
+
+	logger.Info(fmt.Sprintf("Imported %d symbols (%d skipped)", result.Imported, result.Skipped))
+	return result, nil
+}
+
+// searchLocal finds matching entries in the symbol master, best matches first
+func (s *SymbolService) searchLocal(query string, limit int) ([]models.MarketSymbol, error) {
+	// LIKE wildcards are dropped from the query
+	q := strings.NewReplacer("%", "", "_", "").Replace(query)
+	if q == "" {
+		return []models.MarketSymbol{}, nil
+	}
+
+	var candidates []models.MarketSymbol
+	if err := s.db.Where("code LIKE ? OR symbol LIKE ? OR name LIKE ? OR aliases LIKE ?", q+"%", q+"%", "%"+q+"%", "%"+q+"%").
+		Limit(limit * 5).Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to search symbols: %w", err)
+	}
+
+	// Fuzzy: the query characters in order, anything in between (gzmt, 贵茅)
+	if len(candidates) < limit && utf8.RuneCountInString(q) > 1 {
+		fuzzy := "%" + strings.Join(strings.Split(q, ""), "%") + "%"
+		var more []models.MarketSymbol
+		if err := s.db.Where("code LIKE ? OR name LIKE ? OR aliases LIKE ?", fuzzy, fuzzy, fuzzy).
+			Limit(limit * 5).Find(&more).Error; err != nil {
+			return nil, fmt.Errorf("failed to search symbols: %w", err)
+		}
+		candidates = mergeSymbols(candidates, more, len(candidates)+len(more))
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri, rj := symbolRank(&candidates[i], q), symbolRank(&candidates[j], q)
+		if ri != rj {
+			return ri < rj
+		}
+		if len(candidates[i].Code) != len(candidates[j].Code) {
+			return len(candidates[i].Code) < len(candidates[j].Code)
+		}
+		return candidates[i].Symbol < candidates[j].Symbol
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// symbolRank scores how well an entry matches a query, lower is better
+func symbolRank(entry *models.MarketSymbol, query string) int {
+	q := strings.ToUpper(query)
+	code := strings.ToUpper(entry.Code)
+	sym := strings.ToUpper(entry.Symbol)
+	name := strings.ToUpper(entry.Name)
+
+	switch {
+	case code == q || sym == q:
+		return 0
+	case strings.HasPrefix(code, q) || strings.HasPrefix(sym, q):
+		return 1
+	case strings.HasPrefix(name, q):
+		return 2
+	}
+
+	for _, alias := range strings.Split(strings.ToUpper(entry.Aliases), ",") {
+		if alias = strings.TrimSpace(alias); alias != "" && strings.HasPrefix(alias, q) {
+			return 3
+		}
+	}
+
+	if strings.Contains(name, q) || strings.Contains(strings.ToUpper(entry.Aliases), q) {
+		return 4
+	}
+	return 5
+}
+
+// shouldSearchUpstream reports whether a query is long enough and neither it nor a prefix of it
+// has been searched upstream recently
+func (s *SymbolService) shouldSearchUpstream(query string) bool {
+	runes := []rune(strings.ToUpper(query))
+	if len(runes) < minUpstreamQueryLength {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for n := minUpstreamQueryLength; n <= len(runes); n++ {
+		if searchedAt, ok := s.searched[string(runes[:n])]; ok && time.Since(searchedAt) <= upstreamSearchTTL {
+			return false
+		}
+	}
+	return true
+}
+
+// markSearched records a successful upstream search, dropping expired records
+func (s *SymbolService) markSearched(query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for q, searchedAt := range s.searched {
+		if now.Sub(searchedAt) > upstreamSearchTTL {
+			delete(s.searched, q)
+		}
+	}
+	s.searched[strings.ToUpper(query)] = now
+}
+
+// fromSearchResults converts upstream search results to symbol master entries
+func (s *SymbolService) fromSearchResults(results []models.SearchResult) []models.MarketSymbol {
+	entries := make([]models.MarketSymbol, 0, len(results))
+	for _, result := range results {
+		if result.Symbol == "" {
+			continue
+		}
+		var name, assetType string
+		if result.Name != nil {
+			name = *result.Name
+		}
+		if result.AssetType != nil {
+			assetType = strings.ToLower(*result.AssetType)
+		}
+
+		entry := newMarketSymbol(result.Symbol, name, assetType, "")
+		entry.Source = models.SymbolSourceSearch
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// upsert stores entries by canonical symbol.
+// With overwrite, existing entries take the new values; otherwise only their empty fields are filled.
+func (s *SymbolService) upsert(entries []models.MarketSymbol, overwrite bool) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	onConflict := clause.OnConflict{
+		Columns: []clause.Column{{Name: "symbol"}},
+	}
+	if overwrite {
+		onConflict.DoUpdates = clause.AssignmentColumns([]string{"code", "exchange", "name", "aliases", "asset_type", "currency", "source", "updated_at"})
+	} else {
+		fill := func(column string) clause.Assignment {
+			return clause.Assignment{
+				Column: clause.Column{Name: column},
+				Value:  gorm.Expr(fmt.Sprintf("CASE WHEN symbols.%[1]s IS NULL OR symbols.%[1]s = '' THEN excluded.%[1]s ELSE symbols.%[1]s END", column)),
+			}
+		}
+		onConflict.DoUpdates = clause.Set{fill("name"), fill("asset_type"), fill("currency")}
+	}
+
+	if err := s.db.Clauses(onConflict).CreateInBatches(&entries, 500).Error; err != nil {
+		return fmt.Errorf("failed to store symbols: %w", err)
+	}
+	return nil
+}
+
+// newMarketSymbol builds a symbol master entry from a canonical symbol
+func newMarketSymbol(canonical, name, assetType, currency string) models.MarketSymbol {
+	sym := symbol.Parse(canonical)
+	if assetType == "" && sym.IsCrypto() {
+		assetType = string(models.AssetTypeCrypto)
+	}
+	if currency == "" && sym.IsCrypto() {
+		currency = sym.QuoteCurrency
+	}
+
+	return models.MarketSymbol{
+		Symbol:    sym.String(),
+		Code:      sym.Code,
+		Exchange:  string(sym.Exchange),
+		Name:      strings.TrimSpace(name),
+		AssetType: assetType,
+		Currency:  currency,
+	}
+}
+
+// mergeSymbols appends entries not yet present, up to limit entries
+func mergeSymbols(entries, more []models.MarketSymbol, limit int) []models.MarketSymbol {
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		seen[entry.Symbol] = true
+	}
+
+	for _, entry := range more {
+		if len(entries) >= limit {
+			break
+		}
+		if seen[entry.Symbol] {
+			continue
+		}
+		seen[entry.Symbol] = true
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// toSearchResult converts a symbol master entry to a search result
+func toSearchResult(entry *models.MarketSymbol) models.SearchResult {
+	result := models.SearchResult{Symbol: entry.Symbol}
+	if entry.Name != "" {
+		result.Name = &entry.Name
+	}
+	if entry.Exchange != "" {
+		result.Exchange = &entry.Exchange
+	}
+	if entry.AssetType != "" {
+		result.AssetType = &entry.AssetType
+	}
+	return result
+}
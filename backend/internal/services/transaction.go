@@ -0,0 +1,561 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"trackmymoney/internal/models"
+	"trackmymoney/pkg/logger"
+)
+
+// quantityEpsilon absorbs float rounding when a position is closed
+const quantityEpsilon = 1e-9
+
+var (
+	// ErrInvalidTransaction is returned when a transaction is incomplete or inconsistent
+	ErrInvalidTransaction = errors.New("invalid transaction")
+	// ErrInsufficientQuantity is returned when a transaction removes more units than are held at its date
+	ErrInsufficientQuantity = errors.New("insufficient quantity")
+	// ErrLedgerHasTrades is returned when a holding's quantity or price is edited directly after trades were recorded
+	ErrLedgerHasTrades = errors.New("holding has recorded transactions; record a transaction instead of editing quantity or purchase price")
+	// ErrGeneratedTransaction is returned when a transaction generated by a corporate action is edited
+	ErrGeneratedTransaction = errors.New("transaction was generated by a corporate action and cannot be changed")
+	// ErrHoldingNotFound is returned when a transaction refers to a missing holding
+	ErrHoldingNotFound = errors.New("holding not found")
+)
+
+// TransactionService keeps the transaction ledger of stock and crypto holdings.
+// Holdings are projections of their ledger: every write re-derives the holding's
+// quantity and average purchase price from all of its transactions.
+type TransactionService struct {
+	db     *gorm.DB
+	method models.CostBasisMethod // Default for holdings without their own method
+	fx     *FXService
+}
+
+// TransactionFilter selects transactions to list
+type TransactionFilter struct {
+	AssetType models.AssetType
+	AssetID   uint
+	Type      models.TransactionType
+	From      *time.Time
+	To        *time.Time
+}
+
+// NewTransactionService creates a new transaction service. Holdings without their own
+// cost basis method use the given one, or average cost if it is empty or unknown.
+// Realized gains are also reported in the FX service's base currency.
+func NewTransactionService(db *gorm.DB, method models.CostBasisMethod, fx *FXService) *TransactionService {
+	if method == "" || !ValidCostBasisMethod(method) {
+		if method != "" {
+			logger.Warn(fmt.Sprintf("Unknown cost basis method %q, using average cost", method))
+		}
+		method = models.CostBasisAverage
+	}
+
+	return &TransactionService{
+		db:     db,
+		method: method,
+		fx:     fx,
+	}
+}
+
+// List returns transactions in ledger order, optionally filtered
+func (s *TransactionService) List(filter TransactionFilter) ([]models.Transaction, error) {
+	query := s.db.Model(&models.Transaction{})
+	if filter.AssetType != "" {
+		query = query.Where("asset_type = ?", filter.AssetType)
+	}
+	if filter.AssetID != 0 {
+		query = query.Where("asset_id = ?", filter.AssetID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		query = query.Where("date >= ?", truncateDate(*filter.From))
+	}
+	if filter.To != nil {
+		query = query.Where("date <= ?", truncateDate(*filter.To))
+	}
+
+	var transactions []models.Transaction
+	if err := query.Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	sortLedger(transactions)
+	return transactions, nil
+}
+
+// Get returns a single transaction
+func (s *TransactionService) Get(id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := s.db.First(&transaction, id).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// Create records a transaction and updates its holding
+func (s *TransactionService) Create(transaction *models.Transaction) error {
+	if err := normalizeTransaction(transaction); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+	if transaction.Type == models.TransactionOpeningBalance {
+		return fmt.Errorf("%w: opening balances are recorded when a holding is created", ErrInvalidTransaction)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		holding, err := loadHolding(tx, transaction.AssetType, transaction.AssetID)
+		if err != nil {
+			return err
+		}
+		if transaction.Currency == "" {
+			transaction.Currency = holding.Currency
+		}
+
+		if err := tx.Create(transaction).Error; err != nil {
+			return fmt.Errorf("failed to save transaction: %w", err)
+		}
+		_, err = s.rebuildHolding(tx, transaction.AssetType, transaction.AssetID)
+		return err
+	})
+}
+
+// Update replaces a transaction and updates its holding. The holding of a transaction cannot change,
+// nor can a transaction turn into or out of an opening balance. The quantity and price of an opening
+// balance are revised through its holding.
+func (s *TransactionService) Update(transaction *models.Transaction) error {
+	if transaction.CorporateActionID != nil {
+		return ErrGeneratedTransaction
+	}
+	if err := normalizeTransaction(transaction); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.Transaction
+		if err := tx.First(&stored, transaction.ID).Error; err != nil {
+			return err
+		}
+		if stored.Type != transaction.Type &&
+			(stored.Type == models.TransactionOpeningBalance || transaction.Type == models.TransactionOpeningBalance) {
+			return fmt.Errorf("%w: a transaction cannot become or stop being an opening balance", ErrInvalidTransaction)
+		}
+		if stored.Type == models.TransactionOpeningBalance &&
+			(stored.Quantity != transaction.Quantity || stored.Price != transaction.Price) {
+			return fmt.Errorf("%w: edit the holding's quantity or purchase price to revise its opening balance", ErrInvalidTransaction)
+		}
+
+		if err := tx.Save(transaction).Error; err != nil {
+			return fmt.Errorf("failed to save transaction: %w", err)
+		}
+		_, err := s.rebuildHolding(tx, transaction.AssetType, transaction.AssetID)
+		return err
+	})
+}
+
+// Delete removes a transaction and updates its holding
+func (s *TransactionService) Delete(transaction *models.Transaction) error {
+	if transaction.CorporateActionID != nil {
+		return ErrGeneratedTransaction
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(transaction).Error; err != nil {
+			return fmt.Errorf("failed to delete transaction: %w", err)
+		}
+		_, err := s.rebuildHolding(tx, transaction.AssetType, transaction.AssetID)
+		return err
+	})
+}
+
+// CreateStock creates a stock holding together with its opening balance
+func (s *TransactionService) CreateStock(asset *models.StockAsset, date time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(asset).Error; err != nil {
+			return err
+		}
+		return openHolding(tx, models.AssetTypeStock, asset.ID, asset.Quantity, asset.PurchasePrice, asset.Currency, date)
+	})
+}
+
+// CreateCrypto creates a crypto holding together with its opening balance
+func (s *TransactionService) CreateCrypto(asset *models.CryptoAsset, date time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(asset).Error; err != nil {
+			return err
+		}
+		return openHolding(tx, models.AssetTypeCrypto, asset.ID, asset.Quantity, asset.PurchasePrice, asset.QuoteCurrency, date)
+	})
+}
+
+// SaveStock saves an edited stock holding and re-derives it under its cost basis method.
+// A changed quantity or purchase price revises the opening balance, which is only allowed
+// while the ledger holds no other transactions.
+func (s *TransactionService) SaveStock(asset *models.StockAsset) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := reviseOpeningBalance(tx, models.AssetTypeStock, asset.ID, asset.Quantity, asset.PurchasePrice); err != nil {
+			return err
+		}
+		if err := tx.Save(asset).Error; err != nil {
+			return err
+		}
+		holding, err := s.rebuildHolding(tx, models.AssetTypeStock, asset.ID)
+		if err != nil {
+			return err
+		}
+		asset.Quantity, asset.PurchasePrice = holding.Quantity, holding.PurchasePrice
+		return nil
+	})
+}
+
+// SaveCrypto saves an edited crypto holding; see SaveStock
+func (s *TransactionService) SaveCrypto(asset *models.CryptoAsset) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := reviseOpeningBalance(tx, models.AssetTypeCrypto, asset.ID, asset.Quantity, asset.PurchasePrice); err != nil {
+			return err
+		}
+		if err := tx.Save(asset).Error; err != nil {
+			return err
+		}
+		holding, err := s.rebuildHolding(tx, models.AssetTypeCrypto, asset.ID)
+		if err != nil {
+			return err
+		}
+		asset.Quantity, asset.PurchasePrice = holding.Quantity, holding.PurchasePrice
+		return nil
+	})
+}
+
+// DeleteHolding deletes a stock or crypto holding together with its ledger, income events and
+// corporate action adjustments. A holding that secures a debt is not deleted.
+func (s *TransactionService) DeleteHolding(assetType models.AssetType, assetID uint) error {
+	model, err := holdingModel(assetType)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := CheckNotCollateral(tx, assetType, assetID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("asset_type = ? AND asset_id = ?", assetType, assetID).Delete(&models.Transaction{}).Error; err != nil {
+			return fmt.Errorf("failed to delete transactions: %w", err)
+		}
+		if err := tx.Where("asset_type = ? AND asset_id = ?", assetType, assetID).Delete(&models.IncomeEvent{}).Error; err != nil {
+			return fmt.Errorf("failed to delete income events: %w", err)
+		}
+		if assetType == models.AssetTypeStock {
+			if err := tx.Where("stock_asset_id = ?", assetID).Delete(&models.CorporateActionAdjustment{}).Error; err != nil {
+				return fmt.Errorf("failed to delete corporate action adjustments: %w", err)
+			}
+		}
+		return tx.Delete(model, assetID).Error
+	})
+}
+
+// sortLedger orders transactions by date; on the same date opening balances come first,
+// then splits (which apply to units held before the ex-date), then entries in the order recorded
+func sortLedger(transactions []models.Transaction) {
+	rank := func(t models.TransactionType) int {
+		switch t {
+		case models.TransactionOpeningBalance:
+			return 0
+		case models.TransactionSplit:
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if rank(a.Type) != rank(b.Type) {
+			return rank(a.Type) < rank(b.Type)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// loadLedger returns the transactions of a holding in ledger order
+func loadLedger(tx *gorm.DB, assetType models.AssetType, assetID uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	if err := tx.Where("asset_type = ? AND asset_id = ?", assetType, assetID).Find(&transactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load transactions: %w", err)
+	}
+	sortLedger(transactions)
+	return transactions, nil
+}
+
+// methodOf returns the cost basis method of a holding
+func (s *TransactionService) methodOf(holding *holdingRef) models.CostBasisMethod {
+	if holding.Method != "" {
+		return holding.Method
+	}
+	return s.method
+}
+
+// rebuildHolding re-derives a holding from its ledger and stores its quantity and purchase price
+func (s *TransactionService) rebuildHolding(tx *gorm.DB, assetType models.AssetType, assetID uint) (*HoldingProjection, error) {
+	holding, err := loadHolding(tx, assetType, assetID)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := loadLedger(tx, assetType, assetID)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := ProjectHolding(transactions, s.methodOf(holding))
+	if err != nil {
+		return nil, err
+	}
+
+	model, _ := holdingModel(assetType)
+	if err := tx.Model(model).Where("id = ?", assetID).UpdateColumns(map[string]interface{}{
+		"quantity":       projection.Quantity,
+		"purchase_price": projection.PurchasePrice,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update %s holding %d: %w", assetType, assetID, err)
+	}
+	return projection, nil
+}
+
+// openHolding records the opening balance of a new holding
+func openHolding(tx *gorm.DB, assetType models.AssetType, assetID uint, quantity, price float64, currency string, date time.Time) error {
+	if quantity == 0 {
+		return nil
+	}
+
+	opening := models.Transaction{
+		AssetType: assetType,
+		AssetID:   assetID,
+		Type:      models.TransactionOpeningBalance,
+		Date:      date,
+		Quantity:  quantity,
+		Price:     price,
+		Currency:  currency,
+	}
+	if err := normalizeTransaction(&opening); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
+	}
+	if err := tx.Create(&opening).Error; err != nil {
+		return fmt.Errorf("failed to record opening balance: %w", err)
+	}
+	return nil
+}
+
+// reviseOpeningBalance brings the opening balance in line with an edited quantity and purchase price
+func reviseOpeningBalance(tx *gorm.DB, assetType models.AssetType, assetID uint, quantity, price float64) error {
+	holding, err := loadHolding(tx, assetType, assetID)
+	if err != nil {
+		return err
+	}
+	if math.Abs(holding.Quantity-quantity) < quantityEpsilon && math.Abs(holding.PurchasePrice-price) < 0.005 {
+		return nil
+	}
+
+	transactions, err := loadLedger(tx, assetType, assetID)
+	if err != nil {
+		return err
+	}
+	var opening *models.Transaction
+	for i := range transactions {
+		if transactions[i].Type != models.TransactionOpeningBalance {
+			return ErrLedgerHasTrades
+		}
+		opening = &transactions[i]
+	}
+
+	if opening == nil {
+		return openHolding(tx, assetType, assetID, quantity, price, holding.Currency, time.Now())
+	}
+	if quantity == 0 {
+		return tx.Delete(opening).Error
+	}
+	if quantity < 0 {
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidTransaction)
+	}
+	return tx.Model(opening).Updates(map[string]interface{}{"quantity": quantity, "price": price}).Error
+}
+
+// holdingModel returns the model of a holding type
+func holdingModel(assetType models.AssetType) (interface{}, error) {
+	switch assetType {
+	case models.AssetTypeStock:
+		return &models.StockAsset{}, nil
+	case models.AssetTypeCrypto:
+		return &models.CryptoAsset{}, nil
+	default:
+		return nil, fmt.Errorf("%w: transactions are recorded for stock and crypto holdings, not %q", ErrInvalidTransaction, assetType)
+	}
+}
+
+// holdingRef is what the ledger needs to know about a holding
+type holdingRef struct {
+	AssetType     models.AssetType
+	AssetID       uint
+	Name          string
+	Symbol        string // Canonical market symbol
+	Currency      string
+	Method        models.CostBasisMethod // Empty for the default method
+	Quantity      float64
+	PurchasePrice float64
+	Price         float64 // Stored current price, the purchase price if it was never fetched
+}
+
+func stockHoldingRef(asset *models.StockAsset) holdingRef {
+	return holdingRef{
+		AssetType:     models.AssetTypeStock,
+		AssetID:       asset.ID,
+		Name:          asset.Name,
+		Symbol:        StockSymbol(asset),
+		Currency:      asset.Currency,
+		Method:        asset.CostBasisMethod,
+		Quantity:      asset.Quantity,
+		PurchasePrice: asset.PurchasePrice,
+		Price:         storedPrice(asset.CurrentPrice, asset.PurchasePrice),
+	}
+}
+
+func cryptoHoldingRef(asset *models.CryptoAsset) holdingRef {
+	return holdingRef{
+		AssetType:     models.AssetTypeCrypto,
+		AssetID:       asset.ID,
+		Name:          asset.Name,
+		Symbol:        CryptoSymbol(asset),
+		Currency:      asset.QuoteCurrency,
+		Method:        asset.CostBasisMethod,
+		Quantity:      asset.Quantity,
+		PurchasePrice: asset.PurchasePrice,
+		Price:         storedPrice(asset.CurrentPrice, asset.PurchasePrice),
+	}
+}
+
+// loadHolding loads a holding, failing with ErrHoldingNotFound if it does not exist
+func loadHolding(tx *gorm.DB, assetType models.AssetType, assetID uint) (*holdingRef, error) {
+	var holding holdingRef
+	switch assetType {
+	case models.AssetTypeStock:
+		var asset models.StockAsset
+		if err := tx.First(&asset, assetID).Error; err != nil {
+			return nil, holdingLookupError(err)
+		}
+		holding = stockHoldingRef(&asset)
+	case models.AssetTypeCrypto:
+		var asset models.CryptoAsset
+		if err := tx.First(&asset, assetID).Error; err != nil {
+			return nil, holdingLookupError(err)
+		}
+		holding = cryptoHoldingRef(&asset)
+	default:
+		_, err := holdingModel(assetType)
+		return nil, err
+	}
+	return &holding, nil
+}
+
+// loadHoldings loads the stock and crypto holdings, optionally of a single type or a single holding
+func loadHoldings(tx *gorm.DB, assetType models.AssetType, assetID uint) ([]holdingRef, error) {
+	if assetType != "" {
+		if _, err := holdingModel(assetType); err != nil {
+			return nil, err
+		}
+	}
+	byID := func(query *gorm.DB) *gorm.DB {
+		if assetID != 0 {
+			return query.Where("id = ?", assetID)
+		}
+		return query
+	}
+
+	var holdings []holdingRef
+	if assetType == "" || assetType == models.AssetTypeStock {
+		var stocks []models.StockAsset
+		if err := byID(tx).Find(&stocks).Error; err != nil {
+			return nil, fmt.Errorf("failed to load stock assets: %w", err)
+		}
+		for i := range stocks {
+			holdings = append(holdings, stockHoldingRef(&stocks[i]))
+		}
+	}
+	if assetType == "" || assetType == models.AssetTypeCrypto {
+		var cryptos []models.CryptoAsset
+		if err := byID(tx).Find(&cryptos).Error; err != nil {
+			return nil, fmt.Errorf("failed to load crypto assets: %w", err)
+		}
+		for i := range cryptos {
+			holdings = append(holdings, cryptoHoldingRef(&cryptos[i]))
+		}
+	}
+	return holdings, nil
+}
+
+func holdingLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrHoldingNotFound
+	}
+	return err
+}
+
+// normalizeTransaction validates a transaction and keeps only the fields its type uses
+func normalizeTransaction(t *models.Transaction) error {
+	if _, err := holdingModel(t.AssetType); err != nil {
+		return fmt.Errorf("unsupported asset type %q", t.AssetType)
+	}
+	if t.AssetID == 0 {
+		return errors.New("asset ID is required")
+	}
+	if t.Date.IsZero() {
+		return errors.New("date is required")
+	}
+	t.Date = truncateDate(t.Date)
+	t.Currency = strings.ToUpper(strings.TrimSpace(t.Currency))
+	if t.Quantity < 0 || t.Price < 0 || t.Fees < 0 {
+		return errors.New("quantity, price and fees must not be negative")
+	}
+
+	switch t.Type {
+	case models.TransactionOpeningBalance, models.TransactionBuy, models.TransactionSell,
+		models.TransactionTransferIn, models.TransactionTransferOut, models.TransactionDividendReinvest:
+		if t.Quantity <= 0 {
+			return fmt.Errorf("%s requires a positive quantity", t.Type)
+		}
+		t.Ratio = 0
+	case models.TransactionFee:
+		if t.Quantity == 0 && t.Fees == 0 {
+			return errors.New("fee requires fees or a quantity of units")
+		}
+		t.Price = 0
+		t.Ratio = 0
+	case models.TransactionSplit:
+		if t.Ratio <= 0 || t.Ratio == 1 {
+			return fmt.Errorf("invalid split ratio %v", t.Ratio)
+		}
+		t.Quantity = 0
+		t.Price = 0
+		t.Fees = 0
+	default:
+		return fmt.Errorf("unsupported transaction type %q", t.Type)
+	}
+
+	// Only disposals are matched to lots
+	switch t.Type {
+	case models.TransactionSell, models.TransactionTransferOut, models.TransactionFee:
+		if t.LotID != nil && (*t.LotID == 0 || t.Quantity == 0) {
+			t.LotID = nil
+		}
+	default:
+		t.LotID = nil
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"trackmymoney/internal/database"
 	"trackmymoney/internal/models"
+	"trackmymoney/internal/services/symbol"
 
 	"gorm.io/gorm"
 )
@@ -24,6 +26,8 @@ func NewWatchlistService(marketService *MarketService) *WatchlistService {
 
 // Create adds a new watchlist item
 func (s *WatchlistService) Create(watchlist *models.Watchlist) error {
+	watchlist.Symbol = WatchlistSymbol(watchlist.Symbol, watchlist.AssetType)
+
 	// Check if already exists for this user
 	var existing models.Watchlist
 	err := s.db.Where("user_id = ? AND symbol = ?", watchlist.UserID, watchlist.Symbol).First(&existing).Error
@@ -65,7 +69,7 @@ func (s *WatchlistService) Delete(id uint) error {
 }
 
 // GetWatchlistWithQuotes returns watchlist items with real-time quotes
-func (s *WatchlistService) GetWatchlistWithQuotes(userID uint) ([]map[string]interface{}, error) {
+func (s *WatchlistService) GetWatchlistWithQuotes(ctx context.Context, userID uint) ([]map[string]interface{}, error) {
 	// Get watchlist items
 	watchlist, err := s.GetByUserID(userID)
 	if err != nil {
@@ -76,29 +80,35 @@ func (s *WatchlistService) GetWatchlistWithQuotes(userID uint) ([]map[string]int
 		return []map[string]interface{}{}, nil
 	}
 
-	// Extract symbols
-	symbols := make([]string, len(watchlist))
-	for i, item := range watchlist {
-		symbols[i] = item.Symbol
+	// Get quotes grouped by bound data source
+	groups := make(map[uint][]string)
+	for _, item := range watchlist {
+		key := dataSourceKey(item.DataSourceID)
+		groups[key] = append(groups[key], canonicalSymbol(item.Symbol))
 	}
 
-	// Get quotes for all symbols
-	quotesResp, err := s.marketService.GetQuotes(symbols)
-	if err != nil {
-		// If market service fails, return watchlist without quotes
-		result := make([]map[string]interface{}, len(watchlist))
-		for i, item := range watchlist {
-			result[i] = map[string]interface{}{
-				"id":         item.ID,
-				"symbol":     item.Symbol,
-				"name":       item.Name,
-				"asset_type": item.AssetType,
-				"notes":      item.Notes,
-				"created_at": item.CreatedAt,
-				"updated_at": item.UpdatedAt,
-			}
+	quotes := make(map[uint]map[string]models.Quote)
+	for key, symbols := range groups {
+		var dataSourceID *uint
+		if key != 0 {
+			dataSourceID = &key
+		}
+
+		market, err := s.marketService.ForDataSource(dataSourceID)
+		if err != nil {
+			continue
+		}
+
+		// If market service fails, return these items without quotes
+		quotesResp, err := market.GetQuotes(ctx, symbols)
+		if err != nil {
+			continue
+		}
+
+		quotes[key] = make(map[string]models.Quote, len(quotesResp.Quotes))
+		for _, quote := range quotesResp.Quotes {
+			quotes[key][quote.Symbol] = quote
 		}
-		return result, nil
 	}
 
 	// Merge watchlist with quotes
@@ -114,12 +124,13 @@ func (s *WatchlistService) GetWatchlistWithQuotes(userID uint) ([]map[string]int
 			"updated_at": item.UpdatedAt,
 		}
 
+		if item.DataSourceID != nil {
+			itemMap["data_source_id"] = *item.DataSourceID
+		}
+
 		// Find matching quote
-		for _, quote := range quotesResp.Quotes {
-			if quote.Symbol == item.Symbol {
-				itemMap["quote"] = quote
-				break
-			}
+		if quote, ok := quotes[dataSourceKey(item.DataSourceID)][canonicalSymbol(item.Symbol)]; ok {
+			itemMap["quote"] = quote
 		}
 
 		result[i] = itemMap
@@ -127,3 +138,11 @@ func (s *WatchlistService) GetWatchlistWithQuotes(userID uint) ([]map[string]int
 
 	return result, nil
 }
+
+// WatchlistSymbol returns the canonical symbol of a watchlist item; bare crypto symbols are quoted in USD
+func WatchlistSymbol(raw string, assetType string) string {
+	if models.AssetType(assetType) == models.AssetTypeCrypto {
+		return symbol.Crypto(raw, "").String()
+	}
+	return canonicalSymbol(raw)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrInvalidWSTicket is returned when a WebSocket ticket is unknown, expired or already used
+var ErrInvalidWSTicket = errors.New("invalid or expired WebSocket ticket")
+
+// WSTicket is a short-lived, single-use credential for opening a WebSocket connection.
+// Browsers cannot send the Authorization header on a WebSocket upgrade, so they fetch
+// a ticket over authenticated REST and pass it in the upgrade URL instead.
+type WSTicket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WSTicketService issues and redeems WebSocket tickets in memory
+type WSTicketService struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]wsTicketEntry
+}
+
+type wsTicketEntry struct {
+	username  string
+	expiresAt time.Time
+}
+
+// NewWSTicketService creates a ticket service whose tickets expire after ttl
+func NewWSTicketService(ttl time.Duration) *WSTicketService {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+
+	return &WSTicketService{
+		ttl:     ttl,
+		tickets: make(map[string]wsTicketEntry),
+	}
+}
+
+// Issue creates a ticket for a user
+func (s *WSTicketService) Issue(username string) (*WSTicket, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	ticket := &WSTicket{
+		Ticket:    hex.EncodeToString(buf),
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+	s.tickets[ticket.Ticket] = wsTicketEntry{username: username, expiresAt: ticket.ExpiresAt}
+	return ticket, nil
+}
+
+// Redeem consumes a ticket and returns the user it was issued to.
+// A ticket can be redeemed once, and only before it expires.
+func (s *WSTicketService) Redeem(ticket string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.tickets[ticket]
+	if !ok {
+		return "", ErrInvalidWSTicket
+	}
+	delete(s.tickets, ticket)
+
+	if time.Now().After(entry.expiresAt) {
+		return "", ErrInvalidWSTicket
+	}
+	return entry.username, nil
+}
+
+// prune drops expired tickets (caller must hold the lock)
+func (s *WSTicketService) prune(now time.Time) {
+	for ticket, entry := range s.tickets {
+		if now.After(entry.expiresAt) {
+			delete(s.tickets, ticket)
+		}
+	}
+}
@@ -77,6 +77,9 @@ func (e ErrorCode) HTTPStatus() int {
 		}
 	case e >= 2000 && e < 3000:
 		// Server errors
+		if e == ExternalAPIError {
+			return 503
+		}
 		return 500
 	case e >= 3000:
 		// Business logic errors (return 200 with error code in body)
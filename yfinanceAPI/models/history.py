@@ -109,3 +109,40 @@ class SearchResponse(BaseModel):
     query: str = Field(..., description="Search query")
     results: list[SearchResult] = Field(default_factory=list, description="Search results")
     count: int = Field(..., description="Number of results")
+
+
+class CorporateAction(BaseModel):
+    """Corporate action affecting holdings"""
+
+    type: str = Field(..., description="Action type (split, symbol_change)")
+    date: str = Field(..., description="Ex-date in YYYY-MM-DD format")
+    ratio: Optional[float] = Field(None, description="New shares per old share for splits (0.1 for a 1:10 reverse split)")
+    new_symbol: Optional[str] = Field(None, description="New symbol for symbol changes")
+
+    class Config:
+        json_schema_extra = {"example": {"type": "split", "date": "2020-08-31", "ratio": 4.0}}
+
+
+class ActionsResponse(BaseModel):
+    """Corporate actions of a symbol"""
+
+    symbol: str = Field(..., description="Symbol")
+    actions: list[CorporateAction] = Field(default_factory=list, description="Corporate actions, oldest first")
+
+
+class Dividend(BaseModel):
+    """Cash dividend of a symbol"""
+
+    date: str = Field(..., description="Ex-date in YYYY-MM-DD format")
+    amount: float = Field(..., description="Dividend per share")
+
+    class Config:
+        json_schema_extra = {"example": {"date": "2024-08-12", "amount": 0.25}}
+
+
+class DividendsResponse(BaseModel):
+    """Dividend history of a symbol"""
+
+    symbol: str = Field(..., description="Symbol")
+    currency: Optional[str] = Field(None, description="Currency the dividends are paid in")
+    dividends: list[Dividend] = Field(default_factory=list, description="Dividends, oldest first")
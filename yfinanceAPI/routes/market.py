@@ -3,7 +3,7 @@
 from fastapi import APIRouter, HTTPException, Query
 from models.response import ApiResponse
 from models.quote import Quote, QuotesRequest, QuotesResponse
-from models.history import HistoryResponse, InfoResponse, SearchResponse
+from models.history import HistoryResponse, InfoResponse, SearchResponse, ActionsResponse, DividendsResponse
 from services.market_service import market_service
 
 router = APIRouter(prefix="/api/market", tags=["Market"])
@@ -84,6 +84,42 @@ async def get_info(symbol: str):
     return ApiResponse.success(info)
 
 
+@router.get("/actions/{symbol}", response_model=ApiResponse[ActionsResponse])
+async def get_actions(symbol: str):
+    """
+    Get corporate actions (stock splits) of a stock
+
+    Args:
+        symbol: Stock symbol
+
+    Returns:
+        Corporate actions, oldest first
+    """
+    actions = market_service.get_actions(symbol)
+    if not actions:
+        raise HTTPException(status_code=404, detail=f"No corporate actions found for: {symbol}")
+
+    return ApiResponse.success(actions)
+
+
+@router.get("/dividends/{symbol}", response_model=ApiResponse[DividendsResponse])
+async def get_dividends(symbol: str):
+    """
+    Get the cash dividend history of a stock or fund
+
+    Args:
+        symbol: Stock symbol
+
+    Returns:
+        Dividends per share by ex-date, oldest first
+    """
+    dividends = market_service.get_dividends(symbol)
+    if not dividends:
+        raise HTTPException(status_code=404, detail=f"No dividends found for: {symbol}")
+
+    return ApiResponse.success(dividends)
+
+
 @router.get("/search", response_model=ApiResponse[SearchResponse])
 async def search(
     q: str = Query(..., description="Search query", min_length=1),
@@ -6,7 +6,17 @@ from datetime import datetime
 
 import yfinance as yf
 from models.quote import Quote, QuotesResponse
-from models.history import HistoryResponse, HistoryDataPoint, InfoResponse, SearchResponse, SearchResult
+from models.history import (
+    HistoryResponse,
+    HistoryDataPoint,
+    InfoResponse,
+    SearchResponse,
+    SearchResult,
+    CorporateAction,
+    ActionsResponse,
+    Dividend,
+    DividendsResponse,
+)
 
 logger = logging.getLogger(__name__)
 
@@ -182,6 +192,66 @@ class MarketService:
             logger.error(f"Error fetching info for {symbol}: {e}")
             return None
 
+    def get_actions(self, symbol: str) -> Optional[ActionsResponse]:
+        """
+        Get stock splits of a symbol
+
+        Yahoo Finance does not publish ticker changes, so only splits are reported.
+
+        Args:
+            symbol: Stock symbol
+
+        Returns:
+            ActionsResponse or None if failed
+        """
+        try:
+            ticker = yf.Ticker(symbol)
+            splits = ticker.splits
+
+            actions = []
+            for date, ratio in splits.items():
+                if not ratio or ratio <= 0:
+                    continue
+                actions.append(CorporateAction(type="split", date=date.strftime("%Y-%m-%d"), ratio=float(ratio)))
+
+            return ActionsResponse(symbol=symbol.upper(), actions=actions)
+
+        except Exception as e:
+            logger.error(f"Error fetching actions for {symbol}: {e}")
+            return None
+
+    def get_dividends(self, symbol: str) -> Optional[DividendsResponse]:
+        """
+        Get the cash dividend history of a stock or fund
+
+        Args:
+            symbol: Stock symbol
+
+        Returns:
+            DividendsResponse or None if failed
+        """
+        try:
+            ticker = yf.Ticker(symbol)
+            history = ticker.dividends
+
+            dividends = []
+            for date, amount in history.items():
+                if not amount or amount <= 0:
+                    continue
+                dividends.append(Dividend(date=date.strftime("%Y-%m-%d"), amount=float(amount)))
+
+            currency = None
+            try:
+                currency = ticker.fast_info["currency"]
+            except Exception:
+                pass
+
+            return DividendsResponse(symbol=symbol.upper(), currency=currency, dividends=dividends)
+
+        except Exception as e:
+            logger.error(f"Error fetching dividends for {symbol}: {e}")
+            return None
+
     def search(self, query: str, limit: int = 10) -> SearchResponse:
         """
         Search for stocks/crypto by name or symbol